@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"net"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
+)
+
+// Supported LOG_FORMAT values for access logs.
+const (
+	logFormatDefault  = "default"
+	logFormatCombined = "combined"
+	logFormatJSON     = "json"
+)
+
+// combinedTimeLayout is the timestamp layout used by Apache's Common/Combined Log Format.
+const combinedTimeLayout = "02/Jan/2006:15:04:05 -0700"
+
+// responseRecorder captures the status code and body size written by a handler.
+type responseRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int
+}
+
+func (rec *responseRecorder) WriteHeader(code int) {
+	if rec.status == 0 {
+		rec.status = code
+	}
+	rec.ResponseWriter.WriteHeader(code)
+}
+
+func (rec *responseRecorder) Write(b []byte) (int, error) {
+	if rec.status == 0 {
+		rec.status = http.StatusOK
+	}
+	n, err := rec.ResponseWriter.Write(b)
+	rec.bytes += n
+	return n, err
+}
+
+// Unwrap exposes the underlying writer to http.ResponseController.
+func (rec *responseRecorder) Unwrap() http.ResponseWriter {
+	return rec.ResponseWriter
+}
+
+// routeInfo carries what only the router knows back up to logRequests, which
+// wraps the router and therefore never sees mux.Vars itself.
+type routeInfo struct {
+	cep     string
+	apiKey  string
+	subject string
+}
+
+type routeInfoKey struct{}
+
+// captureRouteVars records the matched {cep}, if any, on the request's routeInfo.
+func captureRouteVars(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if info, ok := r.Context().Value(routeInfoKey{}).(*routeInfo); ok {
+			info.cep = mux.Vars(r)["cep"]
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// accessLogEntry is the JSON shape emitted when LOG_FORMAT=json.
+type accessLogEntry struct {
+	Time       string  `json:"time"`
+	RemoteIP   string  `json:"remote_ip"`
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Proto      string  `json:"proto"`
+	Status     int     `json:"status"`
+	Bytes      int     `json:"bytes"`
+	DurationMS float64 `json:"duration_ms"`
+	Referer    string  `json:"referer,omitempty"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+	RequestID  string  `json:"request_id,omitempty"`
+	Cep        string  `json:"cep,omitempty"`
+	APIKey     string  `json:"api_key,omitempty"`
+	JWTSubject string  `json:"jwt_sub,omitempty"`
+}
+
+// logRequests logs one entry per request, with its status, body size, client
+// and latency, in the configured LOG_FORMAT.
+func (app *application) logRequests(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		info := &routeInfo{}
+		r = r.WithContext(context.WithValue(r.Context(), routeInfoKey{}, info))
+		next.ServeHTTP(rec, r)
+		duration := time.Since(start)
+
+		if rec.status == 0 {
+			rec.status = http.StatusOK
+		}
+
+		switch app.cfg.logFormat {
+		case logFormatCombined:
+			fmt.Fprintln(app.accessLog, combinedLogLine(r, rec.status, rec.bytes, start))
+		case logFormatJSON:
+			entry := accessLogEntry{
+				Time:       start.UTC().Format(time.RFC3339Nano),
+				RemoteIP:   remoteIP(r),
+				Method:     r.Method,
+				Path:       r.URL.Path,
+				Proto:      r.Proto,
+				Status:     rec.status,
+				Bytes:      rec.bytes,
+				DurationMS: float64(duration.Microseconds()) / 1000,
+				Referer:    r.Referer(),
+				UserAgent:  r.UserAgent(),
+				Cep:        info.cep,
+				APIKey:     info.apiKey,
+				JWTSubject: info.subject,
+			}
+			entry.RequestID, _ = requestid.FromContext(r.Context())
+			if err := json.NewEncoder(app.accessLog).Encode(entry); err != nil {
+				app.logger.Error("erro ao escrever access log", "err", err)
+			}
+		default:
+			attrs := []slog.Attr{
+				slog.String("method", r.Method),
+				slog.String("path", r.URL.Path),
+				slog.Int("status", rec.status),
+				slog.Int("bytes", rec.bytes),
+				slog.Float64("latency_ms", float64(duration.Microseconds())/1000),
+				slog.String("remote_ip", remoteIP(r)),
+			}
+			if ua := r.UserAgent(); ua != "" {
+				attrs = append(attrs, slog.String("user_agent", ua))
+			}
+			if info.cep != "" {
+				attrs = append(attrs, slog.String("cep", info.cep))
+			}
+			if info.apiKey != "" {
+				attrs = append(attrs, slog.String("api_key", info.apiKey))
+			}
+			if info.subject != "" {
+				attrs = append(attrs, slog.String("jwt_sub", info.subject))
+			}
+			app.logger.LogAttrs(r.Context(), slog.LevelInfo, "requisição", attrs...)
+		}
+	})
+}
+
+// combinedLogLine renders a request in Apache Combined Log Format.
+func combinedLogLine(r *http.Request, status, bytes int, ts time.Time) string {
+	size := "-"
+	if bytes > 0 {
+		size = strconv.Itoa(bytes)
+	}
+
+	uri := r.RequestURI
+	if uri == "" {
+		uri = r.URL.RequestURI()
+	}
+
+	return fmt.Sprintf("%s - - [%s] %q %d %s %q %q",
+		remoteIP(r),
+		ts.Format(combinedTimeLayout),
+		r.Method+" "+uri+" "+r.Proto,
+		status,
+		size,
+		orDash(r.Referer()),
+		orDash(r.UserAgent()),
+	)
+}
+
+// remoteIP returns the host portion of the connection's remote address.
+func remoteIP(r *http.Request) string {
+	host, _, err := net.SplitHostPort(r.RemoteAddr)
+	if err != nil {
+		return r.RemoteAddr
+	}
+	return host
+}
+
+func orDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
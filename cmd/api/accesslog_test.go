@@ -0,0 +1,112 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"regexp"
+	"testing"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCombinedLogLine(t *testing.T) {
+	t.Parallel()
+
+	req := httptest.NewRequest(http.MethodGet, "/cep/01001000?x=1", nil)
+	req.RemoteAddr = "10.0.0.7:51234"
+	req.Header.Set("Referer", "https://example.com/")
+	req.Header.Set("User-Agent", "curl/8.0")
+
+	ts := time.Date(2024, time.March, 5, 14, 2, 3, 0, time.FixedZone("BRT", -3*60*60))
+
+	line := combinedLogLine(req, http.StatusOK, 231, ts)
+	assert.Equal(t,
+		`10.0.0.7 - - [05/Mar/2024:14:02:03 -0300] "GET /cep/01001000?x=1 HTTP/1.1" 200 231 "https://example.com/" "curl/8.0"`,
+		line,
+	)
+}
+
+func TestLogRequestsFormats(t *testing.T) {
+	handler := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusNotFound)
+		_, _ = w.Write([]byte("nope"))
+	})
+
+	t.Run("combined", func(t *testing.T) {
+		var out bytes.Buffer
+		app := &application{cfg: config{logFormat: logFormatCombined}, logger: noopLogger(), accessLog: &out}
+
+		req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		app.logRequests(handler).ServeHTTP(httptest.NewRecorder(), req)
+
+		assert.Regexp(t,
+			regexp.MustCompile(`^192\.0\.2\.1 - - \[[^\]]+\] "GET /cep/123 HTTP/1\.1" 404 4 "-" "-"\n$`),
+			out.String(),
+		)
+	})
+
+	t.Run("json", func(t *testing.T) {
+		var out bytes.Buffer
+		app := &application{cfg: config{logFormat: logFormatJSON}, logger: noopLogger(), accessLog: &out}
+
+		router := mux.NewRouter()
+		router.Use(captureRouteVars)
+		router.Handle("/cep/{cep}", http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			// What requireClientAuth records for an authenticated caller.
+			info := r.Context().Value(routeInfoKey{}).(*routeInfo)
+			info.apiKey, info.subject = "key-1", "user-1"
+			handler.ServeHTTP(w, r)
+		}))
+
+		req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("User-Agent", "tests")
+		assignRequestID(app.logRequests(router)).ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry accessLogEntry
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+		assert.Equal(t, "/cep/123", entry.Path)
+		assert.Equal(t, http.StatusNotFound, entry.Status)
+		assert.Equal(t, 4, entry.Bytes)
+		assert.Equal(t, "tests", entry.UserAgent)
+		assert.Equal(t, "req-1", entry.RequestID)
+		assert.Equal(t, "123", entry.Cep)
+		assert.Equal(t, "key-1", entry.APIKey)
+		assert.Equal(t, "user-1", entry.JWTSubject)
+	})
+
+	t.Run("default", func(t *testing.T) {
+		var out bytes.Buffer
+		app := &application{cfg: config{logFormat: logFormatDefault}, logger: newLogger(&out, nil), accessLog: io.Discard}
+
+		router := mux.NewRouter()
+		router.Use(captureRouteVars)
+		router.Handle("/cep/{cep}", handler)
+
+		req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+		req.Header.Set("X-Request-ID", "req-1")
+		req.Header.Set("User-Agent", "tests")
+		req.RemoteAddr = "192.0.2.1:1234"
+		assignRequestID(app.logRequests(router)).ServeHTTP(httptest.NewRecorder(), req)
+
+		var entry map[string]any
+		assert.NoError(t, json.Unmarshal(out.Bytes(), &entry))
+		assert.Equal(t, "INFO", entry["level"])
+		assert.Equal(t, "requisição", entry["msg"])
+		assert.Equal(t, "GET", entry["method"])
+		assert.Equal(t, "/cep/123", entry["path"])
+		assert.Equal(t, float64(http.StatusNotFound), entry["status"])
+		assert.Equal(t, float64(4), entry["bytes"])
+		assert.Equal(t, "192.0.2.1", entry["remote_ip"])
+		assert.Equal(t, "tests", entry["user_agent"])
+		assert.Equal(t, "req-1", entry["request_id"])
+		assert.Equal(t, "123", entry["cep"])
+		assert.Contains(t, entry, "latency_ms")
+	})
+}
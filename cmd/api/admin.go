@@ -0,0 +1,123 @@
+package main
+
+import (
+	"crypto/subtle"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// isAdmin reports whether the request carries the configured ADMIN_TOKEN as a
+// bearer token. Admin features are disabled entirely when no token is configured.
+func (app *application) isAdmin(r *http.Request) bool {
+	return app.isAdminAuthorization(r.Header.Get("Authorization"))
+}
+
+// isAdminAuthorization checks an Authorization value, from an HTTP header or
+// gRPC metadata, against ADMIN_TOKEN.
+func (app *application) isAdminAuthorization(authorization string) bool {
+	if app.cfg.adminToken == "" {
+		return false
+	}
+
+	token, ok := strings.CutPrefix(authorization, "Bearer ")
+	if !ok {
+		return false
+	}
+
+	return subtle.ConstantTimeCompare([]byte(strings.TrimSpace(token)), []byte(app.cfg.adminToken)) == 1
+}
+
+// requireAdmin rejects requests that are not authenticated with ADMIN_TOKEN.
+func (app *application) requireAdmin(next http.HandlerFunc) http.HandlerFunc {
+	return func(w http.ResponseWriter, r *http.Request) {
+		if !app.isAdmin(r) {
+			writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "não autorizado"})
+			return
+		}
+		next(w, r)
+	}
+}
+
+// expireAllHandler marks every cached CEP stale so it is refreshed on next access.
+// It requires ?confirm=true to guard against accidental calls.
+func (app *application) expireAllHandler(w http.ResponseWriter, r *http.Request) {
+	if !parseBoolOrDefault(r.URL.Query().Get("confirm"), false) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "confirmação necessária: use ?confirm=true"})
+		return
+	}
+
+	n, err := app.service.ExpireAll(r.Context())
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "erro ao expirar cache", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao expirar cache"})
+		return
+	}
+
+	app.batchCache.clear()
+	app.logger.InfoContext(r.Context(), "cache expirado manualmente", "entries", n)
+	writeJSON(w, http.StatusOK, map[string]int64{"expired": n})
+}
+
+// adminMetricsHandler returns the in-process lookup counters as JSON.
+func (app *application) adminMetricsHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, app.service.Metrics())
+}
+
+// providerScoresHandler returns the rolling health of each provider, in the
+// order the next lookup would try them.
+func (app *application) providerScoresHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, app.service.ProviderScores())
+}
+
+// invalidateHandler deletes a single CEP from the cache. Lookups already in
+// flight for it will not write their result back.
+func (app *application) invalidateHandler(w http.ResponseWriter, r *http.Request) {
+	cepValue := mux.Vars(r)["cep"]
+
+	deleted, err := app.service.Invalidate(r.Context(), cepValue)
+	if err != nil {
+		if errors.Is(err, cep.ErrInvalidCEP) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		app.logger.ErrorContext(r.Context(), "erro ao invalidar cep", "cep", cepValue, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao invalidar cep"})
+		return
+	}
+	// Cached batches may include the CEP; they are cheap to rebuild.
+	app.batchCache.clear()
+
+	writeJSON(w, http.StatusOK, map[string]bool{"deleted": deleted})
+}
+
+// logLevelHandler reports the current log level and, on PUT with a body such as
+// {"level":"debug"}, changes it for every logger in the process until the next
+// change or restart.
+func (app *application) logLevelHandler(w http.ResponseWriter, r *http.Request) {
+	if r.Method == http.MethodPut {
+		var body struct {
+			Level string `json:"level"`
+		}
+		if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": `corpo inválido: esperado um objeto JSON com "level"`})
+			return
+		}
+		level, err := parseLogLevel(body.Level)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "nível de log inválido: use debug, info, warn ou error"})
+			return
+		}
+
+		previous := app.logLevel.Level()
+		app.logLevel.Set(level)
+		// Logged at the higher of the two levels so the change is never filtered out.
+		app.logger.Log(r.Context(), max(previous, level), "nível de log alterado", "from", logLevelName(previous), "to", logLevelName(level))
+	}
+
+	writeJSON(w, http.StatusOK, map[string]string{"level": logLevelName(app.logLevel.Level())})
+}
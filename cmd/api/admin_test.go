@@ -0,0 +1,210 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestExpireAllHandler(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		path   string
+		token  string
+		status int
+		body   string
+	}{
+		{name: "requires admin", path: "/admin/cache/expire-all?confirm=true", status: http.StatusUnauthorized},
+		{name: "requires confirmation", path: "/admin/cache/expire-all", token: "s3cret", status: http.StatusBadRequest},
+		{name: "expires everything", path: "/admin/cache/expire-all?confirm=true", token: "s3cret", status: http.StatusOK, body: `{"expired":3}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app, mock := newTestApp(t, &stubHTTPClient{})
+			app.cfg.adminToken = "s3cret"
+
+			if tc.status == http.StatusOK {
+				mock.ExpectExec(`UPDATE ceps SET updated_at = \$1`).WillReturnResult(sqlmock.NewResult(0, 3))
+			}
+
+			req := httptest.NewRequest(http.MethodPost, tc.path, nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			if tc.body != "" {
+				assert.JSONEq(t, tc.body, rec.Body.String())
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestAdminMetrics(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "s3cret"
+
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow([]byte(`{"cep":"01001-000"}`), time.Now(), cep.CacheSchemaVersion))
+	_, err := app.service.Get(context.Background(), "01001000")
+	assert.NoError(t, err)
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/metrics", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"cache_hits":1,"cache_misses":0,"cache_expired":0,"provider_calls":0,"stale_served_on_error":0,"data_changed":0,"hedges":0,"retries":0,"shadow_compared":0,"shadow_mismatches":0,"errors":0}`, rec.Body.String())
+}
+
+func TestProviderScoresHandler(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "s3cret"
+
+	req := httptest.NewRequest(http.MethodGet, "/admin/providers", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"provider":"viacep","success_rate":0,"latency_ms":0,"samples":0}]`, rec.Body.String())
+}
+
+func TestInvalidateHandler(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "s3cret"
+
+	mock.ExpectExec(`DELETE FROM ceps WHERE cep = \$1`).WithArgs("01001000").WillReturnResult(sqlmock.NewResult(0, 1))
+
+	call := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodDelete, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("/admin/cache/01001-000", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"deleted":true}`, rec.Body.String())
+
+	assert.Equal(t, http.StatusUnauthorized, call("/admin/cache/01001000", "").Code)
+	assert.Equal(t, http.StatusBadRequest, call("/admin/cache/123", "s3cret").Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestLogLevelHandler(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "s3cret"
+
+	var logs bytes.Buffer
+	app.logger = newLogger(&logs, app.logLevel)
+
+	call := func(method, body, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, "/admin/loglevel", strings.NewReader(body))
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call(http.MethodGet, "", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"info"}`, rec.Body.String())
+
+	app.logger.Debug("hidden")
+	rec = call(http.MethodPut, `{"level":"DEBUG"}`, "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"level":"debug"}`, rec.Body.String())
+	app.logger.Debug("visible")
+	assert.NotContains(t, logs.String(), "hidden")
+	assert.Contains(t, logs.String(), `"msg":"visible"`)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, `{"level":"verbose"}`, "s3cret").Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPut, `debug`, "s3cret").Code)
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodPut, `{"level":"error"}`, "").Code)
+	assert.Equal(t, slog.LevelDebug, app.logLevel.Level())
+}
+
+func TestPprofRoutes(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "s3cret"
+
+	call := func(path, token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("/admin/debug/pprof/", "").Code)
+	assert.Equal(t, http.StatusUnauthorized, call("/admin/debug/pprof/heap", "").Code)
+
+	rec := call("/admin/debug/pprof/", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "goroutine")
+
+	rec = call("/admin/debug/pprof/goroutine?debug=1", "s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "TestPprofRoutes")
+
+	assert.Equal(t, http.StatusOK, call("/admin/debug/pprof/cmdline", "s3cret").Code)
+	assert.Equal(t, http.StatusNotFound, call("/admin/debug/pprof/nope", "s3cret").Code)
+}
+
+func TestExpvarHandler(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{err: errors.New("connection refused")})
+	app.cfg.adminToken = "s3cret"
+	mock.ExpectQuery(`SELECT payload`).WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+
+	app.routes().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/cep/01001000", nil))
+
+	call := func(token string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/admin/debug/vars", nil)
+		if token != "" {
+			req.Header.Set("Authorization", "Bearer "+token)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call("").Code)
+
+	rec := call("s3cret")
+	assert.Equal(t, http.StatusOK, rec.Code)
+
+	var vars struct {
+		Memstats map[string]any `json:"memstats"`
+		Gocep    struct {
+			Lookups          cep.MetricsSnapshot `json:"lookups"`
+			UpstreamFailures map[string]int      `json:"upstream_failures"`
+		} `json:"gocep"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &vars))
+	assert.NotEmpty(t, vars.Memstats)
+	assert.Equal(t, uint64(1), vars.Gocep.Lookups.CacheMisses)
+	assert.Equal(t, map[string]int{"viacep": 1}, vars.Gocep.UpstreamFailures)
+}
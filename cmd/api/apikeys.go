@@ -0,0 +1,233 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+	"unicode/utf8"
+
+	"github.com/gorilla/mux"
+
+	"github.com/victor-dias21/goCep-k8s/internal/apikey"
+)
+
+// apiKeyHeader carries the client's API key.
+const apiKeyHeader = "X-API-Key"
+
+// parseAPIKeys reads API_KEYS, a comma-separated list of name:secret pairs,
+// into a map from the secret's hash to the client name. Only hashes are kept
+// in memory, as in the api_keys table.
+func parseAPIKeys(value string) (map[string]string, error) {
+	keys := map[string]string{}
+	for _, item := range parseList(value) {
+		name, secret, ok := strings.Cut(item, ":")
+		name, secret = strings.TrimSpace(name), strings.TrimSpace(secret)
+		if !ok || name == "" || secret == "" {
+			return nil, errors.New("entrada sem o formato nome:chave")
+		}
+		keys[apikey.Hash(secret)] = name
+	}
+	return keys, nil
+}
+
+// authenticateAPIKey resolves secret against API_KEYS first and then the
+// api_keys table.
+func (app *application) authenticateAPIKey(ctx context.Context, secret string) (*apikey.Key, error) {
+	if name, ok := app.cfg.apiKeys[apikey.Hash(secret)]; ok {
+		return &apikey.Key{ID: "env:" + name, Name: name}, nil
+	}
+	if app.apiKeys == nil {
+		return nil, apikey.ErrNotFound
+	}
+	return app.apiKeys.Authenticate(ctx, secret)
+}
+
+// authenticateAPIKeyQuota authenticates secret and counts the request against
+// the key's quota, refusing an unknown key with 401, a revoked one with 403 and
+// one over its quota with 429.
+func (app *application) authenticateAPIKeyQuota(ctx context.Context, secret string) (*clientIdentity, error) {
+	key, err := app.authenticateAPIKey(ctx, secret)
+	switch {
+	case errors.Is(err, apikey.ErrNotFound):
+		return nil, &clientAuthError{status: http.StatusUnauthorized, message: "chave de API inválida"}
+	case errors.Is(err, apikey.ErrRevoked):
+		app.logger.WarnContext(ctx, "chave de API revogada em uso", "api_key", key.Name)
+		return nil, &clientAuthError{status: http.StatusForbidden, message: "chave de API revogada"}
+	case err != nil:
+		app.logger.ErrorContext(ctx, "erro ao validar chave de API", "err", err)
+		return nil, &clientAuthError{status: http.StatusServiceUnavailable, message: "falha ao validar chave de API", retryAfter: outageRetryAfter}
+	}
+
+	quota, err := app.chargeQuota(ctx, key)
+	return &clientIdentity{apiKey: key.Name, quota: quota}, err
+}
+
+// quotaRemaining is what is left of a key's daily and monthly quotas after the
+// current request; a negative value means that quota is not enforced.
+type quotaRemaining struct {
+	daily, monthly int64
+}
+
+// noQuota is reported for requests that are not counted.
+var noQuota = quotaRemaining{daily: -1, monthly: -1}
+
+// setHeaders reports the enforced quotas as X-Quota-*-Remaining.
+func (q quotaRemaining) setHeaders(h http.Header) {
+	if q.daily >= 0 {
+		h.Set("X-Quota-Daily-Remaining", strconv.FormatInt(q.daily, 10))
+	}
+	if q.monthly >= 0 {
+		h.Set("X-Quota-Monthly-Remaining", strconv.FormatInt(q.monthly, 10))
+	}
+}
+
+// chargeQuota counts the request against key and refuses it with 429 once the
+// key is over its daily or monthly quota. Accounting needs the database;
+// without one, or when the counter cannot be updated, the request is let
+// through rather than failing paying clients over bookkeeping.
+func (app *application) chargeQuota(ctx context.Context, key *apikey.Key) (quotaRemaining, error) {
+	if app.apiKeys == nil {
+		return noQuota, nil
+	}
+
+	usage, err := app.apiKeys.Record(ctx, key.ID)
+	if err != nil {
+		app.logger.WarnContext(ctx, "erro ao contabilizar uso da chave de API", "api_key", key.Name, "err", err)
+		return noQuota, nil
+	}
+
+	now := time.Now().UTC()
+	daily := quotaOrDefault(key.Daily, app.cfg.apiKeyDailyQuota)
+	monthly := quotaOrDefault(key.Monthly, app.cfg.apiKeyMonthlyQuota)
+	remaining := noQuota
+	if daily > 0 {
+		remaining.daily = max(0, daily-usage.Day)
+	}
+	if monthly > 0 {
+		remaining.monthly = max(0, monthly-usage.Month)
+	}
+
+	switch {
+	case monthly > 0 && usage.Month > monthly:
+		nextMonth := time.Date(now.Year(), now.Month()+1, 1, 0, 0, 0, 0, time.UTC)
+		return remaining, &clientAuthError{status: http.StatusTooManyRequests, message: "cota mensal da chave de API esgotada", retryAfter: nextMonth.Sub(now)}
+	case daily > 0 && usage.Day > daily:
+		tomorrow := time.Date(now.Year(), now.Month(), now.Day()+1, 0, 0, 0, 0, time.UTC)
+		return remaining, &clientAuthError{status: http.StatusTooManyRequests, message: "cota diária da chave de API esgotada", retryAfter: tomorrow.Sub(now)}
+	}
+	return remaining, nil
+}
+
+// quotaOrDefault returns the key's own quota when it has one.
+func quotaOrDefault(quota *int64, fallback int64) int64 {
+	if quota != nil {
+		return *quota
+	}
+	return fallback
+}
+
+// maxAPIKeyNameLength bounds the client name given to a new key.
+const maxAPIKeyNameLength = 100
+
+// createAPIKeyHandler issues a key for the client named in a body such as
+// {"name":"parceiro-a","daily_quota":1000}. The quotas are optional and
+// default to API_KEY_DAILY_QUOTA and API_KEY_MONTHLY_QUOTA. The secret is only
+// ever shown in this response.
+func (app *application) createAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	var body struct {
+		Name string `json:"name"`
+		apikey.Quotas
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(w, r.Body, 1024)).Decode(&body); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": `corpo inválido: esperado um objeto JSON com "name"`})
+		return
+	}
+	name := strings.TrimSpace(body.Name)
+	if name == "" || utf8.RuneCountInString(name) > maxAPIKeyNameLength {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "name deve ter entre 1 e 100 caracteres"})
+		return
+	}
+
+	if (body.Daily != nil && *body.Daily < 0) || (body.Monthly != nil && *body.Monthly < 0) {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "daily_quota e monthly_quota não podem ser negativos"})
+		return
+	}
+
+	key, secret, err := app.apiKeys.Create(r.Context(), name, body.Quotas)
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "erro ao criar chave de API", "name", name, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao criar chave de API"})
+		return
+	}
+
+	app.logger.InfoContext(r.Context(), "chave de API criada", "id", key.ID, "name", key.Name)
+	writeJSON(w, http.StatusCreated, struct {
+		*apikey.Key
+		Secret string `json:"key"`
+	}{key, secret})
+}
+
+// listAPIKeysHandler lists every issued key, without secrets.
+func (app *application) listAPIKeysHandler(w http.ResponseWriter, r *http.Request) {
+	keys, err := app.apiKeys.List(r.Context())
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "erro ao listar chaves de API", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao listar chaves de API"})
+		return
+	}
+	writeJSON(w, http.StatusOK, map[string][]apikey.Key{"keys": keys})
+}
+
+// revokeAPIKeyHandler revokes a key by ID. Requests using it get 403 from then on.
+func (app *application) revokeAPIKeyHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+
+	key, err := app.apiKeys.Revoke(r.Context(), id)
+	if errors.Is(err, apikey.ErrNotFound) {
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "chave de API não encontrada"})
+		return
+	}
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "erro ao revogar chave de API", "id", id, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao revogar chave de API"})
+		return
+	}
+
+	app.logger.InfoContext(r.Context(), "chave de API revogada", "id", key.ID, "name", key.Name)
+	writeJSON(w, http.StatusOK, key)
+}
+
+// maxUsageDays bounds the window of the usage report.
+const maxUsageDays = 366
+
+// apiKeyUsageHandler reports the requests of a key per UTC day over the last
+// ?days=N days (30 by default). Keys from API_KEYS are reported under env:<name>.
+func (app *application) apiKeyUsageHandler(w http.ResponseWriter, r *http.Request) {
+	id := mux.Vars(r)["id"]
+	days := 30
+	if raw := r.URL.Query().Get("days"); raw != "" {
+		n, err := strconv.Atoi(raw)
+		if err != nil || n < 1 || n > maxUsageDays {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "days deve ser um inteiro entre 1 e 366"})
+			return
+		}
+		days = n
+	}
+
+	usage, err := app.apiKeys.DailyUsage(r.Context(), id, days)
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "erro ao consultar uso da chave de API", "id", id, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar uso da chave de API"})
+		return
+	}
+
+	var total int64
+	for _, day := range usage {
+		total += day.Requests
+	}
+	writeJSON(w, http.StatusOK, map[string]any{"id": id, "days": usage, "total": total})
+}
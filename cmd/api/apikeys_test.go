@@ -0,0 +1,194 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/victor-dias21/goCep-k8s/internal/apikey"
+)
+
+func TestParseAPIKeys(t *testing.T) {
+	keys, err := parseAPIKeys("parceiro-a:s3cret, parceiro-b : outra")
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]string{apikey.Hash("s3cret"): "parceiro-a", apikey.Hash("outra"): "parceiro-b"}, keys)
+
+	for _, value := range []string{"semnome", ":chave", "nome:"} {
+		_, err := parseAPIKeys(value)
+		assert.Error(t, err, value)
+	}
+}
+
+func apiKeyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "created_at", "revoked_at", "daily_quota", "monthly_quota"})
+}
+
+func usageRows(day, month int64) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"day", "month"}).AddRow(day, month)
+}
+
+func TestRequireAPIKey(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.apiKeyAuth = true
+	app.cfg.apiKeys = map[string]string{apikey.Hash("env-key"): "parceiro"}
+	app.cfg.adminToken = "admin"
+	handler := app.routes()
+
+	call := func(path string, header http.Header) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		for name, values := range header {
+			req.Header[name] = values
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("/cep/123", nil)
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "chave de API ausente")
+
+	mock.ExpectQuery("FROM api_keys").WithArgs(apikey.Hash("unknown")).WillReturnRows(apiKeyRows())
+	rec = call("/v1/cep/123", http.Header{"X-Api-Key": {"unknown"}})
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "chave de API inválida")
+
+	revokedAt := time.Now()
+	mock.ExpectQuery("FROM api_keys").WithArgs(apikey.Hash("old")).
+		WillReturnRows(apiKeyRows().AddRow("k1", "antigo", revokedAt, revokedAt, nil, nil))
+	rec = call("/cep/123", http.Header{"X-Api-Key": {"old"}})
+	assert.Equal(t, http.StatusForbidden, rec.Code)
+	assert.Contains(t, rec.Body.String(), "chave de API revogada")
+
+	mock.ExpectQuery("FROM api_keys").WithArgs(apikey.Hash("db-key")).
+		WillReturnRows(apiKeyRows().AddRow("k2", "novo", revokedAt, nil, nil, nil))
+	mock.ExpectQuery("INSERT INTO api_key_usage").WithArgs("k2", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(usageRows(1, 1))
+	assert.Equal(t, http.StatusBadRequest, call("/cep/123", http.Header{"X-Api-Key": {"db-key"}}).Code)
+
+	// Keys from API_KEYS are not looked up, only counted.
+	mock.ExpectQuery("INSERT INTO api_key_usage").WithArgs("env:parceiro", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(usageRows(1, 1))
+	assert.Equal(t, http.StatusBadRequest, call("/cep/123", http.Header{"X-Api-Key": {"env-key"}}).Code)
+	assert.Equal(t, http.StatusBadRequest, call("/cep/123", http.Header{"Authorization": {"Bearer admin"}}).Code)
+	assert.Equal(t, http.StatusUnauthorized, call("/v1/rpc/cep/01001000", nil).Code)
+	assert.NotEqual(t, http.StatusUnauthorized, call("/healthz", nil).Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyQuota(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.apiKeyAuth = true
+	app.cfg.apiKeys = map[string]string{apikey.Hash("env-key"): "parceiro"}
+	app.cfg.apiKeyDailyQuota = 2
+	handler := app.routes()
+
+	call := func() *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+		req.Header.Set(apiKeyHeader, "env-key")
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	mock.ExpectQuery("INSERT INTO api_key_usage").WillReturnRows(usageRows(2, 10))
+	rec := call()
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Equal(t, "0", rec.Header().Get("X-Quota-Daily-Remaining"))
+	assert.Empty(t, rec.Header().Get("X-Quota-Monthly-Remaining"))
+
+	mock.ExpectQuery("INSERT INTO api_key_usage").WillReturnRows(usageRows(3, 11))
+	rec = call()
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Contains(t, rec.Body.String(), "cota diária da chave de API esgotada")
+	assert.NotEmpty(t, rec.Header().Get("Retry-After"))
+
+	// Accounting failures do not block the client.
+	mock.ExpectQuery("INSERT INTO api_key_usage").WillReturnError(errors.New("connection reset"))
+	assert.Equal(t, http.StatusBadRequest, call().Code)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// A key's own quotas override the defaults; 0 lifts the daily one.
+	unlimited, monthly := int64(0), int64(100)
+	key := &apikey.Key{ID: "k1", Quotas: apikey.Quotas{Daily: &unlimited, Monthly: &monthly}}
+	mock.ExpectQuery("INSERT INTO api_key_usage").WithArgs("k1", sqlmock.AnyArg(), sqlmock.AnyArg()).WillReturnRows(usageRows(50, 101))
+	remaining, err := app.chargeQuota(context.Background(), key)
+	var authErr *clientAuthError
+	if assert.ErrorAs(t, err, &authErr) {
+		assert.Equal(t, http.StatusTooManyRequests, authErr.status)
+		assert.Contains(t, authErr.message, "cota mensal")
+	}
+	assert.Equal(t, quotaRemaining{daily: -1, monthly: 0}, remaining)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestAPIKeyAdminHandlers(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "admin"
+	handler := app.routes()
+
+	call := func(method, path, body string, admin bool) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(method, path, strings.NewReader(body))
+		if admin {
+			req.Header.Set("Authorization", "Bearer admin")
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	assert.Equal(t, http.StatusUnauthorized, call(http.MethodGet, "/admin/apikeys", "", false).Code)
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/admin/apikeys", `{"name":" "}`, true).Code)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/admin/apikeys", `{"name":"parceiro","daily_quota":-1}`, true).Code)
+
+	daily := int64(1000)
+	mock.ExpectExec("INSERT INTO api_keys").
+		WithArgs(sqlmock.AnyArg(), "parceiro", sqlmock.AnyArg(), sqlmock.AnyArg(), &daily, (*int64)(nil)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	rec := call(http.MethodPost, "/admin/apikeys", `{"name":"parceiro","daily_quota":1000}`, true)
+	assert.Equal(t, http.StatusCreated, rec.Code)
+	var created struct {
+		ID   string `json:"id"`
+		Name string `json:"name"`
+		Key  string `json:"key"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &created))
+	assert.Equal(t, "parceiro", created.Name)
+	assert.NotEmpty(t, created.ID)
+	assert.True(t, strings.HasPrefix(created.Key, "gocep_"))
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM api_keys ORDER BY").
+		WillReturnRows(apiKeyRows().AddRow(created.ID, "parceiro", now, nil, 1000, nil))
+	rec = call(http.MethodGet, "/admin/apikeys", "", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"keys":[{"id":"`+created.ID+`","name":"parceiro","created_at":"2024-05-01T12:00:00Z","daily_quota":1000}]}`, rec.Body.String())
+	assert.NotContains(t, rec.Body.String(), created.Key)
+
+	mock.ExpectQuery("UPDATE api_keys SET revoked_at").WithArgs("nope", sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "created_at", "revoked_at", "daily_quota", "monthly_quota"}))
+	assert.Equal(t, http.StatusNotFound, call(http.MethodDelete, "/admin/apikeys/nope", "", true).Code)
+
+	mock.ExpectQuery("UPDATE api_keys SET revoked_at").WithArgs(created.ID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "created_at", "revoked_at", "daily_quota", "monthly_quota"}).AddRow("parceiro", now, now, 1000, nil))
+	rec = call(http.MethodDelete, "/admin/apikeys/"+created.ID, "", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), `"revoked_at":"2024-05-01T12:00:00Z"`)
+
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodGet, "/admin/apikeys/"+created.ID+"/usage?days=0", "", true).Code)
+	mock.ExpectQuery("FROM api_key_usage").WithArgs(created.ID, sqlmock.AnyArg()).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "requests"}).
+			AddRow(time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), 12).
+			AddRow(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 3))
+	rec = call(http.MethodGet, "/admin/apikeys/"+created.ID+"/usage?days=7", "", true)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"id":"`+created.ID+`","days":[{"date":"2024-04-30","requests":12},{"date":"2024-05-01","requests":3}],"total":15}`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,117 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strings"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/jwtauth"
+)
+
+// clientAuthError is a request refused by client authentication or quotas.
+// status is the HTTP answer; grpcAuthError maps it for gRPC.
+type clientAuthError struct {
+	status     int
+	message    string
+	retryAfter time.Duration
+}
+
+func (e *clientAuthError) Error() string { return e.message }
+
+// clientIdentity is who a request was authenticated as.
+type clientIdentity struct {
+	apiKey  string
+	subject string
+	quota   quotaRemaining
+}
+
+// requireClientAuth guards the public API when API_KEY_AUTH is on or
+// JWT_JWKS_URL is set. Clients send either X-API-Key or a JWT from the
+// cluster's identity provider as Authorization: Bearer; requests with neither
+// get 401. ADMIN_TOKEN is accepted too, and CORS preflights, which never carry
+// credentials, are not checked.
+func (app *application) requireClientAuth(next http.Handler) http.Handler {
+	if !app.cfg.apiKeyAuth && app.jwt == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method == http.MethodOptions || app.isAdmin(r) {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		identity, err := app.authenticateClient(r.Context(), r.Header.Get(apiKeyHeader), r.Header.Get("Authorization"))
+		if identity != nil {
+			identity.quota.setHeaders(w.Header())
+			if info, ok := r.Context().Value(routeInfoKey{}).(*routeInfo); ok {
+				info.apiKey, info.subject = identity.apiKey, identity.subject
+			}
+		}
+		if err != nil {
+			writeClientAuthError(w, err)
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// authenticateClient checks the credentials of a request, whichever transport
+// it came on: an API key when API_KEY_AUTH is on, otherwise a bearer token when
+// JWT_JWKS_URL is set. The identity is also returned when its quota is spent,
+// so the refusal can be attributed.
+func (app *application) authenticateClient(ctx context.Context, apiKey, authorization string) (*clientIdentity, error) {
+	if secret := strings.TrimSpace(apiKey); secret != "" && app.cfg.apiKeyAuth {
+		return app.authenticateAPIKeyQuota(ctx, secret)
+	}
+	if token, ok := strings.CutPrefix(authorization, "Bearer "); ok && app.jwt != nil {
+		claims, err := app.verifyBearerToken(ctx, strings.TrimSpace(token))
+		if err != nil {
+			return nil, err
+		}
+		return &clientIdentity{subject: claims.Subject, quota: noQuota}, nil
+	}
+	return nil, &clientAuthError{status: http.StatusUnauthorized, message: app.missingCredentialsMessage()}
+}
+
+// writeClientAuthError answers a request refused by authenticateClient.
+func writeClientAuthError(w http.ResponseWriter, err error) {
+	var authErr *clientAuthError
+	if !errors.As(err, &authErr) {
+		authErr = &clientAuthError{status: http.StatusInternalServerError, message: "erro interno"}
+	}
+	if authErr.retryAfter > 0 {
+		setRetryAfter(w, authErr.retryAfter)
+	}
+	writeJSON(w, authErr.status, map[string]string{"error": authErr.message})
+}
+
+// missingCredentialsMessage names the credentials this instance accepts.
+func (app *application) missingCredentialsMessage() string {
+	switch {
+	case app.jwt == nil:
+		return "chave de API ausente: envie o header " + apiKeyHeader
+	case !app.cfg.apiKeyAuth:
+		return "token ausente: envie o header Authorization: Bearer <token>"
+	default:
+		return "credenciais ausentes: envie o header " + apiKeyHeader + " ou Authorization: Bearer <token>"
+	}
+}
+
+// verifyBearerToken validates a JWT against JWT_JWKS_URL, JWT_ISSUER and
+// JWT_AUDIENCE, refusing a bad token with 401 and answering 503 when the
+// signing keys cannot be fetched.
+func (app *application) verifyBearerToken(ctx context.Context, token string) (*jwtauth.Claims, error) {
+	claims, err := app.jwt.Verify(ctx, token)
+	switch {
+	case errors.Is(err, jwtauth.ErrKeysUnavailable):
+		app.logger.ErrorContext(ctx, "erro ao obter chaves do JWKS", "err", err)
+		return nil, &clientAuthError{status: http.StatusServiceUnavailable, message: "falha ao validar token", retryAfter: outageRetryAfter}
+	case err != nil:
+		app.logger.DebugContext(ctx, "token JWT recusado", "err", err)
+		return nil, &clientAuthError{status: http.StatusUnauthorized, message: "token inválido"}
+	}
+	return claims, nil
+}
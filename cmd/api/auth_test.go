@@ -0,0 +1,133 @@
+package main
+
+import (
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/victor-dias21/goCep-k8s/internal/apikey"
+	"github.com/victor-dias21/goCep-k8s/internal/jwtauth"
+)
+
+// testIdP serves a JWKS with one P-256 key and signs ES256 tokens with it.
+type testIdP struct {
+	key    *ecdsa.PrivateKey
+	server *httptest.Server
+}
+
+func newTestIdP(t *testing.T) *testIdP {
+	t.Helper()
+	key, err := ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+	assert.NoError(t, err)
+	enc := base64.RawURLEncoding.EncodeToString
+
+	idp := &testIdP{key: key}
+	idp.server = httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		writeJSON(w, http.StatusOK, map[string]any{"keys": []map[string]string{{
+			"kty": "EC", "kid": "k1", "crv": "P-256",
+			"x": enc(key.X.FillBytes(make([]byte, 32))), "y": enc(key.Y.FillBytes(make([]byte, 32))),
+		}}})
+	}))
+	t.Cleanup(idp.server.Close)
+	return idp
+}
+
+func (idp *testIdP) token(t *testing.T, aud string) string {
+	t.Helper()
+	enc := base64.RawURLEncoding.EncodeToString
+	header, _ := json.Marshal(map[string]string{"alg": "ES256", "kid": "k1"})
+	claims, _ := json.Marshal(map[string]any{
+		"iss": idp.server.URL, "sub": "svc-frete", "aud": aud, "exp": time.Now().Add(time.Hour).Unix(),
+	})
+	signed := enc(header) + "." + enc(claims)
+	digest := sha256.Sum256([]byte(signed))
+	r, s, err := ecdsa.Sign(rand.Reader, idp.key, digest[:])
+	assert.NoError(t, err)
+	return signed + "." + enc(append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...))
+}
+
+func TestRequireClientAuthJWT(t *testing.T) {
+	idp := newTestIdP(t)
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.jwt = jwtauth.Config{JWKSURL: idp.server.URL, Issuer: idp.server.URL, Audience: "gocep"}
+	app.jwt = jwtauth.NewVerifier(app.cfg.jwt, idp.server.Client())
+
+	call := func(handler http.Handler, authorization string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+		if authorization != "" {
+			req.Header.Set("Authorization", authorization)
+		}
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	handler := app.routes()
+	assert.Equal(t, http.StatusBadRequest, call(handler, "Bearer "+idp.token(t, "gocep")).Code)
+
+	rec := call(handler, "Bearer "+idp.token(t, "outro"))
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "token inválido")
+
+	rec = call(handler, "")
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Contains(t, rec.Body.String(), "token ausente")
+
+	// With API keys enabled too, either credential works.
+	app.cfg.apiKeyAuth = true
+	app.cfg.apiKeys = map[string]string{apikey.Hash("env-key"): "parceiro"}
+	app.apiKeys = nil
+	handler = app.routes()
+	assert.Contains(t, call(handler, "").Body.String(), "credenciais ausentes")
+	assert.Equal(t, http.StatusBadRequest, call(handler, "Bearer "+idp.token(t, "gocep")).Code)
+	req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+	req.Header.Set(apiKeyHeader, "env-key")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestRequireClientAuthJWKSDown(t *testing.T) {
+	idp := newTestIdP(t)
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.jwt = jwtauth.Config{JWKSURL: idp.server.URL, Issuer: idp.server.URL, Audience: "gocep"}
+	app.jwt = jwtauth.NewVerifier(app.cfg.jwt, idp.server.Client())
+	token := idp.token(t, "gocep")
+	idp.server.Close()
+
+	req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+	req.Header.Set("Authorization", fmt.Sprintf("Bearer %s", token))
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+}
+
+func TestLoadConfigJWT(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+	t.Setenv("JWT_JWKS_URL", "https://idp.example.com/jwks")
+
+	_, err := loadConfig()
+	assert.ErrorContains(t, err, "JWT_ISSUER")
+
+	t.Setenv("JWT_ISSUER", "https://idp.example.com")
+	t.Setenv("JWT_AUDIENCE", "gocep")
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 30*time.Second, cfg.jwt.Leeway)
+
+	t.Setenv("JWT_JWKS_URL", "idp.example.com/jwks")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "JWT_JWKS_URL")
+}
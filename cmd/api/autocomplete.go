@@ -0,0 +1,43 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// streetAutocompleteHandler suggests cached streets for a partial name:
+// GET /autocomplete/streets?q=pauli&uf=SP&city=São Paulo&limit=10. It reads
+// the cache only, so streets nobody has looked up yet are not suggested.
+func (app *application) streetAutocompleteHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, ok := parseLimitParam(w, query.Get("limit"))
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	suggestions, err := app.service.AutocompleteStreets(ctx, cep.AutocompleteQuery{
+		Term:  query.Get("q"),
+		UF:    query.Get("uf"),
+		City:  query.Get("city"),
+		Limit: limit,
+	})
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, suggestions)
+	case r.Context().Err() != nil:
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, cep.ErrInvalidAutocomplete):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		app.logger.ErrorContext(r.Context(), "erro no autocomplete de logradouros", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar logradouros"})
+	}
+}
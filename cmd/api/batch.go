@@ -0,0 +1,301 @@
+package main
+
+import (
+	"context"
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"sort"
+	"strings"
+	"sync"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// batchTimeout bounds a whole batch request.
+const batchTimeout = 30 * time.Second
+
+// batchItem is the outcome of one CEP in a batch.
+type batchItem struct {
+	Data  *cep.Response `json:"data,omitempty"`
+	Error string        `json:"error,omitempty"`
+}
+
+// batchSummary counts the outcomes of a batch so clients need not walk the results.
+type batchSummary struct {
+	Total     int `json:"total"`
+	Succeeded int `json:"succeeded"`
+	Failed    int `json:"failed"`
+	NotFound  int `json:"not_found"`
+}
+
+// batchResponse is the body of POST /cep/batch.
+type batchResponse struct {
+	Summary batchSummary         `json:"summary"`
+	Results map[string]batchItem `json:"results"`
+}
+
+// status is 207 when some CEPs resolved and others did not, unless
+// BATCH_MULTI_STATUS is off.
+func (b *batchResponse) status(multiStatus bool) int {
+	mixed := b.Summary.Succeeded > 0 && b.Summary.Succeeded < b.Summary.Total
+	if mixed && multiStatus {
+		return http.StatusMultiStatus
+	}
+	return http.StatusOK
+}
+
+// batchHandler looks up a JSON array of CEPs concurrently and returns the
+// results keyed by CEP as submitted, with per-item errors.
+func (app *application) batchHandler(w http.ResponseWriter, r *http.Request) {
+	if isCSVRequest(r) {
+		app.batchCSVHandler(w, r)
+		return
+	}
+
+	keys, ok := readBatchKeys(w, r, app.cfg.batchMaxSize)
+	if !ok {
+		return
+	}
+
+	resp, hit := app.resolveBatch(r, keys)
+	if r.Context().Err() != nil {
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+	if hit {
+		w.Header().Set("X-Batch-Cache", "hit")
+	}
+	switch {
+	case wantsCSV(r):
+		app.writeBatchCSV(w, keys, resp)
+	case wantsProtobuf(r):
+		writeProtobuf(w, resp.status(app.cfg.batchMultiStatus), toProtoBatchResponse(resp))
+	case wantsMsgpack(r):
+		writeMsgpack(w, resp.status(app.cfg.batchMultiStatus), resp)
+	default:
+		writeJSON(w, resp.status(app.cfg.batchMultiStatus), resp)
+	}
+}
+
+// resolveBatch answers keys from the batch result cache or looks them up,
+// reporting whether the cache answered.
+func (app *application) resolveBatch(r *http.Request, keys []string) (*batchResponse, bool) {
+	cacheKey := batchCacheKey(keys)
+	if cached, ok := app.batchCache.get(cacheKey); ok {
+		return cached, true
+	}
+
+	epoch := app.batchCache.epoch()
+	ctx, cancel := context.WithTimeout(r.Context(), batchTimeout)
+	defer cancel()
+
+	resp := app.lookupBatch(ctx, keys)
+	// Batches cut short by the timeout, or with items that failed rather than
+	// resolved, are not worth repeating verbatim: the next try may succeed.
+	if ctx.Err() == nil && resp.Summary.Failed == 0 {
+		app.batchCache.set(cacheKey, resp, epoch)
+	}
+	return resp, false
+}
+
+// readBatchKeys decodes a JSON array of at most maxSize distinct CEPs, writing
+// the error response itself when the body is unusable.
+func readBatchKeys(w http.ResponseWriter, r *http.Request, maxSize int) ([]string, bool) {
+	// Each CEP fits comfortably in 32 bytes of JSON.
+	r.Body = http.MaxBytesReader(w, r.Body, int64(maxSize)*32+1024)
+
+	var ceps []string
+	if err := json.NewDecoder(r.Body).Decode(&ceps); err != nil {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "corpo inválido: esperado um array JSON de ceps"})
+		return nil, false
+	}
+	keys := uniqueKeys(ceps)
+	if len(keys) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "informe ao menos um cep"})
+		return nil, false
+	}
+	if len(keys) > maxSize {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{
+			"error": fmt.Sprintf("lote excede o limite de %d ceps", maxSize),
+		})
+		return nil, false
+	}
+	return keys, true
+}
+
+// lookupBatch resolves keys through a pool of BATCH_WORKERS goroutines.
+func (app *application) lookupBatch(ctx context.Context, keys []string) *batchResponse {
+	items, notFound := app.lookupBatchItems(ctx, keys)
+
+	resp := &batchResponse{
+		Summary: batchSummary{Total: len(keys)},
+		Results: make(map[string]batchItem, len(keys)),
+	}
+	for i, key := range keys {
+		resp.Results[key] = items[i]
+		switch {
+		case items[i].Data != nil:
+			resp.Summary.Succeeded++
+		case notFound[i]:
+			resp.Summary.NotFound++
+		default:
+			resp.Summary.Failed++
+		}
+	}
+	return resp
+}
+
+// lookupBatchItems runs the worker pool, returning the items in key order and
+// which of them are unknown CEPs.
+func (app *application) lookupBatchItems(ctx context.Context, keys []string) ([]batchItem, []bool) {
+	items := make([]batchItem, len(keys))
+	notFound := make([]bool, len(keys))
+
+	jobs := make(chan int)
+	var wg sync.WaitGroup
+	for range min(app.cfg.batchWorkers, len(keys)) {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for i := range jobs {
+				items[i], notFound[i] = app.lookupBatchItem(ctx, keys[i])
+			}
+		}()
+	}
+	for i := range keys {
+		jobs <- i
+	}
+	close(jobs)
+	wg.Wait()
+	return items, notFound
+}
+
+// lookupBatchItem resolves one CEP, reporting whether it is unknown.
+func (app *application) lookupBatchItem(ctx context.Context, key string) (batchItem, bool) {
+	result, err := app.service.Lookup(ctx, key)
+	switch {
+	case err == nil:
+		return batchItem{Data: result.Response}, false
+	case errors.Is(err, cep.ErrNotFound):
+		return batchItem{Error: "cep não encontrado"}, true
+	case errors.Is(err, cep.ErrInvalidCEP):
+		return batchItem{Error: err.Error()}, false
+	default:
+		if ctx.Err() == nil {
+			app.logger.ErrorContext(ctx, "erro ao buscar cep em lote", "cep", key, "err", err)
+		}
+		return batchItem{Error: "falha ao consultar cep"}, false
+	}
+}
+
+// uniqueKeys trims the submitted CEPs and drops blanks and repeats, keeping order.
+func uniqueKeys(ceps []string) []string {
+	seen := make(map[string]bool, len(ceps))
+	keys := make([]string, 0, len(ceps))
+	for _, value := range ceps {
+		value = strings.TrimSpace(value)
+		if value == "" || seen[value] {
+			continue
+		}
+		seen[value] = true
+		keys = append(keys, value)
+	}
+	return keys
+}
+
+// batchCacheKey hashes the sorted CEP set, so the same batch in another order
+// shares a cache entry.
+func batchCacheKey(keys []string) string {
+	sorted := append([]string(nil), keys...)
+	sort.Strings(sorted)
+	sum := sha256.Sum256([]byte(strings.Join(sorted, "\n")))
+	return hex.EncodeToString(sum[:])
+}
+
+// batchCache keeps whole batch responses for BATCH_RESULT_CACHE_TTL, so a
+// repeated identical batch skips per-CEP processing. A nil cache is disabled.
+type batchCache struct {
+	ttl time.Duration
+	now func() time.Time
+
+	mu      sync.Mutex
+	entries map[string]batchCacheEntry
+	// cleared counts clear calls, so batches resolved across one are not stored.
+	cleared uint64
+}
+
+type batchCacheEntry struct {
+	resp      *batchResponse
+	expiresAt time.Time
+}
+
+func newBatchCache(ttl time.Duration) *batchCache {
+	if ttl <= 0 {
+		return nil
+	}
+	return &batchCache{ttl: ttl, now: time.Now, entries: map[string]batchCacheEntry{}}
+}
+
+func (c *batchCache) get(key string) (*batchResponse, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !c.now().Before(entry.expiresAt) {
+		return nil, false
+	}
+	return entry.resp, true
+}
+
+// epoch is passed back to set by a batch about to be resolved.
+func (c *batchCache) epoch() uint64 {
+	if c == nil {
+		return 0
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.cleared
+}
+
+// set stores resp unless the cache was cleared since epoch was taken.
+func (c *batchCache) set(key string, resp *batchResponse, epoch uint64) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if c.cleared != epoch {
+		return
+	}
+
+	now := c.now()
+	// Entries live for seconds; sweeping on write keeps the map bounded by
+	// the batches submitted within one TTL.
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = batchCacheEntry{resp: resp, expiresAt: now.Add(c.ttl)}
+}
+
+// clear drops every stored batch, after the CEP cache was invalidated or expired.
+func (c *batchCache) clear() {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	clear(c.entries)
+	c.cleared++
+}
@@ -0,0 +1,145 @@
+package main
+
+import (
+	"encoding/csv"
+	"fmt"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// csvCEPColumn is the header, matched case-insensitively, of the column
+// holding the CEPs in an uploaded spreadsheet.
+const csvCEPColumn = "cep"
+
+// csvInputColumn heads the column echoing each requested key when a JSON batch
+// is answered as CSV; the address already has its own cep column.
+const csvInputColumn = "input"
+
+// isCSVRequest reports whether the batch body is a CSV upload.
+func isCSVRequest(r *http.Request) bool {
+	mediaType, _, err := mime.ParseMediaType(r.Header.Get("Content-Type"))
+	return err == nil && mediaType == "text/csv"
+}
+
+// batchCSVHandler enriches an uploaded CSV: every row is returned with its
+// original columns followed by the address fields, or the error, of the CEP in
+// its cep column. Rows keep their order, repeats included.
+func (app *application) batchCSVHandler(w http.ResponseWriter, r *http.Request) {
+	// Spreadsheet rows carry other columns, so allow far more than a CEP per row.
+	r.Body = http.MaxBytesReader(w, r.Body, int64(app.cfg.batchMaxSize)*1024+4096)
+
+	reader := csv.NewReader(r.Body)
+	reader.FieldsPerRecord = -1
+	records, err := reader.ReadAll()
+	if err != nil || len(records) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "corpo inválido: esperado um CSV com cabeçalho"})
+		return
+	}
+
+	header, rows := records[0], records[1:]
+	column := csvColumn(header, csvCEPColumn)
+	if column < 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": fmt.Sprintf("CSV sem coluna %q", csvCEPColumn)})
+		return
+	}
+
+	values := make([]string, len(rows))
+	for i, row := range rows {
+		if column < len(row) {
+			values[i] = strings.TrimSpace(row[column])
+		}
+	}
+	keys := uniqueKeys(values)
+	if len(keys) == 0 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "informe ao menos um cep"})
+		return
+	}
+	if len(keys) > app.cfg.batchMaxSize {
+		writeJSON(w, http.StatusRequestEntityTooLarge, map[string]string{
+			"error": fmt.Sprintf("lote excede o limite de %d ceps", app.cfg.batchMaxSize),
+		})
+		return
+	}
+
+	resp, hit := app.resolveBatch(r, keys)
+	if r.Context().Err() != nil {
+		w.WriteHeader(statusClientClosedRequest)
+		return
+	}
+	if hit {
+		w.Header().Set("X-Batch-Cache", "hit")
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="ceps.csv"`)
+
+	out := csv.NewWriter(w)
+	_ = out.Write(slices.Concat(header, csvEnrichedColumns()))
+	for i, row := range rows {
+		item := batchItem{Error: "informe o cep"}
+		if values[i] != "" {
+			item = resp.Results[values[i]]
+		}
+		_ = out.Write(slices.Concat(row, csvEnrichedValues(item)))
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		app.logger.Error("erro ao escrever lote csv", "err", err)
+	}
+}
+
+// writeBatchCSV answers a JSON batch with a CSV: a row per requested key, in
+// request order, with the address fields or the error of that key.
+func (app *application) writeBatchCSV(w http.ResponseWriter, keys []string, resp *batchResponse) {
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="ceps.csv"`)
+
+	out := csv.NewWriter(w)
+	_ = out.Write(append([]string{csvInputColumn}, csvEnrichedColumns()...))
+	for _, key := range keys {
+		_ = out.Write(append([]string{key}, csvEnrichedValues(resp.Results[key])...))
+	}
+	out.Flush()
+	if err := out.Error(); err != nil {
+		app.logger.Error("erro ao escrever lote csv", "err", err)
+	}
+}
+
+// csvColumn finds name in header, ignoring case, surrounding spaces and the
+// byte order mark some spreadsheet tools write before the first cell.
+func csvColumn(header []string, name string) int {
+	for i, cell := range header {
+		cell = strings.TrimSpace(strings.TrimPrefix(cell, "\ufeff"))
+		if strings.EqualFold(cell, name) {
+			return i
+		}
+	}
+	return -1
+}
+
+// csvEnrichedColumns are appended to the uploaded header: the address fields
+// by JSON name, then the per-row error.
+func csvEnrichedColumns() []string {
+	var columns []string
+	for _, field := range (&cep.Response{}).Fields() {
+		columns = append(columns, field.Name)
+	}
+	return append(columns, "error")
+}
+
+// csvEnrichedValues lines item up with csvEnrichedColumns.
+func csvEnrichedValues(item batchItem) []string {
+	data := item.Data
+	if data == nil {
+		data = &cep.Response{}
+	}
+	var values []string
+	for _, field := range data.Fields() {
+		values = append(values, field.Value)
+	}
+	return append(values, item.Error)
+}
@@ -0,0 +1,224 @@
+package main
+
+import (
+	"encoding/json"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	cepv1 "github.com/victor-dias21/goCep-k8s/proto/cep/v1"
+	"google.golang.org/protobuf/proto"
+)
+
+// newBatchTestApp runs the batch endpoint in memory-only mode, so concurrent
+// lookups need no ordered database expectations.
+func newBatchTestApp(t *testing.T, cfg config, provider *fakeProvider) *application {
+	t.Helper()
+	app := newApplication(cfg, noopLogger(), new(slog.LevelVar), nil, provider)
+	app.accessLog = io.Discard
+	return app
+}
+
+func postBatch(app *application, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/cep/batch", strings.NewReader(body)))
+	return rec
+}
+
+func TestBatchHandler(t *testing.T) {
+	provider := newFakeProvider(
+		cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"},
+		cep.Response{Cep: "20040-002", Localidade: "Rio de Janeiro", Uf: "RJ"},
+	)
+	app := newBatchTestApp(t, testConfig(), provider)
+
+	rec := postBatch(app, `["01001000", "20040-002", "99999999", "abc", "01001000"]`)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	var body batchResponse
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, batchSummary{Total: 4, Succeeded: 2, Failed: 1, NotFound: 1}, body.Summary)
+	assert.Equal(t, "São Paulo", body.Results["01001000"].Data.Localidade)
+	assert.Equal(t, "RJ", body.Results["20040-002"].Data.Uf)
+	assert.Equal(t, "cep não encontrado", body.Results["99999999"].Error)
+	assert.Contains(t, body.Results["abc"].Error, "invalid CEP")
+	assert.Equal(t, 1, provider.callsFor("01001000"), "repeated CEPs are looked up once")
+}
+
+func TestBatchHandlerStatus(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"})
+
+	for _, tc := range []struct {
+		name        string
+		body        string
+		multiStatus bool
+		status      int
+	}{
+		{name: "all found", body: `["01001000"]`, multiStatus: true, status: http.StatusOK},
+		{name: "mixed", body: `["01001000","99999999"]`, multiStatus: true, status: http.StatusMultiStatus},
+		{name: "mixed without multi-status", body: `["01001000","99999999"]`, status: http.StatusOK},
+		{name: "none found", body: `["99999999"]`, multiStatus: true, status: http.StatusOK},
+		{name: "not an array", body: `{"cep":"01001000"}`, multiStatus: true, status: http.StatusBadRequest},
+		{name: "empty", body: `[]`, multiStatus: true, status: http.StatusBadRequest},
+		{name: "too large", body: `["01001000","01001001","01001002"]`, multiStatus: true, status: http.StatusRequestEntityTooLarge},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			cfg := testConfig()
+			cfg.batchMaxSize = 2
+			cfg.batchMultiStatus = tc.multiStatus
+			app := newBatchTestApp(t, cfg, provider)
+
+			assert.Equal(t, tc.status, postBatch(app, tc.body).Code)
+		})
+	}
+}
+
+func TestBatchHandlerResultCache(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"}, cep.Response{Cep: "20040-002"})
+	cfg := testConfig()
+	cfg.batchResultCacheTTL = time.Minute
+	app := newBatchTestApp(t, cfg, provider)
+
+	first := postBatch(app, `["01001000","20040002"]`)
+	assert.Equal(t, http.StatusOK, first.Code)
+	assert.Empty(t, first.Header().Get("X-Batch-Cache"))
+	hitsBefore := app.service.Metrics().CacheHits
+
+	// Same CEP set in another order: served whole, without per-CEP lookups.
+	repeat := postBatch(app, `["20040002","01001000"]`)
+	assert.Equal(t, http.StatusOK, repeat.Code)
+	assert.Equal(t, "hit", repeat.Header().Get("X-Batch-Cache"))
+	assert.JSONEq(t, first.Body.String(), repeat.Body.String())
+	assert.Equal(t, hitsBefore, app.service.Metrics().CacheHits)
+	assert.Equal(t, 1, provider.callsFor("01001000"))
+
+	app.batchCache.now = func() time.Time { return time.Now().Add(2 * time.Minute) }
+	assert.Empty(t, postBatch(app, `["01001000","20040002"]`).Header().Get("X-Batch-Cache"))
+	assert.Equal(t, hitsBefore+2, app.service.Metrics().CacheHits)
+}
+
+func TestBatchHandlerResultCacheSkipsFailures(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"}, cep.Response{Cep: "20040-002"})
+	cfg := testConfig()
+	cfg.batchResultCacheTTL = time.Minute
+	app := newBatchTestApp(t, cfg, provider)
+
+	provider.setFailing("20040002", true)
+	rec := postBatch(app, `["01001000","20040002"]`)
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Contains(t, rec.Body.String(), "falha ao consultar cep")
+
+	// The provider recovered: the batch is recomputed, not replayed.
+	provider.setFailing("20040002", false)
+	rec = postBatch(app, `["01001000","20040002"]`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Empty(t, rec.Header().Get("X-Batch-Cache"))
+	assert.Equal(t, "hit", postBatch(app, `["01001000","20040002"]`).Header().Get("X-Batch-Cache"))
+}
+
+func TestBatchHandlerResultCacheClearedByAdmin(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"})
+	cfg := testConfig()
+	cfg.batchResultCacheTTL = time.Minute
+	cfg.adminToken = "s3cret"
+	app := newBatchTestApp(t, cfg, provider)
+
+	for _, admin := range []struct{ method, path string }{
+		{http.MethodDelete, "/admin/cache/01001000"},
+		{http.MethodPost, "/admin/cache/expire-all?confirm=true"},
+	} {
+		postBatch(app, `["01001000"]`)
+		assert.Equal(t, "hit", postBatch(app, `["01001000"]`).Header().Get("X-Batch-Cache"))
+
+		req := httptest.NewRequest(admin.method, admin.path, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code, admin.path)
+
+		assert.Empty(t, postBatch(app, `["01001000"]`).Header().Get("X-Batch-Cache"), admin.path)
+	}
+}
+
+func TestBatchHandlerDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.disabledEndpoints = map[string]bool{endpointBatch: true}
+	app := newBatchTestApp(t, cfg, newFakeProvider())
+
+	assert.Equal(t, http.StatusNotFound, postBatch(app, `["01001000"]`).Code)
+}
+
+func TestBatchHandlerCSV(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Localidade: "São Paulo", Uf: "SP"})
+	app := newBatchTestApp(t, testConfig(), provider)
+
+	body := "\ufeffcliente,CEP\nAna,01001000\nBruno,99999999\nCarla,\nDiego,01001000\n"
+	req := httptest.NewRequest(http.MethodPost, "/cep/batch", strings.NewReader(body))
+	req.Header.Set("Content-Type", "text/csv; charset=utf-8")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	assert.Equal(t, "\ufeffcliente,CEP,cep,logradouro,complemento,bairro,localidade,uf,ibge,gia,ddd,siafi,unidade,latitude,longitude,error\n"+
+		"Ana,01001000,01001-000,Praça da Sé,,,São Paulo,SP,,,,,,,,\n"+
+		"Bruno,99999999,,,,,,,,,,,,,,cep não encontrado\n"+
+		"Carla,,,,,,,,,,,,,,,informe o cep\n"+
+		"Diego,01001000,01001-000,Praça da Sé,,,São Paulo,SP,,,,,,,,\n", rec.Body.String())
+	assert.Equal(t, 1, provider.callsFor("01001000"))
+}
+
+func TestBatchHandlerCSVRejectsMissingColumn(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider())
+
+	req := httptest.NewRequest(http.MethodPost, "/cep/batch", strings.NewReader("cliente,codigo\nAna,01001000\n"))
+	req.Header.Set("Content-Type", "text/csv")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), `CSV sem coluna \"cep\"`)
+}
+
+func TestBatchHandlerAcceptCSV(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	app := newBatchTestApp(t, testConfig(), provider)
+
+	req := httptest.NewRequest(http.MethodPost, "/cep/batch", strings.NewReader(`["99999999", "01001000", "abc"]`))
+	req.Header.Set("Accept", "text/csv")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+	lines := strings.Split(strings.TrimSuffix(rec.Body.String(), "\n"), "\n")
+	assert.Len(t, lines, 4)
+	assert.Equal(t, "input,cep,logradouro,complemento,bairro,localidade,uf,ibge,gia,ddd,siafi,unidade,latitude,longitude,error", lines[0])
+	assert.Equal(t, "99999999,,,,,,,,,,,,,,cep não encontrado", lines[1])
+	assert.Equal(t, "01001000,01001-000,,,,São Paulo,SP,,,,,,,,", lines[2])
+	assert.True(t, strings.HasPrefix(lines[3], "abc,"), lines[3])
+}
+
+func TestBatchHandlerProtobuf(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	app := newBatchTestApp(t, testConfig(), provider)
+
+	req := httptest.NewRequest(http.MethodPost, "/cep/batch", strings.NewReader(`["01001000", "99999999"]`))
+	req.Header.Set("Accept", "application/x-protobuf")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusMultiStatus, rec.Code)
+	assert.Equal(t, "application/x-protobuf", rec.Header().Get("Content-Type"))
+	var msg cepv1.BatchGetCepResponse
+	assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &msg))
+	assert.Equal(t, int32(2), msg.GetSummary().GetTotal())
+	assert.Equal(t, "São Paulo", msg.GetResults()["01001000"].GetAddress().GetLocalidade())
+	assert.Equal(t, "cep não encontrado", msg.GetResults()["99999999"].GetError())
+}
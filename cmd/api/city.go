@@ -0,0 +1,39 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/gorilla/mux"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// cityResponse lists the cached CEPs of a municipality grouped by bairro.
+type cityResponse struct {
+	IBGE    string            `json:"ibge"`
+	Bairros []cep.BairroGroup `json:"bairros"`
+}
+
+// cityHandler returns every cached CEP of an IBGE municipality code. It reads the
+// cache only, so a city nobody has looked up yet comes back empty.
+func (app *application) cityHandler(w http.ResponseWriter, r *http.Request) {
+	ibge := mux.Vars(r)["ibge"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	groups, err := app.service.ListByIBGE(ctx, ibge)
+	if err != nil {
+		if errors.Is(err, cep.ErrInvalidIBGE) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		app.logger.ErrorContext(r.Context(), "erro ao listar ceps da cidade", "ibge", ibge, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar cidade"})
+		return
+	}
+
+	writeJSON(w, http.StatusOK, cityResponse{IBGE: ibge, Bairros: groups})
+}
@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCityHandler(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+
+	mock.ExpectQuery(`SELECT cep, payload FROM ceps WHERE payload->>'ibge' = \$1`).
+		WithArgs("3550308").
+		WillReturnRows(sqlmock.NewRows([]string{"cep", "payload"}).
+			AddRow("01002000", []byte(`{"cep":"01002-000","bairro":"Sé","ibge":"3550308"}`)).
+			AddRow("01310100", []byte(`{"cep":"01310-100","bairro":"Bela Vista","ibge":"3550308"}`)))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/city/3550308", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"ibge":"3550308","bairros":[
+		{"bairro":"Bela Vista","ceps":[{"cep":"01310-100","logradouro":"","complemento":"","bairro":"Bela Vista","localidade":"","uf":"","ibge":"3550308","gia":"","ddd":"","siafi":"","unidade":""}]},
+		{"bairro":"Sé","ceps":[{"cep":"01002-000","logradouro":"","complemento":"","bairro":"Sé","localidade":"","uf":"","ibge":"3550308","gia":"","ddd":"","siafi":"","unidade":""}]}
+	]}`, rec.Body.String())
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/city/123", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
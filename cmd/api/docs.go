@@ -0,0 +1,21 @@
+package main
+
+import (
+	"embed"
+	"io/fs"
+	"net/http"
+)
+
+// swaggerUI holds Swagger UI and the page that points it at /openapi.json.
+// See swaggerui/NOTICE for where the assets come from.
+//
+//go:embed swaggerui/index.html swaggerui/swagger-ui-bundle.js swaggerui/swagger-ui.css swaggerui/favicon-32x32.png
+var swaggerUI embed.FS
+
+// docsHandler serves the embedded Swagger UI under /docs/, a playground for
+// the OpenAPI document against the live deployment.
+func docsHandler() http.Handler {
+	// The directory is embedded above, so Sub cannot fail.
+	files, _ := fs.Sub(swaggerUI, "swaggerui")
+	return http.StripPrefix("/docs/", http.FileServer(http.FS(files)))
+}
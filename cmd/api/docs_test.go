@@ -0,0 +1,50 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestDocsHandler(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider())
+	get := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	page := get("/docs/")
+	assert.Equal(t, http.StatusOK, page.Code)
+	assert.Contains(t, page.Header().Get("Content-Type"), "text/html")
+	assert.Contains(t, page.Body.String(), `url: "../openapi.json"`)
+
+	for path, contentType := range map[string]string{
+		"/docs/swagger-ui-bundle.js": "text/javascript",
+		"/docs/swagger-ui.css":       "text/css",
+	} {
+		rec := get(path)
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Contains(t, rec.Header().Get("Content-Type"), contentType, path)
+	}
+
+	redirect := get("/docs")
+	assert.Equal(t, http.StatusMovedPermanently, redirect.Code)
+	assert.Equal(t, "/docs/", redirect.Header().Get("Location"))
+
+	assert.Equal(t, http.StatusNotFound, get("/docs/NOTICE").Code, "only the UI assets are embedded")
+}
+
+func TestDocsDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.disabledEndpoints = map[string]bool{endpointDocs: true}
+	app := newBatchTestApp(t, cfg, newFakeProvider())
+
+	for _, path := range []string{"/docs/", "/openapi.json"} {
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, http.StatusNotFound, rec.Code, path)
+	}
+}
@@ -0,0 +1,93 @@
+package main
+
+import (
+	"crypto/sha256"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// responseETag derives a weak validator from the address and the requested
+// representation. It only changes when the cached payload does, so polling
+// clients can revalidate with If-None-Match. It is weak because timestamps and
+// map ordering in some formats may vary between equivalent bodies.
+func responseETag(resp *cep.Response, format string, selection fieldSet) string {
+	h := sha256.New()
+	h.Write([]byte(format))
+	if selection != nil {
+		names := make([]string, 0, len(selection))
+		for name := range selection {
+			names = append(names, name)
+		}
+		slices.Sort(names)
+		h.Write([]byte(";fields=" + strings.Join(names, ",")))
+	}
+	h.Write([]byte{'\n'})
+	// Marshalling a struct is deterministic, which keeps the tag stable across
+	// replicas and restarts.
+	_ = json.NewEncoder(h).Encode(resp)
+	return `W/"` + hex.EncodeToString(h.Sum(nil)[:16]) + `"`
+}
+
+// etagMatches applies the weak comparison of If-None-Match: any listed tag,
+// or *, matches regardless of the W/ prefix.
+func etagMatches(header, etag string) bool {
+	if header == "" {
+		return false
+	}
+	etag = strings.TrimPrefix(etag, "W/")
+	for _, candidate := range strings.Split(header, ",") {
+		candidate = strings.TrimSpace(candidate)
+		if candidate == "*" || strings.TrimPrefix(candidate, "W/") == etag {
+			return true
+		}
+	}
+	return false
+}
+
+// setCacheHeaders lets browsers and CDNs cache a lookup for as long as the
+// service itself would: max-age is what is left of CACHE_TTL for the served
+// entry, and Last-Modified is when it was fetched from the provider. The body
+// depends on Accept, and when clients must authenticate only their own cache
+// may keep it, so a shared one never serves it to a caller without credentials.
+func (app *application) setCacheHeaders(w http.ResponseWriter, result *cep.Result) {
+	w.Header().Add("Vary", "Accept")
+	if !result.UpdatedAt.IsZero() {
+		w.Header().Set("Last-Modified", result.UpdatedAt.UTC().Format(http.TimeFormat))
+	}
+	if app.cfg.cacheTTL <= 0 {
+		// Entries never expire; leave freshness to the client's heuristics.
+		return
+	}
+
+	remaining := app.cfg.cacheTTL - result.Age
+	if result.Stale || remaining < 0 {
+		remaining = 0
+	}
+	scope := "public"
+	if app.cfg.apiKeyAuth || app.jwt != nil {
+		scope = "private"
+	}
+	w.Header().Set("Cache-Control", fmt.Sprintf("%s, max-age=%d", scope, int(remaining.Seconds())))
+}
+
+// notModified evaluates the conditional headers of r. If-None-Match takes
+// precedence; If-Modified-Since is only consulted without it, as RFC 9110
+// requires.
+func notModified(r *http.Request, etag string, updatedAt time.Time) bool {
+	if header := r.Header.Get("If-None-Match"); header != "" {
+		return etagMatches(header, etag)
+	}
+	since, err := http.ParseTime(r.Header.Get("If-Modified-Since"))
+	if err != nil || updatedAt.IsZero() {
+		return false
+	}
+	// HTTP dates have one-second resolution.
+	return !updatedAt.Truncate(time.Second).After(since)
+}
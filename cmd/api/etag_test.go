@@ -0,0 +1,132 @@
+package main
+
+import (
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/apikey"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestCEPHandlerConditionalGet(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	app := newBatchTestApp(t, testConfig(), provider)
+	get := func(path, ifNoneMatch string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		if ifNoneMatch != "" {
+			req.Header.Set("If-None-Match", ifNoneMatch)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	first := get("/cep/01001000", "")
+	assert.Equal(t, http.StatusOK, first.Code)
+	etag := first.Header().Get("ETag")
+	assert.Regexp(t, `^W/"[0-9a-f]{32}"$`, etag)
+
+	again := get("/cep/01001000", etag)
+	assert.Equal(t, http.StatusNotModified, again.Code)
+	assert.Empty(t, again.Body.String())
+	assert.Equal(t, etag, again.Header().Get("ETag"))
+
+	assert.Equal(t, http.StatusOK, get("/cep/01001000", `W/"outdated"`).Code)
+
+	fields := get("/cep/01001000?fields=cep", etag)
+	assert.Equal(t, http.StatusOK, fields.Code, "another representation has another tag")
+	assert.NotEqual(t, etag, fields.Header().Get("ETag"))
+
+	meta := get("/cep/01001000?meta=true", etag)
+	assert.Equal(t, http.StatusOK, meta.Code)
+	assert.Empty(t, meta.Header().Get("ETag"))
+}
+
+func TestEtagMatches(t *testing.T) {
+	const etag = `W/"abc"`
+	for _, tc := range []struct {
+		header string
+		want   bool
+	}{
+		{header: "", want: false},
+		{header: `W/"abc"`, want: true},
+		{header: `"abc"`, want: true},
+		{header: `"x", W/"abc"`, want: true},
+		{header: "*", want: true},
+		{header: `"abcd"`, want: false},
+	} {
+		assert.Equal(t, tc.want, etagMatches(tc.header, etag), "If-None-Match: %s", tc.header)
+	}
+}
+
+func TestCEPHandlerCacheHeaders(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000", Uf: "SP"})
+	assert.NoError(t, err)
+	updatedAt := time.Now().Add(-20 * time.Minute).UTC()
+
+	lookup := func(t *testing.T, ifModifiedSince, apiKey string) *httptest.ResponseRecorder {
+		app, mock := newTestApp(t, &stubHTTPClient{})
+		if apiKey != "" {
+			app.cfg.apiKeyAuth = true
+			app.cfg.apiKeys = map[string]string{apikey.Hash(apiKey): "parceiro"}
+		}
+		mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, updatedAt, cep.CacheSchemaVersion))
+
+		req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+		if ifModifiedSince != "" {
+			req.Header.Set("If-Modified-Since", ifModifiedSince)
+		}
+		if apiKey != "" {
+			req.Header.Set("X-API-Key", apiKey)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := lookup(t, "", "")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, updatedAt.Format(http.TimeFormat), rec.Header().Get("Last-Modified"))
+	var maxAge int
+	_, err = fmt.Sscanf(rec.Header().Get("Cache-Control"), "public, max-age=%d", &maxAge)
+	assert.NoError(t, err)
+	assert.InDelta(t, 40*60, maxAge, 5, "CACHE_TTL of 1h minus 20m of age")
+	assert.Contains(t, rec.Header().Values("Vary"), "Accept")
+
+	assert.Equal(t, http.StatusNotModified, lookup(t, rec.Header().Get("Last-Modified"), "").Code)
+	assert.Equal(t, http.StatusOK, lookup(t, updatedAt.Add(-time.Minute).Format(http.TimeFormat), "").Code)
+
+	// Shared caches must not hand an authenticated response to anyone else.
+	rec = lookup(t, "", "env-key")
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.True(t, strings.HasPrefix(rec.Header().Get("Cache-Control"), "private, max-age="), rec.Header().Get("Cache-Control"))
+}
+
+func TestNotModified(t *testing.T) {
+	updatedAt := time.Date(2024, 5, 1, 12, 0, 0, 500, time.UTC)
+	for _, tc := range []struct {
+		name                    string
+		ifNoneMatch, ifModSince string
+		want                    bool
+	}{
+		{name: "no conditions", want: false},
+		{name: "modified since", ifModSince: "Wed, 01 May 2024 11:59:59 GMT", want: false},
+		{name: "not modified since", ifModSince: "Wed, 01 May 2024 12:00:00 GMT", want: true},
+		{name: "etag wins", ifNoneMatch: `"other"`, ifModSince: "Wed, 01 May 2024 12:00:00 GMT", want: false},
+		{name: "bad date", ifModSince: "yesterday", want: false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+		req.Header.Set("If-None-Match", tc.ifNoneMatch)
+		req.Header.Set("If-Modified-Since", tc.ifModSince)
+		assert.Equal(t, tc.want, notModified(req, `W/"abc"`, updatedAt), tc.name)
+	}
+}
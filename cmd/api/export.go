@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/csv"
+	"errors"
+	"net/http"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// exportColumns is the CSV header; the first two columns form the resume cursor.
+var exportColumns = []string{
+	"updated_at", "cep", "logradouro", "complemento", "bairro", "localidade",
+	"uf", "ibge", "gia", "ddd", "siafi", "unidade",
+}
+
+// exportHandler streams the whole cache as CSV in (updated_at, cep) order.
+// Clients resume an interrupted download with ?after=<updated_at>,<cep> taken
+// from the last row they received; resumed exports omit the header row.
+func (app *application) exportHandler(w http.ResponseWriter, r *http.Request) {
+	var after *cep.ExportCursor
+	if raw := r.URL.Query().Get("after"); raw != "" {
+		cursor, err := cep.ParseExportCursor(raw)
+		if err != nil {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		after = &cursor
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.Header().Set("Content-Disposition", `attachment; filename="ceps.csv"`)
+
+	out := csv.NewWriter(w)
+	flusher := http.NewResponseController(w)
+
+	// Large exports outlive the server-wide write timeout.
+	if err := flusher.SetWriteDeadline(time.Time{}); err != nil && !errors.Is(err, http.ErrNotSupported) {
+		app.logger.WarnContext(r.Context(), "erro ao remover deadline de escrita da exportação", "err", err)
+	}
+
+	if after == nil {
+		_ = out.Write(exportColumns)
+	}
+
+	var rows int
+	err := app.service.Export(r.Context(), after, func(row cep.ExportRow) error {
+		resp := row.Response
+		record := []string{
+			row.Cursor.UpdatedAt.UTC().Format(time.RFC3339Nano), row.Cursor.CEP,
+			resp.Logradouro, resp.Complemento, resp.Bairro, resp.Localidade,
+			resp.Uf, resp.Ibge, resp.Gia, resp.DDD, resp.Siafi, resp.Unidade,
+		}
+		if err := out.Write(record); err != nil {
+			return err
+		}
+
+		rows++
+		if rows%500 == 0 {
+			out.Flush()
+			if err := out.Error(); err != nil {
+				return err
+			}
+			if err := flusher.Flush(); err != nil && !errors.Is(err, http.ErrNotSupported) {
+				return err
+			}
+		}
+		return nil
+	})
+	out.Flush()
+
+	if err != nil {
+		// Headers are already sent; the truncated body plus this log is all we can do.
+		// The client resumes from the last complete row it received.
+		app.logger.ErrorContext(r.Context(), "erro ao exportar cache", "rows", rows, "err", err)
+	}
+}
@@ -0,0 +1,79 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportHandler(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"cep", "payload", "updated_at"}
+
+	t.Run("requires admin", func(t *testing.T) {
+		app, _ := newTestApp(t, &stubHTTPClient{})
+		app.cfg.adminToken = "s3cret"
+
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/export", nil))
+		assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	})
+
+	t.Run("initial export", func(t *testing.T) {
+		app, mock := newTestApp(t, &stubHTTPClient{})
+		app.cfg.adminToken = "s3cret"
+
+		mock.ExpectQuery(`SELECT cep, payload, updated_at FROM ceps ORDER BY updated_at, cep`).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("01001000", []byte(`{"cep":"01001-000","logradouro":"Praça da Sé","uf":"SP"}`), base))
+
+		req := httptest.NewRequest(http.MethodGet, "/export", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+		assert.Equal(t,
+			"updated_at,cep,logradouro,complemento,bairro,localidade,uf,ibge,gia,ddd,siafi,unidade\n"+
+				"2024-01-01T00:00:00Z,01001000,Praça da Sé,,,,SP,,,,,\n",
+			rec.Body.String(),
+		)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("resumed export", func(t *testing.T) {
+		app, mock := newTestApp(t, &stubHTTPClient{})
+		app.cfg.adminToken = "s3cret"
+
+		mock.ExpectQuery(`WHERE \(updated_at, cep\) > \(\$2, \$3\)`).
+			WithArgs(sqlmock.AnyArg(), base, "01001000").
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("01002000", []byte(`{"cep":"01002-000","uf":"SP"}`), base.Add(time.Second)))
+
+		req := httptest.NewRequest(http.MethodGet, "/export?after=2024-01-01T00:00:00Z,01001000", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Equal(t, "2024-01-01T00:00:01Z,01002000,,,,,SP,,,,,\n", rec.Body.String())
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid cursor", func(t *testing.T) {
+		app, _ := newTestApp(t, &stubHTTPClient{})
+		app.cfg.adminToken = "s3cret"
+
+		req := httptest.NewRequest(http.MethodGet, "/export?after=nope", nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+	})
+}
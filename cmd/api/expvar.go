@@ -0,0 +1,42 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"expvar"
+	"fmt"
+	"net/http"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// countUpstreamFailure adds a failed provider call to the per-provider counts
+// shown in /admin/debug/vars. Unknown CEPs are not failures.
+func countUpstreamFailure(failures *expvar.Map, provider string, err error) {
+	if err != nil && !errors.Is(err, cep.ErrNotFound) {
+		failures.Add(provider, 1)
+	}
+}
+
+// expvarHandler serves the expvar JSON document: the variables published in
+// the process, such as memstats and cmdline, plus a "gocep" object with this
+// application's counters. Those are not registered with expvar.Publish, which
+// is process-wide and panics when a second application is built, as in tests.
+func (app *application) expvarHandler(w http.ResponseWriter, r *http.Request) {
+	gocep, err := json.Marshal(map[string]any{
+		"lookups":           app.service.Metrics(),
+		"upstream_failures": json.RawMessage(app.upstreamFailures.String()),
+		"circuits":          app.service.CircuitStates(),
+	})
+	if err != nil {
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao serializar variáveis"})
+		return
+	}
+
+	w.Header().Set("Content-Type", "application/json; charset=utf-8")
+	fmt.Fprintf(w, "{\n")
+	expvar.Do(func(kv expvar.KeyValue) {
+		fmt.Fprintf(w, "%q: %s,\n", kv.Key, kv.Value)
+	})
+	fmt.Fprintf(w, "%q: %s\n}\n", "gocep", gocep)
+}
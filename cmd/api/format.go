@@ -0,0 +1,255 @@
+package main
+
+import (
+	"encoding/csv"
+	"encoding/json"
+	"fmt"
+	"log/slog"
+	"mime"
+	"net/http"
+	"slices"
+	"strings"
+
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// formatKV is the ?format= value for newline-separated key=value output.
+const formatKV = "kv"
+
+// formatCSV is the ?format= value for a header row plus a CSV row per address.
+const formatCSV = "csv"
+
+// Binary formats for internal clients that want smaller, faster-to-decode
+// payloads than JSON. Protobuf uses the messages of proto/cep/v1; msgpack keeps
+// the JSON field names.
+const (
+	formatProtobuf    = "protobuf"
+	formatMsgpack     = "msgpack"
+	mediaTypeProtobuf = "application/x-protobuf"
+	mediaTypeMsgpack  = "application/msgpack"
+)
+
+// kvValueReplacer keeps each field on a single line.
+var kvValueReplacer = strings.NewReplacer("\r\n", " ", "\n", " ", "\r", " ")
+
+// kvOptionalFields are omitted when empty, matching their omitempty JSON tags.
+var kvOptionalFields = map[string]bool{"latitude": true, "longitude": true}
+
+// wantsKV reports whether the client asked for key=value output, either with
+// ?format=kv or by preferring text/plain in Accept.
+func wantsKV(r *http.Request) bool {
+	return wantsFormat(r, formatKV, "text/plain")
+}
+
+// wantsCSV reports whether the client asked for CSV output, either with
+// ?format=csv or by listing text/csv in Accept.
+func wantsCSV(r *http.Request) bool {
+	return wantsFormat(r, formatCSV, "text/csv")
+}
+
+// wantsProtobuf reports whether the client asked for protobuf output, either
+// with ?format=protobuf or by listing application/x-protobuf in Accept.
+func wantsProtobuf(r *http.Request) bool {
+	return wantsFormat(r, formatProtobuf, mediaTypeProtobuf, "application/protobuf")
+}
+
+// wantsMsgpack reports whether the client asked for MessagePack output, either
+// with ?format=msgpack or by listing application/msgpack in Accept.
+func wantsMsgpack(r *http.Request) bool {
+	return wantsFormat(r, formatMsgpack, mediaTypeMsgpack, "application/x-msgpack")
+}
+
+// formatJSON names the default representation in responseFormat.
+const formatJSON = "json"
+
+// responseFormat picks the representation of a single lookup, in the order the
+// formats are checked: csv, kv, protobuf, msgpack, then JSON.
+func responseFormat(r *http.Request) string {
+	switch {
+	case wantsCSV(r):
+		return formatCSV
+	case wantsKV(r):
+		return formatKV
+	case wantsProtobuf(r):
+		return formatProtobuf
+	case wantsMsgpack(r):
+		return formatMsgpack
+	default:
+		return formatJSON
+	}
+}
+
+// wantsFormat checks ?format= against format, falling back to looking for one
+// of mediaTypes in Accept when the query does not name a format.
+func wantsFormat(r *http.Request, format string, mediaTypes ...string) bool {
+	if value := r.URL.Query().Get("format"); value != "" {
+		return strings.EqualFold(value, format)
+	}
+	for _, part := range strings.Split(r.Header.Get("Accept"), ",") {
+		accepted, _, err := mime.ParseMediaType(strings.TrimSpace(part))
+		if err == nil && slices.Contains(mediaTypes, accepted) {
+			return true
+		}
+	}
+	return false
+}
+
+// textFields lists the fields of resp written by the text formats: the address
+// fields, then the timezone, filtered by selection.
+func textFields(resp *cep.Response, selection fieldSet) []cep.Field {
+	all := resp.Fields()
+	if resp.Timezone != "" {
+		all = append(all, cep.Field{Name: "timezone", Value: resp.Timezone})
+	}
+
+	var fields []cep.Field
+	for _, field := range all {
+		// Like the JSON output, omit optional fields the provider left empty.
+		if field.Value == "" && kvOptionalFields[field.Name] {
+			continue
+		}
+		if selection != nil && !selection[field.Name] {
+			continue
+		}
+		fields = append(fields, field)
+	}
+	return fields
+}
+
+// writeKV writes resp as key=value lines using the JSON field names, for shell
+// scripts and spreadsheet imports. A non-nil selection limits the fields.
+func writeKV(w http.ResponseWriter, status int, resp *cep.Response, selection fieldSet) {
+	var b strings.Builder
+	for _, field := range textFields(resp, selection) {
+		fmt.Fprintf(&b, "%s=%s\n", field.Name, kvValueReplacer.Replace(field.Value))
+	}
+
+	w.Header().Set("Content-Type", "text/plain; charset=utf-8")
+	w.WriteHeader(status)
+	if _, err := w.Write([]byte(b.String())); err != nil {
+		slog.Error("erro ao escrever resposta kv", "err", err)
+	}
+}
+
+// writeCSV writes resp as a header row of JSON field names and a single row of
+// values, ready to be appended to a spreadsheet or loaded by an ETL job.
+func writeCSV(w http.ResponseWriter, status int, resp *cep.Response, selection fieldSet) {
+	fields := textFields(resp, selection)
+	header := make([]string, len(fields))
+	values := make([]string, len(fields))
+	for i, field := range fields {
+		header[i], values[i] = field.Name, field.Value
+	}
+
+	w.Header().Set("Content-Type", "text/csv; charset=utf-8")
+	w.WriteHeader(status)
+	out := csv.NewWriter(w)
+	_ = out.Write(header)
+	_ = out.Write(values)
+	out.Flush()
+	if err := out.Error(); err != nil {
+		slog.Error("erro ao escrever resposta csv", "err", err)
+	}
+}
+
+// writeProtobuf writes msg in the protobuf wire format.
+func writeProtobuf(w http.ResponseWriter, status int, msg proto.Message) {
+	payload, err := proto.Marshal(msg)
+	if err != nil {
+		slog.Error("erro ao codificar resposta protobuf", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao codificar resposta"})
+		return
+	}
+	w.Header().Set("Content-Type", mediaTypeProtobuf)
+	w.WriteHeader(status)
+	if _, err := w.Write(payload); err != nil {
+		slog.Error("erro ao escrever resposta protobuf", "err", err)
+	}
+}
+
+// writeMsgpack writes data as MessagePack, reading the json struct tags so the
+// keys match the JSON output.
+func writeMsgpack(w http.ResponseWriter, status int, data any) {
+	w.Header().Set("Content-Type", mediaTypeMsgpack)
+	w.WriteHeader(status)
+	enc := msgpack.NewEncoder(w)
+	enc.SetCustomStructTag("json")
+	if err := enc.Encode(data); err != nil {
+		slog.Error("erro ao escrever resposta msgpack", "err", err)
+	}
+}
+
+// selectProtoFields clears the fields of msg that are not in selection, the
+// protobuf counterpart of selectFields.
+func selectProtoFields(msg proto.Message, selection fieldSet) {
+	if selection == nil {
+		return
+	}
+	m := msg.ProtoReflect()
+	fields := m.Descriptor().Fields()
+	for i := 0; i < fields.Len(); i++ {
+		if field := fields.Get(i); !selection[string(field.Name())] {
+			m.Clear(field)
+		}
+	}
+}
+
+// fieldSet is a ?fields= selection of response fields by JSON name. A nil set
+// selects every field.
+type fieldSet map[string]bool
+
+// selectableFields are the names ?fields= accepts: the address fields plus the
+// request-time enrichments.
+func selectableFields() map[string]bool {
+	names := map[string]bool{"timezone": true, "complemento_parsed": true}
+	for _, field := range (&cep.Response{}).Fields() {
+		names[field.Name] = true
+	}
+	return names
+}
+
+// parseFields reads ?fields=cep,localidade,uf. Unknown names are rejected so
+// typos do not silently return an empty object.
+func parseFields(raw string) (fieldSet, error) {
+	if strings.TrimSpace(raw) == "" {
+		return nil, nil
+	}
+	known := selectableFields()
+	selection := fieldSet{}
+	for _, name := range strings.Split(raw, ",") {
+		name = strings.ToLower(strings.TrimSpace(name))
+		if name == "" {
+			continue
+		}
+		if !known[name] {
+			return nil, fmt.Errorf("campo desconhecido em fields: %q", name)
+		}
+		selection[name] = true
+	}
+	return selection, nil
+}
+
+// selectFields returns resp reduced to the selected JSON fields, or resp itself
+// when nothing was selected.
+func selectFields(resp *cep.Response, selection fieldSet) any {
+	if selection == nil {
+		return resp
+	}
+	raw, err := json.Marshal(resp)
+	if err != nil {
+		return resp
+	}
+	var fields map[string]any
+	if err := json.Unmarshal(raw, &fields); err != nil {
+		return resp
+	}
+	for name := range fields {
+		if !selection[name] {
+			delete(fields, name)
+		}
+	}
+	return fields
+}
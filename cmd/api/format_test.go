@@ -0,0 +1,220 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	cepv1 "github.com/victor-dias21/goCep-k8s/proto/cep/v1"
+	"github.com/vmihailenco/msgpack/v5"
+	"google.golang.org/protobuf/proto"
+)
+
+func TestCEPHandlerKeyValueFormat(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{
+		Cep:        "01001-000",
+		Logradouro: "Praça da Sé",
+		Bairro:     "Sé",
+		Localidade: "São Paulo",
+		Uf:         "SP",
+		Ibge:       "3550308",
+		Gia:        "1004",
+		DDD:        "11",
+		Siafi:      "7107",
+	})
+	assert.NoError(t, err)
+
+	const want = "cep=01001-000\n" +
+		"logradouro=Praça da Sé\n" +
+		"complemento=\n" +
+		"bairro=Sé\n" +
+		"localidade=São Paulo\n" +
+		"uf=SP\n" +
+		"ibge=3550308\n" +
+		"gia=1004\n" +
+		"ddd=11\n" +
+		"siafi=7107\n" +
+		"unidade=\n"
+
+	for name, setup := range map[string]func(*http.Request){
+		"query param": func(r *http.Request) { r.URL.RawQuery = "format=kv" },
+		"accept":      func(r *http.Request) { r.Header.Set("Accept", "text/plain;q=0.9, */*;q=0.1") },
+	} {
+		t.Run(name, func(t *testing.T) {
+			app, mock := newTestApp(t, &stubHTTPClient{})
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("01001000").
+				WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+			req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+			setup(req)
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/plain; charset=utf-8", rec.Header().Get("Content-Type"))
+			assert.Equal(t, want, rec.Body.String())
+		})
+	}
+}
+
+func TestCEPHandlerCSVFormat(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé, lado ímpar", Localidade: "São Paulo", Uf: "SP"})
+	assert.NoError(t, err)
+
+	for name, tc := range map[string]struct {
+		query, accept, want string
+	}{
+		"query param": {
+			query: "format=csv",
+			want: "cep,logradouro,complemento,bairro,localidade,uf,ibge,gia,ddd,siafi,unidade\n" +
+				"01001-000,\"Praça da Sé, lado ímpar\",,,São Paulo,SP,,,,,\n",
+		},
+		"accept with fields": {
+			query:  "fields=cep,uf",
+			accept: "text/csv",
+			want:   "cep,uf\n01001-000,SP\n",
+		},
+	} {
+		t.Run(name, func(t *testing.T) {
+			app, mock := newTestApp(t, &stubHTTPClient{})
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("01001000").
+				WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+			req := httptest.NewRequest(http.MethodGet, "/cep/01001000?"+tc.query, nil)
+			req.Header.Set("Accept", tc.accept)
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "text/csv; charset=utf-8", rec.Header().Get("Content-Type"))
+			assert.Equal(t, tc.want, rec.Body.String())
+		})
+	}
+}
+
+func TestCEPHandlerBinaryFormats(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Localidade: "São Paulo", Uf: "SP"})
+	assert.NoError(t, err)
+
+	lookup := func(t *testing.T, query, accept string) *httptest.ResponseRecorder {
+		app, mock := newTestApp(t, &stubHTTPClient{})
+		mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+		req := httptest.NewRequest(http.MethodGet, "/cep/01001000?"+query, nil)
+		req.Header.Set("Accept", accept)
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		assert.Equal(t, http.StatusOK, rec.Code)
+		return rec
+	}
+
+	t.Run("protobuf", func(t *testing.T) {
+		rec := lookup(t, "fields=cep,uf", "application/x-protobuf")
+
+		assert.Equal(t, "application/x-protobuf", rec.Header().Get("Content-Type"))
+		var msg cepv1.GetCepResponse
+		assert.NoError(t, proto.Unmarshal(rec.Body.Bytes(), &msg))
+		assert.Equal(t, "01001-000", msg.GetAddress().GetCep())
+		assert.Equal(t, "SP", msg.GetAddress().GetUf())
+		assert.Empty(t, msg.GetAddress().GetLocalidade(), "not selected")
+		assert.NotNil(t, msg.GetUpdatedAt())
+	})
+
+	t.Run("msgpack", func(t *testing.T) {
+		rec := lookup(t, "format=msgpack", "")
+
+		assert.Equal(t, "application/msgpack", rec.Header().Get("Content-Type"))
+		var body map[string]any
+		assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "Praça da Sé", body["logradouro"])
+		assert.NotContains(t, body, "latitude", "omitempty is honoured")
+	})
+
+	t.Run("msgpack meta with fields", func(t *testing.T) {
+		rec := lookup(t, "meta=true&fields=localidade", "application/msgpack")
+
+		var body struct {
+			Data map[string]any `msgpack:"data"`
+			Meta responseMeta   `msgpack:"meta"`
+		}
+		assert.NoError(t, msgpack.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, map[string]any{"localidade": "São Paulo"}, body.Data)
+	})
+}
+
+func TestWantsKV(t *testing.T) {
+	for _, tc := range []struct {
+		query, accept string
+		want          bool
+	}{
+		{query: "", accept: "", want: false},
+		{query: "", accept: "application/json", want: false},
+		{query: "format=KV", accept: "", want: true},
+		{query: "format=json", accept: "text/plain", want: false},
+		{query: "", accept: "text/html, text/plain", want: true},
+		{query: "format=csv", accept: "text/plain", want: false},
+	} {
+		req := httptest.NewRequest(http.MethodGet, "/cep/01001000?"+tc.query, nil)
+		req.Header.Set("Accept", tc.accept)
+		assert.Equal(t, tc.want, wantsKV(req), "query=%q accept=%q", tc.query, tc.accept)
+	}
+}
+
+func TestCEPHandlerFields(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Localidade: "São Paulo", Uf: "SP"})
+	assert.NoError(t, err)
+
+	for _, tc := range []struct {
+		name, query string
+		want        string
+	}{
+		{name: "json", query: "fields=cep,UF", want: `{"cep":"01001-000","uf":"SP"}`},
+		{name: "meta", query: "fields=localidade&meta=true", want: `{"localidade":"São Paulo"}`},
+		{name: "kv", query: "fields=cep,uf&format=kv", want: "cep=01001-000\nuf=SP\n"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app, mock := newTestApp(t, &stubHTTPClient{})
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("01001000").
+				WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000?"+tc.query, nil))
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			switch tc.name {
+			case "kv":
+				assert.Equal(t, tc.want, rec.Body.String())
+			case "meta":
+				var body struct {
+					Data json.RawMessage `json:"data"`
+					Meta responseMeta    `json:"meta"`
+				}
+				assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+				assert.JSONEq(t, tc.want, string(body.Data))
+				assert.False(t, body.Meta.ProviderCalled)
+			default:
+				assert.JSONEq(t, tc.want, rec.Body.String())
+			}
+		})
+	}
+}
+
+func TestCEPHandlerUnknownField(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000?fields=cep,cidade", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "cidade")
+}
@@ -0,0 +1,24 @@
+package main
+
+import (
+	"context"
+	"net/http"
+
+	"github.com/grpc-ecosystem/grpc-gateway/v2/runtime"
+	"google.golang.org/protobuf/encoding/protojson"
+
+	cepv1 "github.com/victor-dias21/goCep-k8s/proto/cep/v1"
+)
+
+// gatewayHandler serves the REST layer generated from cep.proto under /v1/rpc. It
+// calls the gRPC implementation in-process, so fields added to the proto reach
+// both APIs at once without a gRPC listener. JSON keeps the proto field names
+// and empty fields, like the hand-written endpoints.
+func (app *application) gatewayHandler() http.Handler {
+	mux := runtime.NewServeMux(runtime.WithMarshalerOption(runtime.MIMEWildcard, &runtime.JSONPb{
+		MarshalOptions: protojson.MarshalOptions{UseProtoNames: true, EmitUnpopulated: true},
+	}))
+	// Registration only fails for a nil server.
+	_ = cepv1.RegisterCepServiceHandlerServer(context.Background(), mux, &grpcServer{app: app})
+	return mux
+}
@@ -0,0 +1,78 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestGatewayGetCep(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Localidade: "São Paulo", Uf: "SP"})
+	app := newBatchTestApp(t, testConfig(), provider)
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/rpc/cep/01001000", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Address map[string]string `json:"address"`
+		Source  string            `json:"source"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "Praça da Sé", body.Address["logradouro"])
+	assert.Contains(t, body.Address, "complemento", "empty fields are kept, like GET /cep/{cep}")
+	assert.Equal(t, cep.SourceProvider, body.Source)
+}
+
+func TestGatewayGetCepErrors(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider())
+
+	for path, want := range map[string]int{
+		"/v1/rpc/cep/99999999": http.StatusNotFound,
+		"/v1/rpc/cep/abc":      http.StatusBadRequest,
+	} {
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		assert.Equal(t, want, rec.Code, path)
+	}
+}
+
+func TestGatewayBatchGetCep(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo"}))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/v1/rpc/cep:batchGet", strings.NewReader(`{"ceps": ["01001000", "99999999"]}`))
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Summary batchSummary              `json:"summary"`
+		Results map[string]map[string]any `json:"results"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, batchSummary{Total: 2, Succeeded: 1, NotFound: 1}, body.Summary)
+	assert.Equal(t, "cep não encontrado", body.Results["99999999"]["error"])
+}
+
+func TestGatewayBatchGetCepDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.disabledEndpoints = map[string]bool{endpointBatch: true}
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"})
+	app := newBatchTestApp(t, cfg, provider)
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/v1/rpc/cep:batchGet", strings.NewReader(`{"ceps": ["01001000"]}`)))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+	assert.Zero(t, provider.callsFor("01001000"))
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/rpc/cep/01001000", nil))
+	assert.Equal(t, http.StatusOK, rec.Code, "single lookups stay available")
+}
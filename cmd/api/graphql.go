@@ -0,0 +1,181 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+
+	"github.com/graphql-go/graphql"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// graphqlRequest is a GraphQL operation, sent as the POST body or as GET
+// query parameters.
+type graphqlRequest struct {
+	Query         string         `json:"query"`
+	Variables     map[string]any `json:"variables"`
+	OperationName string         `json:"operationName"`
+}
+
+// graphqlHandler serves /graphql with a single query, cep(code: String!). All
+// cep fields of one request are resolved together through a cepLoader.
+func (app *application) graphqlHandler() http.HandlerFunc {
+	schema, err := newGraphQLSchema()
+	if err != nil {
+		// The schema is static; failing here is a programming error.
+		panic(fmt.Sprintf("graphql schema: %v", err))
+	}
+
+	return func(w http.ResponseWriter, r *http.Request) {
+		var req graphqlRequest
+		if r.Method == http.MethodGet {
+			query := r.URL.Query()
+			req.Query, req.OperationName = query.Get("query"), query.Get("operationName")
+			if raw := query.Get("variables"); raw != "" {
+				if err := json.Unmarshal([]byte(raw), &req.Variables); err != nil {
+					writeJSON(w, http.StatusBadRequest, map[string]string{"error": "variables inválido: esperado um objeto JSON"})
+					return
+				}
+			}
+		} else {
+			r.Body = http.MaxBytesReader(w, r.Body, 1<<20)
+			if err := json.NewDecoder(r.Body).Decode(&req); err != nil {
+				writeJSON(w, http.StatusBadRequest, map[string]string{"error": "corpo inválido: esperado um objeto JSON com query"})
+				return
+			}
+		}
+		if strings.TrimSpace(req.Query) == "" {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": "informe a query"})
+			return
+		}
+
+		ctx, cancel := context.WithTimeout(r.Context(), batchTimeout)
+		defer cancel()
+
+		result := graphql.Do(graphql.Params{
+			Schema:         schema,
+			RequestString:  req.Query,
+			VariableValues: req.Variables,
+			OperationName:  req.OperationName,
+			Context:        context.WithValue(ctx, cepLoaderKey{}, app.newCEPLoader(ctx)),
+		})
+		if r.Context().Err() != nil {
+			w.WriteHeader(statusClientClosedRequest)
+			return
+		}
+		writeJSON(w, http.StatusOK, result)
+	}
+}
+
+// newGraphQLSchema builds the schema. Address fields follow Response.Fields,
+// so they match the JSON API.
+func newGraphQLSchema() (graphql.Schema, error) {
+	fields := graphql.Fields{}
+	for _, field := range (&cep.Response{}).Fields() {
+		fields[field.Name] = &graphql.Field{Type: graphql.String}
+	}
+	address := graphql.NewObject(graphql.ObjectConfig{Name: "Address", Fields: fields})
+
+	query := graphql.NewObject(graphql.ObjectConfig{
+		Name: "Query",
+		Fields: graphql.Fields{
+			"cep": &graphql.Field{
+				Type:        address,
+				Description: "Endereço do CEP, ou null quando o CEP não existe.",
+				Args: graphql.FieldConfigArgument{
+					"code": &graphql.ArgumentConfig{Type: graphql.NewNonNull(graphql.String)},
+				},
+				Resolve: func(p graphql.ResolveParams) (any, error) {
+					loader, _ := p.Context.Value(cepLoaderKey{}).(*cepLoader)
+					code, _ := p.Args["code"].(string)
+					return loader.load(code), nil
+				},
+			},
+		},
+	})
+	return graphql.NewSchema(graphql.SchemaConfig{Query: query})
+}
+
+// cepLoaderKey carries the request's cepLoader to the resolvers.
+type cepLoaderKey struct{}
+
+// cepLoader batches the cep fields of one GraphQL request, DataLoader style:
+// resolvers queue their codes and return thunks, which graphql-go evaluates
+// after the whole selection level is resolved. The first thunk looks up every
+// queued code at once through the batch worker pool.
+type cepLoader struct {
+	app *application
+	ctx context.Context
+
+	mu      sync.Mutex
+	pending []string
+	results map[string]cepLoadResult
+}
+
+type cepLoadResult struct {
+	resp *cep.Response
+	err  error
+}
+
+func (app *application) newCEPLoader(ctx context.Context) *cepLoader {
+	return &cepLoader{app: app, ctx: ctx, results: map[string]cepLoadResult{}}
+}
+
+// load queues code and returns the thunk that resolves it. Unknown CEPs
+// resolve to null without an error.
+func (l *cepLoader) load(code string) func() (any, error) {
+	code = strings.TrimSpace(code)
+
+	l.mu.Lock()
+	if _, done := l.results[code]; !done && !slices.Contains(l.pending, code) {
+		l.pending = append(l.pending, code)
+	}
+	l.mu.Unlock()
+
+	return func() (any, error) {
+		l.mu.Lock()
+		defer l.mu.Unlock()
+
+		if _, done := l.results[code]; !done {
+			l.dispatch()
+		}
+		result := l.results[code]
+		if result.resp == nil {
+			return nil, result.err
+		}
+		return result.resp, nil
+	}
+}
+
+// dispatch looks up the pending codes. A request may resolve at most
+// BATCH_MAX_SIZE distinct CEPs, like POST /cep/batch. Callers hold l.mu.
+func (l *cepLoader) dispatch() {
+	keys := l.pending
+	l.pending = nil
+
+	if len(l.results)+len(keys) > l.app.cfg.batchMaxSize {
+		err := fmt.Errorf("consulta excede o limite de %d ceps", l.app.cfg.batchMaxSize)
+		for _, key := range keys {
+			l.results[key] = cepLoadResult{err: err}
+		}
+		return
+	}
+
+	items, notFound := l.app.lookupBatchItems(l.ctx, keys)
+	for i, key := range keys {
+		switch {
+		case items[i].Data != nil:
+			l.results[key] = cepLoadResult{resp: items[i].Data}
+		case notFound[i]:
+			l.results[key] = cepLoadResult{}
+		default:
+			l.results[key] = cepLoadResult{err: errors.New(items[i].Error)}
+		}
+	}
+}
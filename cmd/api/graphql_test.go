@@ -0,0 +1,79 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"net/url"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func postGraphQL(app *application, body string) *httptest.ResponseRecorder {
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/graphql", strings.NewReader(body)))
+	return rec
+}
+
+func TestGraphQLHandler(t *testing.T) {
+	provider := newFakeProvider(
+		cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Uf: "SP"},
+		cep.Response{Cep: "20040-002", Logradouro: "Avenida Rio Branco", Uf: "RJ"},
+	)
+	app := newBatchTestApp(t, testConfig(), provider)
+
+	rec := postGraphQL(app, `{"query": "query($rj: String!) { sp: cep(code: \"01001000\") { logradouro uf } rj: cep(code: $rj) { uf } again: cep(code: \"01001000\") { cep } none: cep(code: \"99999999\") { uf } }", "variables": {"rj": "20040-002"}}`)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data": {
+		"sp": {"logradouro": "Praça da Sé", "uf": "SP"},
+		"rj": {"uf": "RJ"},
+		"again": {"cep": "01001-000"},
+		"none": null
+	}}`, rec.Body.String())
+	assert.Equal(t, 1, provider.callsFor("01001000"), "repeated codes are looked up once")
+}
+
+func TestGraphQLHandlerErrors(t *testing.T) {
+	cfg := testConfig()
+	cfg.batchMaxSize = 1
+	app := newBatchTestApp(t, cfg, newFakeProvider(cep.Response{Cep: "01001-000"}))
+
+	rec := postGraphQL(app, `{"query": "{ a: cep(code: \"abc\") { uf } }"}`)
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Body.String(), "invalid CEP")
+
+	rec = postGraphQL(app, `{"query": "{ a: cep(code: \"01001000\") { uf } b: cep(code: \"20040002\") { uf } }"}`)
+	assert.Contains(t, rec.Body.String(), "consulta excede o limite de 1 ceps")
+
+	rec = postGraphQL(app, `{}`)
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestGraphQLHandlerGet(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider(cep.Response{Cep: "01001-000", Uf: "SP"}))
+
+	rec := httptest.NewRecorder()
+	target := "/graphql?query=" + url.QueryEscape(`{ cep(code: "01001000") { uf } }`)
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"data": {"cep": {"uf": "SP"}}}`, rec.Body.String())
+}
+
+func TestCEPLoaderBatchesQueuedCodes(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"}, cep.Response{Cep: "20040-002"})
+	app := newBatchTestApp(t, testConfig(), provider)
+	loader := app.newCEPLoader(context.Background())
+
+	first := loader.load("01001000")
+	_ = loader.load("20040002")
+	_, err := first()
+
+	assert.NoError(t, err)
+	assert.Equal(t, 1, provider.callsFor("20040002"), "the first thunk resolves every queued code")
+}
@@ -0,0 +1,258 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"net/http"
+	"runtime/debug"
+	"strings"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/protobuf/types/known/timestamppb"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	cepv1 "github.com/victor-dias21/goCep-k8s/proto/cep/v1"
+)
+
+// grpcServer exposes the CEP service over gRPC on GRPC_ADDR for in-cluster
+// consumers. It shares the application's service, so both APIs see the same
+// cache, providers and metrics.
+type grpcServer struct {
+	cepv1.UnimplementedCepServiceServer
+	app *application
+}
+
+// newGRPCServer builds the gRPC server with every service registered. Calls
+// are rate limited, authenticated and counted against quotas like the HTTP API,
+// and a panicking handler fails only its own call.
+func (app *application) newGRPCServer() *grpc.Server {
+	srv := grpc.NewServer(grpc.ChainUnaryInterceptor(app.grpcRecoverPanics, app.grpcRateLimit, app.grpcClientAuth))
+	cepv1.RegisterCepServiceServer(srv, &grpcServer{app: app})
+	healthpb.RegisterHealthServer(srv, &grpcHealth{app: app})
+	return srv
+}
+
+// grpcRecoverPanics is recoverPanics for unary calls: it logs the panic with
+// its stack and fails the call with INTERNAL instead of crashing the process.
+func (app *application) grpcRecoverPanics(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (resp any, err error) {
+	defer func() {
+		if v := recover(); v != nil {
+			app.logger.ErrorContext(ctx, "panic ao atender chamada gRPC",
+				"panic", fmt.Sprint(v), "method", info.FullMethod, "stack", string(debug.Stack()))
+			resp, err = nil, status.Error(codes.Internal, "erro interno")
+		}
+	}()
+	return handler(ctx, req)
+}
+
+// grpcHealthPrefix matches the methods of the health service, which kubelet
+// probes call without credentials.
+var grpcHealthPrefix = "/" + healthpb.Health_ServiceDesc.ServiceName + "/"
+
+// grpcClientAuth is requireClientAuth for unary calls, reading x-api-key and
+// authorization from the request metadata. Quota and Retry-After headers are
+// sent back as response metadata under the same lowercase names.
+func (app *application) grpcClientAuth(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if (!app.cfg.apiKeyAuth && app.jwt == nil) || strings.HasPrefix(info.FullMethod, grpcHealthPrefix) {
+		return handler(ctx, req)
+	}
+
+	md, _ := metadata.FromIncomingContext(ctx)
+	authorization := firstMetadata(md, "authorization")
+	if app.isAdminAuthorization(authorization) {
+		return handler(ctx, req)
+	}
+
+	identity, err := app.authenticateClient(ctx, firstMetadata(md, apiKeyHeader), authorization)
+	header := http.Header{}
+	if identity != nil {
+		identity.quota.setHeaders(header)
+	}
+	var authErr *clientAuthError
+	if errors.As(err, &authErr) && authErr.retryAfter > 0 {
+		header.Set("Retry-After", retryAfterSeconds(authErr.retryAfter))
+	}
+	if len(header) > 0 {
+		out := metadata.MD{}
+		for name, values := range header {
+			out.Append(name, values...)
+		}
+		_ = grpc.SetHeader(ctx, out)
+	}
+	if err != nil {
+		return nil, grpcAuthError(err)
+	}
+	return handler(ctx, req)
+}
+
+// firstMetadata returns the first value of key, or "".
+func firstMetadata(md metadata.MD, key string) string {
+	if values := md.Get(key); len(values) > 0 {
+		return values[0]
+	}
+	return ""
+}
+
+// grpcAuthError maps a refusal from authenticateClient to a gRPC status.
+func grpcAuthError(err error) error {
+	var authErr *clientAuthError
+	if !errors.As(err, &authErr) {
+		return status.Error(codes.Internal, "erro interno")
+	}
+	code := codes.Internal
+	switch authErr.status {
+	case http.StatusUnauthorized:
+		code = codes.Unauthenticated
+	case http.StatusForbidden:
+		code = codes.PermissionDenied
+	case http.StatusTooManyRequests:
+		code = codes.ResourceExhausted
+	case http.StatusServiceUnavailable:
+		code = codes.Unavailable
+	}
+	return status.Error(code, authErr.message)
+}
+
+// grpcHealth implements grpc.health.v1.Health for Kubernetes gRPC probes. Like
+// /healthz, each check pings the database; only Check is supported, which is
+// all the kubelet calls.
+type grpcHealth struct {
+	healthpb.UnimplementedHealthServer
+	app *application
+}
+
+// Check reports the whole server ("") or the CEP service by its full name.
+func (h *grpcHealth) Check(ctx context.Context, req *healthpb.HealthCheckRequest) (*healthpb.HealthCheckResponse, error) {
+	switch req.GetService() {
+	case "", cepv1.CepService_ServiceDesc.ServiceName:
+	default:
+		return nil, status.Errorf(codes.NotFound, "serviço desconhecido: %q", req.GetService())
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, 2*time.Second)
+	defer cancel()
+
+	if err := h.app.service.Ping(ctx); err != nil {
+		h.app.logger.Warn("health check gRPC falhou", "err", err)
+		return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_NOT_SERVING}, nil
+	}
+	return &healthpb.HealthCheckResponse{Status: healthpb.HealthCheckResponse_SERVING}, nil
+}
+
+// GetCep looks up a single CEP with the same deadline as GET /cep/{cep}.
+func (s *grpcServer) GetCep(ctx context.Context, req *cepv1.GetCepRequest) (*cepv1.GetCepResponse, error) {
+	ctx, cancel := context.WithTimeout(ctx, 10*time.Second)
+	defer cancel()
+
+	result, err := s.app.service.Lookup(ctx, req.GetCep())
+	if err != nil {
+		return nil, s.app.grpcLookupError(ctx, req.GetCep(), err)
+	}
+
+	return toProtoGetCepResponse(result), nil
+}
+
+// BatchGetCep mirrors POST /cep/batch, including BATCH_MAX_SIZE and
+// DISABLED_ENDPOINTS.
+func (s *grpcServer) BatchGetCep(ctx context.Context, req *cepv1.BatchGetCepRequest) (*cepv1.BatchGetCepResponse, error) {
+	if !s.app.endpointEnabled(endpointBatch) {
+		return nil, status.Error(codes.Unimplemented, "consulta em lote desativada")
+	}
+	keys := uniqueKeys(req.GetCeps())
+	if len(keys) == 0 {
+		return nil, status.Error(codes.InvalidArgument, "informe ao menos um cep")
+	}
+	if len(keys) > s.app.cfg.batchMaxSize {
+		return nil, status.Errorf(codes.InvalidArgument, "lote excede o limite de %d ceps", s.app.cfg.batchMaxSize)
+	}
+
+	ctx, cancel := context.WithTimeout(ctx, batchTimeout)
+	defer cancel()
+
+	return toProtoBatchResponse(s.app.lookupBatch(ctx, keys)), nil
+}
+
+// grpcLookupError maps a failed lookup to a gRPC status, like writeLookupError
+// does for HTTP.
+func (app *application) grpcLookupError(ctx context.Context, cepValue string, err error) error {
+	switch {
+	case ctx.Err() != nil:
+		return status.FromContextError(ctx.Err()).Err()
+	case errors.Is(err, cep.ErrInvalidCEP):
+		return status.Error(codes.InvalidArgument, err.Error())
+	case errors.Is(err, cep.ErrNotFound):
+		return status.Error(codes.NotFound, "cep não encontrado")
+	case errors.Is(err, cep.ErrNoDataSource):
+		app.logger.ErrorContext(ctx, "sem fonte de dados para cep", "cep", cepValue, "err", err)
+		return status.Error(codes.Unavailable, "serviço indisponível: cache e provedor de cep inacessíveis")
+	case errors.Is(err, cep.ErrCircuitOpen):
+		app.logger.WarnContext(ctx, "provedor indisponível para cep", "cep", cepValue, "err", err)
+		return status.Error(codes.Unavailable, "provedor de cep temporariamente indisponível")
+	case errors.Is(err, cep.ErrUpstreamBadResponse):
+		app.logger.ErrorContext(ctx, "resposta inválida do upstream para cep", "cep", cepValue, "err", err)
+		return status.Error(codes.Unavailable, "resposta inválida do provedor de cep")
+	default:
+		app.logger.ErrorContext(ctx, "erro ao buscar cep", "cep", cepValue, "err", err)
+		return status.Error(codes.Internal, "falha ao consultar cep")
+	}
+}
+
+// toProtoGetCepResponse converts a lookup result for GetCep and for GET
+// /cep/{cep} with Accept: application/x-protobuf.
+func toProtoGetCepResponse(result *cep.Result) *cepv1.GetCepResponse {
+	resp := &cepv1.GetCepResponse{
+		Address: toProtoAddress(result.Response),
+		Source:  result.Source,
+		Stale:   result.Stale,
+	}
+	if !result.UpdatedAt.IsZero() {
+		resp.UpdatedAt = timestamppb.New(result.UpdatedAt)
+	}
+	return resp
+}
+
+// toProtoBatchResponse converts a batch for BatchGetCep and for POST
+// /cep/batch with Accept: application/x-protobuf.
+func toProtoBatchResponse(batch *batchResponse) *cepv1.BatchGetCepResponse {
+	resp := &cepv1.BatchGetCepResponse{
+		Summary: &cepv1.BatchSummary{
+			Total:     int32(batch.Summary.Total),
+			Succeeded: int32(batch.Summary.Succeeded),
+			Failed:    int32(batch.Summary.Failed),
+			NotFound:  int32(batch.Summary.NotFound),
+		},
+		Results: make(map[string]*cepv1.BatchItem, len(batch.Results)),
+	}
+	for key, item := range batch.Results {
+		if item.Data != nil {
+			resp.Results[key] = &cepv1.BatchItem{Outcome: &cepv1.BatchItem_Address{Address: toProtoAddress(item.Data)}}
+		} else {
+			resp.Results[key] = &cepv1.BatchItem{Outcome: &cepv1.BatchItem_Error{Error: item.Error}}
+		}
+	}
+	return resp
+}
+
+func toProtoAddress(resp *cep.Response) *cepv1.Address {
+	return &cepv1.Address{
+		Cep:         resp.Cep,
+		Logradouro:  resp.Logradouro,
+		Complemento: resp.Complemento,
+		Bairro:      resp.Bairro,
+		Localidade:  resp.Localidade,
+		Uf:          resp.Uf,
+		Ibge:        resp.Ibge,
+		Gia:         resp.Gia,
+		Ddd:         resp.DDD,
+		Siafi:       resp.Siafi,
+		Unidade:     resp.Unidade,
+		Latitude:    resp.Latitude,
+		Longitude:   resp.Longitude,
+	}
+}
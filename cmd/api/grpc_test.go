@@ -0,0 +1,195 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"errors"
+	"log/slog"
+	"net"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/credentials/insecure"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+	"google.golang.org/grpc/test/bufconn"
+
+	"github.com/victor-dias21/goCep-k8s/internal/apikey"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	"github.com/victor-dias21/goCep-k8s/internal/jwtauth"
+	cepv1 "github.com/victor-dias21/goCep-k8s/proto/cep/v1"
+)
+
+// newGRPCTestConn serves app's gRPC API over an in-memory listener.
+func newGRPCTestConn(t *testing.T, app *application) *grpc.ClientConn {
+	t.Helper()
+	listener := bufconn.Listen(1 << 20)
+	srv := app.newGRPCServer()
+	go func() { _ = srv.Serve(listener) }()
+	t.Cleanup(srv.Stop)
+
+	conn, err := grpc.NewClient("passthrough:///bufnet",
+		grpc.WithContextDialer(func(ctx context.Context, _ string) (net.Conn, error) { return listener.DialContext(ctx) }),
+		grpc.WithTransportCredentials(insecure.NewCredentials()))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = conn.Close() })
+	return conn
+}
+
+func TestGRPCGetCep(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Localidade: "São Paulo", Uf: "SP"})
+	client := cepv1.NewCepServiceClient(newGRPCTestConn(t, newBatchTestApp(t, testConfig(), provider)))
+
+	resp, err := client.GetCep(context.Background(), &cepv1.GetCepRequest{Cep: "01001000"})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "01001-000", resp.GetAddress().GetCep())
+	assert.Equal(t, "Praça da Sé", resp.GetAddress().GetLogradouro())
+	assert.Equal(t, cep.SourceProvider, resp.GetSource())
+	assert.False(t, resp.GetStale())
+}
+
+func TestGRPCGetCepErrors(t *testing.T) {
+	client := cepv1.NewCepServiceClient(newGRPCTestConn(t, newBatchTestApp(t, testConfig(), newFakeProvider())))
+
+	for cepValue, want := range map[string]codes.Code{
+		"99999999": codes.NotFound,
+		"abc":      codes.InvalidArgument,
+	} {
+		_, err := client.GetCep(context.Background(), &cepv1.GetCepRequest{Cep: cepValue})
+		assert.Equal(t, want, status.Code(err), cepValue)
+	}
+}
+
+func TestGRPCBatchGetCep(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo"})
+	client := cepv1.NewCepServiceClient(newGRPCTestConn(t, newBatchTestApp(t, testConfig(), provider)))
+
+	resp, err := client.BatchGetCep(context.Background(), &cepv1.BatchGetCepRequest{Ceps: []string{"01001000", "99999999", "01001000"}})
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(2), resp.GetSummary().GetTotal())
+	assert.Equal(t, int32(1), resp.GetSummary().GetSucceeded())
+	assert.Equal(t, int32(1), resp.GetSummary().GetNotFound())
+	assert.Equal(t, "São Paulo", resp.GetResults()["01001000"].GetAddress().GetLocalidade())
+	assert.Equal(t, "cep não encontrado", resp.GetResults()["99999999"].GetError())
+}
+
+func TestGRPCBatchGetCepLimits(t *testing.T) {
+	cfg := testConfig()
+	cfg.batchMaxSize = 1
+	client := cepv1.NewCepServiceClient(newGRPCTestConn(t, newBatchTestApp(t, cfg, newFakeProvider())))
+
+	_, err := client.BatchGetCep(context.Background(), &cepv1.BatchGetCepRequest{Ceps: []string{"01001000", "20040002"}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+
+	_, err = client.BatchGetCep(context.Background(), &cepv1.BatchGetCepRequest{Ceps: []string{" "}})
+	assert.Equal(t, codes.InvalidArgument, status.Code(err))
+}
+
+func TestGRPCBatchGetCepDisabled(t *testing.T) {
+	cfg := testConfig()
+	cfg.disabledEndpoints = map[string]bool{endpointBatch: true}
+	provider := newFakeProvider(cep.Response{Cep: "01001-000"})
+	client := cepv1.NewCepServiceClient(newGRPCTestConn(t, newBatchTestApp(t, cfg, provider)))
+
+	_, err := client.BatchGetCep(context.Background(), &cepv1.BatchGetCepRequest{Ceps: []string{"01001000"}})
+	assert.Equal(t, codes.Unimplemented, status.Code(err))
+	assert.Zero(t, provider.callsFor("01001000"))
+}
+
+func TestGRPCHealthCheck(t *testing.T) {
+	db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	app := newApplication(testConfig(), noopLogger(), new(slog.LevelVar), db, &stubHTTPClient{})
+	health := healthpb.NewHealthClient(newGRPCTestConn(t, app))
+
+	mock.ExpectPing()
+	resp, err := health.Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_SERVING, resp.GetStatus())
+
+	mock.ExpectPing().WillReturnError(errors.New("connection refused"))
+	resp, err = health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "gocep.cep.v1.CepService"})
+	assert.NoError(t, err)
+	assert.Equal(t, healthpb.HealthCheckResponse_NOT_SERVING, resp.GetStatus())
+
+	_, err = health.Check(context.Background(), &healthpb.HealthCheckRequest{Service: "other.Service"})
+	assert.Equal(t, codes.NotFound, status.Code(err))
+}
+
+func TestGRPCClientAuth(t *testing.T) {
+	idp := newTestIdP(t)
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.apiKeyAuth = true
+	app.cfg.apiKeys = map[string]string{apikey.Hash("env-key"): "parceiro"}
+	app.cfg.apiKeyDailyQuota = 1
+	app.cfg.adminToken = "admin"
+	app.cfg.jwt = jwtauth.Config{JWKSURL: idp.server.URL, Issuer: idp.server.URL, Audience: "gocep"}
+	app.jwt = jwtauth.NewVerifier(app.cfg.jwt, idp.server.Client())
+	conn := newGRPCTestConn(t, app)
+	client := cepv1.NewCepServiceClient(conn)
+
+	// Authenticated calls reach GetCep, which rejects the malformed CEP.
+	call := func(kv ...string) (metadata.MD, codes.Code) {
+		var header metadata.MD
+		ctx := metadata.AppendToOutgoingContext(context.Background(), kv...)
+		_, err := client.GetCep(ctx, &cepv1.GetCepRequest{Cep: "123"}, grpc.Header(&header))
+		return header, status.Code(err)
+	}
+
+	_, code := call()
+	assert.Equal(t, codes.Unauthenticated, code)
+	mock.ExpectQuery("FROM api_keys").WithArgs(apikey.Hash("wrong")).WillReturnRows(apiKeyRows())
+	_, code = call("x-api-key", "wrong")
+	assert.Equal(t, codes.Unauthenticated, code)
+	_, code = call("authorization", "Bearer not-a-jwt")
+	assert.Equal(t, codes.Unauthenticated, code)
+
+	mock.ExpectQuery("INSERT INTO api_key_usage").WillReturnRows(usageRows(1, 1))
+	header, code := call("x-api-key", "env-key")
+	assert.Equal(t, codes.InvalidArgument, code)
+	assert.Equal(t, []string{"0"}, header.Get("x-quota-daily-remaining"))
+
+	mock.ExpectQuery("INSERT INTO api_key_usage").WillReturnRows(usageRows(2, 2))
+	header, code = call("x-api-key", "env-key")
+	assert.Equal(t, codes.ResourceExhausted, code)
+	assert.NotEmpty(t, header.Get("retry-after"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, code = call("authorization", "Bearer "+idp.token(t, "gocep"))
+	assert.Equal(t, codes.InvalidArgument, code)
+	_, code = call("authorization", "Bearer admin")
+	assert.Equal(t, codes.InvalidArgument, code)
+
+	// Probes need no credentials.
+	_, err := healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
+
+func TestGRPCRecoverPanics(t *testing.T) {
+	var logs bytes.Buffer
+	app := &application{logger: newLogger(&logs, nil)}
+	info := &grpc.UnaryServerInfo{FullMethod: "/gocep.cep.v1.CepService/GetCep"}
+
+	resp, err := app.grpcRecoverPanics(context.Background(), nil, info, func(context.Context, any) (any, error) {
+		var m map[string]int
+		m["boom"]++
+		return "unreachable", nil
+	})
+
+	assert.Nil(t, resp)
+	assert.Equal(t, codes.Internal, status.Code(err))
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, info.FullMethod, entry["method"])
+	assert.Contains(t, entry["panic"], "nil map")
+	assert.Contains(t, entry["stack"], "TestGRPCRecoverPanics")
+}
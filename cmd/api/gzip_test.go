@@ -0,0 +1,113 @@
+package main
+
+import (
+	"compress/gzip"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestCompressResponses(t *testing.T) {
+	large := strings.Repeat("a", 2048)
+	handler := func(body string) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Type", "text/plain")
+			_, _ = io.WriteString(w, body)
+		})
+	}
+
+	for _, tc := range []struct {
+		name           string
+		body           string
+		acceptEncoding string
+		method         string
+		gzipped        bool
+	}{
+		{name: "large", body: large, acceptEncoding: "gzip, deflate", gzipped: true},
+		{name: "below min size", body: "small", acceptEncoding: "gzip"},
+		{name: "not accepted", body: large},
+		{name: "refused", body: large, acceptEncoding: "gzip;q=0, identity"},
+		{name: "head", body: large, acceptEncoding: "gzip", method: http.MethodHead},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			app := &application{cfg: config{gzipEnabled: true, gzipMinSize: 1024}}
+			method := tc.method
+			if method == "" {
+				method = http.MethodGet
+			}
+			req := httptest.NewRequest(method, "/search", nil)
+			req.Header.Set("Accept-Encoding", tc.acceptEncoding)
+			rec := httptest.NewRecorder()
+			app.compressResponses(handler(tc.body)).ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, "Accept-Encoding", rec.Header().Get("Vary"))
+			if !tc.gzipped {
+				assert.Empty(t, rec.Header().Get("Content-Encoding"))
+				assert.Equal(t, tc.body, rec.Body.String())
+				return
+			}
+			assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+			zr, err := gzip.NewReader(rec.Body)
+			assert.NoError(t, err)
+			plain, err := io.ReadAll(zr)
+			assert.NoError(t, err)
+			assert.Equal(t, tc.body, string(plain))
+		})
+	}
+}
+
+func TestCompressResponsesKeepsStatusAndEncoding(t *testing.T) {
+	app := &application{cfg: config{gzipEnabled: true}}
+
+	t.Run("no body", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/cep/01001000/validate", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		app.compressResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.WriteHeader(http.StatusNoContent)
+		})).ServeHTTP(rec, req)
+
+		assert.Equal(t, http.StatusNoContent, rec.Code)
+		assert.Empty(t, rec.Header().Get("Content-Encoding"))
+	})
+
+	t.Run("already encoded", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		req := httptest.NewRequest(http.MethodGet, "/metrics", nil)
+		req.Header.Set("Accept-Encoding", "gzip")
+		app.compressResponses(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("Content-Encoding", "br")
+			_, _ = io.WriteString(w, "brotli bytes")
+		})).ServeHTTP(rec, req)
+
+		assert.Equal(t, "br", rec.Header().Get("Content-Encoding"))
+		assert.Equal(t, "brotli bytes", rec.Body.String())
+	})
+}
+
+func TestBatchHandlerGzip(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	cfg := testConfig()
+	cfg.gzipEnabled, cfg.gzipMinSize = true, 0
+	app := newBatchTestApp(t, cfg, provider)
+
+	req := httptest.NewRequest(http.MethodPost, "/cep/batch", strings.NewReader(`["01001000"]`))
+	req.Header.Set("Accept-Encoding", "gzip")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "gzip", rec.Header().Get("Content-Encoding"))
+	zr, err := gzip.NewReader(rec.Body)
+	assert.NoError(t, err)
+	var body batchResponse
+	assert.NoError(t, json.NewDecoder(zr).Decode(&body))
+	assert.Equal(t, "São Paulo", body.Results["01001000"].Data.Localidade)
+}
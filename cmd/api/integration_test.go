@@ -0,0 +1,227 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"sync"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// fakeProvider is a deterministic ViaCEP stand-in keyed by CEP digits.
+// Unknown CEPs get ViaCEP's {"erro": true} soft error and failing ones a 503.
+type fakeProvider struct {
+	mu      sync.Mutex
+	entries map[string]cep.Response
+	calls   map[string]int
+	failing map[string]bool
+}
+
+func newFakeProvider(entries ...cep.Response) *fakeProvider {
+	p := &fakeProvider{entries: map[string]cep.Response{}, calls: map[string]int{}}
+	for _, e := range entries {
+		p.entries[strings.ReplaceAll(e.Cep, "-", "")] = e
+	}
+	return p
+}
+
+func (p *fakeProvider) Do(req *http.Request) (*http.Response, error) {
+	// ViaCEP paths look like /ws/{cep}/json/.
+	parts := strings.Split(strings.Trim(req.URL.Path, "/"), "/")
+	if len(parts) != 3 || parts[0] != "ws" {
+		return nil, fmt.Errorf("fake provider: unexpected path %q", req.URL.Path)
+	}
+	digits := parts[1]
+
+	p.mu.Lock()
+	p.calls[digits]++
+	entry, ok := p.entries[digits]
+	failing := p.failing[digits]
+	p.mu.Unlock()
+
+	if failing {
+		return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: io.NopCloser(strings.NewReader(""))}, nil
+	}
+
+	body := `{"erro": true}`
+	if ok {
+		raw, err := json.Marshal(entry)
+		if err != nil {
+			return nil, err
+		}
+		body = string(raw)
+	}
+
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Header:     http.Header{"Content-Type": []string{"application/json"}},
+		Body:       io.NopCloser(strings.NewReader(body)),
+	}, nil
+}
+
+// setFailing makes the provider answer 503 for digits until called with false.
+func (p *fakeProvider) setFailing(digits string, failing bool) {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.failing == nil {
+		p.failing = map[string]bool{}
+	}
+	p.failing[digits] = failing
+}
+
+func (p *fakeProvider) callsFor(digits string) int {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return p.calls[digits]
+}
+
+// newTestServer starts the full router over a real HTTP listener with a fake provider.
+func newTestServer(t *testing.T, provider *fakeProvider) (*httptest.Server, sqlmock.Sqlmock) {
+	t.Helper()
+
+	app, mock := newTestApp(t, provider)
+	srv := httptest.NewServer(app.routes())
+	t.Cleanup(srv.Close)
+
+	return srv, mock
+}
+
+func TestCEPEndpointEndToEnd(t *testing.T) {
+	se := cep.Response{Cep: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Localidade: "São Paulo", Uf: "SP"}
+	payload, err := json.Marshal(se)
+	assert.NoError(t, err)
+
+	selectQuery := `SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`
+
+	t.Run("cache hit", func(t *testing.T) {
+		provider := newFakeProvider(se)
+		srv, mock := newTestServer(t, provider)
+
+		mock.ExpectQuery(selectQuery).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+		res, err := http.Get(srv.URL + "/cep/01001-000")
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		var got cep.Response
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		assert.Equal(t, se, got)
+		assert.Equal(t, 0, provider.callsFor("01001000"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cache miss", func(t *testing.T) {
+		provider := newFakeProvider(se)
+		srv, mock := newTestServer(t, provider)
+
+		mock.ExpectQuery(selectQuery).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+		mock.ExpectExec(`INSERT INTO ceps`).
+			WithArgs("01001000", sqlmock.AnyArg(), sqlmock.AnyArg(), cep.CacheSchemaVersion).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		res, err := http.Get(srv.URL + "/cep/01001000")
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		var got cep.Response
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		assert.Equal(t, se, got)
+		assert.Equal(t, 1, provider.callsFor("01001000"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("not found", func(t *testing.T) {
+		provider := newFakeProvider(se)
+		srv, mock := newTestServer(t, provider)
+
+		mock.ExpectQuery(selectQuery).
+			WithArgs("99999999").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+
+		res, err := http.Get(srv.URL + "/cep/99999999")
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		var got map[string]string
+		assert.Equal(t, http.StatusNotFound, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		assert.Equal(t, cep.ErrNotFound.Error(), got["error"])
+		assert.Equal(t, 1, provider.callsFor("99999999"))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("invalid", func(t *testing.T) {
+		provider := newFakeProvider(se)
+		srv, mock := newTestServer(t, provider)
+
+		res, err := http.Get(srv.URL + "/cep/123")
+		assert.NoError(t, err)
+		defer res.Body.Close()
+
+		var got map[string]string
+		assert.Equal(t, http.StatusBadRequest, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		assert.Equal(t, cep.ErrInvalidCEP.Error(), got["error"])
+		assert.Empty(t, provider.calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestMemoryOnlyMode(t *testing.T) {
+	t.Setenv("MEMORY_ONLY", "true")
+	t.Setenv("DB_DSN", "")
+	t.Setenv("DB_HOST", "")
+	t.Setenv("DB_USER", "")
+	t.Setenv("DB_NAME", "")
+
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+	assert.True(t, cfg.memoryOnly)
+	assert.NoError(t, prepareDatabase(context.Background(), nil))
+
+	se := cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"}
+	provider := newFakeProvider(se)
+
+	app := newApplication(cfg, noopLogger(), new(slog.LevelVar), nil, provider)
+	app.accessLog = io.Discard
+	srv := httptest.NewServer(app.routes())
+	t.Cleanup(srv.Close)
+
+	for i := 0; i < 2; i++ {
+		res, err := http.Get(srv.URL + "/cep/01001000")
+		assert.NoError(t, err)
+
+		var got cep.Response
+		assert.Equal(t, http.StatusOK, res.StatusCode)
+		assert.NoError(t, json.NewDecoder(res.Body).Decode(&got))
+		assert.Equal(t, se, got)
+		res.Body.Close()
+	}
+	assert.Equal(t, 1, provider.callsFor("01001000"), "second lookup should come from the memory cache")
+
+	res, err := http.Get(srv.URL + "/healthz")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusOK, res.StatusCode)
+
+	res, err = http.Get(srv.URL + "/export")
+	assert.NoError(t, err)
+	res.Body.Close()
+	assert.Equal(t, http.StatusNotFound, res.StatusCode)
+}
@@ -0,0 +1,65 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"net/http"
+	"strings"
+
+	"github.com/gorilla/mux"
+
+	"github.com/victor-dias21/goCep-k8s/internal/jobs"
+)
+
+// createJobHandler persists a batch for background processing and answers 202
+// with the job ID to poll at GET /jobs/{id}.
+func (app *application) createJobHandler(w http.ResponseWriter, r *http.Request) {
+	keys, ok := readBatchKeys(w, r, app.cfg.jobsMaxSize)
+	if !ok {
+		return
+	}
+
+	id, err := app.jobs.Create(r.Context(), keys)
+	if err != nil {
+		app.logger.ErrorContext(r.Context(), "erro ao criar job", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao criar job"})
+		return
+	}
+
+	// Point at the status route of the same API version, e.g. /v1/jobs/{id}.
+	prefix := strings.TrimSuffix(r.URL.Path, "/jobs/batch")
+	w.Header().Set("Location", prefix+"/jobs/"+id)
+	writeJSON(w, http.StatusAccepted, map[string]any{"id": id, "status": jobs.StatusPending, "total": len(keys)})
+}
+
+// jobHandler reports a job's status and the results gathered so far.
+func (app *application) jobHandler(w http.ResponseWriter, r *http.Request) {
+	job, err := app.jobs.Get(r.Context(), mux.Vars(r)["id"])
+	switch {
+	case errors.Is(err, jobs.ErrNotFound):
+		writeJSON(w, http.StatusNotFound, map[string]string{"error": "job não encontrado"})
+		return
+	case err != nil:
+		app.logger.ErrorContext(r.Context(), "erro ao consultar job", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar job"})
+		return
+	}
+	writeJSON(w, http.StatusOK, job)
+}
+
+// processJobChunk is the jobs.Processor: it runs a chunk through the same
+// worker pool as POST /cep/batch and encodes each item.
+func (app *application) processJobChunk(ctx context.Context, ceps []string) map[string]json.RawMessage {
+	resp := app.lookupBatch(ctx, ceps)
+	out := make(map[string]json.RawMessage, len(resp.Results))
+	for key, item := range resp.Results {
+		raw, err := json.Marshal(item)
+		if err != nil {
+			app.logger.ErrorContext(ctx, "erro ao serializar resultado do cep", "cep", key, "err", err)
+			continue
+		}
+		out[key] = raw
+	}
+	return out
+}
@@ -0,0 +1,105 @@
+package main
+
+import (
+	"context"
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestCreateJobHandler(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	mock.ExpectExec("INSERT INTO batch_jobs").
+		WithArgs(sqlmock.AnyArg(), "pending", []byte(`["01001000","20040-002"]`), sqlmock.AnyArg()).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	rec := httptest.NewRecorder()
+	req := httptest.NewRequest(http.MethodPost, "/jobs/batch", strings.NewReader(`["01001000", "20040-002", "01001000"]`))
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusAccepted, rec.Code)
+	var body struct {
+		ID     string `json:"id"`
+		Status string `json:"status"`
+		Total  int    `json:"total"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Len(t, body.ID, 32)
+	assert.Equal(t, "pending", body.Status)
+	assert.Equal(t, 2, body.Total)
+	assert.Equal(t, "/jobs/"+body.ID, rec.Header().Get("Location"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCreateJobHandlerRejectsOversizedBatch(t *testing.T) {
+	cfg := testConfig()
+	cfg.jobsMaxSize = 1
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg = cfg
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs/batch", strings.NewReader(`["01001000", "20040-002"]`)))
+
+	assert.Equal(t, http.StatusRequestEntityTooLarge, rec.Code)
+}
+
+func TestJobHandler(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	created := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("SELECT status, ceps, results, created_at, updated_at FROM batch_jobs").
+		WithArgs("abc123").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "ceps", "results", "created_at", "updated_at"}).
+			AddRow("running", []byte(`["01001000","99999999"]`), []byte(`{"99999999":{"error":"cep não encontrado"}}`), created, created))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/abc123", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{
+		"id": "abc123",
+		"status": "running",
+		"total": 2,
+		"processed": 1,
+		"results": {"99999999": {"error": "cep não encontrado"}},
+		"created_at": "2024-05-01T12:00:00Z",
+		"updated_at": "2024-05-01T12:00:00Z"
+	}`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestJobHandlerNotFound(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	mock.ExpectQuery("FROM batch_jobs").WithArgs("missing").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "ceps", "results", "created_at", "updated_at"}))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/jobs/missing", nil))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestJobRoutesRequireDatabase(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider())
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodPost, "/jobs/batch", strings.NewReader(`["01001000"]`)))
+
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestProcessJobChunk(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo"}))
+
+	results := app.processJobChunk(context.Background(), []string{"01001000", "99999999"})
+
+	assert.Len(t, results, 2)
+	assert.Contains(t, string(results["01001000"]), `"localidade":"São Paulo"`)
+	assert.JSONEq(t, `{"error":"cep não encontrado"}`, string(results["99999999"]))
+}
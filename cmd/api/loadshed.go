@@ -0,0 +1,44 @@
+package main
+
+import (
+	"net/http"
+	"time"
+)
+
+// newInFlightGate returns a semaphore with n slots, or nil when n <= 0.
+func newInFlightGate(n int) chan struct{} {
+	if n <= 0 {
+		return nil
+	}
+	return make(chan struct{}, n)
+}
+
+// shedLoad serves at most MAX_IN_FLIGHT requests at once and answers 503 to
+// the rest straight away. Queueing them instead would only let latency grow
+// until clients time out, which during a spike hits every request and not
+// just the excess. Health probes are never shed, so a busy pod is not killed.
+func (app *application) shedLoad(next http.Handler) http.Handler {
+	if app.inFlight == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		select {
+		case app.inFlight <- struct{}{}:
+			defer func() { <-app.inFlight }()
+			next.ServeHTTP(w, r)
+		default:
+			app.httpRequests.Shed()
+			// Spikes pass quickly; a short hint spreads retries without
+			// holding clients back for long.
+			setRetryAfter(w, time.Second)
+			app.logger.WarnContext(r.Context(), "requisição descartada por excesso de carga", "max_in_flight", cap(app.inFlight))
+			writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "servidor sobrecarregado, tente novamente"})
+		}
+	})
+}
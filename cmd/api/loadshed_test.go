@@ -0,0 +1,36 @@
+package main
+
+import (
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestShedLoad(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.maxInFlight = 1
+	app.inFlight = newInFlightGate(app.cfg.maxInFlight)
+	handler := app.routes()
+
+	call := func(path string) *httptest.ResponseRecorder {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+		return rec
+	}
+
+	// Occupy the only slot, as a slow request would.
+	app.inFlight <- struct{}{}
+	rec := call("/cep/123")
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.Contains(t, rec.Body.String(), "servidor sobrecarregado")
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.NotEqual(t, http.StatusServiceUnavailable, call("/healthz").Code)
+
+	<-app.inFlight
+	assert.Equal(t, http.StatusBadRequest, call("/cep/123").Code)
+	assert.Empty(t, app.inFlight)
+
+	assert.Nil(t, newInFlightGate(0))
+}
@@ -0,0 +1,68 @@
+package main
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"log/slog"
+	"os"
+	"strings"
+
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
+)
+
+// newLogger returns the application logger. Output is one JSON object per
+// line so Loki and Elasticsearch can index level, cep, status and the other
+// attributes without a parsing stage. Records below level are dropped.
+func newLogger(w io.Writer, level slog.Leveler) *slog.Logger {
+	handler := slog.NewJSONHandler(w, &slog.HandlerOptions{AddSource: true, Level: level})
+	return slog.New(requestIDHandler{handler})
+}
+
+// requestIDHandler adds the request ID carried by the context to records
+// logged through the *Context methods, tying them to their access-log entry.
+type requestIDHandler struct {
+	slog.Handler
+}
+
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id, ok := requestid.FromContext(ctx); ok {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func (h requestIDHandler) WithAttrs(attrs []slog.Attr) slog.Handler {
+	return requestIDHandler{h.Handler.WithAttrs(attrs)}
+}
+
+func (h requestIDHandler) WithGroup(name string) slog.Handler {
+	return requestIDHandler{h.Handler.WithGroup(name)}
+}
+
+// parseLogLevel accepts the four named levels, case-insensitively.
+func parseLogLevel(value string) (slog.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(value)) {
+	case "debug":
+		return slog.LevelDebug, nil
+	case "info":
+		return slog.LevelInfo, nil
+	case "warn":
+		return slog.LevelWarn, nil
+	case "error":
+		return slog.LevelError, nil
+	}
+	return 0, fmt.Errorf("unknown log level %q", value)
+}
+
+// logLevelName is the inverse of parseLogLevel.
+func logLevelName(level slog.Level) string {
+	return strings.ToLower(level.String())
+}
+
+// fatal logs err at error level and exits, replacing log.Fatalf now that the
+// application logs through slog.
+func fatal(logger *slog.Logger, msg string, err error) {
+	logger.Error(msg, "err", err)
+	os.Exit(1)
+}
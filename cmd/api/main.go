@@ -5,19 +5,33 @@ import (
 	"database/sql"
 	"encoding/json"
 	"errors"
+	"expvar"
 	"fmt"
-	"log"
+	"io"
+	"log/slog"
 	"net"
 	"net/http"
+	"net/netip"
 	"net/url"
 	"os"
 	"os/signal"
+	"slices"
+	"strconv"
 	"strings"
 	"syscall"
 	"time"
 
 	"github.com/gorilla/mux"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/collectors"
+	"github.com/prometheus/client_golang/prometheus/promhttp"
+	"github.com/victor-dias21/goCep-k8s/internal/apikey"
 	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	"github.com/victor-dias21/goCep-k8s/internal/jobs"
+	"github.com/victor-dias21/goCep-k8s/internal/jwtauth"
+	"github.com/victor-dias21/goCep-k8s/internal/metrics"
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
+	"github.com/vmihailenco/msgpack/v5"
 
 	_ "github.com/jackc/pgx/v5/stdlib"
 )
@@ -30,100 +44,509 @@ type config struct {
 	readTimeout       time.Duration
 	writeTimeout      time.Duration
 	idleTimeout       time.Duration
+	trimWhitespace    bool
+	logFormat         string
+	lenientCEP        bool
+	trailingData      cep.TrailingDataPolicy
+	upsertMode        cep.UpsertMode
+	notFoundStatus    int
+	notFoundBody      string
+	adminToken        string
+
+	requiredHeaderName  string
+	requiredHeaderValue string
+
+	memoryOnly        bool
+	disabledEndpoints map[string]bool
+
+	dbConnectAttempts int
+	dbConnectInterval time.Duration
+	dbConnectTimeout  time.Duration
+
+	maxIdleConns        int
+	maxIdleConnsPerHost int
+	idleConnTimeout     time.Duration
+	outboundIPVersion   string
+
+	cacheStatsInterval time.Duration
+
+	providerAuth cep.ProviderAuth
+
+	responseLocation *time.Location
+
+	hedgeDelay       time.Duration
+	hedgeMaxInFlight int
+
+	mismatchPolicy cep.MismatchPolicy
+
+	errorEscalationThreshold int
+	errorEscalationWindow    time.Duration
+
+	enableTrailers bool
+
+	staleOnErrorGrace time.Duration
+
+	coalesceWindow time.Duration
+
+	providers        []cep.ProviderName
+	providerStrategy cep.ProviderStrategy
+
+	breaker cep.BreakerConfig
+
+	providerRetries        int
+	providerRetryBaseDelay time.Duration
+	providerRetryMaxDelay  time.Duration
+
+	dynamicProviderOrder bool
+
+	providerTimeouts map[cep.ProviderName]time.Duration
+
+	shadowProvider      cep.ProviderName
+	shadowSamplePercent int
+
+	providerCredentials map[cep.ProviderName]cep.ProviderAuth
+
+	batchMaxSize        int
+	batchWorkers        int
+	batchMultiStatus    bool
+	batchResultCacheTTL time.Duration
+
+	jobsMaxSize      int
+	jobsChunkSize    int
+	jobsPollInterval time.Duration
+	jobsLease        time.Duration
+
+	grpcAddr string
+
+	searchMaxLength int
+	searchCacheTTL  time.Duration
+
+	gzipEnabled bool
+	gzipMinSize int
+
+	logLevel slog.Level
+
+	trustedProxies []netip.Prefix
+
+	rateLimitRPS   float64
+	rateLimitBurst int
+
+	maxInFlight int
+
+	apiKeyAuth bool
+	// apiKeys maps the hash of each API_KEYS secret to its client name.
+	apiKeys map[string]string
+	// Default quotas for keys without their own; 0 means unlimited.
+	apiKeyDailyQuota   int64
+	apiKeyMonthlyQuota int64
+
+	// jwt.JWKSURL is empty when bearer tokens are not accepted.
+	jwt jwtauth.Config
+
+	maxProvidersPerRequest int
+
+	providerFanoutBudget time.Duration
+
+	// providerRegionsFile is reread on SIGHUP; empty when routing is global.
+	providerRegionsFile string
+	providerRegions     cep.RegionalProviderOrder
 }
 
+// Endpoint groups that DISABLED_ENDPOINTS can switch off.
+const (
+	endpointAdmin   = "admin"
+	endpointExport  = "export"
+	endpointBatch   = "batch"
+	endpointSearch  = "search"
+	endpointGraphQL = "graphql"
+	endpointDocs    = "docs"
+)
+
+// statusClientClosedRequest is the non-standard status nginx logs when the
+// client disconnects before a response is written.
+const statusClientClosedRequest = 499
+
+// Supported NOT_FOUND_BODY values.
+const (
+	notFoundBodyError = "error"
+	notFoundBodyFound = "found"
+)
+
 type application struct {
-	cfg     config
-	logger  *log.Logger
-	db      *sql.DB
-	service *cep.Service
+	cfg       config
+	logger    *slog.Logger
+	logLevel  *slog.LevelVar
+	accessLog io.Writer
+	db        *sql.DB
+	service   *cep.Service
+	metrics   *prometheus.Registry
+
+	httpRequests *metrics.HTTPRequests
+	// upstreamFailures counts failed calls by provider for /admin/debug/vars.
+	upstreamFailures *expvar.Map
+
+	batchCache *batchCache
+	// limiter is nil when rate limiting is disabled.
+	limiter *rateLimiter
+	// inFlight holds a token per request being served; nil when MAX_IN_FLIGHT
+	// is 0.
+	inFlight chan struct{}
+	// apiKeys is nil in memory-only mode, where only API_KEYS are accepted.
+	apiKeys *apikey.Store
+	// jwt is nil unless JWT_JWKS_URL is set.
+	jwt *jwtauth.Verifier
+
+	// jobs and jobRunner are nil in memory-only mode.
+	jobs      *jobs.Store
+	jobRunner *jobs.Runner
 }
 
 // main bootstraps configuration, dependencies, and starts the HTTP server.
 func main() {
+	// The level is shared with the admin endpoint so it can change at runtime.
+	logLevel := new(slog.LevelVar)
+	logger := newLogger(os.Stdout, logLevel)
+	// Route the log package and package-level slog calls through the same handler.
+	slog.SetDefault(logger)
+
 	cfg, err := loadConfig()
 	if err != nil {
-		log.Fatalf("config error: %v", err)
+		fatal(logger, "config error", err)
 	}
+	logLevel.Set(cfg.logLevel)
 
-	logger := log.New(os.Stdout, "[gocep] ", log.LstdFlags|log.Lshortfile)
+	build := readBuildInfo()
+	logger.Info("iniciando gocep", "version", build.Version, "commit", build.Commit, "build_date", build.BuildDate)
 
-	db, err := openDB(cfg.dbDSN)
-	if err != nil {
-		logger.Fatalf("database error: %v", err)
+	var db *sql.DB
+	if cfg.memoryOnly {
+		logger.Info("MEMORY_ONLY ativo: sem PostgreSQL, cache apenas em memória")
+	} else {
+		db, err = openDB(cfg, logger)
+		if err != nil {
+			fatal(logger, "database error", err)
+		}
+		defer db.Close()
 	}
-	defer db.Close()
 
 	if err := prepareDatabase(context.Background(), db); err != nil {
-		logger.Fatalf("database migration error: %v", err)
+		fatal(logger, "database migration error", err)
 	}
 
-	httpClient := &http.Client{
-		Timeout: cfg.httpClientTimeout,
-		Transport: &http.Transport{
-			DialContext: (&net.Dialer{
-				Timeout:   5 * time.Second,
-				KeepAlive: 30 * time.Second,
-			}).DialContext,
-		},
+	app := newApplication(cfg, logger, logLevel, db, newHTTPClient(cfg))
+
+	checkCtx, cancelCheck := context.WithTimeout(context.Background(), 10*time.Second)
+	err = app.service.CheckDataSources(checkCtx)
+	cancelCheck()
+	if err != nil {
+		fatal(logger, "nenhuma fonte de dados disponível (cache ou provedor)", err)
 	}
 
-	service := cep.NewService(db, httpClient, cfg.cacheTTL, logger)
+	if err := app.run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
+		fatal(logger, "server error", err)
+	}
+}
 
-	app := &application{
-		cfg:     cfg,
-		logger:  logger,
-		db:      db,
-		service: service,
+// newApplication wires the CEP service and its options around the given database
+// and upstream client. Tests inject a fake upstream client through here.
+// logLevel must be the variable backing logger's handler so the admin
+// endpoint changes the level the logger actually filters on.
+func newApplication(cfg config, logger *slog.Logger, logLevel *slog.LevelVar, db *sql.DB, client cep.HTTPClient) *application {
+	upstreamRequests := metrics.NewUpstreamRequests()
+	httpRequests := metrics.NewHTTPRequests()
+	dbQueries := metrics.NewDBQueries()
+	upstreamFailures := new(expvar.Map)
+	service := cep.NewService(db, client, cfg.cacheTTL, logger,
+		cep.WithTrimWhitespace(cfg.trimWhitespace),
+		cep.WithLenientCEP(cfg.lenientCEP),
+		cep.WithTrailingDataPolicy(cfg.trailingData),
+		cep.WithUpsertMode(cfg.upsertMode),
+		cep.WithProviderAuth(cfg.providerAuth),
+		cep.WithHedging(cfg.hedgeDelay, cfg.hedgeMaxInFlight),
+		cep.WithMismatchPolicy(cfg.mismatchPolicy),
+		cep.WithProviderObserver(func(ctx context.Context, provider string, elapsed time.Duration, err error) {
+			upstreamRequests.Observe(ctx, provider, elapsed, err)
+			countUpstreamFailure(upstreamFailures, provider, err)
+		}),
+		cep.WithQueryObserver(dbQueries.Observe),
+		cep.WithErrorEscalation(cfg.errorEscalationThreshold, cfg.errorEscalationWindow),
+		cep.WithStaleOnError(cfg.staleOnErrorGrace),
+		cep.WithCoalesceWindow(cfg.coalesceWindow),
+		cep.WithProviderOrder(cfg.providers...),
+		cep.WithProviderStrategy(cfg.providerStrategy),
+		cep.WithCircuitBreaker(cfg.breaker),
+		cep.WithRetries(cfg.providerRetries, cfg.providerRetryBaseDelay, cfg.providerRetryMaxDelay),
+		cep.WithDynamicProviderOrder(cfg.dynamicProviderOrder),
+		cep.WithProviderTimeouts(cfg.providerTimeouts),
+		cep.WithShadowProvider(cfg.shadowProvider, cfg.shadowSamplePercent),
+		cep.WithProviderCredentials(cfg.providerCredentials),
+		cep.WithSearchMaxLength(cfg.searchMaxLength),
+		cep.WithSearchCacheTTL(cfg.searchCacheTTL),
+		cep.WithMaxProvidersPerRequest(cfg.maxProvidersPerRequest),
+		cep.WithProviderFanoutBudget(cfg.providerFanoutBudget),
+		cep.WithRegionalProviderOrder(cfg.providerRegions),
+	)
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(
+		metrics.NewCacheCollector(service, cfg.cacheStatsInterval),
+		metrics.NewLookupCollector(service),
+		metrics.NewCircuitCollector(service),
+		upstreamRequests,
+		httpRequests,
+		dbQueries,
+	)
+	if db != nil {
+		// go_sql_* gauges and counters: open, in-use and idle connections, and
+		// how often and how long callers waited for one.
+		registry.MustRegister(collectors.NewDBStatsCollector(db, "cep"))
 	}
 
-	if err := app.run(); err != nil && !errors.Is(err, http.ErrServerClosed) {
-		logger.Fatalf("server error: %v", err)
+	app := &application{
+		cfg:       cfg,
+		logger:    logger,
+		logLevel:  logLevel,
+		accessLog: os.Stdout,
+		db:        db,
+		service:   service,
+		metrics:   registry,
+
+		httpRequests:     httpRequests,
+		upstreamFailures: upstreamFailures,
+
+		batchCache: newBatchCache(cfg.batchResultCacheTTL),
+		limiter:    newRateLimiter(cfg.rateLimitRPS, cfg.rateLimitBurst),
+		inFlight:   newInFlightGate(cfg.maxInFlight),
+	}
+	if cfg.jwt.JWKSURL != "" {
+		app.jwt = jwtauth.NewVerifier(cfg.jwt, client)
+	}
+	if db != nil {
+		app.apiKeys = apikey.NewStore(db)
+		app.jobs = jobs.NewStore(db)
+		app.jobRunner = jobs.NewRunner(app.jobs, app.processJobChunk, cfg.jobsChunkSize, cfg.jobsPollInterval, cfg.jobsLease, logger)
 	}
+	return app
 }
 
-func (app *application) run() error {
+// routes wires every HTTP endpoint into a router.
+func (app *application) routes() http.Handler {
 	router := mux.NewRouter()
+	router.Use(captureRouteVars, app.instrumentRoutes)
 	router.HandleFunc("/healthz", app.healthHandler).Methods(http.MethodGet)
+	router.HandleFunc("/version", app.versionHandler).Methods(http.MethodGet)
+	router.Handle("/metrics", promhttp.HandlerFor(app.metrics, promhttp.HandlerOpts{EnableOpenMetrics: true})).Methods(http.MethodGet)
+	if app.endpointEnabled(endpointDocs) {
+		router.HandleFunc("/openapi.json", app.openAPIHandler).Methods(http.MethodGet)
+		router.Handle("/docs", http.RedirectHandler("/docs/", http.StatusMovedPermanently)).Methods(http.MethodGet)
+		router.PathPrefix("/docs/").Handler(docsHandler()).Methods(http.MethodGet, http.MethodHead)
+	}
+	if !app.endpointEnabled(endpointBatch) {
+		// Like POST /cep/batch; the gateway would map BatchGetCep's
+		// Unimplemented to 501.
+		router.Handle("/v1/rpc/cep:batchGet", http.NotFoundHandler())
+	}
+	router.PathPrefix("/v1/rpc/").Handler(app.requireClientAuth(app.gatewayHandler()))
+	app.versionedRoutes(router)
+
+	if app.endpointEnabled(endpointAdmin) {
+		router.HandleFunc("/admin/cache/expire-all", app.requireAdmin(app.expireAllHandler)).Methods(http.MethodPost)
+		router.HandleFunc("/admin/metrics", app.requireAdmin(app.adminMetricsHandler)).Methods(http.MethodGet)
+		router.HandleFunc("/admin/providers", app.requireAdmin(app.providerScoresHandler)).Methods(http.MethodGet)
+		router.HandleFunc("/admin/cache/{cep}", app.requireAdmin(app.invalidateHandler)).Methods(http.MethodDelete)
+		router.HandleFunc("/admin/loglevel", app.requireAdmin(app.logLevelHandler)).Methods(http.MethodGet, http.MethodPut)
+		app.pprofRoutes(router)
+		router.HandleFunc("/admin/debug/vars", app.requireAdmin(app.expvarHandler)).Methods(http.MethodGet)
+	}
+
+	// Endpoints below read the PostgreSQL cache directly and do not exist in memory-only mode.
+	if app.db != nil && app.endpointEnabled(endpointExport) {
+		router.HandleFunc("/export", app.requireAdmin(app.exportHandler)).Methods(http.MethodGet)
+	}
+	if app.apiKeys != nil && app.endpointEnabled(endpointAdmin) {
+		router.HandleFunc("/admin/apikeys", app.requireAdmin(app.listAPIKeysHandler)).Methods(http.MethodGet)
+		router.HandleFunc("/admin/apikeys", app.requireAdmin(app.createAPIKeyHandler)).Methods(http.MethodPost)
+		router.HandleFunc("/admin/apikeys/{id}", app.requireAdmin(app.revokeAPIKeyHandler)).Methods(http.MethodDelete)
+		router.HandleFunc("/admin/apikeys/{id}/usage", app.requireAdmin(app.apiKeyUsageHandler)).Methods(http.MethodGet)
+	}
+
+	return app.realClientIP(assignRequestID(app.logRequests(app.recoverPanics(app.shedLoad(app.rateLimit(traceContext(app.requireHeader(app.compressResponses(router)))))))))
+}
+
+// v1Routes wires the public API of version 1 into router, which is either the
+// /v1 subrouter or the root for the legacy unversioned paths.
+func (app *application) v1Routes(router *mux.Router) {
+	router.HandleFunc("/cep/city/{ibge}", app.cityHandler).Methods(http.MethodGet)
+	if app.endpointEnabled(endpointBatch) {
+		router.HandleFunc("/cep/batch", app.batchHandler).Methods(http.MethodPost)
+	} else {
+		// Without this, /cep/{cep} would match the path and answer 405.
+		router.HandleFunc("/cep/batch", http.NotFound).Methods(http.MethodPost)
+	}
+	router.HandleFunc("/cep/{cep}/ddd", app.dddHandler).Methods(http.MethodGet)
+	router.HandleFunc("/cep/{cep}/validate", app.validateHandler).Methods(http.MethodGet, http.MethodHead)
 	router.HandleFunc("/cep/{cep}", app.cepHandler).Methods(http.MethodGet)
+	router.HandleFunc("/cep/{cep}", app.cepOptionsHandler).Methods(http.MethodOptions)
+	if app.endpointEnabled(endpointSearch) {
+		router.HandleFunc("/search", app.searchHandler).Methods(http.MethodGet)
+		router.HandleFunc("/autocomplete/streets", app.streetAutocompleteHandler).Methods(http.MethodGet)
+	}
+	if app.endpointEnabled(endpointGraphQL) {
+		router.HandleFunc("/graphql", app.graphqlHandler()).Methods(http.MethodGet, http.MethodPost)
+	}
+
+	// Endpoints below read the PostgreSQL cache directly and do not exist in memory-only mode.
+	if app.db != nil && app.endpointEnabled(endpointSearch) {
+		router.HandleFunc("/search/fulltext", app.fullTextSearchHandler).Methods(http.MethodGet)
+	}
+	if app.jobs != nil && app.endpointEnabled(endpointBatch) {
+		router.HandleFunc("/jobs/batch", app.createJobHandler).Methods(http.MethodPost)
+		router.HandleFunc("/jobs/{id}", app.jobHandler).Methods(http.MethodGet)
+	}
+}
+
+// endpointEnabled reports whether an endpoint group should be registered at all.
+// Disabled groups are never routed, so they answer 404 like unknown paths.
+func (app *application) endpointEnabled(name string) bool {
+	return !app.cfg.disabledEndpoints[name]
+}
 
+// newHTTPClient builds the outbound client used to reach the providers. Idle
+// connection settings are tuned for few upstream hosts to avoid TLS handshake
+// churn. Request deadlines come from the per-provider timeouts, so the client
+// itself sets none.
+func newHTTPClient(cfg config) *http.Client {
+	dialer := &net.Dialer{
+		Timeout:   5 * time.Second,
+		KeepAlive: 30 * time.Second,
+	}
+
+	return &http.Client{
+		Transport: &http.Transport{
+			DialContext: func(ctx context.Context, network, addr string) (net.Conn, error) {
+				return dialer.DialContext(ctx, outboundNetwork(cfg.outboundIPVersion, network), addr)
+			},
+			MaxIdleConns:        cfg.maxIdleConns,
+			MaxIdleConnsPerHost: cfg.maxIdleConnsPerHost,
+			IdleConnTimeout:     cfg.idleConnTimeout,
+		},
+	}
+}
+
+// Supported OUTBOUND_IP_VERSION values.
+const (
+	ipVersionAuto = "auto"
+	ipVersion4    = "ipv4"
+	ipVersion6    = "ipv6"
+)
+
+// outboundNetwork pins a TCP dial to one IP family, working around clusters
+// where one family routes poorly to the provider. "auto" keeps Go's dual-stack
+// behaviour.
+func outboundNetwork(ipVersion, network string) string {
+	if network != "tcp" {
+		return network
+	}
+	switch ipVersion {
+	case ipVersion4:
+		return "tcp4"
+	case ipVersion6:
+		return "tcp6"
+	default:
+		return network
+	}
+}
+
+func (app *application) run() error {
 	srv := &http.Server{
 		Addr:         app.cfg.httpAddr,
-		Handler:      app.logRequests(router),
+		Handler:      app.routes(),
 		ReadTimeout:  app.cfg.readTimeout,
 		WriteTimeout: app.cfg.writeTimeout,
 		IdleTimeout:  app.cfg.idleTimeout,
 	}
 
-	errs := make(chan error, 1)
+	errs := make(chan error, 2)
+
+	if app.cfg.grpcAddr != "" {
+		listener, err := net.Listen("tcp", app.cfg.grpcAddr)
+		if err != nil {
+			return fmt.Errorf("falha ao escutar gRPC em %s: %w", app.cfg.grpcAddr, err)
+		}
+		grpcSrv := app.newGRPCServer()
+		defer grpcSrv.GracefulStop()
+		go func() {
+			app.logger.Info("gRPC escutando", "addr", app.cfg.grpcAddr)
+			errs <- grpcSrv.Serve(listener)
+		}()
+	}
 
 	go func() {
-		app.logger.Printf("API escutando em %s", app.cfg.httpAddr)
+		app.logger.Info("API escutando", "addr", app.cfg.httpAddr)
 		errs <- srv.ListenAndServe()
 	}()
 
+	// Jobs interrupted by shutdown are resumed by any pod once their lease expires.
+	runnerCtx, stopRunner := context.WithCancel(context.Background())
+	defer stopRunner()
+	if app.jobRunner != nil {
+		go app.jobRunner.Run(runnerCtx)
+	}
+
 	quit := make(chan os.Signal, 1)
 	signal.Notify(quit, syscall.SIGINT, syscall.SIGTERM)
 
-	select {
-	case err := <-errs:
-		return err
-	case sig := <-quit:
-		app.logger.Printf("recebido sinal %s, iniciando shutdown gracioso", sig)
-		ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
-		defer cancel()
-		return srv.Shutdown(ctx)
+	// SIGHUP keeps its default, terminating, behaviour unless there is a file to reload.
+	reload := make(chan os.Signal, 1)
+	if app.cfg.providerRegionsFile != "" {
+		signal.Notify(reload, syscall.SIGHUP)
+	}
+
+	for {
+		select {
+		case err := <-errs:
+			return err
+		case <-reload:
+			app.reloadProviderRegions()
+		case sig := <-quit:
+			app.logger.Info("recebido sinal, iniciando shutdown gracioso", "signal", sig.String())
+			ctx, cancel := context.WithTimeout(context.Background(), 10*time.Second)
+			defer cancel()
+			return srv.Shutdown(ctx)
+		}
 	}
 }
 
+// reloadProviderRegions rereads PROVIDER_REGIONS_FILE. A file that no longer
+// parses is logged and the routing in use is kept.
+func (app *application) reloadProviderRegions() {
+	order, err := cep.LoadRegionalProviderOrder(app.cfg.providerRegionsFile)
+	if err != nil {
+		app.logger.Error("falha ao recarregar PROVIDER_REGIONS_FILE, mantendo a configuração atual", "path", app.cfg.providerRegionsFile, "err", err)
+		return
+	}
+	app.service.SetRegionalProviderOrder(order)
+	app.logger.Info("PROVIDER_REGIONS_FILE recarregado", "path", app.cfg.providerRegionsFile, "regions", len(order))
+}
+
 func (app *application) healthHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
 	defer cancel()
 
-	status := map[string]string{
+	status := map[string]any{
 		"status": "ok",
 	}
 
+	// Open circuits are reported but do not fail the probe: the cache can
+	// still serve, and restarting pods would not bring the provider back.
+	if circuits := app.service.CircuitStates(); len(circuits) > 0 {
+		states := make(map[string]string, len(circuits))
+		for _, circuit := range circuits {
+			states[circuit.Provider] = circuit.State
+		}
+		status["circuits"] = states
+	}
+
 	if err := app.service.Ping(ctx); err != nil {
 		status["status"] = "error"
 		status["detail"] = err.Error()
@@ -141,31 +564,287 @@ func (app *application) cepHandler(w http.ResponseWriter, r *http.Request) {
 	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
 	defer cancel()
 
-	result, err := app.service.Get(ctx, cepValue)
+	query := r.URL.Query()
+	selection, err := parseFields(query.Get("fields"))
 	if err != nil {
-		switch {
-		case errors.Is(err, cep.ErrInvalidCEP):
-			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
-		case errors.Is(err, cep.ErrNotFound):
-			writeJSON(w, http.StatusNotFound, map[string]string{"error": err.Error()})
-		default:
-			app.logger.Printf("erro ao buscar cep %s: %v", cepValue, err)
-			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar cep"})
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+		return
+	}
+
+	refresh := parseBoolOrDefault(query.Get("fresh"), false)
+	if refresh && !app.isAdmin(r) {
+		// Forced refreshes bypass the cache, so only operators may trigger them.
+		writeJSON(w, http.StatusUnauthorized, map[string]string{"error": "não autorizado"})
+		return
+	}
+
+	start := time.Now()
+	var (
+		result *cep.Result
+		diff   *[]cep.FieldChange
+	)
+	if refresh {
+		var refreshed *cep.RefreshResult
+		if refreshed, err = app.service.Refresh(ctx, cepValue); err == nil {
+			result, diff = refreshed.Result, &refreshed.Diff
+		}
+	} else {
+		result, err = app.service.Lookup(ctx, cepValue)
+	}
+	lookupDuration := time.Since(start)
+	if err != nil {
+		app.writeLookupError(w, r, cepValue, err)
+		return
+	}
+
+	if app.cfg.enableTrailers {
+		w.Header().Set("Trailer", "X-Lookup-Duration-Ms, X-Cache-Status")
+		defer setStatsTrailers(w, lookupDuration, result)
+	}
+
+	if parseBoolOrDefault(query.Get("timezone"), false) {
+		enriched := *result.Response
+		enriched.Timezone, _ = cep.TimezoneForUF(enriched.Uf)
+		result.Response = &enriched
+	}
+	if parseBoolOrDefault(query.Get("parse_complemento"), false) {
+		enriched := *result.Response
+		parsed := cep.ParseComplemento(enriched.Complemento)
+		enriched.ComplementoParsed = &parsed
+		result.Response = &enriched
+	}
+
+	if result.Stale {
+		// The provider failed and an expired entry was served instead.
+		w.Header().Set("X-Cache-Stale", "on-error")
+	}
+
+	if app.isAdmin(r) {
+		w.Header().Set("X-Cache-Provenance", fmt.Sprintf("tier=%s; age=%d", result.Source, int(result.Age.Seconds())))
+	}
+
+	format := responseFormat(r)
+	wantsDiff := refresh && parseBoolOrDefault(query.Get("diff"), false)
+	withMeta := wantsMeta(r) || wantsDiff
+	if !withMeta {
+		// Metadata such as lookup_ms changes on every request, so only plain
+		// bodies get a validator.
+		etag := responseETag(result.Response, format, selection)
+		w.Header().Set("ETag", etag)
+		app.setCacheHeaders(w, result)
+		if notModified(r, etag, result.UpdatedAt) {
+			w.WriteHeader(http.StatusNotModified)
+			return
+		}
+	}
+
+	switch format {
+	case formatCSV:
+		writeCSV(w, http.StatusOK, result.Response, selection)
+		return
+	case formatKV:
+		writeKV(w, http.StatusOK, result.Response, selection)
+		return
+	case formatProtobuf:
+		// GetCepResponse always carries the source and timestamp, so ?meta is moot.
+		msg := toProtoGetCepResponse(result)
+		selectProtoFields(msg.Address, selection)
+		writeProtobuf(w, http.StatusOK, msg)
+		return
+	}
+	write := writeJSON
+	if format == formatMsgpack {
+		write = writeMsgpack
+	}
+
+	if withMeta {
+		meta := responseMeta{
+			LookupMS:       float64(lookupDuration.Microseconds()) / 1000,
+			ProviderCalled: result.ProviderCalled,
+			UpdatedAt:      app.formatTimestamp(result.UpdatedAt),
 		}
+		if wantsDiff {
+			meta.Diff = diff
+		}
+		write(w, http.StatusOK, envelope{Data: result.Response, Meta: meta, fields: selection})
 		return
 	}
 
+	write(w, http.StatusOK, selectFields(result.Response, selection))
+}
+
+// writeLookupError maps a failed lookup to the HTTP response.
+func (app *application) writeLookupError(w http.ResponseWriter, r *http.Request, cepValue string, err error) {
+	switch {
+	case r.Context().Err() != nil:
+		// The client is gone; record the nginx-style 499 and skip the body.
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, cep.ErrInvalidCEP):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	case errors.Is(err, cep.ErrNotFound):
+		app.writeNotFound(w, cepValue, err)
+	case errors.Is(err, cep.ErrNoDataSource):
+		app.logger.ErrorContext(r.Context(), "sem fonte de dados para cep", "cep", cepValue, "err", err)
+		setRetryAfter(w, outageRetryAfter)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{
+			"error": "serviço indisponível: cache e provedor de cep inacessíveis",
+		})
+	case errors.Is(err, cep.ErrCircuitOpen):
+		app.logger.WarnContext(r.Context(), "provedor indisponível para cep", "cep", cepValue, "err", err)
+		// The breaker lets a probe through after the cooldown at the latest.
+		setRetryAfter(w, app.cfg.breaker.Cooldown)
+		writeJSON(w, http.StatusServiceUnavailable, map[string]string{"error": "provedor de cep temporariamente indisponível"})
+	case errors.Is(err, cep.ErrUpstreamBadResponse):
+		app.logger.ErrorContext(r.Context(), "resposta inválida do upstream para cep", "cep", cepValue, "err", err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "resposta inválida do provedor de cep"})
+	default:
+		app.logger.ErrorContext(r.Context(), "erro ao buscar cep", "cep", cepValue, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar cep"})
+	}
+}
+
+// dddHandler returns only the DDD of a CEP, projected in the database on cache hits.
+func (app *application) dddHandler(w http.ResponseWriter, r *http.Request) {
+	cepValue := mux.Vars(r)["cep"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	result, err := app.service.LookupDDD(ctx, cepValue)
+	if err != nil {
+		app.writeLookupError(w, r, cepValue, err)
+		return
+	}
 	writeJSON(w, http.StatusOK, result)
 }
 
-// logRequests logs basic request metadata and latency.
-func (app *application) logRequests(next http.Handler) http.Handler {
-	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
-		start := time.Now()
-		next.ServeHTTP(w, r)
-		duration := time.Since(start)
-		app.logger.Printf("%s %s %s", r.Method, r.URL.Path, duration)
-	})
+// validateHandler answers 204 when the CEP exists and 404 when it does not,
+// with no body either way, for callers that only need an existence check.
+// Lookups go through the cache like GET /cep/{cep}, so the answer is cached too.
+func (app *application) validateHandler(w http.ResponseWriter, r *http.Request) {
+	cepValue := mux.Vars(r)["cep"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	_, err := app.service.Lookup(ctx, cepValue)
+	switch {
+	case err == nil:
+		w.WriteHeader(http.StatusNoContent)
+	case errors.Is(err, cep.ErrNotFound) && r.Context().Err() == nil:
+		w.WriteHeader(http.StatusNotFound)
+	default:
+		app.writeLookupError(w, r, cepValue, err)
+	}
+}
+
+// setStatsTrailers fills the trailers declared before the body was written, so
+// diagnostics reach HTTP/2 clients without touching the response body.
+func setStatsTrailers(w http.ResponseWriter, lookupDuration time.Duration, result *cep.Result) {
+	status := "hit"
+	if result.ProviderCalled {
+		status = "miss"
+	}
+	w.Header().Set("X-Lookup-Duration-Ms", strconv.FormatFloat(float64(lookupDuration.Microseconds())/1000, 'f', 3, 64))
+	w.Header().Set("X-Cache-Status", status)
+}
+
+// notFoundBody is returned instead of an error when NOT_FOUND_BODY=found.
+type notFoundBody struct {
+	Found bool   `json:"found"`
+	Cep   string `json:"cep"`
+}
+
+// writeNotFound answers an unknown CEP using NOT_FOUND_STATUS and NOT_FOUND_BODY,
+// for clients that treat ViaCEP's soft error as "no data" rather than a failure.
+func (app *application) writeNotFound(w http.ResponseWriter, cepValue string, err error) {
+	if app.cfg.notFoundBody == notFoundBodyFound {
+		writeJSON(w, app.cfg.notFoundStatus, notFoundBody{Found: false, Cep: cepValue})
+		return
+	}
+	writeJSON(w, app.cfg.notFoundStatus, map[string]string{"error": err.Error()})
+}
+
+// envelope is the response shape used when the client asks for metadata (?meta=true).
+type envelope struct {
+	Data *cep.Response `json:"data"`
+	Meta responseMeta  `json:"meta"`
+
+	// fields is the ?fields= selection applied to Data when marshalling.
+	fields fieldSet
+}
+
+// MarshalJSON applies the fields selection to Data.
+func (e envelope) MarshalJSON() ([]byte, error) {
+	return json.Marshal(e.selected())
+}
+
+// EncodeMsgpack applies the fields selection to Data.
+func (e envelope) EncodeMsgpack(enc *msgpack.Encoder) error {
+	return enc.Encode(e.selected())
+}
+
+// selected is e with the fields selection applied to Data.
+func (e envelope) selected() any {
+	return struct {
+		Data any          `json:"data"`
+		Meta responseMeta `json:"meta"`
+	}{selectFields(e.Data, e.fields), e.Meta}
+}
+
+// responseMeta carries server-side lookup details alongside the CEP data.
+type responseMeta struct {
+	LookupMS       float64 `json:"lookup_ms"`
+	ProviderCalled bool    `json:"provider_called"`
+	UpdatedAt      string  `json:"updated_at"`
+	// Diff is set on ?fresh=true&diff=true: null when nothing was cached before,
+	// empty when the provider data is unchanged.
+	Diff *[]cep.FieldChange `json:"diff,omitempty"`
+}
+
+// formatTimestamp renders t for clients in RESPONSE_TIMEZONE. Storage stays UTC;
+// only the presentation changes.
+func (app *application) formatTimestamp(t time.Time) string {
+	loc := app.cfg.responseLocation
+	if loc == nil {
+		loc = time.UTC
+	}
+	return t.In(loc).Format(time.RFC3339)
+}
+
+// wantsMeta reports whether the client requested the envelope/meta response mode.
+func wantsMeta(r *http.Request) bool {
+	return parseBoolOrDefault(r.URL.Query().Get("meta"), false)
+}
+
+// cepOptionsHandler exposes cache metadata as headers without a body or a ViaCEP fetch.
+func (app *application) cepOptionsHandler(w http.ResponseWriter, r *http.Request) {
+	cepValue := mux.Vars(r)["cep"]
+
+	ctx, cancel := context.WithTimeout(r.Context(), 2*time.Second)
+	defer cancel()
+
+	info, err := app.service.Inspect(ctx, cepValue)
+	if err != nil {
+		if errors.Is(err, cep.ErrInvalidCEP) {
+			writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+			return
+		}
+		app.logger.ErrorContext(r.Context(), "erro ao inspecionar cache do cep", "cep", cepValue, "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha ao consultar cache"})
+		return
+	}
+
+	h := w.Header()
+	h.Set("Allow", "GET, OPTIONS")
+	h.Set("X-Cache-Cached", strconv.FormatBool(info.Cached))
+	if info.Cached {
+		h.Set("X-Cache-Age", strconv.Itoa(int(info.Age.Seconds())))
+		h.Set("X-Cache-Updated-At", app.formatTimestamp(info.UpdatedAt))
+		h.Set("X-Cache-Expired", strconv.FormatBool(info.Expired))
+		h.Set("X-Cache-Source", info.Source)
+	}
+	w.WriteHeader(http.StatusNoContent)
 }
 
 // loadConfig loads application configuration from environment variables.
@@ -178,9 +857,277 @@ func loadConfig() (config, error) {
 		readTimeout:       15 * time.Second,
 		writeTimeout:      15 * time.Second,
 		idleTimeout:       60 * time.Second,
+		trimWhitespace:    parseBoolOrDefault(os.Getenv("TRIM_WHITESPACE"), true),
+		logFormat:         strings.ToLower(getEnvOrDefault("LOG_FORMAT", logFormatDefault)),
+		lenientCEP:        parseBoolOrDefault(os.Getenv("CEP_LENIENT"), false),
+		adminToken:        strings.TrimSpace(os.Getenv("ADMIN_TOKEN")),
+		trailingData:      cep.TrailingDataPolicy(strings.ToLower(getEnvOrDefault("UPSTREAM_TRAILING_DATA", string(cep.TrailingDataIgnore)))),
+		notFoundStatus:    parseIntOrDefault(os.Getenv("NOT_FOUND_STATUS"), http.StatusNotFound),
+		notFoundBody:      strings.ToLower(getEnvOrDefault("NOT_FOUND_BODY", notFoundBodyError)),
+		upsertMode:        cep.UpsertMode(strings.ToLower(getEnvOrDefault("CACHE_UPSERT_MODE", string(cep.UpsertUpdate)))),
+
+		requiredHeaderName:  strings.TrimSpace(os.Getenv("REQUIRED_HEADER_NAME")),
+		requiredHeaderValue: os.Getenv("REQUIRED_HEADER_VALUE"),
+
+		memoryOnly:        parseBoolOrDefault(os.Getenv("MEMORY_ONLY"), false),
+		disabledEndpoints: parseSet(os.Getenv("DISABLED_ENDPOINTS")),
+
+		dbConnectAttempts: parseIntOrDefault(os.Getenv("DB_CONNECT_ATTEMPTS"), 10),
+		dbConnectInterval: parseDurationOrDefault(os.Getenv("DB_CONNECT_INTERVAL"), time.Second),
+		dbConnectTimeout:  parseDurationOrDefault(os.Getenv("DB_CONNECT_TIMEOUT"), time.Minute),
+
+		maxIdleConns:        parseIntOrDefault(os.Getenv("OUTBOUND_MAX_IDLE_CONNS"), 64),
+		maxIdleConnsPerHost: parseIntOrDefault(os.Getenv("OUTBOUND_MAX_IDLE_CONNS_PER_HOST"), 32),
+		idleConnTimeout:     parseDurationOrDefault(os.Getenv("OUTBOUND_IDLE_CONN_TIMEOUT"), 90*time.Second),
+		outboundIPVersion:   strings.ToLower(getEnvOrDefault("OUTBOUND_IP_VERSION", ipVersionAuto)),
+
+		cacheStatsInterval: parseDurationOrDefault(os.Getenv("CACHE_STATS_INTERVAL"), time.Minute),
+
+		providerAuth: cep.ProviderAuth{
+			APIKey:     os.Getenv("PROVIDER_API_KEY"),
+			Header:     getEnvOrDefault("PROVIDER_AUTH_HEADER", "X-API-Key"),
+			QueryParam: strings.TrimSpace(os.Getenv("PROVIDER_AUTH_QUERY_PARAM")),
+		},
+
+		hedgeDelay:       parseDurationOrDefault(os.Getenv("HEDGE_DELAY"), 0),
+		hedgeMaxInFlight: parseIntOrDefault(os.Getenv("HEDGE_MAX_INFLIGHT"), 10),
+
+		mismatchPolicy: cep.MismatchPolicy(strings.ToLower(getEnvOrDefault("CEP_MISMATCH_POLICY", string(cep.MismatchAlias)))),
+
+		errorEscalationThreshold: parseIntOrDefault(os.Getenv("PROVIDER_ERROR_ESCALATION_THRESHOLD"), 5),
+		errorEscalationWindow:    parseDurationOrDefault(os.Getenv("PROVIDER_ERROR_ESCALATION_WINDOW"), time.Minute),
+
+		enableTrailers: parseBoolOrDefault(os.Getenv("ENABLE_TRAILERS"), false),
+
+		staleOnErrorGrace: parseDurationOrDefault(os.Getenv("STALE_ON_ERROR_GRACE"), 0),
+
+		coalesceWindow: parseDurationOrDefault(os.Getenv("COALESCE_WINDOW"), 0),
+
+		providerStrategy: cep.ProviderStrategy(strings.ToLower(getEnvOrDefault("PROVIDER_STRATEGY", string(cep.ProviderStrategyFallback)))),
+
+		breaker: cep.BreakerConfig{
+			FailurePercent: parseIntOrDefault(os.Getenv("PROVIDER_BREAKER_FAILURE_PERCENT"), 0),
+			MinRequests:    parseIntOrDefault(os.Getenv("PROVIDER_BREAKER_MIN_REQUESTS"), 10),
+			Window:         parseDurationOrDefault(os.Getenv("PROVIDER_BREAKER_WINDOW"), time.Minute),
+			Cooldown:       parseDurationOrDefault(os.Getenv("PROVIDER_BREAKER_COOLDOWN"), 30*time.Second),
+		},
+
+		providerRetries:        parseIntOrDefault(os.Getenv("PROVIDER_RETRIES"), 0),
+		providerRetryBaseDelay: parseDurationOrDefault(os.Getenv("PROVIDER_RETRY_BASE_DELAY"), 100*time.Millisecond),
+		providerRetryMaxDelay:  parseDurationOrDefault(os.Getenv("PROVIDER_RETRY_MAX_DELAY"), 2*time.Second),
+
+		dynamicProviderOrder: parseBoolOrDefault(os.Getenv("DYNAMIC_PROVIDER_ORDER"), false),
+
+		shadowProvider:      cep.ProviderName(strings.ToLower(getEnvOrDefault("SHADOW_PROVIDER", ""))),
+		shadowSamplePercent: parseIntOrDefault(os.Getenv("SHADOW_SAMPLE_PERCENT"), 10),
+
+		batchMaxSize:        parseIntOrDefault(os.Getenv("BATCH_MAX_SIZE"), 100),
+		batchWorkers:        parseIntOrDefault(os.Getenv("BATCH_WORKERS"), 8),
+		batchMultiStatus:    parseBoolOrDefault(os.Getenv("BATCH_MULTI_STATUS"), true),
+		batchResultCacheTTL: parseDurationOrDefault(os.Getenv("BATCH_RESULT_CACHE_TTL"), 0),
+
+		jobsMaxSize:      parseIntOrDefault(os.Getenv("JOBS_MAX_SIZE"), 10000),
+		jobsChunkSize:    parseIntOrDefault(os.Getenv("JOBS_CHUNK_SIZE"), 100),
+		jobsPollInterval: parseDurationOrDefault(os.Getenv("JOBS_POLL_INTERVAL"), 2*time.Second),
+		jobsLease:        parseDurationOrDefault(os.Getenv("JOBS_LEASE"), 5*time.Minute),
+
+		grpcAddr: getEnvOrDefault("GRPC_ADDR", ""),
+
+		searchMaxLength: parseIntOrDefault(os.Getenv("SEARCH_MAX_LENGTH"), cep.DefaultSearchMaxLength),
+		searchCacheTTL:  parseDurationOrDefault(os.Getenv("SEARCH_CACHE_TTL"), time.Hour),
+
+		gzipEnabled: parseBoolOrDefault(os.Getenv("GZIP_ENABLED"), true),
+		gzipMinSize: parseIntOrDefault(os.Getenv("GZIP_MIN_SIZE"), 1024),
+
+		rateLimitRPS:   parseFloatOrDefault(os.Getenv("RATE_LIMIT_RPS"), 0),
+		rateLimitBurst: parseIntOrDefault(os.Getenv("RATE_LIMIT_BURST"), 20),
+
+		maxInFlight: parseIntOrDefault(os.Getenv("MAX_IN_FLIGHT"), 0),
+
+		apiKeyAuth:         parseBoolOrDefault(os.Getenv("API_KEY_AUTH"), false),
+		apiKeyDailyQuota:   int64(parseIntOrDefault(os.Getenv("API_KEY_DAILY_QUOTA"), 0)),
+		apiKeyMonthlyQuota: int64(parseIntOrDefault(os.Getenv("API_KEY_MONTHLY_QUOTA"), 0)),
+
+		jwt: jwtauth.Config{
+			JWKSURL:  strings.TrimSpace(os.Getenv("JWT_JWKS_URL")),
+			Issuer:   strings.TrimSpace(os.Getenv("JWT_ISSUER")),
+			Audience: strings.TrimSpace(os.Getenv("JWT_AUDIENCE")),
+			Leeway:   parseDurationOrDefault(os.Getenv("JWT_LEEWAY"), 30*time.Second),
+		},
+
+		maxProvidersPerRequest: parseIntOrDefault(os.Getenv("MAX_PROVIDERS_PER_REQUEST"), 0),
+
+		providerFanoutBudget: parseDurationOrDefault(os.Getenv("PROVIDER_FANOUT_BUDGET"), 0),
+
+		providerRegionsFile: strings.TrimSpace(os.Getenv("PROVIDER_REGIONS_FILE")),
+	}
+
+	// Each provider gets its own deadline, e.g. VIACEP_TIMEOUT, falling back to
+	// HTTP_CLIENT_TIMEOUT.
+	cfg.providerTimeouts = map[cep.ProviderName]time.Duration{}
+	for _, name := range cep.BuiltinProviderNames() {
+		key := strings.ToUpper(string(name)) + "_TIMEOUT"
+		cfg.providerTimeouts[name] = parseDurationOrDefault(os.Getenv(key), cfg.httpClientTimeout)
+	}
+
+	seenProviders := map[cep.ProviderName]bool{}
+	for _, item := range parseList(getEnvOrDefault("CEP_PROVIDERS", string(cep.ProviderViaCEP))) {
+		name := cep.ProviderName(item)
+		if !slices.Contains(cep.BuiltinProviderNames(), name) {
+			return cfg, fmt.Errorf("CEP_PROVIDERS contém provedor desconhecido: %q", item)
+		}
+		if seenProviders[name] {
+			return cfg, fmt.Errorf("CEP_PROVIDERS repete o provedor %q", item)
+		}
+		seenProviders[name] = true
+		cfg.providers = append(cfg.providers, name)
+	}
+
+	if token := os.Getenv("CEPABERTO_TOKEN"); token != "" {
+		cfg.providerCredentials = map[cep.ProviderName]cep.ProviderAuth{cep.ProviderCEPAberto: cep.CEPAbertoAuth(token)}
+	} else if seenProviders[cep.ProviderCEPAberto] || cfg.shadowProvider == cep.ProviderCEPAberto {
+		return cfg, errors.New("CEPABERTO_TOKEN deve ser definido para usar o provedor cepaberto")
+	}
+
+	responseTZ := getEnvOrDefault("RESPONSE_TIMEZONE", "UTC")
+	loc, err := time.LoadLocation(responseTZ)
+	if err != nil {
+		return cfg, fmt.Errorf("RESPONSE_TIMEZONE inválido: %q", responseTZ)
+	}
+	cfg.responseLocation = loc
+
+	switch cfg.logFormat {
+	case logFormatDefault, logFormatCombined, logFormatJSON:
+	default:
+		return cfg, fmt.Errorf("LOG_FORMAT inválido: %q", cfg.logFormat)
+	}
+
+	if cfg.trustedProxies, err = parsePrefixes(os.Getenv("TRUSTED_PROXIES")); err != nil {
+		return cfg, fmt.Errorf("TRUSTED_PROXIES inválido: %w", err)
+	}
+
+	if cfg.apiKeys, err = parseAPIKeys(os.Getenv("API_KEYS")); err != nil {
+		return cfg, fmt.Errorf("API_KEYS inválido: %w", err)
+	}
+
+	logLevel := getEnvOrDefault("LOG_LEVEL", "info")
+	if cfg.logLevel, err = parseLogLevel(logLevel); err != nil {
+		return cfg, fmt.Errorf("LOG_LEVEL inválido: %q", logLevel)
+	}
+
+	switch cfg.trailingData {
+	case cep.TrailingDataIgnore, cep.TrailingDataWarn, cep.TrailingDataStrict:
+	default:
+		return cfg, fmt.Errorf("UPSTREAM_TRAILING_DATA inválido: %q", cfg.trailingData)
+	}
+
+	switch cfg.upsertMode {
+	case cep.UpsertUpdate, cep.UpsertKeepFresh:
+	default:
+		return cfg, fmt.Errorf("CACHE_UPSERT_MODE inválido: %q", cfg.upsertMode)
+	}
+
+	switch cfg.outboundIPVersion {
+	case ipVersionAuto, ipVersion4, ipVersion6:
+	default:
+		return cfg, fmt.Errorf("OUTBOUND_IP_VERSION inválido: %q", cfg.outboundIPVersion)
+	}
+
+	switch cfg.mismatchPolicy {
+	case cep.MismatchRequested, cep.MismatchCanonical, cep.MismatchAlias:
+	default:
+		return cfg, fmt.Errorf("CEP_MISMATCH_POLICY inválido: %q", cfg.mismatchPolicy)
+	}
+
+	if cfg.shadowProvider == "" {
+		cfg.shadowSamplePercent = 0
+	} else if !slices.Contains(cep.BuiltinProviderNames(), cfg.shadowProvider) {
+		return cfg, fmt.Errorf("SHADOW_PROVIDER inválido: %q", cfg.shadowProvider)
+	}
+
+	switch cfg.providerStrategy {
+	case cep.ProviderStrategyFallback, cep.ProviderStrategyParallel, cep.ProviderStrategyP2C:
+	default:
+		return cfg, fmt.Errorf("PROVIDER_STRATEGY inválido: %q", cfg.providerStrategy)
+	}
+
+	if cfg.batchMaxSize < 1 || cfg.batchWorkers < 1 {
+		return cfg, errors.New("BATCH_MAX_SIZE e BATCH_WORKERS devem ser maiores que zero")
+	}
+
+	if cfg.jobsMaxSize < 1 || cfg.jobsChunkSize < 1 || cfg.jobsPollInterval <= 0 || cfg.jobsLease <= 0 {
+		return cfg, errors.New("JOBS_MAX_SIZE, JOBS_CHUNK_SIZE, JOBS_POLL_INTERVAL e JOBS_LEASE devem ser maiores que zero")
+	}
+
+	if cfg.rateLimitRPS < 0 || cfg.rateLimitBurst < 1 {
+		return cfg, errors.New("RATE_LIMIT_RPS não pode ser negativo e RATE_LIMIT_BURST deve ser maior que zero")
+	}
+
+	if cfg.maxInFlight < 0 {
+		return cfg, fmt.Errorf("MAX_IN_FLIGHT não pode ser negativo, recebido %d", cfg.maxInFlight)
+	}
+
+	if cfg.gzipMinSize < 0 {
+		return cfg, fmt.Errorf("GZIP_MIN_SIZE não pode ser negativo, recebido %d", cfg.gzipMinSize)
+	}
+
+	if cfg.notFoundStatus != http.StatusNotFound && cfg.notFoundStatus != http.StatusOK {
+		return cfg, fmt.Errorf("NOT_FOUND_STATUS deve ser 404 ou 200, recebido %d", cfg.notFoundStatus)
+	}
+
+	switch cfg.notFoundBody {
+	case notFoundBodyError, notFoundBodyFound:
+	default:
+		return cfg, fmt.Errorf("NOT_FOUND_BODY inválido: %q", cfg.notFoundBody)
 	}
 
-	if cfg.dbDSN != "" {
+	for name := range cfg.disabledEndpoints {
+		switch name {
+		case endpointAdmin, endpointExport, endpointBatch, endpointSearch, endpointGraphQL, endpointDocs:
+		default:
+			return cfg, fmt.Errorf("DISABLED_ENDPOINTS contém grupo desconhecido: %q", name)
+		}
+	}
+
+	if cfg.requiredHeaderName != "" && cfg.requiredHeaderValue == "" {
+		return cfg, errors.New("REQUIRED_HEADER_VALUE deve ser definido junto com REQUIRED_HEADER_NAME")
+	}
+
+	if cfg.apiKeyDailyQuota < 0 || cfg.apiKeyMonthlyQuota < 0 {
+		return cfg, errors.New("API_KEY_DAILY_QUOTA e API_KEY_MONTHLY_QUOTA não podem ser negativos")
+	}
+
+	if cfg.jwt.JWKSURL != "" {
+		if u, err := url.Parse(cfg.jwt.JWKSURL); err != nil || (u.Scheme != "https" && u.Scheme != "http") || u.Host == "" {
+			return cfg, fmt.Errorf("JWT_JWKS_URL inválido: %q", cfg.jwt.JWKSURL)
+		}
+		if cfg.jwt.Issuer == "" || cfg.jwt.Audience == "" {
+			return cfg, errors.New("JWT_ISSUER e JWT_AUDIENCE devem ser definidos junto com JWT_JWKS_URL")
+		}
+		if cfg.jwt.Leeway < 0 {
+			return cfg, errors.New("JWT_LEEWAY não pode ser negativo")
+		}
+	}
+
+	if cfg.apiKeyAuth && cfg.memoryOnly && len(cfg.apiKeys) == 0 {
+		return cfg, errors.New("API_KEY_AUTH sem banco de dados exige API_KEYS")
+	}
+
+	if cfg.maxProvidersPerRequest < 0 {
+		return cfg, fmt.Errorf("MAX_PROVIDERS_PER_REQUEST não pode ser negativo, recebido %d", cfg.maxProvidersPerRequest)
+	}
+
+	if cfg.providerFanoutBudget < 0 {
+		return cfg, fmt.Errorf("PROVIDER_FANOUT_BUDGET não pode ser negativo, recebido %s", cfg.providerFanoutBudget)
+	}
+
+	if cfg.providerRegionsFile != "" {
+		if cfg.providerRegions, err = cep.LoadRegionalProviderOrder(cfg.providerRegionsFile); err != nil {
+			return cfg, fmt.Errorf("PROVIDER_REGIONS_FILE inválido: %w", err)
+		}
+	}
+
+	if cfg.dbDSN != "" || cfg.memoryOnly {
 		return cfg, nil
 	}
 
@@ -215,6 +1162,96 @@ func parseDurationOrDefault(value string, fallback time.Duration) time.Duration
 	return d
 }
 
+// parseIntOrDefault returns an integer or a fallback when parsing fails.
+func parseIntOrDefault(value string, fallback int) int {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback
+	}
+
+	n, err := strconv.Atoi(value)
+	if err != nil {
+		return fallback
+	}
+
+	return n
+}
+
+// parseFloatOrDefault returns a float or a fallback when parsing fails.
+func parseFloatOrDefault(value string, fallback float64) float64 {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback
+	}
+
+	f, err := strconv.ParseFloat(value, 64)
+	if err != nil {
+		return fallback
+	}
+
+	return f
+}
+
+// parseBoolOrDefault returns a boolean or a fallback when parsing fails.
+func parseBoolOrDefault(value string, fallback bool) bool {
+	value = strings.TrimSpace(value)
+	if value == "" {
+		return fallback
+	}
+
+	b, err := strconv.ParseBool(value)
+	if err != nil {
+		return fallback
+	}
+
+	return b
+}
+
+// parseSet splits a comma-separated list into a set of lower-cased, trimmed items.
+func parseSet(value string) map[string]bool {
+	set := map[string]bool{}
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			set[item] = true
+		}
+	}
+	return set
+}
+
+// parseList splits a comma-separated list into lower-cased, trimmed items,
+// keeping their order.
+func parseList(value string) []string {
+	var items []string
+	for _, item := range strings.Split(value, ",") {
+		if item = strings.ToLower(strings.TrimSpace(item)); item != "" {
+			items = append(items, item)
+		}
+	}
+	return items
+}
+
+// parsePrefixes parses a comma-separated list of CIDRs. A bare address is
+// taken as a single-host prefix.
+func parsePrefixes(value string) ([]netip.Prefix, error) {
+	var prefixes []netip.Prefix
+	for _, item := range parseList(value) {
+		if !strings.Contains(item, "/") {
+			addr, err := netip.ParseAddr(item)
+			if err != nil {
+				return nil, fmt.Errorf("%q não é um IP ou CIDR", item)
+			}
+			prefixes = append(prefixes, netip.PrefixFrom(addr.Unmap(), addr.Unmap().BitLen()))
+			continue
+		}
+		prefix, err := netip.ParsePrefix(item)
+		if err != nil {
+			return nil, fmt.Errorf("%q não é um IP ou CIDR", item)
+		}
+		prefixes = append(prefixes, prefix.Masked())
+	}
+	return prefixes, nil
+}
+
 // getEnvOrDefault looks up a trimmed environment variable, falling back when empty.
 func getEnvOrDefault(key, fallback string) string {
 	if v := strings.TrimSpace(os.Getenv(key)); v != "" {
@@ -240,20 +1277,43 @@ func buildDSN(host, port, user, password, database, sslMode string) string {
 	)
 }
 
-// prepareDatabase ensures the CEP cache table exists before serving requests.
+// prepareDatabase ensures the CEP cache, batch job and API key tables exist before serving requests.
+// It is a no-op without a database (memory-only mode).
 func prepareDatabase(ctx context.Context, db *sql.DB) error {
+	if db == nil {
+		return nil
+	}
+
 	const ddl = `
 CREATE TABLE IF NOT EXISTS ceps (
 	cep TEXT PRIMARY KEY,
 	payload JSONB NOT NULL,
 	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
-);`
-	_, err := db.ExecContext(ctx, ddl)
+);
+ALTER TABLE ceps ADD COLUMN IF NOT EXISTS schema_version INTEGER NOT NULL DEFAULT 0;
+CREATE INDEX IF NOT EXISTS ceps_updated_at_cep_idx ON ceps (updated_at, cep);
+CREATE INDEX IF NOT EXISTS ceps_ibge_idx ON ceps ((payload->>'ibge'));
+CREATE EXTENSION IF NOT EXISTS pg_trgm;
+CREATE INDEX IF NOT EXISTS ceps_logradouro_trgm_idx ON ceps USING gin ((payload->>'logradouro') gin_trgm_ops);
+CREATE EXTENSION IF NOT EXISTS unaccent;
+-- unaccent() is only STABLE; generated columns need an IMMUTABLE wrapper.
+CREATE OR REPLACE FUNCTION gocep_unaccent(text) RETURNS text
+	LANGUAGE sql IMMUTABLE PARALLEL SAFE STRICT
+	AS $$ SELECT public.unaccent('public.unaccent', $1) $$;
+ALTER TABLE ceps ADD COLUMN IF NOT EXISTS search_vector tsvector GENERATED ALWAYS AS (
+	setweight(to_tsvector('portuguese', gocep_unaccent(coalesce(payload->>'logradouro', ''))), 'A') ||
+	setweight(to_tsvector('portuguese', gocep_unaccent(coalesce(payload->>'bairro', ''))), 'B') ||
+	setweight(to_tsvector('portuguese', gocep_unaccent(coalesce(payload->>'localidade', ''))), 'C')
+) STORED;
+CREATE INDEX IF NOT EXISTS ceps_search_vector_idx ON ceps USING gin (search_vector);`
+	_, err := db.ExecContext(ctx, ddl+jobs.Schema+apikey.Schema)
 	return err
 }
 
-func openDB(dsn string) (*sql.DB, error) {
-	db, err := sql.Open("pgx", dsn)
+// openDB opens the pool and waits for PostgreSQL to accept connections, retrying
+// with backoff so pods starting before the database do not crash-loop.
+func openDB(cfg config, logger *slog.Logger) (*sql.DB, error) {
+	db, err := sql.Open("pgx", cfg.dbDSN)
 	if err != nil {
 		return nil, err
 	}
@@ -262,10 +1322,10 @@ func openDB(dsn string) (*sql.DB, error) {
 	db.SetMaxIdleConns(5)
 	db.SetConnMaxLifetime(30 * time.Minute)
 
-	ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
+	ctx, cancel := context.WithTimeout(context.Background(), cfg.dbConnectTimeout)
 	defer cancel()
 
-	if err := db.PingContext(ctx); err != nil {
+	if err := waitForDB(ctx, db.PingContext, cfg.dbConnectAttempts, cfg.dbConnectInterval, logger); err != nil {
 		db.Close()
 		return nil, err
 	}
@@ -273,13 +1333,78 @@ func openDB(dsn string) (*sql.DB, error) {
 	return db, nil
 }
 
-// writeJSON standardises JSON responses and logs encoding failures.
+// maxDBConnectInterval caps the exponential backoff between connection attempts.
+const maxDBConnectInterval = 30 * time.Second
+
+// waitForDB calls ping until it succeeds, the attempts run out, or ctx expires.
+// The wait between attempts starts at interval and doubles each time.
+func waitForDB(ctx context.Context, ping func(context.Context) error, attempts int, interval time.Duration, logger *slog.Logger) error {
+	if attempts < 1 {
+		attempts = 1
+	}
+
+	var err error
+	for attempt := 1; ; attempt++ {
+		pingCtx, cancel := context.WithTimeout(ctx, 5*time.Second)
+		err = ping(pingCtx)
+		cancel()
+		if err == nil {
+			return nil
+		}
+		if attempt >= attempts {
+			return fmt.Errorf("banco indisponível após %d tentativas: %w", attempts, err)
+		}
+
+		logger.Warn("banco indisponível", "attempt", attempt, "attempts", attempts, "retry_in", interval, "err", err)
+
+		select {
+		case <-ctx.Done():
+			return fmt.Errorf("prazo para conectar ao banco esgotado: %w", err)
+		case <-time.After(interval):
+		}
+
+		interval = min(interval*2, maxDBConnectInterval)
+	}
+}
+
+// writeJSON standardises JSON responses and logs encoding failures. Error
+// bodies also carry the request ID, so a client can quote it in a report.
 func writeJSON(w http.ResponseWriter, status int, data interface{}) {
+	if body, ok := data.(map[string]string); ok && status >= http.StatusBadRequest {
+		if id := w.Header().Get(requestid.Header); id != "" && body["error"] != "" {
+			data = withRequestID(body, id)
+		}
+	}
 	w.Header().Set("Content-Type", "application/json; charset=utf-8")
 	w.WriteHeader(status)
 	if err := json.NewEncoder(w).Encode(data); err != nil {
 		// At this point there is no safe way to surface the error to the client,
-		// so we log the failure to stderr.
-		log.Printf("erro ao escrever resposta json: %v", err)
+		// so we only log the failure.
+		slog.Error("erro ao escrever resposta json", "err", err)
+	}
+}
+
+// outageRetryAfter is the Retry-After hint sent when neither the cache nor a
+// provider can be reached, which gives a database failover time to complete.
+const outageRetryAfter = 30 * time.Second
+
+// setRetryAfter tells the client how long to wait before retrying, in whole
+// seconds as Retry-After requires, rounded up so it never retries early.
+func setRetryAfter(w http.ResponseWriter, wait time.Duration) {
+	w.Header().Set("Retry-After", retryAfterSeconds(wait))
+}
+
+// retryAfterSeconds renders wait as a Retry-After value in whole seconds.
+func retryAfterSeconds(wait time.Duration) string {
+	return strconv.Itoa(max(1, int((wait+time.Second-1)/time.Second)))
+}
+
+// withRequestID returns a copy of an error body with its request_id set.
+func withRequestID(body map[string]string, id string) map[string]string {
+	out := make(map[string]string, len(body)+1)
+	for key, value := range body {
+		out[key] = value
 	}
+	out["request_id"] = id
+	return out
 }
@@ -0,0 +1,864 @@
+package main
+
+import (
+	"bytes"
+	"context"
+	"database/sql/driver"
+	"encoding/json"
+	"errors"
+	"io"
+	"log/slog"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+type stubHTTPClient struct {
+	response *http.Response
+	err      error
+	calls    int
+}
+
+func (s *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
+	s.calls++
+	return s.response, s.err
+}
+
+func newTestApp(t *testing.T, client cep.HTTPClient) (*application, sqlmock.Sqlmock) {
+	t.Helper()
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	app := newApplication(testConfig(), noopLogger(), new(slog.LevelVar), db, client)
+	app.accessLog = io.Discard
+
+	return app, mock
+}
+
+// testConfig mirrors the defaults from loadConfig without reading the environment.
+// noopLogger discards everything the application logs.
+func noopLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+func testConfig() config {
+	return config{
+		cacheTTL:       time.Hour,
+		trimWhitespace: true,
+		logFormat:      logFormatDefault,
+		trailingData:   cep.TrailingDataIgnore,
+		upsertMode:     cep.UpsertUpdate,
+		notFoundStatus: http.StatusNotFound,
+		notFoundBody:   notFoundBodyError,
+
+		responseLocation: time.UTC,
+		mismatchPolicy:   cep.MismatchAlias,
+
+		batchMaxSize:     100,
+		batchWorkers:     8,
+		batchMultiStatus: true,
+
+		jobsMaxSize:      10000,
+		jobsChunkSize:    100,
+		jobsPollInterval: 2 * time.Second,
+		jobsLease:        5 * time.Minute,
+	}
+}
+
+func TestCEPOptionsCached(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+
+	mock.ExpectQuery(`SELECT updated_at FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}).AddRow(time.Now().Add(-90 * time.Second)))
+
+	req := httptest.NewRequest(http.MethodOptions, "/cep/01001-000", nil)
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, "true", rec.Header().Get("X-Cache-Cached"))
+	assert.Equal(t, "90", rec.Header().Get("X-Cache-Age"))
+	assert.NotEmpty(t, rec.Header().Get("X-Cache-Updated-At"))
+	assert.Equal(t, "false", rec.Header().Get("X-Cache-Expired"))
+	assert.Equal(t, "postgres", rec.Header().Get("X-Cache-Source"))
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCEPOptionsNotCached(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+
+	mock.ExpectQuery(`SELECT updated_at FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"updated_at"}))
+
+	req := httptest.NewRequest(http.MethodOptions, "/cep/01001000", nil)
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusNoContent, rec.Code)
+	assert.Equal(t, "false", rec.Header().Get("X-Cache-Cached"))
+	assert.Empty(t, rec.Header().Get("X-Cache-Age"))
+	assert.Empty(t, rec.Header().Get("X-Cache-Source"))
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCEPHandlerMeta(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	assert.NoError(t, err)
+
+	t.Run("cache hit", func(t *testing.T) {
+		client := &stubHTTPClient{}
+		app, mock := newTestApp(t, client)
+
+		mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000?meta=true", nil))
+
+		var body envelope
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "01001-000", body.Data.Cep)
+		assert.False(t, body.Meta.ProviderCalled)
+		assert.GreaterOrEqual(t, body.Meta.LookupMS, 0.0)
+		assert.Equal(t, 0, client.calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("cache miss", func(t *testing.T) {
+		client := &stubHTTPClient{
+			response: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(bytes.NewReader(payload)),
+			},
+		}
+		app, mock := newTestApp(t, client)
+
+		mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+		mock.ExpectExec(`INSERT INTO ceps`).
+			WithArgs("01001000", sqlmock.AnyArg(), sqlmock.AnyArg(), cep.CacheSchemaVersion).
+			WillReturnResult(sqlmock.NewResult(1, 1))
+
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000?meta=true", nil))
+
+		var body envelope
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.True(t, body.Meta.ProviderCalled)
+		assert.Greater(t, body.Meta.LookupMS, 0.0)
+		assert.Equal(t, 1, client.calls)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("plain response without meta", func(t *testing.T) {
+		app, mock := newTestApp(t, &stubHTTPClient{})
+
+		mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000", nil))
+
+		assert.JSONEq(t, string(payload), rec.Body.String())
+	})
+}
+
+// utcTime matches a time.Time argument stored in UTC.
+type utcTime struct{ got *time.Time }
+
+func (u utcTime) Match(v driver.Value) bool {
+	t, ok := v.(time.Time)
+	*u.got = t
+	return ok && t.Location() == time.UTC
+}
+
+func TestResponseTimezone(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+	t.Setenv("RESPONSE_TIMEZONE", "America/Sao_Paulo")
+
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000"})
+	assert.NoError(t, err)
+
+	app, mock := newTestApp(t, &stubHTTPClient{response: &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(bytes.NewReader(payload)),
+	}})
+	app.cfg.responseLocation = cfg.responseLocation
+
+	var stored time.Time
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+	mock.ExpectExec(`INSERT INTO ceps`).
+		WithArgs("01001000", sqlmock.AnyArg(), utcTime{got: &stored}, cep.CacheSchemaVersion).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000?meta=true", nil))
+
+	var body envelope
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	served, err := time.Parse(time.RFC3339, body.Meta.UpdatedAt)
+	assert.NoError(t, err)
+	_, offset := served.Zone()
+	assert.Equal(t, -3*60*60, offset)
+	assert.WithinDuration(t, stored, served, time.Second)
+
+	t.Setenv("RESPONSE_TIMEZONE", "Mars/Olympus_Mons")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "RESPONSE_TIMEZONE")
+}
+
+func TestOutboundIPVersion(t *testing.T) {
+	for _, tc := range []struct {
+		version, network, want string
+	}{
+		{version: ipVersionAuto, network: "tcp", want: "tcp"},
+		{version: ipVersion4, network: "tcp", want: "tcp4"},
+		{version: ipVersion6, network: "tcp", want: "tcp6"},
+		{version: ipVersion4, network: "tcp6", want: "tcp6"},
+	} {
+		assert.Equal(t, tc.want, outboundNetwork(tc.version, tc.network), "%s/%s", tc.version, tc.network)
+	}
+
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+	t.Setenv("OUTBOUND_IP_VERSION", "IPv4")
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, ipVersion4, cfg.outboundIPVersion)
+
+	// The transport dials with the pinned family: an IPv4-only client refuses
+	// an IPv6 address outright, while an IPv6 client reaches it.
+	listener, err := net.Listen("tcp6", "[::1]:0")
+	if err != nil {
+		t.Skipf("IPv6 loopback unavailable: %v", err)
+	}
+	srv := httptest.NewUnstartedServer(http.NotFoundHandler())
+	srv.Listener = listener
+	srv.Start()
+	t.Cleanup(srv.Close)
+
+	_, err = newHTTPClient(cfg).Get(srv.URL)
+	assert.ErrorContains(t, err, "dial tcp4")
+
+	cfg.outboundIPVersion = ipVersion6
+	resp, err := newHTTPClient(cfg).Get(srv.URL)
+	if assert.NoError(t, err) {
+		resp.Body.Close()
+	}
+
+	t.Setenv("OUTBOUND_IP_VERSION", "ipv5")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "OUTBOUND_IP_VERSION")
+}
+
+func TestLoadConfigProviders(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []cep.ProviderName{cep.ProviderViaCEP}, cfg.providers)
+	assert.Equal(t, cep.ProviderStrategyFallback, cfg.providerStrategy)
+	assert.Equal(t, 5*time.Second, cfg.providerTimeouts[cep.ProviderViaCEP])
+
+	t.Setenv("HTTP_CLIENT_TIMEOUT", "3s")
+	t.Setenv("BRASILAPI_TIMEOUT", "800ms")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, map[cep.ProviderName]time.Duration{
+		cep.ProviderViaCEP:    3 * time.Second,
+		cep.ProviderBrasilAPI: 800 * time.Millisecond,
+		cep.ProviderAPICEP:    3 * time.Second,
+		cep.ProviderCEPAberto: 3 * time.Second,
+	}, cfg.providerTimeouts)
+
+	t.Setenv("CEP_PROVIDERS", "BrasilAPI, viacep, apicep")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, []cep.ProviderName{cep.ProviderBrasilAPI, cep.ProviderViaCEP, cep.ProviderAPICEP}, cfg.providers)
+
+	t.Setenv("CEP_PROVIDERS", "viacep,postmon")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "CEP_PROVIDERS")
+
+	t.Setenv("CEP_PROVIDERS", "viacep,viacep")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "CEP_PROVIDERS")
+
+	t.Setenv("CEP_PROVIDERS", "")
+	t.Setenv("PROVIDER_STRATEGY", "Parallel")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, cep.ProviderStrategyParallel, cfg.providerStrategy)
+
+	t.Setenv("PROVIDER_STRATEGY", "p2c")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, cep.ProviderStrategyP2C, cfg.providerStrategy)
+
+	t.Setenv("PROVIDER_STRATEGY", "random")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "PROVIDER_STRATEGY")
+	t.Setenv("PROVIDER_STRATEGY", "")
+
+	t.Setenv("MAX_PROVIDERS_PER_REQUEST", "2")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 2, cfg.maxProvidersPerRequest)
+	t.Setenv("MAX_PROVIDERS_PER_REQUEST", "-1")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "MAX_PROVIDERS_PER_REQUEST")
+	t.Setenv("MAX_PROVIDERS_PER_REQUEST", "")
+
+	t.Setenv("PROVIDER_FANOUT_BUDGET", "1500ms")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, 1500*time.Millisecond, cfg.providerFanoutBudget)
+	t.Setenv("PROVIDER_FANOUT_BUDGET", "-1s")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "PROVIDER_FANOUT_BUDGET")
+	t.Setenv("PROVIDER_FANOUT_BUDGET", "")
+
+	regions := filepath.Join(t.TempDir(), "regions.json")
+	assert.NoError(t, os.WriteFile(regions, []byte(`{"sp": ["brasilapi"]}`), 0o600))
+	t.Setenv("PROVIDER_REGIONS_FILE", regions)
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, cep.RegionalProviderOrder{"SP": {cep.ProviderBrasilAPI}}, cfg.providerRegions)
+	assert.NoError(t, os.WriteFile(regions, []byte(`{"SP": ["postmon"]}`), 0o600))
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "PROVIDER_REGIONS_FILE")
+	t.Setenv("PROVIDER_REGIONS_FILE", "")
+
+	assert.Equal(t, 0, cfg.shadowSamplePercent, "no shadow traffic without SHADOW_PROVIDER")
+	t.Setenv("SHADOW_PROVIDER", "brasilapi")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, cep.ProviderBrasilAPI, cfg.shadowProvider)
+	assert.Equal(t, 10, cfg.shadowSamplePercent)
+
+	t.Setenv("SHADOW_PROVIDER", "postmon")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "SHADOW_PROVIDER")
+	t.Setenv("SHADOW_PROVIDER", "")
+
+	t.Setenv("CEP_PROVIDERS", "viacep,cepaberto")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "CEPABERTO_TOKEN")
+
+	t.Setenv("CEPABERTO_TOKEN", "s3cret")
+	cfg, err = loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, "Token token=s3cret", cfg.providerCredentials[cep.ProviderCEPAberto].APIKey)
+}
+
+func TestNewHTTPClientTransportTuning(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+	t.Setenv("OUTBOUND_MAX_IDLE_CONNS", "40")
+	t.Setenv("OUTBOUND_MAX_IDLE_CONNS_PER_HOST", "25")
+	t.Setenv("OUTBOUND_IDLE_CONN_TIMEOUT", "2m")
+
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+
+	transport, ok := newHTTPClient(cfg).Transport.(*http.Transport)
+	assert.True(t, ok)
+	assert.Equal(t, 40, transport.MaxIdleConns)
+	assert.Equal(t, 25, transport.MaxIdleConnsPerHost)
+	assert.Equal(t, 2*time.Minute, transport.IdleConnTimeout)
+}
+
+func TestCEPHandlerCacheProvenance(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	assert.NoError(t, err)
+
+	selectQuery := `SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`
+
+	for _, tc := range []struct {
+		name       string
+		cached     bool
+		token      string
+		provenance string
+	}{
+		{name: "postgres tier", cached: true, token: "s3cret", provenance: "tier=postgres; age=120"},
+		{name: "provider tier", cached: false, token: "s3cret", provenance: "tier=provider; age=0"},
+		{name: "without admin token", cached: true, token: "", provenance: ""},
+		{name: "with wrong admin token", cached: true, token: "guess", provenance: ""},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &stubHTTPClient{
+				response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(bytes.NewReader(payload)),
+				},
+			}
+			app, mock := newTestApp(t, client)
+			app.cfg.adminToken = "s3cret"
+
+			rows := sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"})
+			if tc.cached {
+				rows.AddRow(payload, time.Now().Add(-2*time.Minute), cep.CacheSchemaVersion)
+			}
+			mock.ExpectQuery(selectQuery).WithArgs("01001000").WillReturnRows(rows)
+			if !tc.cached {
+				mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+			if tc.token != "" {
+				req.Header.Set("Authorization", "Bearer "+tc.token)
+			}
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, req)
+
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.Equal(t, tc.provenance, rec.Header().Get("X-Cache-Provenance"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestCEPHandlerNotFoundBehaviour(t *testing.T) {
+	for _, tc := range []struct {
+		name   string
+		status int
+		body   string
+		want   string
+	}{
+		{name: "default 404 error", status: http.StatusNotFound, body: notFoundBodyError, want: `{"error":"cep not found","request_id":"req-1"}`},
+		{name: "200 found false", status: http.StatusOK, body: notFoundBodyFound, want: `{"found":false,"cep":"99999-999"}`},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := &stubHTTPClient{
+				response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(`{"erro": true}`)),
+				},
+			}
+			app, mock := newTestApp(t, client)
+			app.cfg.notFoundStatus = tc.status
+			app.cfg.notFoundBody = tc.body
+
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("99999999").
+				WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+
+			req := httptest.NewRequest(http.MethodGet, "/cep/99999-999", nil)
+			req.Header.Set("X-Request-ID", "req-1")
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, req)
+
+			assert.Equal(t, tc.status, rec.Code)
+			assert.JSONEq(t, tc.want, rec.Body.String())
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestWaitForDB(t *testing.T) {
+	logger := noopLogger()
+
+	t.Run("succeeds after transient failures", func(t *testing.T) {
+		calls := 0
+		ping := func(context.Context) error {
+			calls++
+			if calls < 4 {
+				return errors.New("connection refused")
+			}
+			return nil
+		}
+
+		err := waitForDB(context.Background(), ping, 5, time.Millisecond, logger)
+		assert.NoError(t, err)
+		assert.Equal(t, 4, calls)
+	})
+
+	t.Run("gives up after attempts", func(t *testing.T) {
+		calls := 0
+		ping := func(context.Context) error {
+			calls++
+			return errors.New("connection refused")
+		}
+
+		err := waitForDB(context.Background(), ping, 3, time.Millisecond, logger)
+		assert.ErrorContains(t, err, "após 3 tentativas")
+		assert.Equal(t, 3, calls)
+	})
+
+	t.Run("respects startup deadline", func(t *testing.T) {
+		ctx, cancel := context.WithTimeout(context.Background(), 20*time.Millisecond)
+		defer cancel()
+
+		calls := 0
+		ping := func(context.Context) error {
+			calls++
+			return errors.New("connection refused")
+		}
+
+		start := time.Now()
+		err := waitForDB(ctx, ping, 100, 50*time.Millisecond, logger)
+		assert.ErrorContains(t, err, "prazo")
+		assert.Less(t, time.Since(start), time.Second)
+		assert.Equal(t, 1, calls)
+	})
+}
+
+func TestCEPHandlerTimezone(t *testing.T) {
+	for _, tc := range []struct {
+		uf, query, want string
+	}{
+		{uf: "AC", query: "?timezone=true", want: "America/Rio_Branco"},
+		{uf: "SP", query: "?timezone=true", want: "America/Sao_Paulo"},
+		{uf: "SP", query: "", want: ""},
+	} {
+		t.Run(tc.uf+tc.query, func(t *testing.T) {
+			payload, err := json.Marshal(&cep.Response{Cep: "69900-000", Uf: tc.uf})
+			assert.NoError(t, err)
+
+			app, mock := newTestApp(t, &stubHTTPClient{})
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("69900000").
+				WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+			rec := httptest.NewRecorder()
+			app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/69900000"+tc.query, nil))
+
+			var got map[string]any
+			assert.Equal(t, http.StatusOK, rec.Code)
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+			if tc.want == "" {
+				assert.NotContains(t, got, "timezone")
+			} else {
+				assert.Equal(t, tc.want, got["timezone"])
+			}
+		})
+	}
+}
+
+func TestCEPHandlerParseComplemento(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01310-100", Complemento: "de 612 a 1510 - lado par"})
+	assert.NoError(t, err)
+
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("01310100").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01310100?parse_complemento=true", nil))
+
+	var got map[string]any
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &got))
+	assert.Equal(t, "de 612 a 1510 - lado par", got["complemento"])
+	assert.Equal(t, map[string]any{"kind": "range", "from": 612.0, "to": 1510.0, "side": "even"}, got["complemento_parsed"])
+}
+
+func TestCEPHandlerStatsTrailers(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000"})
+	assert.NoError(t, err)
+
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	app.cfg.enableTrailers = true
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now(), cep.CacheSchemaVersion))
+
+	srv := httptest.NewUnstartedServer(app.routes())
+	srv.EnableHTTP2 = true
+	srv.StartTLS()
+	t.Cleanup(srv.Close)
+
+	resp, err := srv.Client().Get(srv.URL + "/cep/01001000")
+	assert.NoError(t, err)
+	defer resp.Body.Close()
+	body, err := io.ReadAll(resp.Body)
+	assert.NoError(t, err)
+
+	assert.Equal(t, 2, resp.ProtoMajor)
+	assert.JSONEq(t, string(payload), string(body))
+	assert.Equal(t, "hit", resp.Trailer.Get("X-Cache-Status"))
+	duration, err := strconv.ParseFloat(resp.Trailer.Get("X-Lookup-Duration-Ms"), 64)
+	assert.NoError(t, err)
+	assert.GreaterOrEqual(t, duration, 0.0)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCEPHandlerStaleOnError(t *testing.T) {
+	payload, err := json.Marshal(&cep.Response{Cep: "01001-000"})
+	assert.NoError(t, err)
+
+	cfg := testConfig()
+	cfg.staleOnErrorGrace = time.Hour
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	app := newApplication(cfg, noopLogger(), new(slog.LevelVar), db, &stubHTTPClient{err: errors.New("i/o timeout")})
+	app.accessLog = io.Discard
+
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(payload, time.Now().Add(-90*time.Minute), cep.CacheSchemaVersion))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Equal(t, "on-error", rec.Header().Get("X-Cache-Stale"))
+	assert.JSONEq(t, string(payload), rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), "gocep_stale_served_on_error_total 1")
+	assert.Contains(t, rec.Body.String(), `gocep_db_query_duration_seconds_count{outcome="ok",query="cache_select"} 1`)
+	assert.Contains(t, rec.Body.String(), `go_sql_open_connections{db_name="cep"}`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCEPHandlerRefreshDiff(t *testing.T) {
+	cached, err := json.Marshal(&cep.Response{Cep: "01001-000", Bairro: "Se"})
+	assert.NoError(t, err)
+	updated, err := json.Marshal(&cep.Response{Cep: "01001-000", Bairro: "Sé"})
+	assert.NoError(t, err)
+
+	client := &stubHTTPClient{response: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(bytes.NewReader(updated))}}
+	app, mock := newTestApp(t, client)
+	app.cfg.adminToken = "s3cret"
+
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).AddRow(cached, time.Now(), cep.CacheSchemaVersion))
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	req := httptest.NewRequest(http.MethodGet, "/cep/01001000?fresh=true&diff=true", nil)
+	req.Header.Set("Authorization", "Bearer s3cret")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var body struct {
+		Data cep.Response `json:"data"`
+		Meta struct {
+			ProviderCalled bool              `json:"provider_called"`
+			Diff           []cep.FieldChange `json:"diff"`
+		} `json:"meta"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+	assert.Equal(t, "Sé", body.Data.Bairro)
+	assert.True(t, body.Meta.ProviderCalled)
+	assert.Equal(t, []cep.FieldChange{{Field: "bairro", Old: "Se", New: "Sé"}}, body.Meta.Diff)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), "gocep_cep_data_changed_total 1")
+}
+
+func TestCEPHandlerRefreshRequiresAdmin(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+	app.cfg.adminToken = "s3cret"
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000?fresh=true", nil))
+
+	assert.Equal(t, http.StatusUnauthorized, rec.Code)
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCircuitBreakerOpen(t *testing.T) {
+	cfg := testConfig()
+	cfg.breaker = cep.BreakerConfig{FailurePercent: 100, MinRequests: 1, Window: time.Minute, Cooldown: time.Minute}
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	client := &stubHTTPClient{err: errors.New("connection refused")}
+	app := newApplication(cfg, noopLogger(), new(slog.LevelVar), db, client)
+	app.accessLog = io.Discard
+
+	for _, want := range []int{http.StatusInternalServerError, http.StatusServiceUnavailable} {
+		mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+			WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000", nil))
+		assert.Equal(t, want, rec.Code)
+		if want == http.StatusServiceUnavailable {
+			assert.Equal(t, "60", rec.Header().Get("Retry-After"))
+		}
+	}
+	assert.Equal(t, 1, client.calls)
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/healthz", nil))
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"status":"ok","circuits":{"viacep":"open"}}`, rec.Body.String())
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+	assert.Contains(t, rec.Body.String(), `gocep_provider_circuit_state{provider="viacep",state="open"} 1`)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestValidateHandler(t *testing.T) {
+	app := newBatchTestApp(t, testConfig(), newFakeProvider(cep.Response{Cep: "01001-000"}))
+
+	for _, tc := range []struct {
+		method string
+		path   string
+		status int
+	}{
+		{http.MethodGet, "/cep/01001000/validate", http.StatusNoContent},
+		{http.MethodHead, "/cep/01001-000/validate", http.StatusNoContent},
+		{http.MethodGet, "/cep/99999999/validate", http.StatusNotFound},
+	} {
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(tc.method, tc.path, nil))
+		assert.Equal(t, tc.status, rec.Code, tc.path)
+		assert.Empty(t, rec.Body.String(), tc.path)
+	}
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/123/validate", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDDDHandler(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+
+	mock.ExpectQuery(`^SELECT payload->>'ddd', updated_at, schema_version FROM ceps WHERE cep = \$1$`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"ddd", "updated_at", "schema_version"}).AddRow("11", time.Now(), cep.CacheSchemaVersion))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000/ddd", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `{"cep":"01001-000","ddd":"11"}`, rec.Body.String())
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/123/ddd", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+}
+
+func TestDisabledEndpoints(t *testing.T) {
+	t.Setenv("DB_DSN", "postgres://localhost/test")
+	t.Setenv("DISABLED_ENDPOINTS", " Export , search")
+
+	cfg, err := loadConfig()
+	assert.NoError(t, err)
+	assert.Equal(t, map[string]bool{"export": true, "search": true}, cfg.disabledEndpoints)
+
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.adminToken = "s3cret"
+	app.cfg.disabledEndpoints = cfg.disabledEndpoints
+
+	call := func(method, path string) int {
+		req := httptest.NewRequest(method, path, nil)
+		req.Header.Set("Authorization", "Bearer s3cret")
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec.Code
+	}
+
+	assert.Equal(t, http.StatusNotFound, call(http.MethodGet, "/export"))
+	assert.Equal(t, http.StatusBadRequest, call(http.MethodPost, "/admin/cache/expire-all"))
+
+	t.Setenv("DISABLED_ENDPOINTS", "admin,soap")
+	_, err = loadConfig()
+	assert.ErrorContains(t, err, "soap")
+}
+
+func TestCEPHandlerNoDataSource(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{err: errors.New("i/o timeout")})
+
+	mock.ExpectQuery(`SELECT payload`).WillReturnError(errors.New("connection reset"))
+
+	req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusServiceUnavailable, rec.Code)
+	assert.JSONEq(t, `{"error":"serviço indisponível: cache e provedor de cep inacessíveis","request_id":"req-1"}`, rec.Body.String())
+	assert.Equal(t, "30", rec.Header().Get("Retry-After"))
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCEPHandlerClientGone(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000", nil).WithContext(ctx))
+
+	assert.Equal(t, statusClientClosedRequest, rec.Code)
+	assert.Empty(t, rec.Body.String())
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestCEPHandlerRejectsNonASCIIDigits(t *testing.T) {
+	client := &stubHTTPClient{}
+	app, mock := newTestApp(t, client)
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000%D9%A3", nil))
+
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+	assert.Contains(t, rec.Body.String(), "only ASCII digits 0-9 are accepted")
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestReloadProviderRegions(t *testing.T) {
+	var logs bytes.Buffer
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.logger = newLogger(&logs, nil)
+	app.cfg.providerRegionsFile = filepath.Join(t.TempDir(), "regions.json")
+
+	assert.NoError(t, os.WriteFile(app.cfg.providerRegionsFile, []byte(`{"RJ": ["apicep"]}`), 0o600))
+	app.reloadProviderRegions()
+	assert.Contains(t, logs.String(), "PROVIDER_REGIONS_FILE recarregado")
+
+	logs.Reset()
+	assert.NoError(t, os.WriteFile(app.cfg.providerRegionsFile, []byte(`{"RJ": `), 0o600))
+	app.reloadProviderRegions()
+	assert.Contains(t, logs.String(), "mantendo a configuração atual")
+}
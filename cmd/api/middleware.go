@@ -0,0 +1,185 @@
+package main
+
+import (
+	"crypto/subtle"
+	"fmt"
+	"net/http"
+	"net/netip"
+	"runtime/debug"
+	"slices"
+	"strings"
+	"time"
+
+	"github.com/gorilla/mux"
+
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
+	"github.com/victor-dias21/goCep-k8s/internal/tracing"
+)
+
+// healthPaths are probed by Kubernetes and bypass access-control middleware.
+var healthPaths = map[string]bool{
+	"/healthz": true,
+}
+
+// requireHeader rejects requests lacking REQUIRED_HEADER_NAME set to
+// REQUIRED_HEADER_VALUE (e.g. a header injected by the mesh sidecar).
+// It is a no-op when no header name is configured.
+func (app *application) requireHeader(next http.Handler) http.Handler {
+	name, value := app.cfg.requiredHeaderName, app.cfg.requiredHeaderValue
+	if name == "" {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		got := r.Header.Get(name)
+		if got == "" || subtle.ConstantTimeCompare([]byte(got), []byte(value)) != 1 {
+			writeJSON(w, http.StatusForbidden, map[string]string{"error": "acesso negado"})
+			return
+		}
+
+		next.ServeHTTP(w, r)
+	})
+}
+
+// assignRequestID reuses the caller's X-Request-ID, or generates one when it
+// is missing or unusable, echoes it on the response and stores it in the
+// request context for logs and outbound provider calls.
+func assignRequestID(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		id := r.Header.Get(requestid.Header)
+		if !requestid.Valid(id) {
+			id = requestid.New()
+		}
+		w.Header().Set(requestid.Header, id)
+		next.ServeHTTP(w, r.WithContext(requestid.NewContext(r.Context(), id)))
+	})
+}
+
+// recoverPanics turns a handler panic into a 500 JSON response, logging the
+// value and stack with the request ID, instead of letting net/http drop the
+// connection. http.ErrAbortHandler is re-raised, as it is a deliberate abort.
+func (app *application) recoverPanics(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		rec := &responseRecorder{ResponseWriter: w}
+		defer func() {
+			v := recover()
+			if v == nil {
+				return
+			}
+			if v == http.ErrAbortHandler {
+				panic(v)
+			}
+
+			app.logger.ErrorContext(r.Context(), "panic ao atender requisição",
+				"panic", fmt.Sprint(v), "method", r.Method, "path", r.URL.Path, "stack", string(debug.Stack()))
+			if rec.status != 0 {
+				// Part of the response is already out; cutting it short is all that is left.
+				panic(http.ErrAbortHandler)
+			}
+			writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "erro interno"})
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
+
+// traceContext stores the caller's W3C traceparent in the request context so
+// provider latency observations can carry the trace ID as an exemplar.
+func traceContext(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if sc, ok := tracing.Parse(r.Header.Get("traceparent")); ok {
+			r = r.WithContext(tracing.NewContext(r.Context(), sc))
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// realClientIP replaces r.RemoteAddr with the client address reported by a
+// proxy in TRUSTED_PROXIES, so access logs and per-client limits see the caller
+// rather than the ingress or load balancer. Forwarding headers from any other
+// peer are ignored, since clients can set them freely.
+func (app *application) realClientIP(next http.Handler) http.Handler {
+	trusted := app.cfg.trustedProxies
+	if len(trusted) == 0 {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if addr, ok := forwardedClientIP(r, trusted); ok {
+			r = r.WithContext(r.Context())
+			r.RemoteAddr = addr.String()
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// forwardedClientIP walks X-Forwarded-For from the nearest hop and returns the
+// first address that is not a trusted proxy; X-Real-IP is used when there is
+// no usable X-Forwarded-For. It reports false when the direct peer is not
+// trusted or no header names a client.
+func forwardedClientIP(r *http.Request, trusted []netip.Prefix) (netip.Addr, bool) {
+	peer, err := netip.ParseAddr(remoteIP(r))
+	if err != nil || !isTrustedProxy(peer.Unmap(), trusted) {
+		return netip.Addr{}, false
+	}
+
+	if values := r.Header.Values("X-Forwarded-For"); len(values) > 0 {
+		hops := strings.Split(strings.Join(values, ","), ",")
+		var client netip.Addr
+		for i := len(hops) - 1; i >= 0; i-- {
+			addr, err := netip.ParseAddr(strings.TrimSpace(hops[i]))
+			if err != nil {
+				// Anything left of a malformed hop cannot be trusted.
+				break
+			}
+			client = addr.Unmap()
+			if !isTrustedProxy(client, trusted) {
+				break
+			}
+		}
+		if client.IsValid() {
+			return client, true
+		}
+	}
+
+	if addr, err := netip.ParseAddr(strings.TrimSpace(r.Header.Get("X-Real-IP"))); err == nil {
+		return addr.Unmap(), true
+	}
+	return netip.Addr{}, false
+}
+
+func isTrustedProxy(addr netip.Addr, trusted []netip.Prefix) bool {
+	return slices.ContainsFunc(trusted, func(prefix netip.Prefix) bool {
+		return prefix.Contains(addr)
+	})
+}
+
+// instrumentRoutes feeds the gocep_http_* metrics. It runs inside the router,
+// where the matched route template is known, so paths that match no route are
+// not counted.
+func (app *application) instrumentRoutes(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		route := "unknown"
+		if current := mux.CurrentRoute(r); current != nil {
+			if template, err := current.GetPathTemplate(); err == nil {
+				route = template
+			}
+		}
+
+		start := time.Now()
+		rec := &responseRecorder{ResponseWriter: w}
+		app.httpRequests.Started()
+		defer func() {
+			status := rec.status
+			if status == 0 {
+				status = http.StatusOK
+			}
+			app.httpRequests.Finished(r.Method, route, status, time.Since(start))
+		}()
+		next.ServeHTTP(rec, r)
+	})
+}
@@ -0,0 +1,200 @@
+package main
+
+import (
+	"bytes"
+	"encoding/json"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestRequireHeader(t *testing.T) {
+	ok := http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		w.WriteHeader(http.StatusOK)
+	})
+
+	app := &application{cfg: config{requiredHeaderName: "X-Internal-Auth", requiredHeaderValue: "mesh"}}
+	handler := app.requireHeader(ok)
+
+	for _, tc := range []struct {
+		name   string
+		path   string
+		value  string
+		status int
+	}{
+		{name: "present", path: "/cep/01001000", value: "mesh", status: http.StatusOK},
+		{name: "absent", path: "/cep/01001000", status: http.StatusForbidden},
+		{name: "wrong value", path: "/cep/01001000", value: "direct", status: http.StatusForbidden},
+		{name: "health exempt", path: "/healthz", status: http.StatusOK},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, tc.path, nil)
+			if tc.value != "" {
+				req.Header.Set("X-Internal-Auth", tc.value)
+			}
+			rec := httptest.NewRecorder()
+			handler.ServeHTTP(rec, req)
+			assert.Equal(t, tc.status, rec.Code)
+		})
+	}
+
+	t.Run("disabled when unset", func(t *testing.T) {
+		app := &application{}
+		rec := httptest.NewRecorder()
+		app.requireHeader(ok).ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/cep/01001000", nil))
+		assert.Equal(t, http.StatusOK, rec.Code)
+	})
+}
+
+func TestTraceparentExemplarOnMetrics(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{response: &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`)),
+	}})
+	mock.ExpectQuery(`SELECT payload`).WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+	req.Header.Set("traceparent", "00-4bf92f3577b34da6a3ce929d0e0e4736-00f067aa0ba902b7-01")
+	app.routes().ServeHTTP(httptest.NewRecorder(), req)
+
+	req = httptest.NewRequest(http.MethodGet, "/metrics", nil)
+	req.Header.Set("Accept", "application/openmetrics-text; version=1.0.0")
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, req)
+
+	assert.Contains(t, rec.Body.String(), `trace_id="4bf92f3577b34da6a3ce929d0e0e4736"`)
+}
+
+func TestRealClientIP(t *testing.T) {
+	trusted, err := parsePrefixes("10.0.0.0/8, 192.0.2.1")
+	assert.NoError(t, err)
+
+	var got string
+	app := &application{cfg: config{trustedProxies: trusted}}
+	handler := app.realClientIP(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		got = remoteIP(r)
+	}))
+
+	for _, tc := range []struct {
+		name      string
+		peer      string
+		forwarded []string
+		realIP    string
+		want      string
+	}{
+		{name: "untrusted peer keeps its address", peer: "203.0.113.9:5000", forwarded: []string{"198.51.100.7"}, want: "203.0.113.9"},
+		{name: "trusted peer without headers", peer: "10.1.2.3:5000", want: "10.1.2.3"},
+		{name: "single hop", peer: "10.1.2.3:5000", forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+		{name: "skips trusted hops", peer: "10.1.2.3:5000", forwarded: []string{"198.51.100.7, 192.0.2.1", "10.9.9.9"}, want: "198.51.100.7"},
+		{name: "ignores spoofed hops left of the client", peer: "10.1.2.3:5000", forwarded: []string{"1.1.1.1, 198.51.100.7"}, want: "198.51.100.7"},
+		{name: "stops at a malformed hop", peer: "10.1.2.3:5000", forwarded: []string{"198.51.100.7, unknown, 10.9.9.9"}, want: "10.9.9.9"},
+		{name: "falls back to X-Real-IP", peer: "10.1.2.3:5000", realIP: "198.51.100.8", want: "198.51.100.8"},
+		{name: "ipv4-mapped peer", peer: "[::ffff:10.1.2.3]:5000", forwarded: []string{"198.51.100.7"}, want: "198.51.100.7"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+			req.RemoteAddr = tc.peer
+			for _, value := range tc.forwarded {
+				req.Header.Add("X-Forwarded-For", value)
+			}
+			if tc.realIP != "" {
+				req.Header.Set("X-Real-IP", tc.realIP)
+			}
+
+			handler.ServeHTTP(httptest.NewRecorder(), req)
+			assert.Equal(t, tc.want, got)
+		})
+	}
+}
+
+func TestParsePrefixes(t *testing.T) {
+	prefixes, err := parsePrefixes("10.0.0.1/8, 2001:db8::1")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"10.0.0.0/8", "2001:db8::1/128"}, []string{prefixes[0].String(), prefixes[1].String()})
+
+	_, err = parsePrefixes("10.0.0.0/8,proxy")
+	assert.ErrorContains(t, err, `"proxy"`)
+}
+
+func TestAssignRequestID(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	var logs bytes.Buffer
+	app.logger = newLogger(&logs, nil)
+
+	call := func(id string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, "/cep/123", nil)
+		if id != "" {
+			req.Header.Set("X-Request-ID", id)
+		}
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, req)
+		return rec
+	}
+
+	rec := call("req-1")
+	assert.Equal(t, "req-1", rec.Header().Get("X-Request-ID"))
+	assert.JSONEq(t, `{"error":"invalid CEP: expected exactly 8 digits","request_id":"req-1"}`, rec.Body.String())
+	assert.Contains(t, logs.String(), `"request_id":"req-1"`)
+
+	for _, id := range []string{"", "bad id\n"} {
+		generated := call(id).Header().Get("X-Request-ID")
+		assert.Len(t, generated, 32)
+		assert.NotEqual(t, id, generated)
+	}
+}
+
+func TestInstrumentRoutes(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+
+	for _, path := range []string{"/cep/123", "/v1/cep/456", "/cep/789"} {
+		app.routes().ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+	}
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	body := rec.Body.String()
+	assert.Contains(t, body, `gocep_http_requests_total{method="GET",route="/cep/{cep}",status="400"} 2`)
+	assert.Contains(t, body, `gocep_http_requests_total{method="GET",route="/v1/cep/{cep}",status="400"} 1`)
+	assert.Contains(t, body, `gocep_http_request_duration_seconds_count{method="GET",route="/cep/{cep}",status="400"} 2`)
+	// The scrape itself is still being served.
+	assert.Contains(t, body, "gocep_http_requests_in_flight 1")
+	assert.NotContains(t, body, "/cep/123")
+}
+
+func TestRecoverPanics(t *testing.T) {
+	var logs bytes.Buffer
+	app := &application{logger: newLogger(&logs, nil)}
+	handler := assignRequestID(app.recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var m map[string]int
+		m["boom"]++
+	})))
+
+	req := httptest.NewRequest(http.MethodGet, "/cep/01001000", nil)
+	req.Header.Set("X-Request-ID", "req-1")
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+
+	assert.Equal(t, http.StatusInternalServerError, rec.Code)
+	assert.JSONEq(t, `{"error":"erro interno","request_id":"req-1"}`, rec.Body.String())
+
+	var entry map[string]any
+	assert.NoError(t, json.Unmarshal(logs.Bytes(), &entry))
+	assert.Equal(t, "ERROR", entry["level"])
+	assert.Equal(t, "req-1", entry["request_id"])
+	assert.Contains(t, entry["panic"], "nil map")
+	assert.Contains(t, entry["stack"], "TestRecoverPanics")
+
+	abort := app.recoverPanics(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		panic(http.ErrAbortHandler)
+	}))
+	assert.PanicsWithValue(t, http.ErrAbortHandler, func() {
+		abort.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/", nil))
+	})
+}
@@ -0,0 +1,419 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	"github.com/victor-dias21/goCep-k8s/internal/jobs"
+)
+
+// openAPIDocument is the subset of OpenAPI 3.0 this service describes itself
+// with. Schemas stay as plain maps; the structure around them is typed so the
+// route list cannot drift silently (see TestOpenAPIMatchesRoutes).
+type openAPIDocument struct {
+	OpenAPI    string                                  `json:"openapi"`
+	Info       openAPIInfo                             `json:"info"`
+	Servers    []openAPIServer                         `json:"servers"`
+	Paths      map[string]map[string]*openAPIOperation `json:"paths"`
+	Components openAPIComponents                       `json:"components"`
+	Security   []map[string][]string                   `json:"security,omitempty"`
+}
+
+type openAPIInfo struct {
+	Title       string `json:"title"`
+	Description string `json:"description"`
+	Version     string `json:"version"`
+}
+
+type openAPIServer struct {
+	URL string `json:"url"`
+}
+
+type openAPIOperation struct {
+	OperationID string                     `json:"operationId"`
+	Summary     string                     `json:"summary"`
+	Tags        []string                   `json:"tags,omitempty"`
+	Parameters  []openAPIParameter         `json:"parameters,omitempty"`
+	RequestBody *openAPIRequestBody        `json:"requestBody,omitempty"`
+	Responses   map[string]openAPIResponse `json:"responses"`
+}
+
+type openAPIParameter struct {
+	Name        string     `json:"name"`
+	In          string     `json:"in"`
+	Description string     `json:"description,omitempty"`
+	Required    bool       `json:"required,omitempty"`
+	Schema      jsonSchema `json:"schema"`
+}
+
+type openAPIRequestBody struct {
+	Required bool                        `json:"required"`
+	Content  map[string]openAPIMediaType `json:"content"`
+}
+
+type openAPIResponse struct {
+	Description string                      `json:"description"`
+	Content     map[string]openAPIMediaType `json:"content,omitempty"`
+}
+
+type openAPIMediaType struct {
+	Schema jsonSchema `json:"schema"`
+}
+
+type openAPIComponents struct {
+	Schemas         map[string]jsonSchema `json:"schemas"`
+	SecuritySchemes map[string]jsonSchema `json:"securitySchemes,omitempty"`
+}
+
+// jsonSchema is an OpenAPI schema object.
+type jsonSchema map[string]any
+
+func schemaRef(name string) jsonSchema {
+	return jsonSchema{"$ref": "#/components/schemas/" + name}
+}
+
+func arrayOf(items jsonSchema) jsonSchema {
+	return jsonSchema{"type": "array", "items": items}
+}
+
+var stringSchema = jsonSchema{"type": "string"}
+
+func jsonContent(schema jsonSchema) map[string]openAPIMediaType {
+	return map[string]openAPIMediaType{"application/json": {Schema: schema}}
+}
+
+func jsonResponse(description string, schema jsonSchema) openAPIResponse {
+	return openAPIResponse{Description: description, Content: jsonContent(schema)}
+}
+
+func errorResponse(description string) openAPIResponse {
+	return jsonResponse(description, schemaRef("Error"))
+}
+
+func pathParam(name, description string) openAPIParameter {
+	return openAPIParameter{Name: name, In: "path", Description: description, Required: true, Schema: stringSchema}
+}
+
+func queryParam(name, description string, schema jsonSchema) openAPIParameter {
+	return openAPIParameter{Name: name, In: "query", Description: description, Schema: schema}
+}
+
+// openAPISpec describes the v1 API as this instance serves it: endpoint groups
+// switched off by DISABLED_ENDPOINTS, or needing a database the instance does
+// not have, are left out, following the same conditions as v1Routes.
+func (app *application) openAPISpec() *openAPIDocument {
+	cepParam := pathParam("cep", "CEP com ou sem hífen, por exemplo 01001000")
+	limitParam := queryParam("limit", "máximo de resultados", jsonSchema{"type": "integer", "minimum": 1})
+	lookupErrors := map[string]openAPIResponse{
+		"400": errorResponse("CEP inválido"),
+		"404": errorResponse("CEP não encontrado"),
+		"502": errorResponse("resposta inválida do provedor"),
+		"503": errorResponse("cache e provedor indisponíveis"),
+	}
+	if app.cfg.apiKeyAuth {
+		lookupErrors["401"] = errorResponse("chave de API ausente ou inválida")
+		lookupErrors["403"] = errorResponse("chave de API revogada")
+	}
+	if app.jwt != nil {
+		lookupErrors["401"] = errorResponse("credenciais ausentes ou inválidas")
+	}
+	withLookupErrors := func(responses map[string]openAPIResponse) map[string]openAPIResponse {
+		for status, resp := range lookupErrors {
+			responses[status] = resp
+		}
+		return responses
+	}
+
+	paths := map[string]map[string]*openAPIOperation{
+		"/cep/{cep}": {
+			"get": {
+				OperationID: "getCep",
+				Summary:     "Consulta um CEP no cache ou no provedor",
+				Tags:        []string{"cep"},
+				Parameters: []openAPIParameter{
+					cepParam,
+					queryParam("fields", "campos a devolver, separados por vírgula", stringSchema),
+					queryParam("format", "formato alternativo ao JSON", jsonSchema{"type": "string", "enum": []string{formatKV, formatCSV, formatProtobuf, formatMsgpack}}),
+					queryParam("meta", "envolve a resposta em data e meta", jsonSchema{"type": "boolean"}),
+					queryParam("timezone", "acrescenta o fuso horário da UF", jsonSchema{"type": "boolean"}),
+					queryParam("parse_complemento", "acrescenta complemento_parsed", jsonSchema{"type": "boolean"}),
+				},
+				Responses: withLookupErrors(map[string]openAPIResponse{
+					"200": {Description: "endereço do CEP", Content: map[string]openAPIMediaType{
+						"application/json": {Schema: schemaRef("Address")},
+						"text/plain":       {Schema: stringSchema},
+						"text/csv":         {Schema: stringSchema},
+						mediaTypeProtobuf:  {Schema: jsonSchema{"type": "string", "format": "binary"}},
+						mediaTypeMsgpack:   {Schema: jsonSchema{"type": "string", "format": "binary"}},
+					}},
+					"304": {Description: "não modificado desde If-None-Match ou If-Modified-Since"},
+				}),
+			},
+		},
+		"/cep/{cep}/ddd": {
+			"get": {
+				OperationID: "getCepDDD",
+				Summary:     "Devolve apenas o DDD de um CEP",
+				Tags:        []string{"cep"},
+				Parameters:  []openAPIParameter{cepParam},
+				Responses:   withLookupErrors(map[string]openAPIResponse{"200": jsonResponse("DDD do CEP", schemaRef("DDD"))}),
+			},
+		},
+		"/cep/{cep}/validate": {
+			"get":  validateOperation(cepParam, "validateCep"),
+			"head": validateOperation(cepParam, "validateCepHead"),
+		},
+		"/cep/city/{ibge}": {
+			"get": {
+				OperationID: "listCityCeps",
+				Summary:     "Lista os CEPs em cache de um município, por bairro",
+				Tags:        []string{"cep"},
+				Parameters:  []openAPIParameter{pathParam("ibge", "código IBGE do município")},
+				Responses: map[string]openAPIResponse{
+					"200": jsonResponse("CEPs agrupados por bairro", schemaRef("City")),
+					"400": errorResponse("código IBGE inválido"),
+				},
+			},
+		},
+	}
+
+	if app.endpointEnabled(endpointBatch) {
+		paths["/cep/batch"] = map[string]*openAPIOperation{
+			"post": {
+				OperationID: "batchGetCep",
+				Summary:     "Consulta vários CEPs de uma vez",
+				Tags:        []string{"batch"},
+				RequestBody: &openAPIRequestBody{Required: true, Content: map[string]openAPIMediaType{
+					"application/json": {Schema: arrayOf(stringSchema)},
+					"text/csv":         {Schema: stringSchema},
+				}},
+				Responses: map[string]openAPIResponse{
+					"200": jsonResponse("todos os CEPs resolvidos", schemaRef("BatchResponse")),
+					"207": jsonResponse("parte dos CEPs falhou", schemaRef("BatchResponse")),
+					"400": errorResponse("corpo inválido"),
+					"413": errorResponse("lote acima de BATCH_MAX_SIZE"),
+				},
+			},
+		}
+	}
+	if app.endpointEnabled(endpointSearch) {
+		paths["/search"] = map[string]*openAPIOperation{
+			"get": {
+				OperationID: "searchAddress",
+				Summary:     "Busca CEPs por UF, cidade e logradouro no provedor",
+				Tags:        []string{"search"},
+				Parameters: []openAPIParameter{
+					queryParam("uf", "sigla da UF", stringSchema),
+					queryParam("city", "nome da cidade", stringSchema),
+					queryParam("street", "trecho do logradouro", stringSchema),
+				},
+				Responses: map[string]openAPIResponse{
+					"200": jsonResponse("endereços encontrados", arrayOf(schemaRef("Address"))),
+					"400": errorResponse("busca inválida"),
+					"502": errorResponse("falha no provedor"),
+				},
+			},
+		}
+		paths["/autocomplete/streets"] = map[string]*openAPIOperation{
+			"get": {
+				OperationID: "autocompleteStreets",
+				Summary:     "Sugere logradouros já em cache",
+				Tags:        []string{"search"},
+				Parameters: []openAPIParameter{
+					queryParam("q", "trecho do logradouro", stringSchema),
+					queryParam("uf", "filtra pela UF", stringSchema),
+					queryParam("city", "filtra pela cidade", stringSchema),
+					limitParam,
+				},
+				Responses: map[string]openAPIResponse{
+					"200": jsonResponse("sugestões", arrayOf(schemaRef("StreetSuggestion"))),
+					"400": errorResponse("termo ou limite inválido"),
+				},
+			},
+		}
+	}
+	if app.endpointEnabled(endpointGraphQL) {
+		graphql := func(id string) *openAPIOperation {
+			return &openAPIOperation{
+				OperationID: id,
+				Summary:     "Executa uma consulta GraphQL sobre os CEPs",
+				Tags:        []string{"graphql"},
+				Responses:   map[string]openAPIResponse{"200": jsonResponse("resultado GraphQL", jsonSchema{"type": "object"})},
+			}
+		}
+		paths["/graphql"] = map[string]*openAPIOperation{"get": graphql("graphqlGet"), "post": graphql("graphqlPost")}
+	}
+	if app.db != nil && app.endpointEnabled(endpointSearch) {
+		paths["/search/fulltext"] = map[string]*openAPIOperation{
+			"get": {
+				OperationID: "fullTextSearch",
+				Summary:     "Busca textual, sem acentos, nos endereços em cache",
+				Tags:        []string{"search"},
+				Parameters:  []openAPIParameter{queryParam("q", "termos da busca", stringSchema), limitParam},
+				Responses: map[string]openAPIResponse{
+					"200": jsonResponse("endereços encontrados", arrayOf(schemaRef("Address"))),
+					"400": errorResponse("busca inválida"),
+				},
+			},
+		}
+	}
+	if app.jobs != nil && app.endpointEnabled(endpointBatch) {
+		paths["/jobs/batch"] = map[string]*openAPIOperation{
+			"post": {
+				OperationID: "createBatchJob",
+				Summary:     "Agenda um lote grande para processamento assíncrono",
+				Tags:        []string{"batch"},
+				RequestBody: &openAPIRequestBody{Required: true, Content: jsonContent(arrayOf(stringSchema))},
+				Responses: map[string]openAPIResponse{
+					"202": jsonResponse("job criado; consulte o Location", schemaRef("Job")),
+					"400": errorResponse("corpo inválido"),
+					"413": errorResponse("lote acima de JOBS_MAX_SIZE"),
+				},
+			},
+		}
+		paths["/jobs/{id}"] = map[string]*openAPIOperation{
+			"get": {
+				OperationID: "getBatchJob",
+				Summary:     "Consulta o andamento e os resultados de um job",
+				Tags:        []string{"batch"},
+				Parameters:  []openAPIParameter{pathParam("id", "ID devolvido na criação do job")},
+				Responses: map[string]openAPIResponse{
+					"200": jsonResponse("estado do job", schemaRef("Job")),
+					"404": errorResponse("job não encontrado"),
+				},
+			},
+		}
+	}
+
+	doc := &openAPIDocument{
+		OpenAPI: "3.0.3",
+		Info: openAPIInfo{
+			Title:       "goCep API",
+			Description: "Consulta de CEPs com cache em PostgreSQL. Os caminhos sem versão são aliases de /v1.",
+			Version:     legacyAPIVersion,
+		},
+		Servers:    []openAPIServer{{URL: "/" + legacyAPIVersion}},
+		Paths:      paths,
+		Components: openAPIComponents{Schemas: openAPISchemas()},
+	}
+	// Each entry of security is an alternative: either credential is enough.
+	if app.cfg.apiKeyAuth || app.jwt != nil {
+		doc.Components.SecuritySchemes = map[string]jsonSchema{}
+	}
+	if app.cfg.apiKeyAuth {
+		doc.Components.SecuritySchemes["apiKey"] = jsonSchema{"type": "apiKey", "in": "header", "name": apiKeyHeader}
+		doc.Security = append(doc.Security, map[string][]string{"apiKey": {}})
+	}
+	if app.jwt != nil {
+		doc.Components.SecuritySchemes["bearer"] = jsonSchema{"type": "http", "scheme": "bearer", "bearerFormat": "JWT"}
+		doc.Security = append(doc.Security, map[string][]string{"bearer": {}})
+	}
+	return doc
+}
+
+func validateOperation(cepParam openAPIParameter, id string) *openAPIOperation {
+	return &openAPIOperation{
+		OperationID: id,
+		Summary:     "Verifica se um CEP existe, sem corpo",
+		Tags:        []string{"cep"},
+		Parameters:  []openAPIParameter{cepParam},
+		Responses: map[string]openAPIResponse{
+			"204": {Description: "o CEP existe"},
+			"404": {Description: "o CEP não existe"},
+			"400": errorResponse("CEP inválido"),
+		},
+	}
+}
+
+// openAPISchemas holds the response bodies. Address is built from the fields
+// of cep.Response, so new address fields show up without editing this file.
+func openAPISchemas() map[string]jsonSchema {
+	address := map[string]jsonSchema{}
+	for _, field := range (&cep.Response{}).Fields() {
+		address[field.Name] = stringSchema
+	}
+	address["timezone"] = jsonSchema{"type": "string", "description": "só com ?timezone=true"}
+	address["complemento_parsed"] = jsonSchema{
+		"type":        "object",
+		"description": "só com ?parse_complemento=true",
+		"properties": map[string]jsonSchema{
+			"kind": stringSchema,
+			"from": {"type": "integer"},
+			"to":   {"type": "integer"},
+			"side": stringSchema,
+		},
+	}
+
+	return map[string]jsonSchema{
+		"Address": {"type": "object", "properties": address},
+		"Error": {
+			"type":       "object",
+			"properties": map[string]jsonSchema{"error": stringSchema, "request_id": stringSchema},
+		},
+		"DDD": {
+			"type":       "object",
+			"properties": map[string]jsonSchema{"cep": stringSchema, "ddd": stringSchema},
+		},
+		"City": {
+			"type": "object",
+			"properties": map[string]jsonSchema{
+				"ibge": stringSchema,
+				"bairros": arrayOf(jsonSchema{
+					"type": "object",
+					"properties": map[string]jsonSchema{
+						"bairro": stringSchema,
+						"ceps":   arrayOf(schemaRef("Address")),
+					},
+				}),
+			},
+		},
+		"BatchResponse": {
+			"type": "object",
+			"properties": map[string]jsonSchema{
+				"summary": {
+					"type": "object",
+					"properties": map[string]jsonSchema{
+						"total":     {"type": "integer"},
+						"succeeded": {"type": "integer"},
+						"failed":    {"type": "integer"},
+						"not_found": {"type": "integer"},
+					},
+				},
+				"results": {"type": "object", "additionalProperties": schemaRef("BatchItem")},
+			},
+		},
+		"BatchItem": {
+			"type": "object",
+			"properties": map[string]jsonSchema{
+				"data":  schemaRef("Address"),
+				"error": stringSchema,
+			},
+		},
+		"StreetSuggestion": {
+			"type": "object",
+			"properties": map[string]jsonSchema{
+				"logradouro": stringSchema,
+				"localidade": stringSchema,
+				"uf":         stringSchema,
+				"ceps":       {"type": "integer"},
+			},
+		},
+		"Job": {
+			"type": "object",
+			"properties": map[string]jsonSchema{
+				"id":         stringSchema,
+				"status":     {"type": "string", "enum": []string{jobs.StatusPending, jobs.StatusRunning, jobs.StatusDone}},
+				"total":      {"type": "integer"},
+				"processed":  {"type": "integer"},
+				"results":    {"type": "object", "additionalProperties": schemaRef("BatchItem")},
+				"created_at": {"type": "string", "format": "date-time"},
+				"updated_at": {"type": "string", "format": "date-time"},
+			},
+		},
+	}
+}
+
+// openAPIHandler serves the document at /openapi.json for client generators.
+func (app *application) openAPIHandler(w http.ResponseWriter, r *http.Request) {
+	writeJSON(w, http.StatusOK, app.openAPISpec())
+}
@@ -0,0 +1,139 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"sort"
+	"strings"
+	"testing"
+
+	"github.com/gorilla/mux"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/victor-dias21/goCep-k8s/internal/jwtauth"
+)
+
+// openAPIOperations lists "METHOD /path" for every operation in doc.
+func openAPIOperations(doc *openAPIDocument) []string {
+	var ops []string
+	for path, item := range doc.Paths {
+		for method := range item {
+			ops = append(ops, strings.ToUpper(method)+" "+path)
+		}
+	}
+	sort.Strings(ops)
+	return ops
+}
+
+// v1RouteOperations lists "METHOD /path" for every route v1Routes registers,
+// leaving out the CORS preflight handlers.
+func v1RouteOperations(t *testing.T, app *application) []string {
+	t.Helper()
+	router := mux.NewRouter()
+	app.v1Routes(router)
+
+	var ops []string
+	err := router.Walk(func(route *mux.Route, _ *mux.Router, _ []*mux.Route) error {
+		path, err := route.GetPathTemplate()
+		if err != nil {
+			return nil
+		}
+		methods, err := route.GetMethods()
+		if err != nil {
+			return nil
+		}
+		for _, method := range methods {
+			if method != http.MethodOptions {
+				ops = append(ops, method+" "+path)
+			}
+		}
+		return nil
+	})
+	assert.NoError(t, err)
+	sort.Strings(ops)
+	return ops
+}
+
+func TestOpenAPIMatchesRoutes(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	assert.Equal(t, v1RouteOperations(t, app), openAPIOperations(app.openAPISpec()))
+
+	t.Run("disabled groups and memory-only mode", func(t *testing.T) {
+		cfg := testConfig()
+		cfg.disabledEndpoints = map[string]bool{endpointBatch: true, endpointGraphQL: true}
+		app := newBatchTestApp(t, cfg, newFakeProvider())
+
+		ops := openAPIOperations(app.openAPISpec())
+		assert.NotContains(t, ops, "POST /cep/batch")
+		assert.NotContains(t, ops, "GET /search/fulltext")
+		assert.Contains(t, ops, "GET /search")
+	})
+}
+
+func TestOpenAPIHandler(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/openapi.json", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var doc struct {
+		OpenAPI    string                    `json:"openapi"`
+		Servers    []map[string]string       `json:"servers"`
+		Paths      map[string]map[string]any `json:"paths"`
+		Components struct {
+			Schemas map[string]struct {
+				Properties map[string]any `json:"properties"`
+			} `json:"schemas"`
+		} `json:"components"`
+	}
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &doc))
+	assert.Equal(t, "3.0.3", doc.OpenAPI)
+	assert.Equal(t, "/v1", doc.Servers[0]["url"])
+	assert.Contains(t, doc.Paths["/cep/{cep}"], "get")
+	assert.Contains(t, doc.Components.Schemas["Address"].Properties, "localidade")
+}
+
+func TestOpenAPIAPIKeySecurity(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	assert.Empty(t, app.openAPISpec().Security)
+
+	app.cfg.apiKeyAuth = true
+	doc := app.openAPISpec()
+	assert.Equal(t, []map[string][]string{{"apiKey": {}}}, doc.Security)
+	assert.Equal(t, apiKeyHeader, doc.Components.SecuritySchemes["apiKey"]["name"])
+	assert.Contains(t, doc.Paths["/cep/{cep}"]["get"].Responses, "401")
+
+	app.jwt = jwtauth.NewVerifier(jwtauth.Config{JWKSURL: "https://idp.example.com/jwks"}, &stubHTTPClient{})
+	doc = app.openAPISpec()
+	assert.Equal(t, []map[string][]string{{"apiKey": {}}, {"bearer": {}}}, doc.Security)
+	assert.Equal(t, "bearer", doc.Components.SecuritySchemes["bearer"]["scheme"])
+}
+
+func TestOpenAPIReferencesResolve(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	doc := app.openAPISpec()
+	raw, err := json.Marshal(doc)
+	assert.NoError(t, err)
+
+	var walk func(v any)
+	walk = func(v any) {
+		switch v := v.(type) {
+		case map[string]any:
+			if ref, ok := v["$ref"].(string); ok {
+				assert.Contains(t, doc.Components.Schemas, strings.TrimPrefix(ref, "#/components/schemas/"))
+			}
+			for _, child := range v {
+				walk(child)
+			}
+		case []any:
+			for _, child := range v {
+				walk(child)
+			}
+		}
+	}
+	var generic any
+	assert.NoError(t, json.Unmarshal(raw, &generic))
+	walk(generic)
+}
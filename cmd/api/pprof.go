@@ -0,0 +1,27 @@
+package main
+
+import (
+	"net/http"
+	"net/http/pprof"
+
+	"github.com/gorilla/mux"
+)
+
+// pprofPrefix is where the profiling endpoints live, behind ADMIN_TOKEN like
+// the rest of /admin.
+const pprofPrefix = "/admin/debug/pprof/"
+
+// pprofRoutes mounts net/http/pprof without touching http.DefaultServeMux,
+// which the package registers itself on and this server never exposes.
+func (app *application) pprofRoutes(router *mux.Router) {
+	router.HandleFunc(pprofPrefix, app.requireAdmin(pprof.Index)).Methods(http.MethodGet)
+	router.HandleFunc(pprofPrefix+"cmdline", app.requireAdmin(pprof.Cmdline)).Methods(http.MethodGet)
+	router.HandleFunc(pprofPrefix+"profile", app.requireAdmin(pprof.Profile)).Methods(http.MethodGet)
+	router.HandleFunc(pprofPrefix+"symbol", app.requireAdmin(pprof.Symbol)).Methods(http.MethodGet, http.MethodPost)
+	router.HandleFunc(pprofPrefix+"trace", app.requireAdmin(pprof.Trace)).Methods(http.MethodGet)
+	// pprof.Index only resolves named profiles under /debug/pprof/, so they
+	// are dispatched here: heap, goroutine, allocs, block, mutex...
+	router.HandleFunc(pprofPrefix+"{profile}", app.requireAdmin(func(w http.ResponseWriter, r *http.Request) {
+		pprof.Handler(mux.Vars(r)["profile"]).ServeHTTP(w, r)
+	})).Methods(http.MethodGet)
+}
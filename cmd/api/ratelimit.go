@@ -0,0 +1,146 @@
+package main
+
+import (
+	"context"
+	"math"
+	"net"
+	"net/http"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/peer"
+	"google.golang.org/grpc/status"
+)
+
+// rateLimiter keeps a token bucket per client IP: each client may send burst
+// requests at once and then rate requests per second.
+type rateLimiter struct {
+	rate  float64
+	burst float64
+	now   func() time.Time
+
+	mu        sync.Mutex
+	buckets   map[string]*tokenBucket
+	lastSweep time.Time
+}
+
+type tokenBucket struct {
+	tokens float64
+	last   time.Time
+}
+
+// newRateLimiter returns nil, which allows everything, when rps <= 0.
+func newRateLimiter(rps float64, burst int) *rateLimiter {
+	if rps <= 0 {
+		return nil
+	}
+	return &rateLimiter{rate: rps, burst: float64(burst), now: time.Now, buckets: map[string]*tokenBucket{}}
+}
+
+// allow takes a token from key's bucket. It reports whether one was available,
+// how many are left, how long until the next one when none was, and how long
+// until the bucket is full again.
+func (l *rateLimiter) allow(key string) (allowed bool, remaining int, wait, reset time.Duration) {
+	now := l.now()
+	l.mu.Lock()
+	defer l.mu.Unlock()
+
+	l.sweep(now)
+	b, ok := l.buckets[key]
+	if !ok {
+		b = &tokenBucket{tokens: l.burst, last: now}
+		l.buckets[key] = b
+	}
+	b.tokens = min(l.burst, b.tokens+now.Sub(b.last).Seconds()*l.rate)
+	b.last = now
+
+	if b.tokens < 1 {
+		return false, 0, l.until(1 - b.tokens), l.until(l.burst - b.tokens)
+	}
+	b.tokens--
+	return true, int(b.tokens), 0, l.until(l.burst - b.tokens)
+}
+
+// until is how long the bucket takes to gain tokens.
+func (l *rateLimiter) until(tokens float64) time.Duration {
+	return time.Duration(tokens / l.rate * float64(time.Second))
+}
+
+// sweep drops the buckets of clients idle long enough to be full again, at
+// most once a minute, so memory does not grow with every IP ever seen.
+func (l *rateLimiter) sweep(now time.Time) {
+	if now.Sub(l.lastSweep) < time.Minute {
+		return
+	}
+	l.lastSweep = now
+	refill := time.Duration(l.burst / l.rate * float64(time.Second))
+	for key, b := range l.buckets {
+		if now.Sub(b.last) >= refill {
+			delete(l.buckets, key)
+		}
+	}
+}
+
+// rateLimit answers 429 to clients over RATE_LIMIT_RPS. Clients are told
+// apart by IP, as resolved by realClientIP. Health probes are never limited.
+func (app *application) rateLimit(next http.Handler) http.Handler {
+	if app.limiter == nil {
+		return next
+	}
+
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if healthPaths[r.URL.Path] {
+			next.ServeHTTP(w, r)
+			return
+		}
+
+		allowed, remaining, wait, reset := app.limiter.allow(remoteIP(r))
+		w.Header().Set("X-RateLimit-Limit", strconv.Itoa(app.cfg.rateLimitBurst))
+		w.Header().Set("X-RateLimit-Remaining", strconv.Itoa(remaining))
+		// Whole seconds until the bucket is full, rounded up.
+		w.Header().Set("X-RateLimit-Reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))))
+		if !allowed {
+			setRetryAfter(w, wait)
+			writeJSON(w, http.StatusTooManyRequests, map[string]string{"error": "limite de requisições excedido"})
+			return
+		}
+		next.ServeHTTP(w, r)
+	})
+}
+
+// grpcRateLimit applies RATE_LIMIT_RPS to unary gRPC calls, keyed by the peer's
+// IP: the gRPC listener is reached directly, so there are no proxy headers to
+// resolve. Over the limit calls fail with RESOURCE_EXHAUSTED, and the
+// X-RateLimit-* and Retry-After values travel as response metadata.
+func (app *application) grpcRateLimit(ctx context.Context, req any, info *grpc.UnaryServerInfo, handler grpc.UnaryHandler) (any, error) {
+	if app.limiter == nil || strings.HasPrefix(info.FullMethod, grpcHealthPrefix) {
+		return handler(ctx, req)
+	}
+
+	var ip string
+	if p, ok := peer.FromContext(ctx); ok {
+		ip = p.Addr.String()
+		if host, _, err := net.SplitHostPort(ip); err == nil {
+			ip = host
+		}
+	}
+
+	allowed, remaining, wait, reset := app.limiter.allow(ip)
+	header := metadata.Pairs(
+		"x-ratelimit-limit", strconv.Itoa(app.cfg.rateLimitBurst),
+		"x-ratelimit-remaining", strconv.Itoa(remaining),
+		"x-ratelimit-reset", strconv.Itoa(int(math.Ceil(reset.Seconds()))),
+	)
+	if !allowed {
+		header.Set("retry-after", retryAfterSeconds(wait))
+		_ = grpc.SetHeader(ctx, header)
+		return nil, status.Error(codes.ResourceExhausted, "limite de requisições excedido")
+	}
+	_ = grpc.SetHeader(ctx, header)
+	return handler(ctx, req)
+}
@@ -0,0 +1,112 @@
+package main
+
+import (
+	"context"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"google.golang.org/grpc"
+	"google.golang.org/grpc/codes"
+	healthpb "google.golang.org/grpc/health/grpc_health_v1"
+	"google.golang.org/grpc/metadata"
+	"google.golang.org/grpc/status"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	cepv1 "github.com/victor-dias21/goCep-k8s/proto/cep/v1"
+)
+
+func TestRateLimiterAllow(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	limiter := newRateLimiter(2, 3)
+	limiter.now = func() time.Time { return now }
+
+	for want := 2; want >= 0; want-- {
+		allowed, remaining, _, reset := limiter.allow("192.0.2.1")
+		assert.True(t, allowed)
+		assert.Equal(t, want, remaining)
+		assert.Equal(t, time.Duration(3-want)*500*time.Millisecond, reset)
+	}
+	allowed, _, wait, reset := limiter.allow("192.0.2.1")
+	assert.False(t, allowed)
+	assert.Equal(t, 500*time.Millisecond, wait)
+	assert.Equal(t, 1500*time.Millisecond, reset)
+
+	// Other clients have their own bucket.
+	allowed, _, _, _ = limiter.allow("192.0.2.2")
+	assert.True(t, allowed)
+
+	// Two tokens per second.
+	now = now.Add(500 * time.Millisecond)
+	allowed, remaining, _, _ := limiter.allow("192.0.2.1")
+	assert.True(t, allowed)
+	assert.Equal(t, 0, remaining)
+
+	// Idle buckets are dropped once full again.
+	now = now.Add(time.Hour)
+	limiter.allow("192.0.2.3")
+	assert.Len(t, limiter.buckets, 1)
+
+	assert.Nil(t, newRateLimiter(0, 10))
+}
+
+func TestRateLimitMiddleware(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{})
+	app.cfg.rateLimitRPS, app.cfg.rateLimitBurst = 1, 2
+	app.limiter = newRateLimiter(app.cfg.rateLimitRPS, app.cfg.rateLimitBurst)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	app.limiter.now = func() time.Time { return now }
+	handler := app.routes()
+
+	call := func(path string) *httptest.ResponseRecorder {
+		req := httptest.NewRequest(http.MethodGet, path, nil)
+		req.RemoteAddr = "192.0.2.1:1234"
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, req)
+		return rec
+	}
+
+	// Remaining counts down with each request; Reset is how long the
+	// bucket takes to fill back up at one token per second.
+	for _, want := range []struct{ remaining, reset string }{{"1", "1"}, {"0", "2"}} {
+		rec := call("/cep/123")
+		assert.Equal(t, http.StatusBadRequest, rec.Code)
+		assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Limit"))
+		assert.Equal(t, want.remaining, rec.Header().Get("X-RateLimit-Remaining"))
+		assert.Equal(t, want.reset, rec.Header().Get("X-RateLimit-Reset"))
+	}
+
+	rec := call("/cep/123")
+	assert.Equal(t, http.StatusTooManyRequests, rec.Code)
+	assert.Contains(t, rec.Body.String(), "limite de requisições excedido")
+	assert.Equal(t, "1", rec.Header().Get("Retry-After"))
+	assert.Equal(t, "0", rec.Header().Get("X-RateLimit-Remaining"))
+	assert.Equal(t, "2", rec.Header().Get("X-RateLimit-Reset"))
+
+	assert.NotEqual(t, http.StatusTooManyRequests, call("/healthz").Code)
+}
+
+func TestGRPCRateLimit(t *testing.T) {
+	cfg := testConfig()
+	cfg.rateLimitRPS, cfg.rateLimitBurst = 1, 1
+	app := newBatchTestApp(t, cfg, newFakeProvider(cep.Response{Cep: "01001-000"}))
+	app.limiter = newRateLimiter(cfg.rateLimitRPS, cfg.rateLimitBurst)
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	app.limiter.now = func() time.Time { return now }
+	conn := newGRPCTestConn(t, app)
+	client := cepv1.NewCepServiceClient(conn)
+
+	var header metadata.MD
+	_, err := client.GetCep(context.Background(), &cepv1.GetCepRequest{Cep: "01001000"}, grpc.Header(&header))
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"0"}, header.Get("x-ratelimit-remaining"))
+
+	_, err = client.GetCep(context.Background(), &cepv1.GetCepRequest{Cep: "01001000"}, grpc.Header(&header))
+	assert.Equal(t, codes.ResourceExhausted, status.Code(err))
+	assert.Equal(t, []string{"1"}, header.Get("retry-after"))
+
+	_, err = healthpb.NewHealthClient(conn).Check(context.Background(), &healthpb.HealthCheckRequest{})
+	assert.NoError(t, err)
+}
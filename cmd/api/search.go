@@ -0,0 +1,78 @@
+package main
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// searchHandler proxies ViaCEP's address search: GET /search?uf=SP&city=...&street=...
+// returns the matching addresses, an empty list when none match.
+func (app *application) searchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	ctx, cancel := context.WithTimeout(r.Context(), 10*time.Second)
+	defer cancel()
+
+	results, err := app.service.Search(ctx, cep.SearchQuery{
+		UF:     query.Get("uf"),
+		City:   query.Get("city"),
+		Street: query.Get("street"),
+	})
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, results)
+	case r.Context().Err() != nil:
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, cep.ErrInvalidSearch):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		app.logger.ErrorContext(r.Context(), "erro na busca por endereço", "err", err)
+		writeJSON(w, http.StatusBadGateway, map[string]string{"error": "falha ao consultar o provedor de cep"})
+	}
+}
+
+// fullTextSearchHandler searches the cached street, bairro and city names:
+// GET /search/fulltext?q=praca da se&limit=20. Accents and case are ignored.
+func (app *application) fullTextSearchHandler(w http.ResponseWriter, r *http.Request) {
+	query := r.URL.Query()
+
+	limit, ok := parseLimitParam(w, query.Get("limit"))
+	if !ok {
+		return
+	}
+
+	ctx, cancel := context.WithTimeout(r.Context(), 5*time.Second)
+	defer cancel()
+
+	results, err := app.service.FullTextSearch(ctx, query.Get("q"), limit)
+	switch {
+	case err == nil:
+		writeJSON(w, http.StatusOK, results)
+	case r.Context().Err() != nil:
+		w.WriteHeader(statusClientClosedRequest)
+	case errors.Is(err, cep.ErrInvalidSearch):
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": err.Error()})
+	default:
+		app.logger.ErrorContext(r.Context(), "erro na busca textual", "err", err)
+		writeJSON(w, http.StatusInternalServerError, map[string]string{"error": "falha na busca textual"})
+	}
+}
+
+// parseLimitParam reads an optional ?limit=; zero means the service default.
+// It writes the 400 itself when the value is not a positive integer.
+func parseLimitParam(w http.ResponseWriter, raw string) (int, bool) {
+	if raw == "" {
+		return 0, true
+	}
+	n, err := strconv.Atoi(raw)
+	if err != nil || n < 1 {
+		writeJSON(w, http.StatusBadRequest, map[string]string{"error": "limit deve ser um inteiro positivo"})
+		return 0, false
+	}
+	return n, true
+}
@@ -0,0 +1,99 @@
+package main
+
+import (
+	"encoding/json"
+	"errors"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"strings"
+	"testing"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestSearchHandler(t *testing.T) {
+	client := &stubHTTPClient{response: &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`[{"cep":"01310-100","logradouro":"Avenida Paulista","localidade":"São Paulo","uf":"SP"}]`)),
+	}}
+	app, _ := newTestApp(t, client)
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?uf=SP&city=S%C3%A3o+Paulo&street=Avenida+Paulista", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var results []cep.Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	assert.Len(t, results, 1)
+	assert.Equal(t, "01310-100", results[0].Cep)
+}
+
+func TestSearchHandlerErrors(t *testing.T) {
+	app, _ := newTestApp(t, &stubHTTPClient{err: errors.New("connection refused")})
+
+	for query, want := range map[string]int{
+		"uf=SP&city=São+Paulo":                      http.StatusBadRequest,
+		"uf=SP&city=S%C3%A3o+Paulo&street=Sé/../":   http.StatusBadRequest,
+		"uf=SP&city=S%C3%A3o+Paulo&street=Paulista": http.StatusBadGateway,
+	} {
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?"+query, nil))
+		assert.Equal(t, want, rec.Code, query)
+	}
+
+	app.cfg.disabledEndpoints = map[string]bool{endpointSearch: true}
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search?uf=SP", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
+
+func TestStreetAutocompleteHandler(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	mock.ExpectQuery(`ILIKE \$1`).
+		WithArgs("%pauli%", "SP", "", "pauli", 5).
+		WillReturnRows(sqlmock.NewRows([]string{"logradouro", "localidade", "uf", "count"}).
+			AddRow("Avenida Paulista", "São Paulo", "SP", 12))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/autocomplete/streets?q=pauli&uf=SP&limit=5", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.JSONEq(t, `[{"logradouro":"Avenida Paulista","localidade":"São Paulo","uf":"SP","ceps":12}]`, rec.Body.String())
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	for _, query := range []string{"q=pa", "q=pauli&limit=0", "q=pauli&limit=x"} {
+		rec := httptest.NewRecorder()
+		app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/autocomplete/streets?"+query, nil))
+		assert.Equal(t, http.StatusBadRequest, rec.Code, query)
+	}
+}
+
+func TestFullTextSearchHandler(t *testing.T) {
+	app, mock := newTestApp(t, &stubHTTPClient{})
+	mock.ExpectQuery(`search_vector @@ query`).
+		WithArgs("praca da se", 20).
+		WillReturnRows(sqlmock.NewRows([]string{"cep", "payload"}).
+			AddRow("01001000", []byte(`{"cep":"01001-000","logradouro":"Praça da Sé"}`)))
+
+	rec := httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/fulltext?q=praca+da+se", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	var results []cep.Response
+	assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &results))
+	assert.Equal(t, "Praça da Sé", results[0].Logradouro)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	rec = httptest.NewRecorder()
+	app.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/fulltext?q=", nil))
+	assert.Equal(t, http.StatusBadRequest, rec.Code)
+
+	memoryOnly := newBatchTestApp(t, testConfig(), newFakeProvider())
+	rec = httptest.NewRecorder()
+	memoryOnly.routes().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/search/fulltext?q=se", nil))
+	assert.Equal(t, http.StatusNotFound, rec.Code)
+}
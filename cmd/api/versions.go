@@ -0,0 +1,55 @@
+package main
+
+import (
+	"net/http"
+
+	"github.com/gorilla/mux"
+)
+
+// legacyAPIVersion is the version served by the unversioned paths, such as
+// /cep/{cep}, that predate /v1. They stay as aliases so existing clients keep
+// working.
+const legacyAPIVersion = "v1"
+
+// apiVersion is a URL version prefix and the public routes it serves.
+type apiVersion struct {
+	name   string
+	routes func(*mux.Router)
+}
+
+// apiVersions lists the served versions, oldest first. A breaking change to a
+// response goes into a new version with its own route set, while the older
+// versions keep their handlers until they are retired.
+func (app *application) apiVersions() []apiVersion {
+	return []apiVersion{
+		{name: "v1", routes: app.v1Routes},
+	}
+}
+
+// versionedRoutes mounts every API version under its prefix, plus the legacy
+// unversioned aliases. Responses carry the version that produced them in
+// API-Version, and every versioned route requires client credentials when
+// API_KEY_AUTH or JWT_JWKS_URL is set.
+func (app *application) versionedRoutes(router *mux.Router) {
+	for _, version := range app.apiVersions() {
+		sub := router.PathPrefix("/" + version.name).Subrouter()
+		sub.Use(apiVersionHeader(version.name), app.requireClientAuth)
+		version.routes(sub)
+
+		if version.name == legacyAPIVersion {
+			legacy := router.NewRoute().Subrouter()
+			legacy.Use(apiVersionHeader(version.name), app.requireClientAuth)
+			version.routes(legacy)
+		}
+	}
+}
+
+// apiVersionHeader tags responses with the API version.
+func apiVersionHeader(version string) mux.MiddlewareFunc {
+	return func(next http.Handler) http.Handler {
+		return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			w.Header().Set("API-Version", version)
+			next.ServeHTTP(w, r)
+		})
+	}
+}
@@ -0,0 +1,43 @@
+package main
+
+import (
+	"encoding/json"
+	"net/http"
+	"net/http/httptest"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestVersionedRoutes(t *testing.T) {
+	provider := newFakeProvider(cep.Response{Cep: "01001-000", Localidade: "São Paulo", Uf: "SP"})
+	app := newBatchTestApp(t, testConfig(), provider)
+	handler := app.routes()
+
+	for _, path := range []string{"/v1/cep/01001000", "/cep/01001000"} {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, path, nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code, path)
+		assert.Equal(t, "v1", rec.Header().Get("API-Version"), path)
+		var body cep.Response
+		assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &body))
+		assert.Equal(t, "São Paulo", body.Localidade, path)
+	}
+
+	t.Run("gateway keeps its own prefix", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/rpc/cep/01001000", nil))
+
+		assert.Equal(t, http.StatusOK, rec.Code)
+		assert.Contains(t, rec.Body.String(), `"address"`)
+	})
+
+	t.Run("operational routes are unversioned", func(t *testing.T) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/v1/healthz", nil))
+
+		assert.Equal(t, http.StatusNotFound, rec.Code)
+	})
+}
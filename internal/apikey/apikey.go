@@ -0,0 +1,168 @@
+// Package apikey issues and authenticates API client keys. Keys live in PostgreSQL
+// as SHA-256 hashes, so a leaked table does not expose working credentials.
+package apikey
+
+import (
+	"context"
+	"crypto/rand"
+	"crypto/sha256"
+	"database/sql"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Schema creates the key table. It is applied with the rest of the DDL at startup.
+const Schema = `
+CREATE TABLE IF NOT EXISTS api_keys (
+	id TEXT PRIMARY KEY,
+	name TEXT NOT NULL,
+	key_hash TEXT NOT NULL UNIQUE,
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	revoked_at TIMESTAMPTZ
+);
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS daily_quota BIGINT;
+ALTER TABLE api_keys ADD COLUMN IF NOT EXISTS monthly_quota BIGINT;
+CREATE TABLE IF NOT EXISTS api_key_usage (
+	key_id TEXT NOT NULL,
+	day DATE NOT NULL,
+	requests BIGINT NOT NULL,
+	PRIMARY KEY (key_id, day)
+);`
+
+var (
+	// ErrNotFound is returned for keys that were never issued.
+	ErrNotFound = errors.New("api key not found")
+	// ErrRevoked is returned for keys that were issued and later revoked.
+	ErrRevoked = errors.New("api key revoked")
+)
+
+// Key identifies an API client. The secret itself is never kept.
+type Key struct {
+	ID        string     `json:"id"`
+	Name      string     `json:"name"`
+	CreatedAt time.Time  `json:"created_at"`
+	RevokedAt *time.Time `json:"revoked_at,omitempty"`
+	Quotas
+}
+
+// Quotas caps the requests of a key per UTC day and calendar month. A nil
+// field falls back to the service-wide default; zero means unlimited.
+type Quotas struct {
+	Daily   *int64 `json:"daily_quota,omitempty"`
+	Monthly *int64 `json:"monthly_quota,omitempty"`
+}
+
+// secretPrefix marks issued secrets so they are easy to spot in code and by
+// secret scanners.
+const secretPrefix = "gocep_"
+
+// Hash returns the stored form of a secret. Keys are random and long, so a
+// plain SHA-256 is enough; a slow password hash would only add latency to
+// every request.
+func Hash(secret string) string {
+	sum := sha256.Sum256([]byte(secret))
+	return hex.EncodeToString(sum[:])
+}
+
+// Store reads and writes keys in PostgreSQL.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewStore builds a Store on db.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, now: time.Now}
+}
+
+// Authenticate returns the key matching secret. It fails with ErrNotFound or
+// ErrRevoked when the secret cannot be used.
+func (s *Store) Authenticate(ctx context.Context, secret string) (*Key, error) {
+	var key Key
+	err := s.db.QueryRowContext(ctx,
+		`SELECT id, name, created_at, revoked_at, daily_quota, monthly_quota FROM api_keys WHERE key_hash = $1`, Hash(secret)).
+		Scan(&key.ID, &key.Name, &key.CreatedAt, &key.RevokedAt, &key.Daily, &key.Monthly)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("authenticate api key: %w", err)
+	}
+	if key.RevokedAt != nil {
+		return &key, ErrRevoked
+	}
+	return &key, nil
+}
+
+// Create issues a key for the client name and returns it with its secret. The
+// secret is not stored and cannot be retrieved again.
+func (s *Store) Create(ctx context.Context, name string, quotas Quotas) (*Key, string, error) {
+	id, err := randomHex(16)
+	if err != nil {
+		return nil, "", err
+	}
+	secret, err := randomHex(32)
+	if err != nil {
+		return nil, "", err
+	}
+	secret = secretPrefix + secret
+
+	key := &Key{ID: id, Name: name, CreatedAt: s.now().UTC(), Quotas: quotas}
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO api_keys (id, name, key_hash, created_at, daily_quota, monthly_quota) VALUES ($1, $2, $3, $4, $5, $6)`,
+		key.ID, key.Name, Hash(secret), key.CreatedAt, key.Daily, key.Monthly)
+	if err != nil {
+		return nil, "", fmt.Errorf("create api key: %w", err)
+	}
+	return key, secret, nil
+}
+
+// List returns every key, revoked ones included, oldest first.
+func (s *Store) List(ctx context.Context) ([]Key, error) {
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT id, name, created_at, revoked_at, daily_quota, monthly_quota FROM api_keys ORDER BY created_at, id`)
+	if err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	defer rows.Close()
+
+	keys := []Key{}
+	for rows.Next() {
+		var key Key
+		if err := rows.Scan(&key.ID, &key.Name, &key.CreatedAt, &key.RevokedAt, &key.Daily, &key.Monthly); err != nil {
+			return nil, fmt.Errorf("scan api key: %w", err)
+		}
+		keys = append(keys, key)
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("list api keys: %w", err)
+	}
+	return keys, nil
+}
+
+// Revoke disables the key with the given ID from now on. Revoking a revoked
+// key keeps the original revocation time.
+func (s *Store) Revoke(ctx context.Context, id string) (*Key, error) {
+	key := Key{ID: id}
+	err := s.db.QueryRowContext(ctx,
+		`UPDATE api_keys SET revoked_at = COALESCE(revoked_at, $2) WHERE id = $1 RETURNING name, created_at, revoked_at, daily_quota, monthly_quota`,
+		id, s.now().UTC()).
+		Scan(&key.Name, &key.CreatedAt, &key.RevokedAt, &key.Daily, &key.Monthly)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("revoke api key %s: %w", id, err)
+	}
+	return &key, nil
+}
+
+func randomHex(n int) (string, error) {
+	b := make([]byte, n)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate api key: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
@@ -0,0 +1,113 @@
+package apikey
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store := NewStore(db)
+	store.now = func() time.Time { return testNow }
+	return store, mock
+}
+
+func keyRows() *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "name", "created_at", "revoked_at", "daily_quota", "monthly_quota"})
+}
+
+func TestHash(t *testing.T) {
+	assert.Equal(t, "2bb80d537b1da3e38bd30361aa855686bde0eacd7162fef6a25fe97bf527a25b", Hash("secret"))
+}
+
+func TestStoreAuthenticate(t *testing.T) {
+	store, mock := newTestStore(t)
+	ctx := context.Background()
+
+	mock.ExpectQuery("FROM api_keys WHERE key_hash").WithArgs(Hash("good")).
+		WillReturnRows(keyRows().AddRow("k1", "parceiro", testNow, nil, 1000, nil))
+	key, err := store.Authenticate(ctx, "good")
+	assert.NoError(t, err)
+	assert.Equal(t, "parceiro", key.Name)
+	assert.Equal(t, int64(1000), *key.Daily)
+	assert.Nil(t, key.Monthly)
+
+	mock.ExpectQuery("FROM api_keys WHERE key_hash").WithArgs(Hash("old")).
+		WillReturnRows(keyRows().AddRow("k2", "antigo", testNow, testNow, nil, nil))
+	_, err = store.Authenticate(ctx, "old")
+	assert.ErrorIs(t, err, ErrRevoked)
+
+	mock.ExpectQuery("FROM api_keys WHERE key_hash").WithArgs(Hash("nope")).WillReturnRows(keyRows())
+	_, err = store.Authenticate(ctx, "nope")
+	assert.ErrorIs(t, err, ErrNotFound)
+
+	mock.ExpectQuery("FROM api_keys WHERE key_hash").WillReturnError(errors.New("connection reset"))
+	_, err = store.Authenticate(ctx, "good")
+	assert.Error(t, err)
+	assert.NotErrorIs(t, err, ErrNotFound)
+
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreCreate(t *testing.T) {
+	store, mock := newTestStore(t)
+	daily := int64(500)
+	mock.ExpectExec("INSERT INTO api_keys").
+		WithArgs(sqlmock.AnyArg(), "parceiro", sqlmock.AnyArg(), testNow, &daily, (*int64)(nil)).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	key, secret, err := store.Create(context.Background(), "parceiro", Quotas{Daily: &daily})
+
+	assert.NoError(t, err)
+	assert.Equal(t, "parceiro", key.Name)
+	assert.Len(t, key.ID, 32)
+	assert.True(t, strings.HasPrefix(secret, secretPrefix))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	// Every call issues a fresh secret.
+	mock.ExpectExec("INSERT INTO api_keys").WillReturnResult(sqlmock.NewResult(0, 1))
+	_, other, err := store.Create(context.Background(), "parceiro", Quotas{})
+	assert.NoError(t, err)
+	assert.NotEqual(t, secret, other)
+}
+
+func TestStoreList(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("FROM api_keys ORDER BY").
+		WillReturnRows(keyRows().AddRow("k1", "parceiro", testNow, nil, nil, nil).AddRow("k2", "antigo", testNow, testNow, nil, nil))
+
+	keys, err := store.List(context.Background())
+
+	assert.NoError(t, err)
+	assert.Len(t, keys, 2)
+	assert.Nil(t, keys[0].RevokedAt)
+	assert.Equal(t, testNow, *keys[1].RevokedAt)
+}
+
+func TestStoreRevoke(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("UPDATE api_keys SET revoked_at").WithArgs("k1", testNow).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "created_at", "revoked_at", "daily_quota", "monthly_quota"}).AddRow("parceiro", testNow, testNow, nil, nil))
+	mock.ExpectQuery("UPDATE api_keys SET revoked_at").WithArgs("nope", testNow).
+		WillReturnRows(sqlmock.NewRows([]string{"name", "created_at", "revoked_at", "daily_quota", "monthly_quota"}))
+
+	key, err := store.Revoke(context.Background(), "k1")
+	assert.NoError(t, err)
+	assert.Equal(t, testNow, *key.RevokedAt)
+
+	_, err = store.Revoke(context.Background(), "nope")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
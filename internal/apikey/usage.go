@@ -0,0 +1,81 @@
+package apikey
+
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
+// Usage is the number of requests a key made in the current UTC day and
+// calendar month, the one being recorded included.
+type Usage struct {
+	Day   int64
+	Month int64
+}
+
+// DailyUsage is the request count of a key on one UTC day.
+type DailyUsage struct {
+	Date     string `json:"date"`
+	Requests int64  `json:"requests"`
+}
+
+// Record counts one request for keyID and returns the updated totals. The
+// counter lives in PostgreSQL, so every replica sees the same totals.
+func (s *Store) Record(ctx context.Context, keyID string) (Usage, error) {
+	day := dayOf(s.now())
+	monthStart := time.Date(day.Year(), day.Month(), 1, 0, 0, 0, 0, time.UTC)
+
+	// The subquery reads the snapshot from before the upsert, so it sums the
+	// earlier days of the month and today's count is added on top.
+	var usage Usage
+	err := s.db.QueryRowContext(ctx, `
+WITH today AS (
+	INSERT INTO api_key_usage (key_id, day, requests) VALUES ($1, $2, 1)
+	ON CONFLICT (key_id, day) DO UPDATE SET requests = api_key_usage.requests + 1
+	RETURNING requests
+)
+SELECT today.requests, today.requests + (
+	SELECT COALESCE(SUM(requests), 0) FROM api_key_usage WHERE key_id = $1 AND day >= $3 AND day < $2
+) FROM today`,
+		keyID, day, monthStart).
+		Scan(&usage.Day, &usage.Month)
+	if err != nil {
+		return Usage{}, fmt.Errorf("record api key usage: %w", err)
+	}
+	return usage, nil
+}
+
+// DailyUsage returns the request counts of keyID over the last days UTC
+// days, today included, oldest first. Days without requests are omitted.
+func (s *Store) DailyUsage(ctx context.Context, keyID string, days int) ([]DailyUsage, error) {
+	since := dayOf(s.now()).AddDate(0, 0, 1-days)
+	rows, err := s.db.QueryContext(ctx,
+		`SELECT day, requests FROM api_key_usage WHERE key_id = $1 AND day >= $2 ORDER BY day`,
+		keyID, since)
+	if err != nil {
+		return nil, fmt.Errorf("load api key usage: %w", err)
+	}
+	defer rows.Close()
+
+	usage := []DailyUsage{}
+	for rows.Next() {
+		var (
+			day      time.Time
+			requests int64
+		)
+		if err := rows.Scan(&day, &requests); err != nil {
+			return nil, fmt.Errorf("scan api key usage: %w", err)
+		}
+		usage = append(usage, DailyUsage{Date: day.Format(time.DateOnly), Requests: requests})
+	}
+	if err := rows.Err(); err != nil {
+		return nil, fmt.Errorf("load api key usage: %w", err)
+	}
+	return usage, nil
+}
+
+// dayOf truncates t to the start of its UTC day.
+func dayOf(t time.Time) time.Time {
+	t = t.UTC()
+	return time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, time.UTC)
+}
@@ -0,0 +1,39 @@
+package apikey
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestStoreRecord(t *testing.T) {
+	store, mock := newTestStore(t)
+	day := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	monthStart := time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("INSERT INTO api_key_usage").WithArgs("k1", day, monthStart).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "month"}).AddRow(3, 40))
+
+	usage, err := store.Record(context.Background(), "k1")
+
+	assert.NoError(t, err)
+	assert.Equal(t, Usage{Day: 3, Month: 40}, usage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestStoreDailyUsage(t *testing.T) {
+	store, mock := newTestStore(t)
+	since := time.Date(2024, 4, 2, 0, 0, 0, 0, time.UTC)
+	mock.ExpectQuery("FROM api_key_usage").WithArgs("k1", since).
+		WillReturnRows(sqlmock.NewRows([]string{"day", "requests"}).
+			AddRow(time.Date(2024, 4, 30, 0, 0, 0, 0, time.UTC), 12).
+			AddRow(time.Date(2024, 5, 1, 0, 0, 0, 0, time.UTC), 3))
+
+	usage, err := store.DailyUsage(context.Background(), "k1", 30)
+
+	assert.NoError(t, err)
+	assert.Equal(t, []DailyUsage{{Date: "2024-04-30", Requests: 12}, {Date: "2024-05-01", Requests: 3}}, usage)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
@@ -0,0 +1,96 @@
+package cep
+
+import (
+	"context"
+	"database/sql"
+	"fmt"
+	"time"
+)
+
+// expiredAt is the updated_at value ExpireAll stamps on entries. Anything at or
+// before it is stale regardless of the configured TTL.
+var expiredAt = time.Unix(0, 0).UTC()
+
+// ExpireAll marks every cached entry stale while keeping the data, so each CEP
+// is refreshed from upstream the next time it is requested. It returns the
+// number of entries affected.
+func (s *Service) ExpireAll(ctx context.Context) (int64, error) {
+	if s.db == nil {
+		return s.memory.expireAll(), nil
+	}
+
+	query := fmt.Sprintf("UPDATE %s SET updated_at = $1", s.tableName)
+	res, err := s.db.ExecContext(ctx, query, expiredAt)
+	if err != nil {
+		return 0, fmt.Errorf("expire cache: %w", err)
+	}
+	return res.RowsAffected()
+}
+
+// CacheAgeBuckets are the upper bounds used by CacheStats.AgeBuckets.
+var CacheAgeBuckets = []time.Duration{time.Hour, 24 * time.Hour, 7 * 24 * time.Hour, 30 * 24 * time.Hour}
+
+// CacheStats summarises the cache contents.
+type CacheStats struct {
+	Entries int64
+	Expired int64
+	// AgeBuckets[i] counts entries younger than or equal to CacheAgeBuckets[i] (cumulative).
+	AgeBuckets []int64
+	OldestAge  time.Duration
+}
+
+// CacheStats runs a single aggregate query over the cache. It scans the whole
+// table, so callers should throttle it.
+func (s *Service) CacheStats(ctx context.Context) (CacheStats, error) {
+	now := s.now()
+	stats := CacheStats{AgeBuckets: make([]int64, len(CacheAgeBuckets))}
+
+	if s.db == nil {
+		s.memory.forEach(func(_ string, entry memoryEntry) {
+			stats.add(now.Sub(entry.updatedAt), s.isExpired(entry.updatedAt))
+		})
+		return stats, nil
+	}
+
+	// $1 is the expiry threshold, $2.. the bucket thresholds.
+	expiredBefore := expiredAt
+	if s.cacheTTL > 0 {
+		expiredBefore = now.Add(-s.cacheTTL)
+	}
+	args := []any{expiredBefore}
+	columns := "COUNT(*), COUNT(*) FILTER (WHERE updated_at <= $1)"
+	for i, bound := range CacheAgeBuckets {
+		columns += fmt.Sprintf(", COUNT(*) FILTER (WHERE updated_at >= $%d)", i+2)
+		args = append(args, now.Add(-bound))
+	}
+	columns += ", MIN(updated_at)"
+
+	dest := []any{&stats.Entries, &stats.Expired}
+	for i := range stats.AgeBuckets {
+		dest = append(dest, &stats.AgeBuckets[i])
+	}
+	var oldest sql.NullTime
+	dest = append(dest, &oldest)
+
+	query := fmt.Sprintf("SELECT %s FROM %s", columns, s.tableName)
+	if err := s.db.QueryRowContext(ctx, query, args...).Scan(dest...); err != nil {
+		return CacheStats{}, fmt.Errorf("query cache stats: %w", err)
+	}
+	if oldest.Valid {
+		stats.OldestAge = now.Sub(oldest.Time)
+	}
+	return stats, nil
+}
+
+func (st *CacheStats) add(age time.Duration, expired bool) {
+	st.Entries++
+	if expired {
+		st.Expired++
+	}
+	for i, bound := range CacheAgeBuckets {
+		if age <= bound {
+			st.AgeBuckets[i]++
+		}
+	}
+	st.OldestAge = max(st.OldestAge, age)
+}
@@ -0,0 +1,76 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceExpireAll(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectExec(`UPDATE ceps SET updated_at = \$1`).
+		WithArgs(expiredAt).
+		WillReturnResult(sqlmock.NewResult(0, 42))
+
+	service := NewService(db, nil, time.Hour, noopLogger())
+
+	n, err := service.ExpireAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(42), n)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceExpiredEntryIsRefreshedWithoutTTL(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("76543210").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).
+			AddRow([]byte(`{"cep":"76543-210"}`), expiredAt, CacheSchemaVersion))
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	client := &stubHTTPClient{
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"76543-210"}`)),
+		},
+	}
+	// A zero TTL disables expiration, but force-expired rows must still refresh.
+	service := NewService(db, client, 0, noopLogger())
+
+	res, err := service.Lookup(context.Background(), "76543210")
+	assert.NoError(t, err)
+	assert.True(t, res.ProviderCalled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceExpireAllMemory(t *testing.T) {
+	client := &stubHTTPClient{
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"76543-210"}`)),
+		},
+	}
+	service := NewService(nil, client, time.Hour, noopLogger())
+	service.memory.set("76543210", Response{Cep: "76543-210"}, time.Now())
+
+	n, err := service.ExpireAll(context.Background())
+	assert.NoError(t, err)
+	assert.Equal(t, int64(1), n)
+
+	info, err := service.Inspect(context.Background(), "76543210")
+	assert.NoError(t, err)
+	assert.True(t, info.Cached)
+	assert.True(t, info.Expired)
+}
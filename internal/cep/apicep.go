@@ -0,0 +1,73 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+// apiCEPURL expects the formatted CEP (01001-000).
+const apiCEPURL = "https://cdn.apicep.com/file/apicep/%s.json"
+
+// apiCEPResponse is the apicep.com payload. Failures are reported in the body
+// through status/ok, often with an HTTP 200.
+type apiCEPResponse struct {
+	Status   int    `json:"status"`
+	OK       bool   `json:"ok"`
+	Code     string `json:"code"`
+	State    string `json:"state"`
+	City     string `json:"city"`
+	District string `json:"district"`
+	Address  string `json:"address"`
+	Message  string `json:"message"`
+}
+
+// apiCEP is a built-in provider for apicep.com. It shares the Service's HTTP
+// client, credentials and hedging with the other built-in providers.
+type apiCEP struct {
+	service   *Service
+	urlFormat string
+}
+
+func (p *apiCEP) String() string { return string(ProviderAPICEP) }
+
+func (p *apiCEP) Lookup(ctx context.Context, cep string) (*Response, error) {
+	resp, err := p.service.doProviderRequest(ctx, fmt.Sprintf(p.urlFormat, formatCEP(cep)), p.service.authFor(ProviderAPICEP))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("apicep returned status %d", resp.StatusCode)
+	}
+
+	var body apiCEPResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty body with status %d", ErrUpstreamBadResponse, resp.StatusCode)
+		}
+		return nil, err
+	}
+
+	switch {
+	case body.Status == http.StatusNotFound:
+		return nil, ErrNotFound
+	case !body.OK:
+		return nil, fmt.Errorf("apicep returned status %d: %s", body.Status, body.Message)
+	}
+
+	return &Response{
+		Cep:        body.Code,
+		Logradouro: body.Address,
+		Bairro:     body.District,
+		Localidade: body.City,
+		Uf:         body.State,
+	}, nil
+}
@@ -0,0 +1,66 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceAPICEPProvider(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		status  int
+		body    string
+		want    *Response
+		wantErr error
+	}{
+		{
+			name:   "found",
+			status: http.StatusOK,
+			body:   `{"status":200,"ok":true,"code":"06233-030","state":"SP","city":"Osasco","district":"Piratininga","address":"Rua Paiva","statusText":"ok"}`,
+			want:   &Response{Cep: "06233-030", Logradouro: "Rua Paiva", Bairro: "Piratininga", Localidade: "Osasco", Uf: "SP"},
+		},
+		{
+			name:    "not found in body",
+			status:  http.StatusOK,
+			body:    `{"status":404,"ok":false,"message":"CEP não encontrado","statusText":"not_found"}`,
+			wantErr: ErrNotFound,
+		},
+		{
+			name:    "empty body",
+			status:  http.StatusOK,
+			wantErr: ErrUpstreamBadResponse,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "https://cdn.apicep.com/file/apicep/06233-030.json", req.URL.String())
+				return &http.Response{StatusCode: tc.status, Body: io.NopCloser(strings.NewReader(tc.body))}, nil
+			})
+			service := NewService(nil, client, time.Hour, noopLogger(), WithProviderOrder(ProviderAPICEP))
+
+			resp, err := service.Get(context.Background(), "06233030")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, resp)
+		})
+	}
+}
+
+func TestServiceAPICEPRejectedInBody(t *testing.T) {
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"status":429,"ok":false,"message":"Too many requests"}`))}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(), WithProviderOrder(ProviderAPICEP))
+
+	_, err := service.Get(context.Background(), "06233030")
+	assert.EqualError(t, err, "apicep returned status 429: Too many requests")
+}
@@ -0,0 +1,137 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sort"
+	"strings"
+	"unicode/utf8"
+)
+
+// Autocomplete limits.
+const (
+	DefaultAutocompleteLimit = 10
+	MaxAutocompleteLimit     = 50
+)
+
+// ErrInvalidAutocomplete indicates that the autocomplete term is too short or too long.
+var ErrInvalidAutocomplete = errors.New("invalid autocomplete query")
+
+// AutocompleteQuery asks for cached streets containing Term, optionally within
+// one UF and city.
+type AutocompleteQuery struct {
+	Term  string
+	UF    string
+	City  string
+	Limit int
+}
+
+// StreetSuggestion is a distinct cached street; CEPs counts its cached CEPs.
+type StreetSuggestion struct {
+	Logradouro string `json:"logradouro"`
+	Localidade string `json:"localidade"`
+	Uf         string `json:"uf"`
+	CEPs       int    `json:"ceps"`
+}
+
+// AutocompleteStreets suggests streets from the cache whose logradouro
+// contains the term, best trigram matches first. Like ListByIBGE it never calls
+// the provider. Terms need at least 3 characters, the size of a trigram.
+func (s *Service) AutocompleteStreets(ctx context.Context, q AutocompleteQuery) ([]StreetSuggestion, error) {
+	q.Term = strings.TrimSpace(q.Term)
+	q.UF = strings.ToUpper(strings.TrimSpace(q.UF))
+	q.City = strings.TrimSpace(q.City)
+
+	maxLength := s.searchMaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultSearchMaxLength
+	}
+	if length := utf8.RuneCountInString(q.Term); length < minSearchLength || length > maxLength {
+		return nil, fmt.Errorf("%w: term must have between %d and %d characters", ErrInvalidAutocomplete, minSearchLength, maxLength)
+	}
+	switch {
+	case q.Limit <= 0:
+		q.Limit = DefaultAutocompleteLimit
+	case q.Limit > MaxAutocompleteLimit:
+		q.Limit = MaxAutocompleteLimit
+	}
+
+	if s.db == nil {
+		return s.autocompleteMemory(q), nil
+	}
+	return s.queryAutocomplete(ctx, q)
+}
+
+// queryAutocomplete is served by the pg_trgm GIN index on payload->>'logradouro',
+// which covers ILIKE '%term%' as well as the similarity ranking.
+func (s *Service) queryAutocomplete(ctx context.Context, q AutocompleteQuery) ([]StreetSuggestion, error) {
+	query := fmt.Sprintf(`
+SELECT payload->>'logradouro', payload->>'localidade', payload->>'uf', COUNT(*)
+FROM %s
+WHERE payload->>'logradouro' ILIKE $1
+	AND ($2 = '' OR payload->>'uf' = $2)
+	AND ($3 = '' OR lower(payload->>'localidade') = lower($3))
+GROUP BY 1, 2, 3
+ORDER BY similarity(payload->>'logradouro', $4) DESC, 1
+LIMIT $5`, s.tableName)
+	rows, err := s.db.QueryContext(ctx, query, "%"+escapeLike(q.Term)+"%", q.UF, q.City, q.Term, q.Limit)
+	if err != nil {
+		return nil, fmt.Errorf("query street autocomplete: %w", err)
+	}
+	defer rows.Close()
+
+	suggestions := []StreetSuggestion{}
+	for rows.Next() {
+		var suggestion StreetSuggestion
+		if err := rows.Scan(&suggestion.Logradouro, &suggestion.Localidade, &suggestion.Uf, &suggestion.CEPs); err != nil {
+			return nil, err
+		}
+		suggestions = append(suggestions, suggestion)
+	}
+	return suggestions, rows.Err()
+}
+
+// autocompleteMemory approximates the trigram ranking with a case-insensitive
+// substring match, streets starting with the term first.
+func (s *Service) autocompleteMemory(q AutocompleteQuery) []StreetSuggestion {
+	term := strings.ToLower(q.Term)
+	counts := map[StreetSuggestion]int{}
+	s.memory.forEach(func(_ string, entry memoryEntry) {
+		resp := entry.resp
+		if !strings.Contains(strings.ToLower(resp.Logradouro), term) ||
+			(q.UF != "" && resp.Uf != q.UF) ||
+			(q.City != "" && !strings.EqualFold(resp.Localidade, q.City)) {
+			return
+		}
+		counts[StreetSuggestion{Logradouro: resp.Logradouro, Localidade: resp.Localidade, Uf: resp.Uf}]++
+	})
+
+	suggestions := make([]StreetSuggestion, 0, len(counts))
+	for suggestion, n := range counts {
+		suggestion.CEPs = n
+		suggestions = append(suggestions, suggestion)
+	}
+	sort.Slice(suggestions, func(i, j int) bool {
+		pi := strings.HasPrefix(strings.ToLower(suggestions[i].Logradouro), term)
+		pj := strings.HasPrefix(strings.ToLower(suggestions[j].Logradouro), term)
+		if pi != pj {
+			return pi
+		}
+		if suggestions[i].Logradouro != suggestions[j].Logradouro {
+			return suggestions[i].Logradouro < suggestions[j].Logradouro
+		}
+		return suggestions[i].Localidade < suggestions[j].Localidade
+	})
+	if len(suggestions) > q.Limit {
+		suggestions = suggestions[:q.Limit]
+	}
+	return suggestions
+}
+
+// likeEscaper escapes the LIKE wildcards so user input matches literally.
+var likeEscaper = strings.NewReplacer(`\`, `\\`, `%`, `\%`, `_`, `\_`)
+
+func escapeLike(value string) string {
+	return likeEscaper.Replace(value)
+}
@@ -0,0 +1,62 @@
+package cep
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceAutocompleteStreets(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`FROM ceps\s+WHERE payload->>'logradouro' ILIKE \$1`).
+		WithArgs(`%100\%%`, "SP", "São Paulo", "100%", MaxAutocompleteLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"logradouro", "localidade", "uf", "count"}))
+	mock.ExpectQuery(`ORDER BY similarity\(payload->>'logradouro', \$4\) DESC`).
+		WithArgs("%pauli%", "", "", "pauli", DefaultAutocompleteLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"logradouro", "localidade", "uf", "count"}).
+			AddRow("Avenida Paulista", "São Paulo", "SP", 12).
+			AddRow("Rua Paulista", "Campinas", "SP", 1))
+
+	service := NewService(db, nil, time.Hour, noopLogger())
+
+	suggestions, err := service.AutocompleteStreets(context.Background(), AutocompleteQuery{Term: "100%", UF: "sp", City: "São Paulo", Limit: 500})
+	assert.NoError(t, err)
+	assert.NotNil(t, suggestions)
+	assert.Empty(t, suggestions)
+
+	suggestions, err = service.AutocompleteStreets(context.Background(), AutocompleteQuery{Term: " pauli "})
+	assert.NoError(t, err)
+	assert.Equal(t, []StreetSuggestion{
+		{Logradouro: "Avenida Paulista", Localidade: "São Paulo", Uf: "SP", CEPs: 12},
+		{Logradouro: "Rua Paulista", Localidade: "Campinas", Uf: "SP", CEPs: 1},
+	}, suggestions)
+
+	_, err = service.AutocompleteStreets(context.Background(), AutocompleteQuery{Term: "pa"})
+	assert.ErrorIs(t, err, ErrInvalidAutocomplete)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceAutocompleteStreetsMemory(t *testing.T) {
+	service := NewService(nil, nil, time.Hour, noopLogger())
+	service.memory.set("01310100", Response{Cep: "01310-100", Logradouro: "Avenida Paulista", Localidade: "São Paulo", Uf: "SP"}, time.Now())
+	service.memory.set("01310200", Response{Cep: "01310-200", Logradouro: "Avenida Paulista", Localidade: "São Paulo", Uf: "SP"}, time.Now())
+	service.memory.set("13010000", Response{Cep: "13010-000", Logradouro: "Paulista", Localidade: "Campinas", Uf: "SP"}, time.Now())
+	service.memory.set("20040002", Response{Cep: "20040-002", Logradouro: "Avenida Rio Branco", Localidade: "Rio de Janeiro", Uf: "RJ"}, time.Now())
+
+	suggestions, err := service.AutocompleteStreets(context.Background(), AutocompleteQuery{Term: "PAULI"})
+	assert.NoError(t, err)
+	assert.Equal(t, []StreetSuggestion{
+		{Logradouro: "Paulista", Localidade: "Campinas", Uf: "SP", CEPs: 1},
+		{Logradouro: "Avenida Paulista", Localidade: "São Paulo", Uf: "SP", CEPs: 2},
+	}, suggestions)
+
+	suggestions, err = service.AutocompleteStreets(context.Background(), AutocompleteQuery{Term: "avenida", City: "rio de janeiro"})
+	assert.NoError(t, err)
+	assert.Equal(t, []StreetSuggestion{{Logradouro: "Avenida Rio Branco", Localidade: "Rio de Janeiro", Uf: "RJ", CEPs: 1}}, suggestions)
+}
@@ -0,0 +1,62 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const brasilAPIURL = "https://brasilapi.com.br/api/cep/v1/%s"
+
+// brasilAPIResponse is the v1 CEP payload of BrasilAPI.
+type brasilAPIResponse struct {
+	Cep          string `json:"cep"`
+	State        string `json:"state"`
+	City         string `json:"city"`
+	Neighborhood string `json:"neighborhood"`
+	Street       string `json:"street"`
+}
+
+// brasilAPI is a built-in fallback provider. It shares the Service's HTTP
+// client, credentials and hedging with viaCEP, and maps BrasilAPI's field
+// names onto the ViaCEP-shaped Response.
+type brasilAPI struct {
+	service   *Service
+	urlFormat string
+}
+
+func (p *brasilAPI) String() string { return string(ProviderBrasilAPI) }
+
+func (p *brasilAPI) Lookup(ctx context.Context, cep string) (*Response, error) {
+	resp, err := p.service.doProviderRequest(ctx, fmt.Sprintf(p.urlFormat, cep), p.service.authFor(ProviderBrasilAPI))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("brasilapi returned status %d", resp.StatusCode)
+	}
+
+	var body brasilAPIResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty body with status %d", ErrUpstreamBadResponse, resp.StatusCode)
+		}
+		return nil, err
+	}
+
+	return &Response{
+		Cep:        formatCEP(body.Cep),
+		Logradouro: body.Street,
+		Bairro:     body.Neighborhood,
+		Localidade: body.City,
+		Uf:         body.State,
+	}, nil
+}
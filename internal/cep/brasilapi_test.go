@@ -0,0 +1,78 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceBrasilAPIFallback(t *testing.T) {
+	brasilAPIBody := `{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Sé","street":"Praça da Sé","service":"correios"}`
+
+	for _, tc := range []struct {
+		name   string
+		viaCEP func() (*http.Response, error)
+	}{
+		{name: "viacep error status", viaCEP: func() (*http.Response, error) {
+			return &http.Response{StatusCode: http.StatusServiceUnavailable, Body: http.NoBody}, nil
+		}},
+		{name: "viacep timeout", viaCEP: func() (*http.Response, error) {
+			return nil, errors.New("Client.Timeout exceeded while awaiting headers")
+		}},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			var hosts []string
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				hosts = append(hosts, req.URL.Host)
+				if req.URL.Host == "viacep.com.br" {
+					return tc.viaCEP()
+				}
+				assert.Equal(t, "/api/cep/v1/01001000", req.URL.Path)
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(brasilAPIBody))}, nil
+			})
+			service := NewService(nil, client, time.Hour, noopLogger(), WithProviderOrder(ProviderViaCEP, ProviderBrasilAPI))
+
+			resp, err := service.Get(context.Background(), "01001000")
+			assert.NoError(t, err)
+			assert.Equal(t, []string{"viacep.com.br", "brasilapi.com.br"}, hosts)
+			assert.Equal(t, &Response{Cep: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Localidade: "São Paulo", Uf: "SP"}, resp)
+		})
+	}
+}
+
+func TestServiceBrasilAPINotFound(t *testing.T) {
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(), WithProviderOrder(ProviderBrasilAPI))
+
+	_, err := service.Get(context.Background(), "99999999")
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestServiceProviderTimeouts(t *testing.T) {
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		if req.URL.Host == "viacep.com.br" {
+			<-req.Context().Done()
+			return nil, req.Context().Err()
+		}
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"01001000","state":"SP"}`))}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(),
+		WithProviderOrder(ProviderViaCEP, ProviderBrasilAPI),
+		WithProviderTimeouts(map[ProviderName]time.Duration{ProviderViaCEP: 20 * time.Millisecond}))
+
+	ctx, cancel := context.WithTimeout(context.Background(), time.Second)
+	defer cancel()
+	resp, err := service.Get(ctx, "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, "SP", resp.Uf)
+	assert.Equal(t, "viacep", service.ProviderScores()[0].Provider)
+	assert.Equal(t, 0.0, service.ProviderScores()[0].SuccessRate)
+}
@@ -0,0 +1,207 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"log/slog"
+	"sync"
+	"time"
+)
+
+// ErrCircuitOpen is returned for a provider whose circuit breaker is open.
+var ErrCircuitOpen = errors.New("provider circuit breaker is open")
+
+// BreakerConfig configures the per-provider circuit breaker.
+type BreakerConfig struct {
+	// FailurePercent opens the circuit once this share of calls in Window
+	// failed. Zero disables the breaker.
+	FailurePercent int
+	// MinRequests is the number of calls in Window needed before the failure
+	// rate is considered, so a single early failure cannot trip the breaker.
+	MinRequests int
+	Window      time.Duration
+	// Cooldown is how long the circuit stays open before a probe is let through.
+	Cooldown time.Duration
+}
+
+// Circuit breaker states.
+const (
+	CircuitClosed   = "closed"
+	CircuitOpen     = "open"
+	CircuitHalfOpen = "half-open"
+)
+
+// CircuitState is the breaker state of one provider.
+type CircuitState struct {
+	Provider string
+	State    string
+}
+
+// WithCircuitBreaker wraps every provider in a circuit breaker, so a flapping
+// provider fails fast instead of spending the request timeout on each call.
+func WithCircuitBreaker(cfg BreakerConfig) Option {
+	return func(s *Service) {
+		if cfg.FailurePercent > 0 && cfg.Window > 0 && cfg.Cooldown > 0 {
+			s.breakerConfig = cfg
+		}
+	}
+}
+
+// wrapBreakers puts a breaker in front of each provider when configured.
+func (s *Service) wrapBreakers() {
+	if s.breakerConfig.FailurePercent <= 0 {
+		return
+	}
+	wrapped := make([]Provider, len(s.providers))
+	for i, provider := range s.providers {
+		wrapped[i] = &breakerProvider{
+			Provider: provider,
+			name:     providerName(provider, i),
+			cfg:      s.breakerConfig,
+			now:      func() time.Time { return s.now() },
+			logger:   s.logger,
+		}
+	}
+	s.providers = wrapped
+}
+
+// CircuitStates reports the breaker state of each provider, in provider order.
+// It is empty when the breaker is disabled.
+func (s *Service) CircuitStates() []CircuitState {
+	var states []CircuitState
+	for _, provider := range s.providers {
+		if breaker, ok := provider.(*breakerProvider); ok {
+			states = append(states, CircuitState{Provider: breaker.name, State: breaker.state()})
+		}
+	}
+	return states
+}
+
+// breakerProvider guards a Provider with a failure-rate circuit breaker.
+type breakerProvider struct {
+	Provider
+	name   string
+	cfg    BreakerConfig
+	now    func() time.Time
+	logger *slog.Logger
+
+	mu        sync.Mutex
+	calls     []breakerCall
+	openUntil time.Time
+	open      bool
+	probing   bool
+}
+
+// breakerCall is one outcome in the sliding window.
+type breakerCall struct {
+	at     time.Time
+	failed bool
+}
+
+func (b *breakerProvider) String() string { return b.name }
+
+func (b *breakerProvider) Lookup(ctx context.Context, cep string) (*Response, error) {
+	probe, err := b.allow()
+	if err != nil {
+		return nil, err
+	}
+
+	resp, err := b.Provider.Lookup(ctx, cep)
+	if err != nil && ctx.Err() != nil {
+		// The caller gave up; that says nothing about the provider.
+		b.release(probe)
+		return nil, err
+	}
+	b.record(ctx, probe, err != nil && !errors.Is(err, ErrNotFound))
+	return resp, err
+}
+
+// allow reports whether a call may proceed and whether it is the half-open probe.
+func (b *breakerProvider) allow() (probe bool, err error) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	if !b.open {
+		return false, nil
+	}
+	if b.now().Before(b.openUntil) || b.probing {
+		return false, fmt.Errorf("%w: %s", ErrCircuitOpen, b.name)
+	}
+	b.probing = true
+	return true, nil
+}
+
+// release ends a probe without an outcome.
+func (b *breakerProvider) release(probe bool) {
+	if !probe {
+		return
+	}
+	b.mu.Lock()
+	b.probing = false
+	b.mu.Unlock()
+}
+
+// record adds a call outcome and moves the breaker between states.
+func (b *breakerProvider) record(ctx context.Context, probe, failed bool) {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	now := b.now()
+	if probe {
+		b.probing = false
+		if failed {
+			b.openUntil = now.Add(b.cfg.Cooldown)
+			b.logger.WarnContext(ctx, "provider probe failed, circuit stays open", "provider", b.name)
+			return
+		}
+		b.open = false
+		b.calls = b.calls[:0]
+		b.logger.InfoContext(ctx, "provider recovered, circuit closed", "provider", b.name)
+		return
+	}
+	if b.open {
+		// A call admitted before the circuit opened; the window restarts on close.
+		return
+	}
+
+	cutoff := now.Add(-b.cfg.Window)
+	kept := b.calls[:0]
+	for _, call := range b.calls {
+		if call.at.After(cutoff) {
+			kept = append(kept, call)
+		}
+	}
+	b.calls = append(kept, breakerCall{at: now, failed: failed})
+
+	if len(b.calls) < b.cfg.MinRequests {
+		return
+	}
+	failures := 0
+	for _, call := range b.calls {
+		if call.failed {
+			failures++
+		}
+	}
+	if failures*100 >= b.cfg.FailurePercent*len(b.calls) {
+		b.open = true
+		b.openUntil = now.Add(b.cfg.Cooldown)
+		b.logger.ErrorContext(ctx, "provider circuit opened",
+			"provider", b.name, "failures", failures, "calls", len(b.calls), "window", b.cfg.Window, "cooldown", b.cfg.Cooldown)
+	}
+}
+
+// state names the current breaker state.
+func (b *breakerProvider) state() string {
+	b.mu.Lock()
+	defer b.mu.Unlock()
+
+	switch {
+	case !b.open:
+		return CircuitClosed
+	case b.probing || !b.now().Before(b.openUntil):
+		return CircuitHalfOpen
+	default:
+		return CircuitOpen
+	}
+}
@@ -0,0 +1,81 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestCircuitBreaker(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var calls int
+	var fail bool
+	provider := ProviderFunc(func(context.Context, string) (*Response, error) {
+		calls++
+		if fail {
+			return nil, errors.New("connection refused")
+		}
+		return &Response{Uf: "SP"}, nil
+	})
+	service := NewService(nil, nil, 0, noopLogger(),
+		WithProviders(provider),
+		WithCircuitBreaker(BreakerConfig{FailurePercent: 50, MinRequests: 4, Window: time.Minute, Cooldown: 30 * time.Second}))
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// One success and two failures stay below MinRequests.
+	_, err := service.fetchFromProviders(ctx, "01001000")
+	assert.NoError(t, err)
+	fail = true
+	for i := 0; i < 2; i++ {
+		_, err = service.fetchFromProviders(ctx, "01001000")
+		assert.Error(t, err)
+	}
+	assert.Equal(t, []CircuitState{{Provider: "#1", State: CircuitClosed}}, service.CircuitStates())
+
+	// The fourth call reaches MinRequests with 3/4 failures and opens the circuit.
+	_, err = service.fetchFromProviders(ctx, "01001000")
+	assert.Error(t, err)
+	assert.Equal(t, CircuitOpen, service.CircuitStates()[0].State)
+
+	_, err = service.fetchFromProviders(ctx, "01001000")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 4, calls, "open circuit must not call the provider")
+
+	// After the cooldown a failed probe keeps the circuit open.
+	now = now.Add(31 * time.Second)
+	assert.Equal(t, CircuitHalfOpen, service.CircuitStates()[0].State)
+	_, err = service.fetchFromProviders(ctx, "01001000")
+	assert.NotErrorIs(t, err, ErrCircuitOpen)
+	assert.Equal(t, 5, calls)
+	_, err = service.fetchFromProviders(ctx, "01001000")
+	assert.ErrorIs(t, err, ErrCircuitOpen)
+
+	// A successful probe closes it again.
+	now = now.Add(31 * time.Second)
+	fail = false
+	_, err = service.fetchFromProviders(ctx, "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, CircuitClosed, service.CircuitStates()[0].State)
+}
+
+func TestCircuitBreakerIgnoresNotFound(t *testing.T) {
+	provider := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, ErrNotFound })
+	service := NewService(nil, nil, 0, noopLogger(),
+		WithProviders(provider),
+		WithCircuitBreaker(BreakerConfig{FailurePercent: 50, MinRequests: 1, Window: time.Minute, Cooldown: time.Minute}))
+
+	for i := 0; i < 3; i++ {
+		_, err := service.fetchFromProviders(context.Background(), "99999999")
+		assert.ErrorIs(t, err, ErrNotFound)
+	}
+	assert.Equal(t, CircuitClosed, service.CircuitStates()[0].State)
+}
+
+func TestCircuitBreakerDisabled(t *testing.T) {
+	service := NewService(nil, &stubHTTPClient{}, 0, noopLogger())
+	assert.Empty(t, service.CircuitStates())
+}
@@ -0,0 +1,88 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+)
+
+const cepAbertoURL = "https://www.cepaberto.com/api/v3/cep?cep=%s"
+
+// CEPAbertoAuth builds the credentials CEP Aberto expects for token.
+func CEPAbertoAuth(token string) ProviderAuth {
+	return ProviderAuth{APIKey: "Token token=" + token, Header: "Authorization"}
+}
+
+// cepAbertoResponse is the v3 CEP payload of CEP Aberto.
+type cepAbertoResponse struct {
+	Cep         string `json:"cep"`
+	Logradouro  string `json:"logradouro"`
+	Complemento string `json:"complemento"`
+	Bairro      string `json:"bairro"`
+	Latitude    string `json:"latitude"`
+	Longitude   string `json:"longitude"`
+	Cidade      struct {
+		Nome string `json:"nome"`
+		Ibge string `json:"ibge"`
+		DDD  int    `json:"ddd"`
+	} `json:"cidade"`
+	Estado struct {
+		Sigla string `json:"sigla"`
+	} `json:"estado"`
+}
+
+// cepAberto is a built-in provider for cepaberto.com, which requires a token
+// (see CEPAbertoAuth) and, unlike ViaCEP, returns coordinates.
+type cepAberto struct {
+	service   *Service
+	urlFormat string
+}
+
+func (p *cepAberto) String() string { return string(ProviderCEPAberto) }
+
+func (p *cepAberto) Lookup(ctx context.Context, cep string) (*Response, error) {
+	resp, err := p.service.doProviderRequest(ctx, fmt.Sprintf(p.urlFormat, cep), p.service.authFor(ProviderCEPAberto))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("cepaberto returned status %d", resp.StatusCode)
+	}
+
+	var body cepAbertoResponse
+	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty body with status %d", ErrUpstreamBadResponse, resp.StatusCode)
+		}
+		return nil, err
+	}
+	// Unknown CEPs come back as an empty object.
+	if body.Cep == "" {
+		return nil, ErrNotFound
+	}
+
+	result := &Response{
+		Cep:         formatCEP(body.Cep),
+		Logradouro:  body.Logradouro,
+		Complemento: body.Complemento,
+		Bairro:      body.Bairro,
+		Localidade:  body.Cidade.Nome,
+		Uf:          body.Estado.Sigla,
+		Ibge:        body.Cidade.Ibge,
+		Latitude:    body.Latitude,
+		Longitude:   body.Longitude,
+	}
+	if body.Cidade.DDD > 0 {
+		result.DDD = strconv.Itoa(body.Cidade.DDD)
+	}
+	return result, nil
+}
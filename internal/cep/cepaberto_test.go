@@ -0,0 +1,53 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceCEPAbertoProvider(t *testing.T) {
+	for _, tc := range []struct {
+		name    string
+		body    string
+		want    *Response
+		wantErr error
+	}{
+		{
+			name: "found",
+			body: `{"altitude":760.0,"cep":"01001000","latitude":"-23.5479099981","longitude":"-46.636","logradouro":"Praça da Sé","bairro":"Sé","complemento":"- lado ímpar","cidade":{"ddd":11,"ibge":"3550308","nome":"São Paulo"},"estado":{"sigla":"SP"}}`,
+			want: &Response{
+				Cep: "01001-000", Logradouro: "Praça da Sé", Complemento: "- lado ímpar", Bairro: "Sé",
+				Localidade: "São Paulo", Uf: "SP", Ibge: "3550308", DDD: "11",
+				Latitude: "-23.5479099981", Longitude: "-46.636",
+			},
+		},
+		{name: "empty object", body: `{}`, wantErr: ErrNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				assert.Equal(t, "https://www.cepaberto.com/api/v3/cep?cep=01001000", req.URL.String())
+				assert.Equal(t, "Token token=s3cret", req.Header.Get("Authorization"))
+				assert.Empty(t, req.Header.Get("X-API-Key"), "global credentials are not sent to cepaberto")
+				return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(tc.body))}, nil
+			})
+			service := NewService(nil, client, time.Hour, noopLogger(),
+				WithProviderOrder(ProviderCEPAberto),
+				WithProviderAuth(ProviderAuth{APIKey: "global", Header: "X-API-Key"}),
+				WithProviderCredentials(map[ProviderName]ProviderAuth{ProviderCEPAberto: CEPAbertoAuth("s3cret")}))
+
+			resp, err := service.Get(context.Background(), "01001000")
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, tc.want, resp)
+		})
+	}
+}
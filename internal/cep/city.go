@@ -0,0 +1,96 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"sort"
+)
+
+// ErrInvalidIBGE indicates that the municipality code is not 7 digits.
+var ErrInvalidIBGE = errors.New("invalid IBGE code: expected exactly 7 digits")
+
+// BairroGroup lists the cached CEPs of one bairro.
+type BairroGroup struct {
+	Bairro string     `json:"bairro"`
+	CEPs   []Response `json:"ceps"`
+}
+
+// ListByIBGE returns every cached CEP of the municipality with the given IBGE
+// code, grouped by bairro. It only reads the cache and never calls the provider,
+// so the result covers the CEPs that have been looked up so far.
+func (s *Service) ListByIBGE(ctx context.Context, ibge string) ([]BairroGroup, error) {
+	if len(ibge) != 7 || !isDigits(ibge) {
+		return nil, ErrInvalidIBGE
+	}
+
+	var entries []Response
+	if s.db == nil {
+		s.memory.forEach(func(_ string, entry memoryEntry) {
+			if entry.resp.Ibge == ibge {
+				entries = append(entries, entry.resp)
+			}
+		})
+	} else {
+		var err error
+		if entries, err = s.queryByIBGE(ctx, ibge); err != nil {
+			return nil, err
+		}
+	}
+
+	sort.Slice(entries, func(i, j int) bool {
+		if entries[i].Bairro != entries[j].Bairro {
+			return entries[i].Bairro < entries[j].Bairro
+		}
+		return entries[i].Cep < entries[j].Cep
+	})
+
+	groups := []BairroGroup{}
+	for _, entry := range entries {
+		if n := len(groups); n == 0 || groups[n-1].Bairro != entry.Bairro {
+			groups = append(groups, BairroGroup{Bairro: entry.Bairro})
+		}
+		last := &groups[len(groups)-1]
+		last.CEPs = append(last.CEPs, entry)
+	}
+	return groups, nil
+}
+
+// queryByIBGE loads the cached payloads of a municipality. The lookup is served
+// by the expression index on payload->>'ibge'.
+func (s *Service) queryByIBGE(ctx context.Context, ibge string) ([]Response, error) {
+	query := fmt.Sprintf("SELECT cep, payload FROM %s WHERE payload->>'ibge' = $1", s.tableName)
+	rows, err := s.db.QueryContext(ctx, query, ibge)
+	if err != nil {
+		return nil, fmt.Errorf("query ceps by ibge: %w", err)
+	}
+	defer rows.Close()
+
+	var entries []Response
+	for rows.Next() {
+		var (
+			cep     string
+			payload []byte
+			resp    Response
+		)
+		if err := rows.Scan(&cep, &payload); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil, fmt.Errorf("decode cached cep %s: %w", cep, err)
+		}
+		entries = append(entries, resp)
+	}
+	return entries, rows.Err()
+}
+
+// isDigits reports whether value consists only of ASCII digits.
+func isDigits(value string) bool {
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,71 @@
+package cep
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceListByIBGE(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT cep, payload FROM ceps WHERE payload->>'ibge' = \$1`).
+		WithArgs("3550308").
+		WillReturnRows(sqlmock.NewRows([]string{"cep", "payload"}).
+			AddRow("01310100", []byte(`{"cep":"01310-100","bairro":"Bela Vista","ibge":"3550308"}`)).
+			AddRow("01001000", []byte(`{"cep":"01001-000","bairro":"Sé","ibge":"3550308"}`)).
+			AddRow("01311000", []byte(`{"cep":"01311-000","bairro":"Bela Vista","ibge":"3550308"}`)).
+			AddRow("01002000", []byte(`{"cep":"01002-000","bairro":"Sé","ibge":"3550308"}`)))
+
+	groups, err := NewService(db, nil, time.Hour, noopLogger()).ListByIBGE(context.Background(), "3550308")
+	assert.NoError(t, err)
+	assert.Equal(t, []BairroGroup{
+		{Bairro: "Bela Vista", CEPs: []Response{
+			{Cep: "01310-100", Bairro: "Bela Vista", Ibge: "3550308"},
+			{Cep: "01311-000", Bairro: "Bela Vista", Ibge: "3550308"},
+		}},
+		{Bairro: "Sé", CEPs: []Response{
+			{Cep: "01001-000", Bairro: "Sé", Ibge: "3550308"},
+			{Cep: "01002-000", Bairro: "Sé", Ibge: "3550308"},
+		}},
+	}, groups)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceListByIBGEEmptyAndInvalid(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT cep, payload FROM ceps WHERE payload->>'ibge' = \$1`).
+		WithArgs("1100015").
+		WillReturnRows(sqlmock.NewRows([]string{"cep", "payload"}))
+
+	service := NewService(db, nil, time.Hour, noopLogger())
+
+	groups, err := service.ListByIBGE(context.Background(), "1100015")
+	assert.NoError(t, err)
+	assert.Empty(t, groups)
+	assert.NotNil(t, groups)
+
+	_, err = service.ListByIBGE(context.Background(), "35503")
+	assert.ErrorIs(t, err, ErrInvalidIBGE)
+	_, err = service.ListByIBGE(context.Background(), "355030x")
+	assert.ErrorIs(t, err, ErrInvalidIBGE)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceListByIBGEMemory(t *testing.T) {
+	service := NewService(nil, nil, time.Hour, noopLogger())
+	service.memory.set("01001000", Response{Cep: "01001-000", Bairro: "Sé", Ibge: "3550308"}, time.Now())
+	service.memory.set("20040002", Response{Cep: "20040-002", Bairro: "Centro", Ibge: "3304557"}, time.Now())
+
+	groups, err := service.ListByIBGE(context.Background(), "3550308")
+	assert.NoError(t, err)
+	assert.Equal(t, []BairroGroup{{Bairro: "Sé", CEPs: []Response{{Cep: "01001-000", Bairro: "Sé", Ibge: "3550308"}}}}, groups)
+}
@@ -0,0 +1,73 @@
+package cep
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithCoalesceWindow makes lookups for the same CEP share one provider result:
+// callers arriving while a fetch is in flight wait for it, and callers arriving
+// up to window after it finished reuse its outcome instead of fetching again.
+// This absorbs bursts that are staggered by a few milliseconds, which plain
+// in-flight deduplication misses. A window <= 0 disables coalescing.
+func WithCoalesceWindow(window time.Duration) Option {
+	return func(s *Service) {
+		if window > 0 {
+			s.coalescer = &coalescer{window: window, calls: map[string]*coalescedCall{}}
+		}
+	}
+}
+
+// coalescer tracks provider fetches per CEP, in flight or recently finished.
+type coalescer struct {
+	window time.Duration
+
+	mu    sync.Mutex
+	calls map[string]*coalescedCall
+}
+
+// coalescedCall is one provider fetch shared by every caller in its window.
+type coalescedCall struct {
+	done chan struct{}
+	resp *Response
+	err  error
+}
+
+// fetch runs fn for cep unless a shared call is in flight or finished within the
+// window. shared reports whether the result came from another caller's fetch.
+// The leader's fetch does not inherit ctx cancellation, since other callers may
+// depend on it; every caller still stops waiting when its own ctx is done.
+func (c *coalescer) fetch(ctx context.Context, cep string, fn func(context.Context) (*Response, error)) (resp *Response, shared bool, err error) {
+	c.mu.Lock()
+	call, ok := c.calls[cep]
+	if !ok {
+		call = &coalescedCall{done: make(chan struct{})}
+		c.calls[cep] = call
+	}
+	c.mu.Unlock()
+
+	if !ok {
+		call.resp, call.err = fn(context.WithoutCancel(ctx))
+		close(call.done)
+		time.AfterFunc(c.window, func() {
+			c.mu.Lock()
+			defer c.mu.Unlock()
+			if c.calls[cep] == call {
+				delete(c.calls, cep)
+			}
+		})
+	} else {
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, true, ctx.Err()
+		}
+	}
+
+	if call.err != nil {
+		return nil, ok, call.err
+	}
+	copied := *call.resp
+	return &copied, ok, nil
+}
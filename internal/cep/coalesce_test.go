@@ -0,0 +1,135 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceCoalescesStaggeredLookups(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	// Both lookups miss the cache (e.g. a lagging replica), but only the first
+	// reaches the provider and writes the result.
+	noRows := func() *sqlmock.Rows { return sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}) }
+	mock.ExpectQuery(`SELECT payload`).WithArgs("01001000").WillReturnRows(noRows())
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+	mock.ExpectQuery(`SELECT payload`).WithArgs("01001000").WillReturnRows(noRows())
+
+	var calls atomic.Int32
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return okResponse(), nil
+	})
+	service := NewService(db, client, time.Hour, noopLogger(), WithCoalesceWindow(time.Second))
+
+	first, err := service.Lookup(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.True(t, first.ProviderCalled)
+
+	time.Sleep(20 * time.Millisecond) // staggered: the first fetch has already finished
+
+	second, err := service.Lookup(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.False(t, second.ProviderCalled)
+	assert.Equal(t, "01001-000", second.Response.Cep)
+
+	assert.Equal(t, int32(1), calls.Load())
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceCoalesceWindowExpires(t *testing.T) {
+	var calls atomic.Int32
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return okResponse(), nil
+	})
+	// fetchShared is called directly so the memory cache cannot mask the refetch.
+	service := NewService(nil, client, time.Hour, noopLogger(), WithCoalesceWindow(10*time.Millisecond))
+
+	_, _, err := service.fetchShared(context.Background(), "01001000")
+	assert.NoError(t, err)
+	time.Sleep(50 * time.Millisecond)
+	_, shared, err := service.fetchShared(context.Background(), "01001000")
+	assert.NoError(t, err)
+
+	assert.False(t, shared)
+	assert.Equal(t, int32(2), calls.Load())
+}
+
+func TestServiceCoalescesConcurrentLookups(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		calls.Add(1)
+		<-release
+		return okResponse(), nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(), WithCoalesceWindow(50*time.Millisecond))
+
+	var wg sync.WaitGroup
+	for i := 0; i < 8; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, _, err := service.fetchShared(context.Background(), "01001000")
+			assert.NoError(t, err)
+			assert.Equal(t, "01001-000", resp.Cep)
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+
+	assert.Equal(t, int32(1), calls.Load())
+}
+
+func TestServiceCoalescedLookupsShareMismatchRewrite(t *testing.T) {
+	release := make(chan struct{})
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		<-release
+		return &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"01310-000","logradouro":"Avenida Paulista"}`)),
+		}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(),
+		WithCoalesceWindow(50*time.Millisecond), WithMismatchPolicy(MismatchRequested))
+
+	// Every lookup misses the cache while the leader's fetch is blocked, so
+	// followers get the shared answer rather than the leader's cached copy.
+	var wg sync.WaitGroup
+	results := make(chan *Result, 4)
+	for i := 0; i < cap(results); i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			result, err := service.Lookup(context.Background(), "01310999")
+			assert.NoError(t, err)
+			results <- result
+		}()
+	}
+	time.Sleep(20 * time.Millisecond)
+	close(release)
+	wg.Wait()
+	close(results)
+
+	var followers int
+	for result := range results {
+		assert.Equal(t, "01310-999", result.Response.Cep)
+		if !result.ProviderCalled {
+			followers++
+		}
+	}
+	assert.Equal(t, cap(results)-1, followers)
+}
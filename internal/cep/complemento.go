@@ -0,0 +1,79 @@
+package cep
+
+import (
+	"regexp"
+	"strconv"
+	"strings"
+)
+
+// Complemento kinds recognised by ParseComplemento.
+const (
+	ComplementoRange = "range" // "de 1 a 100"
+	ComplementoFrom  = "from"  // "de 201 ao fim"
+	ComplementoUpTo  = "up_to" // "até 200"
+	ComplementoSide  = "side"  // "lado par" on its own
+)
+
+// Sides of the street a CEP may be restricted to.
+const (
+	SideEven = "even"
+	SideOdd  = "odd"
+)
+
+// Complemento is the structured form of ViaCEP's complemento, which describes
+// the house-number range a street CEP covers. Fields stay empty when the value
+// does not follow a known pattern.
+type Complemento struct {
+	Kind string `json:"kind,omitempty"`
+	From int    `json:"from,omitempty"`
+	To   int    `json:"to,omitempty"`
+	Side string `json:"side,omitempty"`
+}
+
+var (
+	complementoSideRe  = regexp.MustCompile(`^(.*?)\s*-?\s*lado (par|impar)$`)
+	complementoRangeRe = regexp.MustCompile(`^de (\d+)(?:/\d+)? ao? (\d+/)?(\d+)$`)
+	complementoFromRe  = regexp.MustCompile(`^de (\d+)(?:/\d+)? ao fim$`)
+	complementoUpToRe  = regexp.MustCompile(`^ate (\d+/)?(\d+)$`)
+
+	complementoFolder = strings.NewReplacer("á", "a", "é", "e", "í", "i", "ó", "o", "ú", "u", "â", "a", "ê", "e", "ô", "o", "ã", "a", "õ", "o", "ç", "c")
+)
+
+// ParseComplemento extracts the number range and street side from values such
+// as "de 612 a 1510 - lado par" or "até 1198/1199". Paired numbers ("1001/1002")
+// list the first even and odd numbers; From takes the lower and To the upper.
+func ParseComplemento(raw string) Complemento {
+	value := complementoFolder.Replace(strings.ToLower(strings.Join(strings.Fields(raw), " ")))
+
+	var parsed Complemento
+	if m := complementoSideRe.FindStringSubmatch(value); m != nil {
+		value = m[1]
+		parsed.Side = SideEven
+		if m[2] == "impar" {
+			parsed.Side = SideOdd
+		}
+	}
+
+	switch {
+	case value == "" && parsed.Side != "":
+		parsed.Kind = ComplementoSide
+	case complementoRangeRe.MatchString(value):
+		m := complementoRangeRe.FindStringSubmatch(value)
+		parsed.Kind, parsed.From, parsed.To = ComplementoRange, atoi(m[1]), atoi(m[3])
+	case complementoFromRe.MatchString(value):
+		m := complementoFromRe.FindStringSubmatch(value)
+		parsed.Kind, parsed.From = ComplementoFrom, atoi(m[1])
+	case complementoUpToRe.MatchString(value):
+		m := complementoUpToRe.FindStringSubmatch(value)
+		parsed.Kind, parsed.To = ComplementoUpTo, atoi(m[2])
+	default:
+		return Complemento{}
+	}
+	return parsed
+}
+
+// atoi converts digits already validated by a pattern.
+func atoi(digits string) int {
+	n, _ := strconv.Atoi(digits)
+	return n
+}
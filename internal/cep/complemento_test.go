@@ -0,0 +1,31 @@
+package cep
+
+import (
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestParseComplemento(t *testing.T) {
+	t.Parallel()
+
+	for _, tc := range []struct {
+		raw  string
+		want Complemento
+	}{
+		{raw: "de 1 a 100", want: Complemento{Kind: ComplementoRange, From: 1, To: 100}},
+		{raw: "até 200", want: Complemento{Kind: ComplementoUpTo, To: 200}},
+		{raw: "de 201 ao fim", want: Complemento{Kind: ComplementoFrom, From: 201}},
+		{raw: "de 612 a 1510 - lado par", want: Complemento{Kind: ComplementoRange, From: 612, To: 1510, Side: SideEven}},
+		{raw: "até 699 - lado ímpar", want: Complemento{Kind: ComplementoUpTo, To: 699, Side: SideOdd}},
+		{raw: "de 1001/1002 a 1699/1700", want: Complemento{Kind: ComplementoRange, From: 1001, To: 1700}},
+		{raw: "até 1198/1199", want: Complemento{Kind: ComplementoUpTo, To: 1199}},
+		{raw: "De 2 ao 98  Lado Par", want: Complemento{Kind: ComplementoRange, From: 2, To: 98, Side: SideEven}},
+		{raw: "lado ímpar", want: Complemento{Kind: ComplementoSide, Side: SideOdd}},
+		{raw: "", want: Complemento{}},
+		{raw: "bloco B", want: Complemento{}},
+		{raw: "km 12 - lado par", want: Complemento{}},
+	} {
+		assert.Equal(t, tc.want, ParseComplemento(tc.raw), "raw=%q", tc.raw)
+	}
+}
@@ -0,0 +1,85 @@
+package cep
+
+import (
+	"context"
+	"sync"
+	"time"
+)
+
+// WithErrorEscalation logs provider failures at error level only while more
+// than threshold of them happened within window; isolated blips stay at warn.
+// The level drops back to warn once the provider recovers. A threshold <= 0
+// keeps every failure at warn.
+func WithErrorEscalation(threshold int, window time.Duration) Option {
+	return func(s *Service) {
+		if threshold > 0 && window > 0 {
+			s.escalation = &failureEscalator{threshold: threshold, window: window}
+		}
+	}
+}
+
+// failureEscalator tracks provider failures in a sliding window.
+type failureEscalator struct {
+	threshold int
+	window    time.Duration
+
+	mu        sync.Mutex
+	failures  []time.Time
+	escalated bool
+}
+
+// failure records a failure at now and reports whether failures are currently
+// escalated, how many fall in the window, and whether this call escalated them.
+func (e *failureEscalator) failure(now time.Time) (escalated bool, count int, changed bool) {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	cutoff := now.Add(-e.window)
+	kept := e.failures[:0]
+	for _, at := range e.failures {
+		if at.After(cutoff) {
+			kept = append(kept, at)
+		}
+	}
+	e.failures = append(kept, now)
+
+	wasEscalated := e.escalated
+	e.escalated = len(e.failures) > e.threshold
+	return e.escalated, len(e.failures), e.escalated && !wasEscalated
+}
+
+// success reports whether a successful call ended an escalation.
+func (e *failureEscalator) success() bool {
+	e.mu.Lock()
+	defer e.mu.Unlock()
+
+	recovered := e.escalated
+	e.escalated = false
+	e.failures = e.failures[:0]
+	return recovered
+}
+
+// logProviderFailure logs a failed provider lookup at the current level.
+func (s *Service) logProviderFailure(ctx context.Context, cep string, err error) {
+	if s.escalation == nil {
+		s.logger.WarnContext(ctx, "provider lookup failed", "cep", cep, "err", err)
+		return
+	}
+
+	escalated, count, changed := s.escalation.failure(s.now())
+	if changed {
+		s.logger.ErrorContext(ctx, "provider failures escalated", "failures", count, "window", s.escalation.window)
+	}
+	if escalated {
+		s.logger.ErrorContext(ctx, "provider lookup failed", "cep", cep, "failures", count, "window", s.escalation.window, "err", err)
+		return
+	}
+	s.logger.WarnContext(ctx, "provider lookup failed", "cep", cep, "err", err)
+}
+
+// logProviderSuccess de-escalates after a failure streak.
+func (s *Service) logProviderSuccess(ctx context.Context) {
+	if s.escalation != nil && s.escalation.success() {
+		s.logger.InfoContext(ctx, "provider recovered, failure logging back to warn")
+	}
+}
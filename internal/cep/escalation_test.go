@@ -0,0 +1,103 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"log/slog"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
+)
+
+func TestServiceErrorEscalation(t *testing.T) {
+	failing := true
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		if failing {
+			return nil, errors.New("connection refused")
+		}
+		return okResponse(), nil
+	})
+
+	var logs strings.Builder
+	service := NewService(nil, client, time.Hour, textLogger(&logs), WithErrorEscalation(3, time.Minute))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	lookup := func() string {
+		logs.Reset()
+		_, _ = service.Get(context.Background(), "01001000")
+		now = now.Add(time.Second)
+		return logs.String()
+	}
+
+	// A short blip stays at warn.
+	for i := 0; i < 3; i++ {
+		assert.True(t, strings.HasPrefix(lookup(), `level=WARN msg="provider lookup failed" cep=01001000`))
+	}
+
+	// The fourth failure within the window crosses the threshold.
+	escalated := lookup()
+	assert.Contains(t, escalated, `level=ERROR msg="provider failures escalated" failures=4 window=1m0s`)
+	assert.Contains(t, escalated, `level=ERROR msg="provider lookup failed" cep=01001000 failures=4 window=1m0s`)
+	assert.True(t, strings.HasPrefix(lookup(), `level=ERROR msg="provider lookup failed"`))
+
+	// Recovery de-escalates, and the next failure is back at warn.
+	failing = false
+	assert.Contains(t, lookup(), `level=INFO msg="provider recovered`)
+	service.memory = newMemoryCache()
+	failing = true
+	assert.True(t, strings.HasPrefix(lookup(), `level=WARN msg="provider lookup failed"`))
+}
+
+func TestServiceErrorEscalationWindow(t *testing.T) {
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+
+	var logs strings.Builder
+	service := NewService(nil, client, time.Hour, textLogger(&logs), WithErrorEscalation(2, time.Minute))
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	service.now = func() time.Time { return now }
+
+	// Failures spread wider than the window never escalate.
+	for i := 0; i < 6; i++ {
+		_, _ = service.Get(context.Background(), "01001000")
+		now = now.Add(45 * time.Second)
+	}
+	assert.NotContains(t, logs.String(), "level=ERROR")
+}
+
+// requestIDHandler tags records with the request ID of the context they were
+// logged with, like the application logger does.
+type requestIDHandler struct{ slog.Handler }
+
+func (h requestIDHandler) Handle(ctx context.Context, record slog.Record) error {
+	if id, ok := requestid.FromContext(ctx); ok {
+		record.AddAttrs(slog.String("request_id", id))
+	}
+	return h.Handler.Handle(ctx, record)
+}
+
+func TestServiceProviderFailureLogsCarryRequestID(t *testing.T) {
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return nil, errors.New("connection refused")
+	})
+	var logs strings.Builder
+	logger := slog.New(requestIDHandler{textLogger(&logs).Handler()})
+	service := NewService(nil, client, time.Hour, logger,
+		WithRetries(1, time.Millisecond, time.Millisecond),
+		WithErrorEscalation(3, time.Minute))
+
+	_, err := service.Get(requestid.NewContext(context.Background(), "req-1"), "01001000")
+	assert.Error(t, err)
+
+	lines := strings.Split(strings.TrimSpace(logs.String()), "\n")
+	assert.Len(t, lines, 2, "one retry and one failure")
+	for _, line := range lines {
+		assert.Contains(t, line, "request_id=req-1")
+	}
+}
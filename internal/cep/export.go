@@ -0,0 +1,121 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"strings"
+	"time"
+)
+
+// defaultExportPageSize bounds how many rows each keyset page loads.
+const defaultExportPageSize = 1000
+
+// ErrInvalidCursor is returned when an export cursor cannot be parsed.
+var ErrInvalidCursor = errors.New("invalid export cursor")
+
+// ExportCursor identifies a position in the (updated_at, cep) export order.
+type ExportCursor struct {
+	UpdatedAt time.Time
+	CEP       string
+}
+
+// String renders the cursor as "<updated_at RFC3339Nano>,<cep>", matching the
+// first two columns of an exported CSV row.
+func (c ExportCursor) String() string {
+	return c.UpdatedAt.UTC().Format(time.RFC3339Nano) + "," + c.CEP
+}
+
+// ParseExportCursor parses the representation produced by ExportCursor.String.
+func ParseExportCursor(value string) (ExportCursor, error) {
+	ts, cep, ok := strings.Cut(value, ",")
+	if !ok {
+		return ExportCursor{}, ErrInvalidCursor
+	}
+
+	updatedAt, err := time.Parse(time.RFC3339Nano, strings.TrimSpace(ts))
+	if err != nil {
+		return ExportCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	digits, err := normalizeCEP(cep)
+	if err != nil {
+		return ExportCursor{}, fmt.Errorf("%w: %v", ErrInvalidCursor, err)
+	}
+
+	return ExportCursor{UpdatedAt: updatedAt, CEP: digits}, nil
+}
+
+// ExportRow is a single cached entry yielded by Export.
+type ExportRow struct {
+	Cursor   ExportCursor
+	Response Response
+}
+
+// Export streams every cached entry in (updated_at, cep) order to fn, starting
+// after the given cursor when non-nil. Rows are loaded one keyset page at a time,
+// so memory stays bounded regardless of table size.
+func (s *Service) Export(ctx context.Context, after *ExportCursor, fn func(ExportRow) error) error {
+	if s.db == nil {
+		return ErrNoDatabase
+	}
+
+	pageSize := s.exportPageSize
+	if pageSize <= 0 {
+		pageSize = defaultExportPageSize
+	}
+
+	for {
+		n, last, err := s.exportPage(ctx, after, pageSize, fn)
+		if err != nil {
+			return err
+		}
+		if n < pageSize {
+			return nil
+		}
+		after = &last
+	}
+}
+
+func (s *Service) exportPage(ctx context.Context, after *ExportCursor, limit int, fn func(ExportRow) error) (int, ExportCursor, error) {
+	query := fmt.Sprintf("SELECT cep, payload, updated_at FROM %s ORDER BY updated_at, cep LIMIT $1", s.tableName)
+	args := []any{limit}
+	if after != nil {
+		query = fmt.Sprintf(
+			"SELECT cep, payload, updated_at FROM %s WHERE (updated_at, cep) > ($2, $3) ORDER BY updated_at, cep LIMIT $1",
+			s.tableName,
+		)
+		args = append(args, after.UpdatedAt, after.CEP)
+	}
+
+	rows, err := s.db.QueryContext(ctx, query, args...)
+	if err != nil {
+		return 0, ExportCursor{}, fmt.Errorf("query export page: %w", err)
+	}
+	defer rows.Close()
+
+	var (
+		n    int
+		last ExportCursor
+	)
+	for rows.Next() {
+		var (
+			row     ExportRow
+			payload []byte
+		)
+		if err := rows.Scan(&row.Cursor.CEP, &payload, &row.Cursor.UpdatedAt); err != nil {
+			return n, last, err
+		}
+		if err := json.Unmarshal(payload, &row.Response); err != nil {
+			return n, last, fmt.Errorf("decode cached cep %s: %w", row.Cursor.CEP, err)
+		}
+		if err := fn(row); err != nil {
+			return n, last, err
+		}
+		n++
+		last = row.Cursor
+	}
+
+	return n, last, rows.Err()
+}
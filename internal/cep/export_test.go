@@ -0,0 +1,84 @@
+package cep
+
+import (
+	"context"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestExportCursorRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	cursor := ExportCursor{UpdatedAt: time.Date(2024, 1, 2, 3, 4, 5, 600, time.UTC), CEP: "01001000"}
+
+	parsed, err := ParseExportCursor(cursor.String())
+	assert.NoError(t, err)
+	assert.True(t, cursor.UpdatedAt.Equal(parsed.UpdatedAt))
+	assert.Equal(t, cursor.CEP, parsed.CEP)
+
+	_, err = ParseExportCursor("yesterday,01001000")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+	_, err = ParseExportCursor("01001000")
+	assert.ErrorIs(t, err, ErrInvalidCursor)
+}
+
+func TestServiceExport(t *testing.T) {
+	base := time.Date(2024, 1, 1, 0, 0, 0, 0, time.UTC)
+	cols := []string{"cep", "payload", "updated_at"}
+
+	t.Run("initial export pages through keyset", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		mock.ExpectQuery(`SELECT cep, payload, updated_at FROM ceps ORDER BY updated_at, cep LIMIT \$1`).
+			WithArgs(2).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("01001000", []byte(`{"cep":"01001-000"}`), base).
+				AddRow("01002000", []byte(`{"cep":"01002-000"}`), base.Add(time.Second)))
+		mock.ExpectQuery(`SELECT cep, payload, updated_at FROM ceps WHERE \(updated_at, cep\) > \(\$2, \$3\) ORDER BY updated_at, cep LIMIT \$1`).
+			WithArgs(2, base.Add(time.Second), "01002000").
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("01003000", []byte(`{"cep":"01003-000"}`), base.Add(2*time.Second)))
+
+		service := NewService(db, nil, time.Hour, noopLogger())
+		service.exportPageSize = 2
+
+		var got []string
+		err = service.Export(context.Background(), nil, func(row ExportRow) error {
+			got = append(got, row.Response.Cep)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, []string{"01001-000", "01002-000", "01003-000"}, got)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("resumes after cursor", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		after := ExportCursor{UpdatedAt: base.Add(time.Second), CEP: "01002000"}
+
+		mock.ExpectQuery(`SELECT cep, payload, updated_at FROM ceps WHERE \(updated_at, cep\) > \(\$2, \$3\)`).
+			WithArgs(defaultExportPageSize, after.UpdatedAt, after.CEP).
+			WillReturnRows(sqlmock.NewRows(cols).
+				AddRow("01003000", []byte(`{"cep":"01003-000"}`), base.Add(2*time.Second)))
+
+		service := NewService(db, nil, time.Hour, noopLogger())
+
+		var got []ExportRow
+		err = service.Export(context.Background(), &after, func(row ExportRow) error {
+			got = append(got, row)
+			return nil
+		})
+		assert.NoError(t, err)
+		assert.Len(t, got, 1)
+		assert.Equal(t, "01003000", got[0].Cursor.CEP)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
@@ -0,0 +1,93 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+)
+
+// ProviderStrategy controls how a Service with several providers uses them.
+type ProviderStrategy string
+
+// Supported provider strategies.
+const (
+	// ProviderStrategyFallback asks providers one at a time, in order.
+	ProviderStrategyFallback ProviderStrategy = "fallback"
+	// ProviderStrategyParallel asks every provider at once and keeps the first
+	// answer, trading extra upstream traffic for tail latency.
+	ProviderStrategyParallel ProviderStrategy = "parallel"
+	// ProviderStrategyP2C picks two providers at random and asks the one with
+	// the lower rolling latency, falling back to the other on failure.
+	ProviderStrategyP2C ProviderStrategy = "p2c"
+)
+
+// WithProviderStrategy selects how providers are consulted. Defaults to
+// ProviderStrategyFallback.
+func WithProviderStrategy(strategy ProviderStrategy) Option {
+	return func(s *Service) {
+		s.providerStrategy = strategy
+	}
+}
+
+// providerOutcome is the answer of one provider in a race.
+type providerOutcome struct {
+	resp *Response
+	err  error
+}
+
+// raceProviders queries every provider concurrently and returns the first
+// successful response, cancelling the others. A not-found answer only wins when
+// no provider finds the CEP; otherwise the last error is returned.
+func (s *Service) raceProviders(ctx context.Context, cep string) (*Response, error) {
+	ctx, cancel := context.WithCancel(ctx)
+	defer cancel()
+
+	outcomes := make(chan providerOutcome, len(s.providers))
+	for i, provider := range s.providers {
+		go func(i int, provider Provider) {
+			start := s.now()
+			resp, err := provider.Lookup(ctx, cep)
+			if ctx.Err() == nil {
+				s.recordProviderCall(ctx, i, s.now().Sub(start), err)
+			}
+			outcomes <- providerOutcome{resp: resp, err: err}
+		}(i, provider)
+	}
+
+	var lastErr error
+	notFound := false
+	for range s.providers {
+		outcome := <-outcomes
+		if outcome.err == nil {
+			return outcome.resp, nil
+		}
+		if errors.Is(outcome.err, ErrNotFound) {
+			notFound = true
+			continue
+		}
+		lastErr = outcome.err
+	}
+	if notFound {
+		return nil, ErrNotFound
+	}
+	return nil, lastErr
+}
+
+// twoChoices implements the power of two choices: it draws two distinct
+// providers at random and orders them by rolling latency, fastest first.
+// Providers without samples go first so that each one gets measured.
+func (s *Service) twoChoices() []int {
+	a := rand.IntN(len(s.providers))
+	b := rand.IntN(len(s.providers) - 1)
+	if b >= a {
+		b++
+	}
+
+	sa, sb := s.scores[a].snapshot(), s.scores[b].snapshot()
+	switch {
+	case sa.Samples == 0:
+	case sb.Samples == 0, sb.LatencyMS < sa.LatencyMS:
+		a, b = b, a
+	}
+	return []int{a, b}
+}
@@ -0,0 +1,109 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceParallelProvidersFirstAnswerWins(t *testing.T) {
+	slowCancelled := make(chan struct{})
+	slow := ProviderFunc(func(ctx context.Context, _ string) (*Response, error) {
+		<-ctx.Done()
+		close(slowCancelled)
+		return nil, ctx.Err()
+	})
+	fast := ProviderFunc(func(context.Context, string) (*Response, error) {
+		return &Response{Localidade: "São Paulo"}, nil
+	})
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(slow, fast), WithProviderStrategy(ProviderStrategyParallel))
+
+	resp, err := service.Get(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, "São Paulo", resp.Localidade)
+	assert.Equal(t, "01001-000", resp.Cep)
+
+	select {
+	case <-slowCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("slow provider was not cancelled")
+	}
+}
+
+func TestServiceParallelProvidersFailures(t *testing.T) {
+	failing := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, errors.New("connection refused") })
+	notFound := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, ErrNotFound })
+	found := ProviderFunc(func(context.Context, string) (*Response, error) {
+		time.Sleep(10 * time.Millisecond)
+		return &Response{Uf: "SP"}, nil
+	})
+
+	for _, tc := range []struct {
+		name      string
+		providers []Provider
+		wantErr   string
+	}{
+		{name: "not found does not beat a later answer", providers: []Provider{notFound, found}},
+		{name: "not found over failures", providers: []Provider{failing, notFound}, wantErr: ErrNotFound.Error()},
+		{name: "all failing", providers: []Provider{failing, failing}, wantErr: "connection refused"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			service := NewService(nil, nil, time.Hour, noopLogger(),
+				WithProviders(tc.providers...), WithProviderStrategy(ProviderStrategyParallel))
+
+			resp, err := service.Get(context.Background(), "01001000")
+			if tc.wantErr != "" {
+				assert.EqualError(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "SP", resp.Uf)
+		})
+	}
+}
+
+func TestServiceP2CPrefersFasterProvider(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var slowCalls, fastCalls int
+	// Each stub advances the service clock by its simulated latency.
+	slow := ProviderFunc(func(context.Context, string) (*Response, error) {
+		slowCalls++
+		now = now.Add(80 * time.Millisecond)
+		return &Response{Uf: "SP"}, nil
+	})
+	fast := ProviderFunc(func(context.Context, string) (*Response, error) {
+		fastCalls++
+		now = now.Add(10 * time.Millisecond)
+		return &Response{Uf: "SP"}, nil
+	})
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(slow, fast), WithProviderStrategy(ProviderStrategyP2C))
+	service.now = func() time.Time { return now }
+
+	for i := 0; i < 200; i++ {
+		_, err := service.fetchFromProviders(context.Background(), "01001000")
+		assert.NoError(t, err)
+	}
+
+	// Only the first call, before either provider has a sample, may go to the
+	// slow one.
+	assert.LessOrEqual(t, slowCalls, 1)
+	assert.Equal(t, 200, slowCalls+fastCalls)
+}
+
+func TestServiceP2CFallsBackToOtherChoice(t *testing.T) {
+	failing := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, errors.New("connection refused") })
+	found := ProviderFunc(func(context.Context, string) (*Response, error) { return &Response{Uf: "SP"}, nil })
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(failing, found), WithProviderStrategy(ProviderStrategyP2C))
+
+	for i := 0; i < 20; i++ {
+		resp, err := service.fetchFromProviders(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.Equal(t, "SP", resp.Uf)
+	}
+}
@@ -0,0 +1,69 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"strings"
+	"unicode/utf8"
+)
+
+// Full-text search limits.
+const (
+	DefaultFullTextLimit = 20
+	MaxFullTextLimit     = 100
+)
+
+// FullTextSearch finds cached CEPs whose street, bairro or city match q, using
+// the search_vector column (Portuguese stemming, accents ignored). q accepts
+// web search syntax: quoted phrases, "or" and a leading "-" to exclude words.
+// Street matches rank above bairro and city matches. It needs PostgreSQL.
+func (s *Service) FullTextSearch(ctx context.Context, q string, limit int) ([]Response, error) {
+	if s.db == nil {
+		return nil, ErrNoDatabase
+	}
+
+	q = strings.TrimSpace(q)
+	maxLength := s.searchMaxLength
+	if maxLength <= 0 {
+		maxLength = DefaultSearchMaxLength
+	}
+	if length := utf8.RuneCountInString(q); length == 0 || length > maxLength {
+		return nil, fmt.Errorf("%w: q must have between 1 and %d characters", ErrInvalidSearch, maxLength)
+	}
+	switch {
+	case limit <= 0:
+		limit = DefaultFullTextLimit
+	case limit > MaxFullTextLimit:
+		limit = MaxFullTextLimit
+	}
+
+	query := fmt.Sprintf(`
+SELECT cep, payload
+FROM %s, websearch_to_tsquery('portuguese', gocep_unaccent($1)) AS query
+WHERE search_vector @@ query
+ORDER BY ts_rank(search_vector, query) DESC, cep
+LIMIT $2`, s.tableName)
+	rows, err := s.db.QueryContext(ctx, query, q, limit)
+	if err != nil {
+		return nil, fmt.Errorf("full-text search: %w", err)
+	}
+	defer rows.Close()
+
+	results := []Response{}
+	for rows.Next() {
+		var (
+			cep     string
+			payload []byte
+			resp    Response
+		)
+		if err := rows.Scan(&cep, &payload); err != nil {
+			return nil, err
+		}
+		if err := json.Unmarshal(payload, &resp); err != nil {
+			return nil, fmt.Errorf("decode cached cep %s: %w", cep, err)
+		}
+		results = append(results, resp)
+	}
+	return results, rows.Err()
+}
@@ -0,0 +1,45 @@
+package cep
+
+import (
+	"context"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceFullTextSearch(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`websearch_to_tsquery\('portuguese', gocep_unaccent\(\$1\)\) AS query\s+WHERE search_vector @@ query`).
+		WithArgs("praca da se", DefaultFullTextLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"cep", "payload"}).
+			AddRow("01001000", []byte(`{"cep":"01001-000","logradouro":"Praça da Sé","bairro":"Sé"}`)))
+	mock.ExpectQuery(`search_vector @@ query`).
+		WithArgs("paulista", MaxFullTextLimit).
+		WillReturnRows(sqlmock.NewRows([]string{"cep", "payload"}))
+
+	service := NewService(db, nil, time.Hour, noopLogger())
+
+	results, err := service.FullTextSearch(context.Background(), " praca da se ", 0)
+	assert.NoError(t, err)
+	assert.Equal(t, []Response{{Cep: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé"}}, results)
+
+	results, err = service.FullTextSearch(context.Background(), "paulista", 1000)
+	assert.NoError(t, err)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+
+	_, err = service.FullTextSearch(context.Background(), "  ", 0)
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+	_, err = service.FullTextSearch(context.Background(), strings.Repeat("a", DefaultSearchMaxLength+1), 0)
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	_, err = NewService(nil, nil, time.Hour, noopLogger()).FullTextSearch(context.Background(), "paulista", 0)
+	assert.ErrorIs(t, err, ErrNoDatabase)
+}
@@ -0,0 +1,125 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
+)
+
+// WithHedging sends a second, identical provider request when the first has not
+// answered within delay, and uses whichever answers first. At most maxInFlight
+// hedged requests run at once across the Service, so a slow upstream is never
+// hit with twice the traffic. A delay <= 0 disables hedging.
+func WithHedging(delay time.Duration, maxInFlight int) Option {
+	return func(s *Service) {
+		s.hedgeDelay = delay
+		if delay > 0 && maxInFlight > 0 {
+			s.hedgeSlots = make(chan struct{}, maxInFlight)
+		}
+	}
+}
+
+// attempt is the outcome of one provider request.
+type attempt struct {
+	id   int
+	resp *http.Response
+	err  error
+}
+
+// hedgedRequest performs a GET to a provider URL, hedging it when enabled.
+// The returned body must be closed by the caller.
+func (s *Service) hedgedRequest(ctx context.Context, url string, auth ProviderAuth) (*http.Response, error) {
+	if s.hedgeDelay <= 0 || s.hedgeSlots == nil {
+		return s.sendProviderRequest(ctx, url, auth)
+	}
+
+	results := make(chan attempt, 2)
+	cancels := make([]context.CancelFunc, 0, 2)
+	launch := func(release func()) {
+		attemptCtx, cancel := context.WithCancel(ctx)
+		id := len(cancels)
+		cancels = append(cancels, cancel)
+		go func() {
+			if release != nil {
+				defer release()
+			}
+			resp, err := s.sendProviderRequest(attemptCtx, url, auth)
+			results <- attempt{id: id, resp: resp, err: err}
+		}()
+	}
+
+	launch(nil)
+	pending := 1
+
+	timer := time.NewTimer(s.hedgeDelay)
+	defer timer.Stop()
+
+	for {
+		select {
+		case <-timer.C:
+			select {
+			case s.hedgeSlots <- struct{}{}:
+				s.counters.hedges.Add(1)
+				launch(func() { <-s.hedgeSlots })
+				pending++
+			default:
+				// Hedge budget exhausted; keep waiting on the original request.
+			}
+		case res := <-results:
+			pending--
+			if res.err != nil && pending > 0 {
+				// The other request may still succeed.
+				continue
+			}
+			for id, cancel := range cancels {
+				if id != res.id {
+					cancel()
+				}
+			}
+			go discardAttempts(results, pending)
+			if res.err != nil {
+				cancels[res.id]()
+				return nil, res.err
+			}
+			res.resp.Body = &cancelOnClose{ReadCloser: res.resp.Body, cancel: cancels[res.id]}
+			return res.resp, nil
+		}
+	}
+}
+
+// discardAttempts closes the responses of requests that lost the hedge race.
+func discardAttempts(results <-chan attempt, pending int) {
+	for ; pending > 0; pending-- {
+		if res := <-results; res.err == nil {
+			res.resp.Body.Close()
+		}
+	}
+}
+
+// sendProviderRequest performs a single provider request, forwarding the
+// caller's request ID so the call can be matched in the provider's logs.
+func (s *Service) sendProviderRequest(ctx context.Context, url string, auth ProviderAuth) (*http.Response, error) {
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
+	if err != nil {
+		return nil, err
+	}
+	if id, ok := requestid.FromContext(ctx); ok {
+		req.Header.Set(requestid.Header, id)
+	}
+	authorize(req, auth)
+	return s.client.Do(req)
+}
+
+// cancelOnClose releases the winning request's context once its body is consumed.
+type cancelOnClose struct {
+	io.ReadCloser
+	cancel context.CancelFunc
+}
+
+func (c *cancelOnClose) Close() error {
+	defer c.cancel()
+	return c.ReadCloser.Close()
+}
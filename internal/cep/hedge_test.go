@@ -0,0 +1,83 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func okResponse() *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`))}
+}
+
+func TestServiceHedgingFiresForSlowRequest(t *testing.T) {
+	var calls atomic.Int32
+	firstCancelled := make(chan struct{})
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		if calls.Add(1) == 1 {
+			// The original request hangs until the hedge wins and cancels it.
+			<-req.Context().Done()
+			close(firstCancelled)
+			return nil, req.Context().Err()
+		}
+		return okResponse(), nil
+	})
+
+	service := NewService(nil, client, time.Hour, noopLogger(), WithHedging(10*time.Millisecond, 1))
+	resp, err := service.Get(context.Background(), "01001000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, "01001-000", resp.Cep)
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Equal(t, uint64(1), service.Metrics().Hedges)
+
+	select {
+	case <-firstCancelled:
+	case <-time.After(time.Second):
+		t.Fatal("slow request was not cancelled after the hedge won")
+	}
+}
+
+func TestServiceHedgingSkippedForFastRequest(t *testing.T) {
+	var calls atomic.Int32
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		return okResponse(), nil
+	})
+
+	service := NewService(nil, client, time.Hour, noopLogger(), WithHedging(time.Second, 1))
+	_, err := service.Get(context.Background(), "01001000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, uint64(0), service.Metrics().Hedges)
+}
+
+func TestServiceHedgingRespectsInFlightCap(t *testing.T) {
+	var calls atomic.Int32
+	release := make(chan struct{})
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		calls.Add(1)
+		<-release
+		return okResponse(), nil
+	})
+
+	service := NewService(nil, client, time.Hour, noopLogger(), WithHedging(5*time.Millisecond, 1))
+	service.hedgeSlots <- struct{}{} // another lookup already holds the only slot
+
+	go func() {
+		time.Sleep(50 * time.Millisecond)
+		close(release)
+	}()
+	_, err := service.Get(context.Background(), "01001000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, int32(1), calls.Load())
+	assert.Equal(t, uint64(0), service.Metrics().Hedges)
+}
@@ -0,0 +1,106 @@
+package cep
+
+import (
+	"context"
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+// generations versions each CEP's cache entry so that a lookup which started
+// before an invalidation cannot write its (now stale) result back afterwards.
+// Writes hold the CEP's own lock shared while checking and persisting,
+// invalidations exclusively, so the check and the write are atomic with respect
+// to Invalidate while a slow statement for one CEP never holds up another. A
+// CEP is only tracked while a lookup or invalidation of it is in flight.
+// Generations are per process; each replica protects its own in-flight lookups.
+type generations struct {
+	mu   sync.Mutex
+	byID map[string]*generation
+}
+
+// generation is the state of one CEP, shared by the tickets taken on it.
+type generation struct {
+	mu      sync.RWMutex
+	version atomic.Uint64
+	holders int // guarded by generations.mu
+}
+
+// ticket is a claim on a CEP's generation, taken before a provider fetch and
+// released once its result has been written.
+type ticket struct {
+	cep     string
+	gen     *generation
+	version uint64
+}
+
+func newGenerations() *generations {
+	return &generations{byID: map[string]*generation{}}
+}
+
+// begin returns the ticket a lookup should carry to its cache write. Every
+// ticket must be passed to end.
+func (g *generations) begin(cep string) ticket {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	gen, ok := g.byID[cep]
+	if !ok {
+		gen = &generation{}
+		g.byID[cep] = gen
+	}
+	gen.holders++
+	return ticket{cep: cep, gen: gen, version: gen.version.Load()}
+}
+
+// end releases t, forgetting the CEP once nothing in flight refers to it.
+func (g *generations) end(t ticket) {
+	g.mu.Lock()
+	defer g.mu.Unlock()
+
+	t.gen.holders--
+	if t.gen.holders == 0 {
+		delete(g.byID, t.cep)
+	}
+}
+
+// Invalidate deletes the cached entry for a CEP and makes sure lookups already
+// in flight for it do not write their result back. It reports whether an entry
+// was removed.
+func (s *Service) Invalidate(ctx context.Context, rawCEP string) (bool, error) {
+	cepDigits, err := s.normalize(rawCEP)
+	if err != nil {
+		return false, err
+	}
+
+	t := s.generations.begin(cepDigits)
+	defer s.generations.end(t)
+	t.gen.mu.Lock()
+	defer t.gen.mu.Unlock()
+	t.gen.version.Add(1)
+
+	if s.db == nil {
+		return s.memory.delete(cepDigits), nil
+	}
+
+	query := fmt.Sprintf("DELETE FROM %s WHERE cep = $1", s.tableName)
+	res, err := s.db.ExecContext(ctx, query, cepDigits)
+	if err != nil {
+		return false, fmt.Errorf("invalidate cep %s: %w", cepDigits, err)
+	}
+	n, err := res.RowsAffected()
+	return n > 0, err
+}
+
+// saveIfCurrent writes data under t's CEP unless the CEP was invalidated after
+// t was taken. It reports whether the write was attempted.
+func (s *Service) saveIfCurrent(ctx context.Context, t ticket, data *Response, fetchedAt time.Time, mode UpsertMode) (bool, error) {
+	t.gen.mu.RLock()
+	defer t.gen.mu.RUnlock()
+
+	if t.gen.version.Load() != t.version {
+		return false, nil
+	}
+	return true, s.saveToCache(ctx, t.cep, data, fetchedAt, mode)
+}
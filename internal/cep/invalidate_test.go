@@ -0,0 +1,130 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceInvalidate(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectExec(`DELETE FROM ceps WHERE cep = \$1`).WithArgs("01001000").WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec(`DELETE FROM ceps WHERE cep = \$1`).WithArgs("01001000").WillReturnResult(sqlmock.NewResult(0, 0))
+
+	service := NewService(db, nil, time.Hour, noopLogger())
+
+	deleted, err := service.Invalidate(context.Background(), "01001-000")
+	assert.NoError(t, err)
+	assert.True(t, deleted)
+
+	deleted, err = service.Invalidate(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.False(t, deleted)
+
+	_, err = service.Invalidate(context.Background(), "123")
+	assert.ErrorIs(t, err, ErrInvalidCEP)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceInvalidateDuringLookupSticks(t *testing.T) {
+	t.Run("postgres", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		mock.ExpectQuery(`SELECT payload`).WithArgs("01001000").
+			WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+		mock.ExpectExec(`DELETE FROM ceps WHERE cep = \$1`).WithArgs("01001000").WillReturnResult(sqlmock.NewResult(0, 1))
+		// No INSERT: the fetched value must not be written back after the delete.
+
+		var service *Service
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			// The invalidation lands while the provider request is in flight.
+			_, err := service.Invalidate(context.Background(), "01001000")
+			assert.NoError(t, err)
+			return okResponse(), nil
+		})
+		var logs strings.Builder
+		service = NewService(db, client, time.Hour, textLogger(&logs))
+
+		resp, err := service.Get(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.Equal(t, "01001-000", resp.Cep)
+		assert.Contains(t, logs.String(), "invalidated during lookup")
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		var service *Service
+		client := clientFunc(func(req *http.Request) (*http.Response, error) {
+			_, err := service.Invalidate(context.Background(), "01001000")
+			assert.NoError(t, err)
+			return okResponse(), nil
+		})
+		service = NewService(nil, client, time.Hour, noopLogger())
+
+		_, err := service.Get(context.Background(), "01001000")
+		assert.NoError(t, err)
+
+		info, err := service.Inspect(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.False(t, info.Cached)
+
+		// Lookups that start after the invalidation cache normally again.
+		client = clientFunc(func(req *http.Request) (*http.Response, error) { return okResponse(), nil })
+		service.client = client
+		_, err = service.Get(context.Background(), "01001000")
+		assert.NoError(t, err)
+		info, err = service.Inspect(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.True(t, info.Cached)
+	})
+}
+
+func TestServiceSlowInvalidateDoesNotBlockOtherCEPs(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.MatchExpectationsInOrder(false)
+
+	mock.ExpectExec(`DELETE FROM ceps WHERE cep = \$1`).WithArgs("01001000").
+		WillDelayFor(500 * time.Millisecond).WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectQuery(`SELECT payload`).WithArgs("20040002").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(0, 1))
+
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"20040-002"}`))}, nil
+	})
+	service := NewService(db, client, time.Hour, noopLogger())
+
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		_, err := service.Invalidate(context.Background(), "01001000")
+		assert.NoError(t, err)
+	}()
+	time.Sleep(50 * time.Millisecond)
+
+	_, err = service.Get(context.Background(), "20040002")
+	assert.NoError(t, err)
+	select {
+	case <-done:
+		t.Fatal("lookup of another CEP waited for the DELETE")
+	default:
+	}
+
+	<-done
+	assert.NoError(t, mock.ExpectationsWereMet())
+	// Nothing is in flight any more, so no generation is kept.
+	assert.Empty(t, service.generations.byID)
+}
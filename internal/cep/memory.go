@@ -0,0 +1,70 @@
+package cep
+
+import (
+	"sync"
+	"time"
+)
+
+// memoryEntry is a cached response kept in process memory.
+type memoryEntry struct {
+	resp      Response
+	updatedAt time.Time
+}
+
+// memoryCache is the in-process cache used when Service runs without PostgreSQL.
+// It is unbounded and lost on restart, which is fine for demos and local runs.
+type memoryCache struct {
+	mu      sync.RWMutex
+	entries map[string]memoryEntry
+}
+
+func newMemoryCache() *memoryCache {
+	return &memoryCache{entries: map[string]memoryEntry{}}
+}
+
+func (c *memoryCache) get(cep string) (memoryEntry, bool) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	entry, ok := c.entries[cep]
+	return entry, ok
+}
+
+// set stores resp under cep unless the entry already holds data fetched after
+// updatedAt, mirroring the updated_at guard of the Postgres upsert.
+func (c *memoryCache) set(cep string, resp Response, updatedAt time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	if entry, ok := c.entries[cep]; ok && entry.updatedAt.After(updatedAt) {
+		return
+	}
+	c.entries[cep] = memoryEntry{resp: resp, updatedAt: updatedAt}
+}
+
+// delete removes cep and reports whether it was present.
+func (c *memoryCache) delete(cep string) bool {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	_, ok := c.entries[cep]
+	delete(c.entries, cep)
+	return ok
+}
+
+// forEach calls fn for every entry while holding the read lock.
+func (c *memoryCache) forEach(fn func(cep string, entry memoryEntry)) {
+	c.mu.RLock()
+	defer c.mu.RUnlock()
+	for cep, entry := range c.entries {
+		fn(cep, entry)
+	}
+}
+
+// expireAll marks every entry stale and returns how many were touched.
+func (c *memoryCache) expireAll() int64 {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	for cep, entry := range c.entries {
+		entry.updatedAt = expiredAt
+		c.entries[cep] = entry
+	}
+	return int64(len(c.entries))
+}
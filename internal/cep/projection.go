@@ -0,0 +1,64 @@
+package cep
+
+import (
+	"context"
+	"database/sql"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// DDDResult is the narrow projection served by the DDD endpoint.
+type DDDResult struct {
+	Cep string `json:"cep"`
+	DDD string `json:"ddd"`
+}
+
+// LookupDDD returns only the DDD of a CEP. Fresh cache hits are projected in
+// PostgreSQL (payload->>'ddd') so the rest of the payload never crosses the
+// wire; misses and stale rows fall back to a full Lookup, which refreshes the
+// cache as usual.
+func (s *Service) LookupDDD(ctx context.Context, rawCEP string) (*DDDResult, error) {
+	cepDigits, err := s.normalize(rawCEP)
+	if err != nil {
+		return nil, err
+	}
+
+	if s.db != nil {
+		ddd, ok, err := s.loadDDDFromCache(ctx, cepDigits)
+		if err != nil {
+			s.logger.WarnContext(ctx, "ddd projection failed, falling back to full lookup", "cep", cepDigits, "err", err)
+		} else if ok {
+			s.counters.cacheHits.Add(1)
+			return &DDDResult{Cep: formatCEP(cepDigits), DDD: ddd}, nil
+		}
+	}
+
+	result, err := s.Lookup(ctx, cepDigits)
+	if err != nil {
+		return nil, err
+	}
+	return &DDDResult{Cep: result.Response.Cep, DDD: result.Response.DDD}, nil
+}
+
+// loadDDDFromCache reads the DDD of a fresh, current-schema row.
+func (s *Service) loadDDDFromCache(ctx context.Context, cep string) (string, bool, error) {
+	query := fmt.Sprintf("SELECT payload->>'ddd', updated_at, schema_version FROM %s WHERE cep = $1", s.tableName)
+
+	var (
+		ddd           sql.NullString
+		updatedAt     time.Time
+		schemaVersion int
+	)
+	switch err := s.db.QueryRowContext(ctx, query, cep).Scan(&ddd, &updatedAt, &schemaVersion); {
+	case errors.Is(err, sql.ErrNoRows):
+		return "", false, nil
+	case err != nil:
+		return "", false, err
+	}
+
+	if schemaVersion < CacheSchemaVersion || s.isExpired(updatedAt) {
+		return "", false, nil
+	}
+	return ddd.String, true, nil
+}
@@ -0,0 +1,58 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceLookupDDDProjectsInDatabase(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`^SELECT payload->>'ddd', updated_at, schema_version FROM ceps WHERE cep = \$1$`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"ddd", "updated_at", "schema_version"}).AddRow("11", time.Now(), CacheSchemaVersion))
+
+	client := &stubHTTPClient{}
+	result, err := NewService(db, client, time.Hour, noopLogger()).LookupDDD(context.Background(), "01001-000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &DDDResult{Cep: "01001-000", DDD: "11"}, result)
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceLookupDDDFallsBackToFullLookup(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	// Expired projection row: the full lookup refetches and refreshes the cache.
+	mock.ExpectQuery(`SELECT payload->>'ddd'`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"ddd", "updated_at", "schema_version"}).AddRow("11", time.Now().Add(-2*time.Hour), CacheSchemaVersion))
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps`).
+		WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	client := &stubHTTPClient{response: okResponseWithDDD()}
+	result, err := NewService(db, client, time.Hour, noopLogger()).LookupDDD(context.Background(), "01001000")
+
+	assert.NoError(t, err)
+	assert.Equal(t, &DDDResult{Cep: "01001-000", DDD: "11"}, result)
+	assert.Equal(t, 1, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func okResponseWithDDD() *http.Response {
+	return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"01001-000","ddd":"11"}`))}
+}
@@ -0,0 +1,242 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"time"
+)
+
+// Provider is an upstream source of CEP data. Lookup receives the 8 normalised
+// digits and returns ErrNotFound when the provider does not know the CEP.
+type Provider interface {
+	Lookup(ctx context.Context, cep string) (*Response, error)
+}
+
+// ProviderFunc adapts a function to the Provider interface.
+type ProviderFunc func(ctx context.Context, cep string) (*Response, error)
+
+// Lookup calls f.
+func (f ProviderFunc) Lookup(ctx context.Context, cep string) (*Response, error) {
+	return f(ctx, cep)
+}
+
+// ProviderName identifies a built-in provider.
+type ProviderName string
+
+// Built-in providers.
+const (
+	ProviderViaCEP    ProviderName = "viacep"
+	ProviderBrasilAPI ProviderName = "brasilapi"
+	ProviderAPICEP    ProviderName = "apicep"
+	ProviderCEPAberto ProviderName = "cepaberto"
+)
+
+// BuiltinProviderNames lists every built-in provider.
+func BuiltinProviderNames() []ProviderName {
+	return []ProviderName{ProviderViaCEP, ProviderBrasilAPI, ProviderAPICEP, ProviderCEPAberto}
+}
+
+// WithProviderOrder selects the built-in providers and the order in which they
+// are consulted, e.g. ViaCEP with BrasilAPI as fallback. Defaults to ViaCEP
+// alone. Unknown names are ignored; WithProviders takes precedence.
+func WithProviderOrder(names ...ProviderName) Option {
+	return func(s *Service) {
+		s.providerOrder = names
+	}
+}
+
+// builtinProviders resolves the configured provider order.
+func (s *Service) builtinProviders() []Provider {
+	order := s.providerOrder
+	if len(order) == 0 {
+		order = []ProviderName{ProviderViaCEP}
+	}
+
+	providers := make([]Provider, 0, len(order))
+	for _, name := range order {
+		if provider := s.builtinProvider(name); provider != nil {
+			providers = append(providers, provider)
+		}
+	}
+	return providers
+}
+
+// builtinProvider builds the named provider with its timeout, or returns nil
+// for an unknown name.
+func (s *Service) builtinProvider(name ProviderName) Provider {
+	var provider Provider
+	switch name {
+	case ProviderViaCEP:
+		provider = &viaCEP{service: s, urlFormat: viaCEPURL}
+	case ProviderBrasilAPI:
+		provider = &brasilAPI{service: s, urlFormat: brasilAPIURL}
+	case ProviderAPICEP:
+		provider = &apiCEP{service: s, urlFormat: apiCEPURL}
+	case ProviderCEPAberto:
+		provider = &cepAberto{service: s, urlFormat: cepAbertoURL}
+	default:
+		return nil
+	}
+	if timeout := s.providerTimeouts[name]; timeout > 0 {
+		provider = &timeoutProvider{Provider: provider, name: string(name), timeout: timeout}
+	}
+	return provider
+}
+
+// WithProviderCredentials sets credentials for individual built-in providers,
+// overriding WithProviderAuth for them, e.g. CEP Aberto's Authorization token.
+func WithProviderCredentials(credentials map[ProviderName]ProviderAuth) Option {
+	return func(s *Service) {
+		s.credentials = credentials
+	}
+}
+
+// authFor returns the credentials to send to the named provider.
+func (s *Service) authFor(name ProviderName) ProviderAuth {
+	if auth, ok := s.credentials[name]; ok {
+		return auth
+	}
+	return s.providerAuth
+}
+
+// WithProviderTimeouts bounds each call to a built-in provider, so a slow
+// fallback cannot use up the budget of the whole lookup. Providers without an
+// entry only inherit the caller's deadline.
+func WithProviderTimeouts(timeouts map[ProviderName]time.Duration) Option {
+	return func(s *Service) {
+		s.providerTimeouts = timeouts
+	}
+}
+
+// timeoutProvider gives every call to a provider its own deadline.
+type timeoutProvider struct {
+	Provider
+	name    string
+	timeout time.Duration
+}
+
+func (p *timeoutProvider) String() string { return p.name }
+
+func (p *timeoutProvider) Lookup(ctx context.Context, cep string) (*Response, error) {
+	ctx, cancel := context.WithTimeout(ctx, p.timeout)
+	defer cancel()
+	return p.Provider.Lookup(ctx, cep)
+}
+
+// WithProviders replaces the built-in providers. Providers are consulted
+// in order: a provider that fails hands the lookup to the next one, while a
+// not-found answer is final.
+func WithProviders(providers ...Provider) Option {
+	return func(s *Service) {
+		s.providers = providers
+	}
+}
+
+// fetchFromProviders looks cep up using the configured provider strategy and
+// post-processes the answer.
+func (s *Service) fetchFromProviders(ctx context.Context, cep string) (*Response, error) {
+	var (
+		resp *Response
+		err  error
+	)
+	switch {
+	case s.providerStrategy == ProviderStrategyParallel && len(s.providers) > 1:
+		resp, err = s.raceProviders(ctx, cep)
+	case s.providerStrategy == ProviderStrategyP2C && len(s.providers) > 1:
+		resp, err = s.consultProviders(ctx, cep, s.twoChoices())
+	default:
+		resp, err = s.consultProviders(ctx, cep, s.regionalSequence(cep))
+	}
+	if err != nil {
+		return nil, err
+	}
+	s.finishResponse(cep, resp)
+	return resp, nil
+}
+
+// WithMaxProvidersPerRequest caps how many providers one lookup tries in turn
+// before giving up, bounding its worst-case latency when many are configured.
+// The parallel strategy asks every provider at once and is not capped. A max
+// <= 0 tries them all.
+func WithMaxProvidersPerRequest(max int) Option {
+	return func(s *Service) {
+		s.maxProviders = max
+	}
+}
+
+// WithProviderFanoutBudget caps the wall-clock time one lookup spends across
+// all the providers it tries in turn, independently of their own timeouts.
+// Once spent, the provider in flight is cancelled and the lookup fails with the
+// last error. A budget <= 0 disables the cap.
+func WithProviderFanoutBudget(budget time.Duration) Option {
+	return func(s *Service) {
+		s.fanoutBudget = budget
+	}
+}
+
+// consultProviders asks the providers in sequence, by index, one at a time and
+// returns the first answer. The last error is returned when every one fails.
+func (s *Service) consultProviders(ctx context.Context, cep string, sequence []int) (*Response, error) {
+	if s.maxProviders > 0 && len(sequence) > s.maxProviders {
+		sequence = sequence[:s.maxProviders]
+	}
+	budgetCtx := ctx
+	if s.fanoutBudget > 0 {
+		var cancel context.CancelFunc
+		budgetCtx, cancel = context.WithTimeout(ctx, s.fanoutBudget)
+		defer cancel()
+	}
+
+	err := errors.New("no provider configured")
+	for n, i := range sequence {
+		provider := s.providers[i]
+		start := s.now()
+		var resp *Response
+		resp, err = provider.Lookup(budgetCtx, cep)
+		if budgetCtx.Err() == nil {
+			s.recordProviderCall(ctx, i, s.now().Sub(start), err)
+		}
+		if err == nil {
+			return resp, nil
+		}
+		if errors.Is(err, ErrNotFound) || ctx.Err() != nil {
+			return nil, err
+		}
+		if budgetCtx.Err() != nil {
+			s.logger.WarnContext(ctx, "provider fan-out budget exhausted", "cep", cep, "budget", s.fanoutBudget, "tried", n+1)
+			return nil, fmt.Errorf("provider fan-out budget of %s exhausted: %w", s.fanoutBudget, err)
+		}
+		if n < len(sequence)-1 {
+			s.logger.WarnContext(ctx, "provider failed, falling back", "provider", providerName(provider, i), "cep", cep, "err", err)
+		}
+	}
+	return nil, err
+}
+
+// providerName labels a provider in logs.
+func providerName(provider Provider, index int) string {
+	if named, ok := provider.(fmt.Stringer); ok {
+		return named.String()
+	}
+	return fmt.Sprintf("#%d", index+1)
+}
+
+// recordProviderCall feeds one completed provider call into the provider's
+// score and the ProviderObserver.
+func (s *Service) recordProviderCall(ctx context.Context, i int, elapsed time.Duration, err error) {
+	s.scores[i].record(elapsed, err)
+	if s.observe != nil {
+		s.observe(ctx, s.scores[i].name, elapsed, err)
+	}
+}
+
+// finishResponse applies the provider-independent clean-up to resp.
+func (s *Service) finishResponse(cep string, resp *Response) {
+	if s.trimWhitespace {
+		trimResponse(resp)
+	}
+	if resp.Cep == "" {
+		resp.Cep = formatCEP(cep)
+	}
+}
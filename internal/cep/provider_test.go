@@ -0,0 +1,113 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceProviders(t *testing.T) {
+	failing := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, errors.New("connection refused") })
+	notFound := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, ErrNotFound })
+
+	var secondCalls int
+	second := ProviderFunc(func(_ context.Context, cep string) (*Response, error) {
+		secondCalls++
+		return &Response{Logradouro: "  Praça da Sé "}, nil
+	})
+
+	for _, tc := range []struct {
+		name      string
+		providers []Provider
+		wantErr   error
+		wantCalls int
+	}{
+		{name: "falls through a failing provider", providers: []Provider{failing, second}, wantCalls: 1},
+		{name: "not found is final", providers: []Provider{notFound, second}, wantErr: ErrNotFound},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			secondCalls = 0
+			service := NewService(nil, nil, time.Hour, noopLogger(), WithProviders(tc.providers...))
+
+			resp, err := service.Get(context.Background(), "01001000")
+			assert.Equal(t, tc.wantCalls, secondCalls)
+			if tc.wantErr != nil {
+				assert.ErrorIs(t, err, tc.wantErr)
+				return
+			}
+			assert.NoError(t, err)
+			assert.Equal(t, "01001-000", resp.Cep)
+			assert.Equal(t, "Praça da Sé", resp.Logradouro)
+		})
+	}
+}
+
+func TestServiceProvidersAllFail(t *testing.T) {
+	first := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, errors.New("first down") })
+	second := ProviderFunc(func(context.Context, string) (*Response, error) { return nil, errors.New("second down") })
+	service := NewService(nil, nil, time.Hour, noopLogger(), WithProviders(first, second))
+
+	_, err := service.Get(context.Background(), "01001000")
+	assert.EqualError(t, err, "second down")
+}
+
+func TestServiceMaxProvidersPerRequest(t *testing.T) {
+	var calls int
+	failing := ProviderFunc(func(context.Context, string) (*Response, error) {
+		calls++
+		return nil, fmt.Errorf("provider %d down", calls)
+	})
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(failing, failing, failing, failing), WithMaxProvidersPerRequest(2))
+
+	_, err := service.Get(context.Background(), "01001000")
+	assert.EqualError(t, err, "provider 2 down")
+	assert.Equal(t, 2, calls)
+}
+
+func TestServiceProviderFanoutBudget(t *testing.T) {
+	var calls atomic.Int32
+	slow := ProviderFunc(func(ctx context.Context, _ string) (*Response, error) {
+		calls.Add(1)
+		select {
+		case <-time.After(60 * time.Millisecond):
+			return nil, errors.New("upstream 502")
+		case <-ctx.Done():
+			return nil, ctx.Err()
+		}
+	})
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(slow, slow, slow, slow), WithProviderFanoutBudget(100*time.Millisecond))
+
+	start := time.Now()
+	_, err := service.Get(context.Background(), "01001000")
+	elapsed := time.Since(start)
+
+	// The second provider is cut off when the budget runs out; the others are
+	// never asked.
+	assert.ErrorContains(t, err, "fan-out budget of 100ms exhausted")
+	assert.Equal(t, int32(2), calls.Load())
+	assert.Less(t, elapsed, 200*time.Millisecond)
+}
+
+func TestServiceProviderObserver(t *testing.T) {
+	down := errors.New("connection refused")
+	var observed []string
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(
+			namedProvider{name: "primary", ProviderFunc: func(context.Context, string) (*Response, error) { return nil, down }},
+			namedProvider{name: "secondary", ProviderFunc: func(context.Context, string) (*Response, error) { return &Response{}, nil }},
+		),
+		WithProviderObserver(func(_ context.Context, provider string, _ time.Duration, err error) {
+			observed = append(observed, fmt.Sprintf("%s:%v", provider, err))
+		}))
+
+	_, err := service.Get(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"primary:connection refused", "secondary:<nil>"}, observed)
+}
@@ -0,0 +1,103 @@
+package cep
+
+import (
+	"context"
+	"strings"
+)
+
+// Field is a named Response field, in JSON order.
+type Field struct {
+	Name  string
+	Value string
+}
+
+// Fields lists the address fields of r by JSON name. Request-time enrichment
+// (timezone, parsed complemento) is not included.
+func (r *Response) Fields() []Field {
+	return []Field{
+		{"cep", r.Cep},
+		{"logradouro", r.Logradouro},
+		{"complemento", r.Complemento},
+		{"bairro", r.Bairro},
+		{"localidade", r.Localidade},
+		{"uf", r.Uf},
+		{"ibge", r.Ibge},
+		{"gia", r.Gia},
+		{"ddd", r.DDD},
+		{"siafi", r.Siafi},
+		{"unidade", r.Unidade},
+		{"latitude", r.Latitude},
+		{"longitude", r.Longitude},
+	}
+}
+
+// FieldChange describes one field that differs between two responses.
+type FieldChange struct {
+	Field string `json:"field"`
+	Old   string `json:"old"`
+	New   string `json:"new"`
+}
+
+// Diff returns the fields that differ from old to updated, in JSON order.
+func Diff(old, updated *Response) []FieldChange {
+	oldFields, newFields := old.Fields(), updated.Fields()
+	changes := []FieldChange{}
+	for i := range oldFields {
+		if oldFields[i].Value != newFields[i].Value {
+			changes = append(changes, FieldChange{Field: oldFields[i].Name, Old: oldFields[i].Value, New: newFields[i].Value})
+		}
+	}
+	return changes
+}
+
+// RefreshResult is a forced refresh outcome. Diff is nil when nothing was
+// cached before the refresh and empty when the provider data is unchanged.
+type RefreshResult struct {
+	*Result
+	Diff []FieldChange
+}
+
+// Refresh fetches a CEP from the provider regardless of the cache, stores the
+// answer and reports how it differs from the previously cached value. Changed
+// values are counted in MetricsSnapshot.DataChanged, which surfaces upstream
+// corrections.
+func (s *Service) Refresh(ctx context.Context, rawCEP string) (*RefreshResult, error) {
+	cepDigits, err := s.normalize(rawCEP)
+	if err != nil {
+		return nil, err
+	}
+
+	previous, err := s.loadFromCache(ctx, cepDigits)
+	if err != nil {
+		s.logger.WarnContext(ctx, "cache lookup failed before refresh", "cep", cepDigits, "err", err)
+	}
+
+	t := s.generations.begin(cepDigits)
+	defer s.generations.end(t)
+	fetchedAt := s.now().UTC()
+	fresh, err := s.fetchObserved(ctx, cepDigits)
+	if err != nil {
+		return nil, err
+	}
+	// A forced refresh exists to replace rows that are still fresh, so it
+	// skips the keep-fresh clause of CACHE_UPSERT_MODE; newer rows still win.
+	s.persist(ctx, t, fresh, fetchedAt, UpsertUpdate)
+
+	result := &RefreshResult{Result: &Result{Response: fresh, ProviderCalled: true, Source: SourceProvider, UpdatedAt: fetchedAt}}
+	if previous != nil {
+		result.Diff = Diff(previous.resp, fresh)
+		if len(result.Diff) > 0 {
+			s.counters.dataChanged.Add(1)
+			s.logger.InfoContext(ctx, "provider data changed", "cep", cepDigits, "changes", describeChanges(result.Diff))
+		}
+	}
+	return result, nil
+}
+
+func describeChanges(changes []FieldChange) string {
+	names := make([]string, len(changes))
+	for i, change := range changes {
+		names[i] = change.Field
+	}
+	return strings.Join(names, ", ")
+}
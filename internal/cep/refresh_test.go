@@ -0,0 +1,85 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceRefreshDiff(t *testing.T) {
+	provider := clientFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(
+			`{"cep":"01001-000","logradouro":"Praça da Sé","bairro":"Sé","localidade":"São Paulo","uf":"SP"}`))}, nil
+	})
+
+	for _, tc := range []struct {
+		name        string
+		cached      *Response
+		wantDiff    []FieldChange
+		wantChanged uint64
+	}{
+		{
+			name:        "changed field",
+			cached:      &Response{Cep: "01001-000", Logradouro: "Praca da Se", Bairro: "Sé", Localidade: "São Paulo", Uf: "SP"},
+			wantDiff:    []FieldChange{{Field: "logradouro", Old: "Praca da Se", New: "Praça da Sé"}},
+			wantChanged: 1,
+		},
+		{
+			name:     "unchanged",
+			cached:   &Response{Cep: "01001-000", Logradouro: "Praça da Sé", Bairro: "Sé", Localidade: "São Paulo", Uf: "SP"},
+			wantDiff: []FieldChange{},
+		},
+		{name: "nothing cached"},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			service := NewService(nil, provider, time.Hour, noopLogger())
+			if tc.cached != nil {
+				service.memory.set("01001000", *tc.cached, time.Now())
+			}
+
+			result, err := service.Refresh(context.Background(), "01001-000")
+			assert.NoError(t, err)
+			assert.True(t, result.ProviderCalled)
+			assert.Equal(t, "Praça da Sé", result.Response.Logradouro)
+			assert.Equal(t, tc.wantDiff, result.Diff)
+			assert.Equal(t, tc.wantChanged, service.Metrics().DataChanged)
+
+			cached, err := service.Lookup(context.Background(), "01001000")
+			assert.NoError(t, err)
+			assert.False(t, cached.ProviderCalled)
+			assert.Equal(t, "Praça da Sé", cached.Response.Logradouro)
+		})
+	}
+}
+
+func TestServiceRefreshReplacesFreshRowInKeepFreshMode(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT payload`).WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).
+			AddRow(`{"cep":"01001-000","logradouro":"Praca da Se"}`, now.Add(-time.Minute), CacheSchemaVersion))
+	// The row is well within the TTL, yet the write carries no keep-fresh clause.
+	mock.ExpectExec(`WHERE \(ceps\.updated_at <= EXCLUDED\.updated_at OR ceps\.schema_version < EXCLUDED\.schema_version\)\s*$`).
+		WithArgs("01001000", sqlmock.AnyArg(), now, CacheSchemaVersion).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	provider := clientFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"01001-000","logradouro":"Praça da Sé"}`))}, nil
+	})
+	service := NewService(db, provider, time.Hour, noopLogger(), WithUpsertMode(UpsertKeepFresh))
+	service.now = func() time.Time { return now }
+
+	result, err := service.Refresh(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, []FieldChange{{Field: "logradouro", Old: "Praca da Se", New: "Praça da Sé"}}, result.Diff)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
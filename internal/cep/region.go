@@ -0,0 +1,168 @@
+package cep
+
+import (
+	"encoding/json"
+	"fmt"
+	"os"
+	"slices"
+	"strconv"
+	"strings"
+)
+
+// ufRange is a span of CEPs, by their first five digits, assigned to one UF.
+type ufRange struct {
+	first, last int
+	uf          string
+}
+
+// ufRanges are the Correios CEP ranges of each UF. DF and GO, and AM and RR,
+// interleave, so some UFs own more than one span.
+var ufRanges = []ufRange{
+	{1000, 19999, "SP"},
+	{20000, 28999, "RJ"},
+	{29000, 29999, "ES"},
+	{30000, 39999, "MG"},
+	{40000, 48999, "BA"},
+	{49000, 49999, "SE"},
+	{50000, 56999, "PE"},
+	{57000, 57999, "AL"},
+	{58000, 58999, "PB"},
+	{59000, 59999, "RN"},
+	{60000, 63999, "CE"},
+	{64000, 64999, "PI"},
+	{65000, 65999, "MA"},
+	{66000, 68899, "PA"},
+	{68900, 68999, "AP"},
+	{69000, 69299, "AM"},
+	{69300, 69399, "RR"},
+	{69400, 69899, "AM"},
+	{69900, 69999, "AC"},
+	{70000, 72799, "DF"},
+	{72800, 72999, "GO"},
+	{73000, 73699, "DF"},
+	{73700, 76799, "GO"},
+	{76800, 76999, "RO"},
+	{77000, 77999, "TO"},
+	{78000, 78899, "MT"},
+	{79000, 79999, "MS"},
+	{80000, 87999, "PR"},
+	{88000, 89999, "SC"},
+	{90000, 99999, "RS"},
+}
+
+// ufForCEP returns the UF whose range holds the 8-digit cep.
+func ufForCEP(cep string) (string, bool) {
+	if len(cep) < 5 {
+		return "", false
+	}
+	prefix, err := strconv.Atoi(cep[:5])
+	if err != nil {
+		return "", false
+	}
+	for _, r := range ufRanges {
+		if prefix >= r.first && prefix <= r.last {
+			return r.uf, true
+		}
+	}
+	return "", false
+}
+
+// RegionalProviderOrder maps a UF ("SP") or a CEP prefix of one to eight
+// digits ("690") to the built-in providers to try first for CEPs in it. The
+// longest matching prefix wins over the UF. Providers left out of an entry are
+// still tried afterwards in the global order.
+type RegionalProviderOrder map[string][]ProviderName
+
+// LoadRegionalProviderOrder reads a RegionalProviderOrder from a JSON file
+// such as {"SP": ["brasilapi", "viacep"], "690": ["viacep"]}.
+func LoadRegionalProviderOrder(path string) (RegionalProviderOrder, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	var order RegionalProviderOrder
+	if err := json.Unmarshal(data, &order); err != nil {
+		return nil, fmt.Errorf("parse %s: %w", path, err)
+	}
+
+	normalized := make(RegionalProviderOrder, len(order))
+	for region, names := range order {
+		key := strings.ToUpper(strings.TrimSpace(region))
+		if _, isUF := ufTimezones[key]; !isUF && !isCEPPrefix(key) {
+			return nil, fmt.Errorf("region %q is neither a UF nor a CEP prefix", region)
+		}
+		if len(names) == 0 {
+			return nil, fmt.Errorf("region %q lists no provider", region)
+		}
+		for i, name := range names {
+			names[i] = ProviderName(strings.ToLower(strings.TrimSpace(string(name))))
+			if !slices.Contains(BuiltinProviderNames(), names[i]) {
+				return nil, fmt.Errorf("region %q: unknown provider %q", region, name)
+			}
+		}
+		normalized[key] = names
+	}
+	return normalized, nil
+}
+
+func isCEPPrefix(value string) bool {
+	if len(value) == 0 || len(value) > 8 {
+		return false
+	}
+	for _, r := range value {
+		if r < '0' || r > '9' {
+			return false
+		}
+	}
+	return true
+}
+
+// preferred returns the providers configured for cep's region, if any.
+func (o RegionalProviderOrder) preferred(cep string) []ProviderName {
+	for n := min(len(cep), 8); n > 0; n-- {
+		if names, ok := o[cep[:n]]; ok {
+			return names
+		}
+	}
+	if uf, ok := ufForCEP(cep); ok {
+		return o[uf]
+	}
+	return nil
+}
+
+// WithRegionalProviderOrder routes CEPs of the given regions to their
+// preferred providers under ProviderStrategyFallback.
+func WithRegionalProviderOrder(order RegionalProviderOrder) Option {
+	return func(s *Service) {
+		s.SetRegionalProviderOrder(order)
+	}
+}
+
+// SetRegionalProviderOrder replaces the regional routing, e.g. after the file
+// it came from was edited. It is safe to call while lookups run.
+func (s *Service) SetRegionalProviderOrder(order RegionalProviderOrder) {
+	s.regions.Store(&order)
+}
+
+// regionalSequence puts the providers preferred for cep's region first and
+// the rest after them in the global order.
+func (s *Service) regionalSequence(cep string) []int {
+	sequence := s.providerSequence()
+	order := s.regions.Load()
+	if order == nil {
+		return sequence
+	}
+	names := order.preferred(cep)
+	if len(names) == 0 {
+		return sequence
+	}
+
+	rank := func(i int) int {
+		if n := slices.Index(names, ProviderName(s.scores[i].name)); n >= 0 {
+			return n
+		}
+		return len(names)
+	}
+	slices.SortStableFunc(sequence, func(a, b int) int { return rank(a) - rank(b) })
+	return sequence
+}
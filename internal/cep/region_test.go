@@ -0,0 +1,96 @@
+package cep
+
+import (
+	"context"
+	"os"
+	"path/filepath"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestUFForCEP(t *testing.T) {
+	for cep, want := range map[string]string{
+		"01001000": "SP",
+		"20040002": "RJ",
+		"69301000": "RR",
+		"69900000": "AC",
+		"70040010": "DF",
+		"74000000": "GO",
+		"90010000": "RS",
+	} {
+		uf, ok := ufForCEP(cep)
+		assert.True(t, ok, cep)
+		assert.Equal(t, want, uf, cep)
+	}
+	_, ok := ufForCEP("00000000")
+	assert.False(t, ok)
+}
+
+func TestLoadRegionalProviderOrder(t *testing.T) {
+	write := func(t *testing.T, body string) string {
+		path := filepath.Join(t.TempDir(), "regions.json")
+		assert.NoError(t, os.WriteFile(path, []byte(body), 0o600))
+		return path
+	}
+
+	order, err := LoadRegionalProviderOrder(write(t, `{"sp": ["BrasilAPI", "viacep"], "690": ["apicep"]}`))
+	assert.NoError(t, err)
+	assert.Equal(t, RegionalProviderOrder{
+		"SP":  {ProviderBrasilAPI, ProviderViaCEP},
+		"690": {ProviderAPICEP},
+	}, order)
+
+	for _, body := range []string{
+		`{"XX": ["viacep"]}`,
+		`{"SP": []}`,
+		`{"SP": ["correios"]}`,
+		`["viacep"]`,
+	} {
+		_, err := LoadRegionalProviderOrder(write(t, body))
+		assert.Error(t, err, body)
+	}
+	_, err = LoadRegionalProviderOrder(filepath.Join(t.TempDir(), "missing.json"))
+	assert.Error(t, err)
+}
+
+func TestServiceRegionalProviderOrder(t *testing.T) {
+	var calls []string
+	provider := func(name ProviderName) Provider {
+		return namedProvider{name: string(name), ProviderFunc: func(context.Context, string) (*Response, error) {
+			calls = append(calls, string(name))
+			return nil, ErrUpstreamBadResponse
+		}}
+	}
+	service := NewService(nil, nil, time.Hour, noopLogger(),
+		WithProviders(provider(ProviderViaCEP), provider(ProviderBrasilAPI), provider(ProviderAPICEP)),
+		WithRegionalProviderOrder(RegionalProviderOrder{
+			"RJ":  {ProviderAPICEP},
+			"SP":  {ProviderBrasilAPI, ProviderViaCEP},
+			"010": {ProviderAPICEP, ProviderBrasilAPI},
+		}))
+
+	for _, tc := range []struct {
+		cep  string
+		want []string
+	}{
+		{cep: "20040002", want: []string{"apicep", "viacep", "brasilapi"}},
+		{cep: "13010000", want: []string{"brasilapi", "viacep", "apicep"}},
+		// The prefix is more specific than SP.
+		{cep: "01001000", want: []string{"apicep", "brasilapi", "viacep"}},
+		// Unmapped regions keep the global order.
+		{cep: "90010000", want: []string{"viacep", "brasilapi", "apicep"}},
+	} {
+		calls = nil
+		_, err := service.fetchFromProviders(context.Background(), tc.cep)
+		assert.Error(t, err)
+		assert.Equal(t, tc.want, calls, tc.cep)
+	}
+
+	// A reload takes effect on the next lookup.
+	service.SetRegionalProviderOrder(nil)
+	calls = nil
+	_, _ = service.fetchFromProviders(context.Background(), "20040002")
+	assert.Equal(t, []string{"viacep", "brasilapi", "apicep"}, calls)
+}
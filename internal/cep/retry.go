@@ -0,0 +1,82 @@
+package cep
+
+import (
+	"context"
+	"fmt"
+	"io"
+	"math/rand/v2"
+	"net/http"
+	"time"
+)
+
+// retryPolicy configures WithRetries.
+type retryPolicy struct {
+	max       int
+	baseDelay time.Duration
+	maxDelay  time.Duration
+}
+
+// WithRetries repeats provider requests that failed with a 5xx status or a
+// network error up to maxRetries times, waiting an exponentially growing,
+// jittered delay between attempts (baseDelay, 2*baseDelay, ... capped at
+// maxDelay). A retry that would not finish before the context deadline is not
+// attempted. maxRetries <= 0 disables retries.
+func WithRetries(maxRetries int, baseDelay, maxDelay time.Duration) Option {
+	return func(s *Service) {
+		if maxRetries > 0 && baseDelay > 0 {
+			s.retry = retryPolicy{max: maxRetries, baseDelay: baseDelay, maxDelay: max(maxDelay, baseDelay)}
+		}
+	}
+}
+
+// doProviderRequest performs a GET to a provider URL with the given
+// credentials and the configured retries and hedging. The returned body must be closed by the caller.
+func (s *Service) doProviderRequest(ctx context.Context, url string, auth ProviderAuth) (*http.Response, error) {
+	for attempt := 0; ; attempt++ {
+		resp, err := s.hedgedRequest(ctx, url, auth)
+		if attempt >= s.retry.max || ctx.Err() != nil || !retryable(resp, err) {
+			return resp, err
+		}
+
+		delay := s.retry.backoff(attempt)
+		if deadline, ok := ctx.Deadline(); ok && time.Until(deadline) <= delay {
+			return resp, err
+		}
+		reason := fmt.Sprint(err)
+		if resp != nil {
+			reason = fmt.Sprintf("status %d", resp.StatusCode)
+			_, _ = io.Copy(io.Discard, resp.Body)
+			resp.Body.Close()
+		}
+		s.logger.WarnContext(ctx, "provider request failed, retrying", "reason", reason, "retry", attempt+1, "max_retries", s.retry.max, "delay", delay)
+		s.counters.retries.Add(1)
+
+		timer := time.NewTimer(delay)
+		select {
+		case <-timer.C:
+		case <-ctx.Done():
+			timer.Stop()
+			return nil, ctx.Err()
+		}
+	}
+}
+
+// retryable reports whether a provider request is worth repeating.
+func retryable(resp *http.Response, err error) bool {
+	if err != nil {
+		return true
+	}
+	return resp.StatusCode >= http.StatusInternalServerError
+}
+
+// backoff returns the delay before retry attempt+1: the exponential step with
+// "equal jitter", i.e. uniformly between half and all of it, so that replicas
+// retrying the same outage spread out.
+func (p retryPolicy) backoff(attempt int) time.Duration {
+	step := p.maxDelay
+	if attempt < 30 {
+		step = min(p.baseDelay<<attempt, p.maxDelay)
+	}
+	half := step / 2
+	return half + rand.N(step-half+1)
+}
@@ -0,0 +1,95 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceRetries(t *testing.T) {
+	for _, tc := range []struct {
+		name      string
+		failures  []func() (*http.Response, error)
+		wantErr   bool
+		wantCalls int
+	}{
+		{
+			name: "recovers after 5xx and network error",
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusBadGateway, Body: http.NoBody}, nil
+				},
+				func() (*http.Response, error) { return nil, errors.New("connection reset by peer") },
+			},
+			wantCalls: 3,
+		},
+		{
+			name: "gives up after max retries",
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) { return nil, errors.New("connection refused") },
+				func() (*http.Response, error) { return nil, errors.New("connection refused") },
+				func() (*http.Response, error) { return nil, errors.New("connection refused") },
+			},
+			wantErr:   true,
+			wantCalls: 3,
+		},
+		{
+			name: "4xx is not retried",
+			failures: []func() (*http.Response, error){
+				func() (*http.Response, error) {
+					return &http.Response{StatusCode: http.StatusTooManyRequests, Body: http.NoBody}, nil
+				},
+			},
+			wantErr:   true,
+			wantCalls: 1,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			calls := 0
+			client := clientFunc(func(*http.Request) (*http.Response, error) {
+				calls++
+				if calls <= len(tc.failures) {
+					return tc.failures[calls-1]()
+				}
+				return okResponse(), nil
+			})
+			service := NewService(nil, client, time.Hour, noopLogger(), WithRetries(2, time.Millisecond, 4*time.Millisecond))
+
+			_, err := service.Get(context.Background(), "01001000")
+			assert.Equal(t, tc.wantErr, err != nil, "err: %v", err)
+			assert.Equal(t, tc.wantCalls, calls)
+			assert.Equal(t, uint64(calls-1), service.Metrics().Retries)
+		})
+	}
+}
+
+func TestServiceRetriesRespectDeadline(t *testing.T) {
+	calls := 0
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		calls++
+		return nil, errors.New("connection refused")
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(), WithRetries(3, time.Second, time.Second))
+
+	ctx, cancel := context.WithTimeout(context.Background(), 100*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	_, err := service.Get(ctx, "01001000")
+	assert.EqualError(t, err, "connection refused")
+	assert.Equal(t, 1, calls)
+	assert.Less(t, time.Since(start), 100*time.Millisecond)
+}
+
+func TestRetryBackoff(t *testing.T) {
+	policy := retryPolicy{max: 10, baseDelay: 100 * time.Millisecond, maxDelay: time.Second}
+	for attempt, step := range []time.Duration{100, 200, 400, 800, 1000, 1000} {
+		step *= time.Millisecond
+		delay := policy.backoff(attempt)
+		assert.GreaterOrEqual(t, delay, step/2)
+		assert.LessOrEqual(t, delay, step)
+	}
+}
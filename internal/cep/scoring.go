@@ -0,0 +1,125 @@
+package cep
+
+import (
+	"errors"
+	"math"
+	"sort"
+	"sync"
+	"time"
+)
+
+// scoreAlpha weighs the latest call in the rolling averages; roughly the last
+// 1/scoreAlpha calls dominate a provider's score.
+const scoreAlpha = 0.1
+
+// scoreBuckets groups success rates so providers within 5 points of each other
+// are ordered by latency rather than by noise in their success rate.
+const scoreBuckets = 20
+
+// ProviderScore is the rolling health of one provider.
+type ProviderScore struct {
+	Provider    string  `json:"provider"`
+	SuccessRate float64 `json:"success_rate"`
+	LatencyMS   float64 `json:"latency_ms"`
+	Samples     uint64  `json:"samples"`
+}
+
+// WithDynamicProviderOrder reorders the fallback chain by provider health: the
+// provider with the best rolling success rate is tried first, and latency
+// breaks ties. Providers without samples keep their configured order behind
+// the scored ones. Disabled by default.
+func WithDynamicProviderOrder(enabled bool) Option {
+	return func(s *Service) {
+		s.dynamicOrder = enabled
+	}
+}
+
+// providerScore tracks rolling averages for one provider.
+type providerScore struct {
+	name string
+
+	mu      sync.Mutex
+	success float64
+	latency float64 // seconds
+	samples uint64
+}
+
+// record adds one call outcome. Unknown CEPs count as successes.
+func (p *providerScore) record(elapsed time.Duration, err error) {
+	ok := 0.0
+	if err == nil || errors.Is(err, ErrNotFound) {
+		ok = 1
+	}
+
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	if p.samples == 0 {
+		p.success, p.latency = ok, elapsed.Seconds()
+	} else {
+		p.success += scoreAlpha * (ok - p.success)
+		p.latency += scoreAlpha * (elapsed.Seconds() - p.latency)
+	}
+	p.samples++
+}
+
+func (p *providerScore) snapshot() ProviderScore {
+	p.mu.Lock()
+	defer p.mu.Unlock()
+	return ProviderScore{
+		Provider:    p.name,
+		SuccessRate: p.success,
+		LatencyMS:   p.latency * 1000,
+		Samples:     p.samples,
+	}
+}
+
+// initScores starts tracking every configured provider.
+func (s *Service) initScores() {
+	s.scores = make([]*providerScore, len(s.providers))
+	for i, provider := range s.providers {
+		s.scores[i] = &providerScore{name: providerName(provider, i)}
+	}
+}
+
+// providerSequence returns provider indexes in the order they should be tried.
+func (s *Service) providerSequence() []int {
+	order := make([]int, len(s.providers))
+	for i := range order {
+		order[i] = i
+	}
+	if !s.dynamicOrder || len(order) < 2 {
+		return order
+	}
+
+	type rank struct {
+		bucket  int
+		latency float64
+	}
+	ranks := make([]rank, len(order))
+	for i, score := range s.scores {
+		snapshot := score.snapshot()
+		if snapshot.Samples == 0 {
+			ranks[i] = rank{bucket: -1, latency: math.Inf(1)}
+			continue
+		}
+		ranks[i] = rank{bucket: int(snapshot.SuccessRate * scoreBuckets), latency: snapshot.LatencyMS}
+	}
+	sort.SliceStable(order, func(a, b int) bool {
+		ra, rb := ranks[order[a]], ranks[order[b]]
+		if ra.bucket != rb.bucket {
+			return ra.bucket > rb.bucket
+		}
+		return ra.latency < rb.latency
+	})
+	return order
+}
+
+// ProviderScores reports the rolling health of each provider in the order the
+// next lookup would try them.
+func (s *Service) ProviderScores() []ProviderScore {
+	scores := make([]ProviderScore, 0, len(s.providers))
+	for _, i := range s.providerSequence() {
+		scores = append(scores, s.scores[i].snapshot())
+	}
+	return scores
+}
@@ -0,0 +1,73 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// namedProvider gives a test provider a stable name in scores.
+type namedProvider struct {
+	ProviderFunc
+	name string
+}
+
+func (p namedProvider) String() string { return p.name }
+
+func TestServiceDynamicProviderOrder(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	var primaryDown bool
+	var calls []string
+	provider := func(name string, latency time.Duration, down *bool) Provider {
+		return namedProvider{name: name, ProviderFunc: func(context.Context, string) (*Response, error) {
+			calls = append(calls, name)
+			now = now.Add(latency)
+			if down != nil && *down {
+				return nil, errors.New("connection refused")
+			}
+			return &Response{}, nil
+		}}
+	}
+	service := NewService(nil, nil, 0, noopLogger(),
+		WithProviders(provider("primary", 200*time.Millisecond, &primaryDown), provider("secondary", 50*time.Millisecond, nil)),
+		WithDynamicProviderOrder(true))
+	service.now = func() time.Time { return now }
+	ctx := context.Background()
+
+	// Unscored providers keep the configured order.
+	_, err := service.fetchFromProviders(ctx, "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"primary"}, calls)
+
+	// Failures demote the primary; the secondary is tried first from then on.
+	primaryDown = true
+	for i := 0; i < 3; i++ {
+		_, err = service.fetchFromProviders(ctx, "01001000")
+		assert.NoError(t, err)
+	}
+	assert.Equal(t, []string{"primary", "primary", "secondary", "secondary", "secondary"}, calls)
+
+	scores := service.ProviderScores()
+	assert.Equal(t, "secondary", scores[0].Provider)
+	assert.Equal(t, 1.0, scores[0].SuccessRate)
+	assert.InDelta(t, 50, scores[0].LatencyMS, 0.001)
+	assert.Equal(t, uint64(3), scores[0].Samples)
+	assert.Equal(t, "primary", scores[1].Provider)
+	assert.InDelta(t, 0.9, scores[1].SuccessRate, 0.001)
+}
+
+func TestServiceDynamicProviderOrderPrefersFaster(t *testing.T) {
+	service := NewService(nil, nil, 0, noopLogger(),
+		WithProviders(namedProvider{name: "slow"}, namedProvider{name: "fast"}),
+		WithDynamicProviderOrder(true))
+	service.scores[0].record(300*time.Millisecond, nil)
+	service.scores[1].record(40*time.Millisecond, ErrNotFound)
+
+	assert.Equal(t, []int{1, 0}, service.providerSequence())
+
+	service.dynamicOrder = false
+	assert.Equal(t, []int{0, 1}, service.providerSequence())
+}
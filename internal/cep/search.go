@@ -0,0 +1,201 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"net/http"
+	"net/url"
+	"strings"
+	"sync"
+	"time"
+	"unicode"
+	"unicode/utf8"
+)
+
+// viaCEPSearchURL is ViaCEP's address search, ws/{UF}/{cidade}/{logradouro}/json.
+const viaCEPSearchURL = "https://viacep.com.br/ws/%s/%s/%s/json/"
+
+// DefaultSearchMaxLength bounds free-text search parameters when no limit is configured.
+const DefaultSearchMaxLength = 100
+
+// minSearchLength mirrors ViaCEP, which rejects city/street terms shorter than 3 characters.
+const minSearchLength = 3
+
+// ErrInvalidSearch indicates that address search parameters are missing, oversized, or malformed.
+var ErrInvalidSearch = errors.New("invalid search query")
+
+// SearchQuery holds the parameters of an address-to-CEP search.
+type SearchQuery struct {
+	UF     string
+	City   string
+	Street string
+}
+
+// Validate checks the query before it is used to build an upstream URL.
+// maxLength <= 0 falls back to DefaultSearchMaxLength.
+func (q SearchQuery) Validate(maxLength int) error {
+	if maxLength <= 0 {
+		maxLength = DefaultSearchMaxLength
+	}
+
+	if len(q.UF) != 2 || !isASCIILetters(q.UF) {
+		return fmt.Errorf("%w: uf must have exactly 2 letters", ErrInvalidSearch)
+	}
+
+	if err := validateSearchTerm("city", q.City, maxLength); err != nil {
+		return err
+	}
+	return validateSearchTerm("street", q.Street, maxLength)
+}
+
+// WithSearchMaxLength bounds the city and street search terms; n <= 0 keeps
+// DefaultSearchMaxLength.
+func WithSearchMaxLength(n int) Option {
+	return func(s *Service) {
+		s.searchMaxLength = n
+	}
+}
+
+// WithSearchCacheTTL keeps address search results in process memory for ttl,
+// so repeated searches skip ViaCEP. Zero disables the cache.
+func WithSearchCacheTTL(ttl time.Duration) Option {
+	return func(s *Service) {
+		if ttl > 0 {
+			s.searchCache = &searchCache{ttl: ttl, entries: map[string]searchCacheEntry{}}
+		}
+	}
+}
+
+// Search returns the CEPs ViaCEP knows for a street in a city. Only ViaCEP
+// offers address search, so it is used regardless of the provider chain, with
+// the same retries, hedging and credentials as lookups. No match yields an
+// empty slice.
+func (s *Service) Search(ctx context.Context, q SearchQuery) ([]Response, error) {
+	q = SearchQuery{
+		UF:     strings.ToUpper(strings.TrimSpace(q.UF)),
+		City:   strings.TrimSpace(q.City),
+		Street: strings.TrimSpace(q.Street),
+	}
+	if err := q.Validate(s.searchMaxLength); err != nil {
+		return nil, err
+	}
+
+	key := strings.ToLower(q.UF + "\n" + q.City + "\n" + q.Street)
+	if results, ok := s.searchCache.get(key, s.now()); ok {
+		return results, nil
+	}
+
+	s.counters.providerCalls.Add(1)
+	target := fmt.Sprintf(viaCEPSearchURL, q.UF, url.PathEscape(q.City), url.PathEscape(q.Street))
+	resp, err := s.doProviderRequest(ctx, target, s.authFor(ProviderViaCEP))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode >= http.StatusBadRequest {
+		return nil, fmt.Errorf("viacep search returned status %d", resp.StatusCode)
+	}
+
+	var results []Response
+	if err := json.NewDecoder(resp.Body).Decode(&results); err != nil {
+		return nil, fmt.Errorf("%w: search: %v", ErrUpstreamBadResponse, err)
+	}
+	if results == nil {
+		results = []Response{}
+	}
+	for i := range results {
+		s.finishResponse("", &results[i])
+	}
+
+	s.searchCache.set(key, results, s.now())
+	return results, nil
+}
+
+// searchCache holds address search results for WithSearchCacheTTL. A nil
+// cache is disabled.
+type searchCache struct {
+	ttl time.Duration
+
+	mu      sync.Mutex
+	entries map[string]searchCacheEntry
+}
+
+type searchCacheEntry struct {
+	results   []Response
+	expiresAt time.Time
+}
+
+func (c *searchCache) get(key string, now time.Time) ([]Response, bool) {
+	if c == nil {
+		return nil, false
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	entry, ok := c.entries[key]
+	if !ok || !now.Before(entry.expiresAt) {
+		return nil, false
+	}
+	// Callers may enrich the results; hand out a copy.
+	return append([]Response(nil), entry.results...), true
+}
+
+func (c *searchCache) set(key string, results []Response, now time.Time) {
+	if c == nil {
+		return
+	}
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	// Sweeping on write keeps the map bounded by the searches made within one TTL.
+	for k, entry := range c.entries {
+		if !now.Before(entry.expiresAt) {
+			delete(c.entries, k)
+		}
+	}
+	c.entries[key] = searchCacheEntry{
+		results:   append([]Response(nil), results...),
+		expiresAt: now.Add(c.ttl),
+	}
+}
+
+func validateSearchTerm(name, value string, maxLength int) error {
+	length := utf8.RuneCountInString(strings.TrimSpace(value))
+	if length < minSearchLength {
+		return fmt.Errorf("%w: %s must have at least %d characters", ErrInvalidSearch, name, minSearchLength)
+	}
+	if length > maxLength {
+		return fmt.Errorf("%w: %s exceeds %d characters", ErrInvalidSearch, name, maxLength)
+	}
+
+	for _, r := range value {
+		if !isAllowedSearchRune(r) {
+			return fmt.Errorf("%w: %s contains disallowed character %q", ErrInvalidSearch, name, r)
+		}
+	}
+	return nil
+}
+
+// isAllowedSearchRune accepts letters (accents included), digits, spaces and
+// the punctuation that shows up in Brazilian street names.
+func isAllowedSearchRune(r rune) bool {
+	switch {
+	case unicode.IsLetter(r), unicode.IsDigit(r):
+		return true
+	case r == ' ', r == '.', r == '-', r == '\'', r == ',':
+		return true
+	}
+	return false
+}
+
+func isASCIILetters(value string) bool {
+	for _, r := range value {
+		if (r < 'a' || r > 'z') && (r < 'A' || r > 'Z') {
+			return false
+		}
+	}
+	return true
+}
@@ -0,0 +1,81 @@
+package cep
+
+import (
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestSearchQueryValidate(t *testing.T) {
+	t.Parallel()
+
+	valid := SearchQuery{UF: "SP", City: "São Paulo", Street: "Av. Paulista"}
+	assert.NoError(t, valid.Validate(0))
+
+	tooLong := SearchQuery{UF: "SP", City: "São Paulo", Street: strings.Repeat("a", 51)}
+	err := tooLong.Validate(50)
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+	assert.Contains(t, err.Error(), "street exceeds 50 characters")
+
+	disallowed := SearchQuery{UF: "SP", City: "São Paulo/../x", Street: "Paulista"}
+	err = disallowed.Validate(0)
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+	assert.Contains(t, err.Error(), "city contains disallowed character")
+
+	tooShort := SearchQuery{UF: "SP", City: "São Paulo", Street: "Av"}
+	assert.ErrorIs(t, tooShort.Validate(0), ErrInvalidSearch)
+
+	badUF := SearchQuery{UF: "S1", City: "São Paulo", Street: "Paulista"}
+	assert.ErrorIs(t, badUF.Validate(0), ErrInvalidSearch)
+}
+
+func TestServiceSearch(t *testing.T) {
+	t.Parallel()
+
+	var paths []string
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		paths = append(paths, req.URL.EscapedPath())
+		body := `[{"cep":"01310-100","logradouro":"Avenida Paulista ","uf":"SP"},{"cep":"01310-200","logradouro":"Avenida Paulista","uf":"SP"}]`
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(), WithSearchCacheTTL(time.Minute))
+
+	results, err := service.Search(context.Background(), SearchQuery{UF: "sp", City: "São Paulo", Street: "Avenida Paulista"})
+	assert.NoError(t, err)
+	assert.Len(t, results, 2)
+	assert.Equal(t, "Avenida Paulista", results[0].Logradouro, "results are trimmed like lookups")
+	assert.Equal(t, []string{"/ws/SP/S%C3%A3o%20Paulo/Avenida%20Paulista/json/"}, paths)
+
+	_, err = service.Search(context.Background(), SearchQuery{UF: "SP", City: "são paulo", Street: " avenida paulista"})
+	assert.NoError(t, err)
+	assert.Len(t, paths, 1, "equivalent searches are served from the cache")
+	assert.Equal(t, uint64(1), service.Metrics().ProviderCalls)
+}
+
+func TestServiceSearchNoResultsAndErrors(t *testing.T) {
+	t.Parallel()
+
+	body := `[]`
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger())
+	query := SearchQuery{UF: "SP", City: "São Paulo", Street: "Rua Inexistente"}
+
+	results, err := service.Search(context.Background(), query)
+	assert.NoError(t, err)
+	assert.NotNil(t, results)
+	assert.Empty(t, results)
+
+	body = `{"erro": true}`
+	_, err = service.Search(context.Background(), query)
+	assert.ErrorIs(t, err, ErrUpstreamBadResponse)
+
+	_, err = service.Search(context.Background(), SearchQuery{UF: "SP", City: "São Paulo", Street: "Av"})
+	assert.ErrorIs(t, err, ErrInvalidSearch)
+}
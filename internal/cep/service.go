@@ -6,22 +6,78 @@ import (
 	"encoding/json"
 	"errors"
 	"fmt"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync/atomic"
 	"time"
+	"unicode"
 )
 
-const viaCepURL = "https://viacep.com.br/ws/%s/json/"
-
 // ErrInvalidCEP indicates that the provided value does not match the expected CEP format.
 var ErrInvalidCEP = errors.New("invalid CEP: expected exactly 8 digits")
 
+// ErrNonASCIIDigit rejects CEPs written with digits from other scripts (e.g.
+// Arabic-Indic numerals). Stripping them could turn a typo into a valid but
+// wrong CEP, so they are refused rather than ignored.
+var ErrNonASCIIDigit = fmt.Errorf("%w (only ASCII digits 0-9 are accepted)", ErrInvalidCEP)
+
 // ErrNotFound is returned when neither the cache nor ViaCEP know the requested CEP.
 var ErrNotFound = errors.New("cep not found")
 
-// httpClient is the subset of http.Client used by Service, enabling tests with stubs.
-type httpClient interface {
+// ErrUpstreamBadResponse signals that ViaCEP answered with a malformed payload.
+var ErrUpstreamBadResponse = errors.New("bad response from upstream")
+
+// ErrNoDataSource means neither the cache nor the provider could answer.
+var ErrNoDataSource = errors.New("no data source available")
+
+// ErrNoDatabase is returned by operations that need the PostgreSQL cache when
+// the service runs in memory-only mode.
+var ErrNoDatabase = errors.New("operation requires the PostgreSQL cache")
+
+// TrailingDataPolicy controls how Service reacts to content after the JSON object in a ViaCEP response.
+type TrailingDataPolicy string
+
+// Supported trailing data policies.
+const (
+	TrailingDataIgnore TrailingDataPolicy = "ignore"
+	TrailingDataWarn   TrailingDataPolicy = "warn"
+	TrailingDataStrict TrailingDataPolicy = "strict"
+)
+
+// UpsertMode controls how cache writes treat an existing row for the same CEP.
+type UpsertMode string
+
+// Supported upsert modes.
+const (
+	// UpsertUpdate replaces the existing row unless it is newer than the write.
+	UpsertUpdate UpsertMode = "update"
+	// UpsertKeepFresh additionally leaves rows that are still within the cache TTL
+	// untouched, so concurrent misses for the same CEP do not rewrite each other.
+	UpsertKeepFresh UpsertMode = "keep-fresh"
+)
+
+// MismatchPolicy controls what happens when the provider answers with a
+// different CEP than requested, e.g. the canonical CEP of a range.
+type MismatchPolicy string
+
+// Supported mismatch policies.
+const (
+	// MismatchRequested caches and returns the entry under the requested CEP.
+	MismatchRequested MismatchPolicy = "requested"
+	// MismatchCanonical caches and returns the entry under the provider's CEP only.
+	MismatchCanonical MismatchPolicy = "canonical"
+	// MismatchAlias returns the provider's CEP and caches it under both keys.
+	MismatchAlias MismatchPolicy = "alias"
+)
+
+// CacheSchemaVersion tags cached rows with the shape of Response they were
+// written with. Bump it whenever Response gains fields so older rows are
+// refreshed instead of served with the outdated shape.
+const CacheSchemaVersion = 2
+
+// HTTPClient is the subset of http.Client used by Service, enabling tests with stubs.
+type HTTPClient interface {
 	Do(req *http.Request) (*http.Response, error)
 }
 
@@ -39,79 +95,539 @@ type Response struct {
 	Siafi       string `json:"siafi"`
 	Unidade     string `json:"unidade"`
 	Erro        bool   `json:"erro,omitempty"`
+	// Latitude and Longitude are only filled in by providers that know them,
+	// such as CEP Aberto.
+	Latitude  string `json:"latitude,omitempty"`
+	Longitude string `json:"longitude,omitempty"`
+
+	// Timezone is request-time enrichment (?timezone=true); it is never cached.
+	Timezone string `json:"timezone,omitempty"`
+	// ComplementoParsed is request-time enrichment (?parse_complemento=true); it is never cached.
+	ComplementoParsed *Complemento `json:"complemento_parsed,omitempty"`
+}
+
+// ProviderAuth holds credentials attached to outbound provider requests.
+// An empty APIKey sends no credentials, which is what ViaCEP expects.
+type ProviderAuth struct {
+	APIKey string
+	// Header receives the key verbatim (e.g. "X-API-Key" or "Authorization").
+	Header string
+	// QueryParam, when set, sends the key as a query parameter instead of a header.
+	QueryParam string
+}
+
+// Cache tiers and sources that can serve a lookup.
+const (
+	SourceMemory   = "memory"
+	SourcePostgres = "postgres"
+	SourceProvider = "provider"
+)
+
+// Result wraps a Response with details about how the lookup was served.
+type Result struct {
+	Response       *Response
+	ProviderCalled bool
+	// Source names the tier that served the response (SourceMemory, SourcePostgres or SourceProvider).
+	Source string
+	// Age is how old the served entry is; zero for fresh provider data.
+	Age time.Duration
+	// UpdatedAt is when the served entry was fetched from the provider, in UTC.
+	UpdatedAt time.Time
+	// Stale is set when an expired entry was served because the provider failed.
+	Stale bool
+}
+
+// CacheInfo describes what the cache knows about a CEP without consulting ViaCEP.
+type CacheInfo struct {
+	Cached    bool
+	Expired   bool
+	UpdatedAt time.Time
+	Age       time.Duration
+	Source    string
 }
 
-// Service fetches CEP details, caching them in PostgreSQL.
+// Service fetches CEP details, caching them in PostgreSQL or, without a
+// database, in process memory.
 type Service struct {
 	db        *sql.DB
-	client    httpClient
+	memory    *memoryCache
+	client    HTTPClient
+	providers []Provider
 	cacheTTL  time.Duration
-	logger    *log.Logger
+	logger    *slog.Logger
 	now       func() time.Time
 	tableName string
+
+	trimWhitespace bool
+	lenientCEP     bool
+	trailingData   TrailingDataPolicy
+	exportPageSize int
+	upsertMode     UpsertMode
+	providerAuth   ProviderAuth
+	hedgeDelay     time.Duration
+	hedgeSlots     chan struct{}
+	mismatch       MismatchPolicy
+	observe        ProviderObserver
+	observeQuery   QueryObserver
+	escalation     *failureEscalator
+	generations    *generations
+	staleGrace     time.Duration
+	coalescer      *coalescer
+	providerOrder  []ProviderName
+
+	providerStrategy ProviderStrategy
+	providerTimeouts map[ProviderName]time.Duration
+	credentials      map[ProviderName]ProviderAuth
+	breakerConfig    BreakerConfig
+	retry            retryPolicy
+	dynamicOrder     bool
+	maxProviders     int
+	fanoutBudget     time.Duration
+	regions          atomic.Pointer[RegionalProviderOrder]
+	scores           []*providerScore
+	shadowName       ProviderName
+	shadowPercent    int
+	shadow           *shadowing
+	searchMaxLength  int
+	searchCache      *searchCache
+
+	counters lookupCounters
+}
+
+// Option customises optional Service behaviour.
+type Option func(*Service)
+
+// WithTrimWhitespace toggles trimming of every string field in ViaCEP responses,
+// turning whitespace-only values into empty strings. Enabled by default.
+func WithTrimWhitespace(enabled bool) Option {
+	return func(s *Service) {
+		s.trimWhitespace = enabled
+	}
+}
+
+// WithLenientCEP enables correction of common OCR confusions (letter O for zero,
+// I/l for one, ...) when a CEP fails strict validation. Disabled by default.
+func WithLenientCEP(enabled bool) Option {
+	return func(s *Service) {
+		s.lenientCEP = enabled
+	}
+}
+
+// WithTrailingDataPolicy sets how trailing content after the upstream JSON object
+// is handled: ignored (default), logged as a warning, or rejected.
+func WithTrailingDataPolicy(policy TrailingDataPolicy) Option {
+	return func(s *Service) {
+		s.trailingData = policy
+	}
+}
+
+// WithUpsertMode selects how cache writes resolve conflicts with an existing row.
+func WithUpsertMode(mode UpsertMode) Option {
+	return func(s *Service) {
+		s.upsertMode = mode
+	}
+}
+
+// WithProviderAuth attaches credentials to every outbound provider request.
+func WithProviderAuth(auth ProviderAuth) Option {
+	return func(s *Service) {
+		s.providerAuth = auth
+	}
+}
+
+// WithMismatchPolicy selects how provider answers for a different CEP are cached
+// and returned. Defaults to MismatchAlias.
+func WithMismatchPolicy(policy MismatchPolicy) Option {
+	return func(s *Service) {
+		s.mismatch = policy
+	}
+}
+
+// ProviderObserver is notified after every call to a provider with the
+// provider's name, the call's duration and its outcome (nil, ErrNotFound or
+// another error). Calls abandoned because the lookup was cancelled, including
+// the losers of a parallel race, are not reported.
+type ProviderObserver func(ctx context.Context, provider string, elapsed time.Duration, err error)
+
+// WithProviderObserver registers fn to observe provider calls, e.g. to feed
+// per-provider latency histograms.
+func WithProviderObserver(fn ProviderObserver) Option {
+	return func(s *Service) {
+		s.observe = fn
+	}
+}
+
+// Cache queries reported to a QueryObserver.
+const (
+	QueryCacheSelect = "cache_select"
+	QueryCacheUpsert = "cache_upsert"
+)
+
+// QueryObserver is notified after every PostgreSQL cache read or write on the
+// lookup path with the query's name (QueryCacheSelect or QueryCacheUpsert), its
+// duration and its error. A missing row is not an error.
+type QueryObserver func(query string, elapsed time.Duration, err error)
+
+// WithQueryObserver registers fn to observe cache queries, e.g. to feed a
+// latency histogram. It is never called in memory-only mode.
+func WithQueryObserver(fn QueryObserver) Option {
+	return func(s *Service) {
+		s.observeQuery = fn
+	}
+}
+
+// WithStaleOnError serves an expired cache entry when the provider fails, as
+// long as it expired no more than grace ago. A grace <= 0 disables it.
+func WithStaleOnError(grace time.Duration) Option {
+	return func(s *Service) {
+		s.staleGrace = grace
+	}
 }
 
 // NewService builds a Service. cacheTTL <= 0 disables cache expiration.
-func NewService(db *sql.DB, client httpClient, cacheTTL time.Duration, logger *log.Logger) *Service {
+// A nil db switches the cache to process memory (memory-only mode).
+func NewService(db *sql.DB, client HTTPClient, cacheTTL time.Duration, logger *slog.Logger, opts ...Option) *Service {
 	if logger == nil {
-		logger = log.New(log.Writer(), "", log.LstdFlags)
+		logger = slog.Default()
 	}
 
-	return &Service{
-		db:        db,
-		client:    client,
-		cacheTTL:  cacheTTL,
-		logger:    logger,
-		now:       time.Now,
-		tableName: "ceps",
+	s := &Service{
+		db:             db,
+		client:         client,
+		cacheTTL:       cacheTTL,
+		logger:         logger,
+		now:            time.Now,
+		tableName:      "ceps",
+		trimWhitespace: true,
+		trailingData:   TrailingDataIgnore,
+		upsertMode:     UpsertUpdate,
+		mismatch:       MismatchAlias,
+		generations:    newGenerations(),
+	}
+	if db == nil {
+		s.memory = newMemoryCache()
+	}
+	for _, opt := range opts {
+		opt(s)
 	}
+	if len(s.providers) == 0 && client != nil {
+		s.providers = s.builtinProviders()
+	}
+	s.wrapBreakers()
+	s.initScores()
+	s.initShadow()
+
+	return s
 }
 
 // Get retrieves CEP information from cache or ViaCEP.
 func (s *Service) Get(ctx context.Context, rawCEP string) (*Response, error) {
-	cepDigits, err := normalizeCEP(rawCEP)
+	result, err := s.Lookup(ctx, rawCEP)
 	if err != nil {
-		return nil, ErrInvalidCEP
+		return nil, err
 	}
+	return result.Response, nil
+}
 
-	if cached, err := s.loadFromCache(ctx, cepDigits); err != nil {
-		return nil, fmt.Errorf("query cache: %w", err)
-	} else if cached != nil {
-		return cached, nil
+// Lookup behaves like Get but also reports how the response was obtained.
+func (s *Service) Lookup(ctx context.Context, rawCEP string) (*Result, error) {
+	result, err := s.lookup(ctx, rawCEP)
+	s.countLookupError(err)
+	return result, err
+}
+
+func (s *Service) lookup(ctx context.Context, rawCEP string) (*Result, error) {
+	// Abandoned requests should not cost a cache query or a provider round trip.
+	if err := ctx.Err(); err != nil {
+		return nil, err
 	}
 
-	fresh, err := s.fetchFromViaCEP(ctx, cepDigits)
+	cepDigits, err := s.normalize(rawCEP)
 	if err != nil {
 		return nil, err
 	}
 
-	if err := s.saveToCache(ctx, cepDigits, fresh); err != nil {
-		s.logger.Printf("warn: failed to persist cep %s cache: %v", cepDigits, err)
+	// A failing cache degrades to provider-only lookups instead of failing the request.
+	cached, cacheErr := s.loadFromCache(ctx, cepDigits)
+	if cacheErr != nil {
+		s.logger.WarnContext(ctx, "cache lookup failed, trying provider", "cep", cepDigits, "err", cacheErr)
+	} else if cached != nil && !cached.expired {
+		s.counters.cacheHits.Add(1)
+		s.logger.DebugContext(ctx, "cache hit", "cep", cepDigits)
+		return s.cachedResult(cached), nil
 	}
+	s.counters.cacheMisses.Add(1)
+	if cached != nil {
+		s.counters.cacheExpired.Add(1)
+	}
+	s.logger.DebugContext(ctx, "cache miss, fetching from provider", "cep", cepDigits)
+
+	t := s.generations.begin(cepDigits)
+	defer s.generations.end(t)
+	// Rows are stamped with when the fetch started, not when it was written, so
+	// a slow fetch cannot pass off its answer as newer than one fetched after it.
+	fetchedAt := s.now().UTC()
+	fresh, shared, err := s.fetchShared(ctx, cepDigits)
+	if err != nil {
+		if cacheErr != nil && !errors.Is(err, ErrNotFound) {
+			return nil, fmt.Errorf("%w: cache: %v; provider: %v", ErrNoDataSource, cacheErr, err)
+		}
+		if stale := s.staleFallback(cached, err); stale != nil {
+			s.logger.WarnContext(ctx, "serving stale cep after provider error", "cep", cepDigits, "age", stale.Age.Round(time.Second), "err", err)
+			return stale, nil
+		}
+		return nil, err
+	}
+
+	if shared {
+		// The caller that fetched it has already cached it.
+		return &Result{Response: fresh, Source: SourceProvider, UpdatedAt: s.now().UTC()}, nil
+	}
+
+	s.persist(ctx, t, fresh, fetchedAt, s.upsertMode)
+
+	return &Result{Response: fresh, ProviderCalled: true, Source: SourceProvider, UpdatedAt: fetchedAt}, nil
+}
+
+// persist caches a fresh provider answer, fetched at fetchedAt, under every key
+// the mismatch policy selects, skipping keys invalidated since t was taken.
+// mode decides whether rows still within the cache TTL are replaced.
+func (s *Service) persist(ctx context.Context, t ticket, fresh *Response, fetchedAt time.Time, mode UpsertMode) {
+	for _, key := range s.cacheKeys(ctx, t.cep, fresh) {
+		saved, err := s.saveKey(ctx, t, key, fresh, fetchedAt, mode)
+		if err != nil {
+			s.logger.WarnContext(ctx, "failed to persist cep cache", "cep", key, "err", err)
+		} else if !saved {
+			s.logger.WarnContext(ctx, "cep was invalidated during lookup, not caching", "cep", key)
+		}
+	}
+}
 
-	return fresh, nil
+// saveKey writes fresh under key, guarded by t when key is the CEP looked up.
+func (s *Service) saveKey(ctx context.Context, t ticket, key string, fresh *Response, fetchedAt time.Time, mode UpsertMode) (bool, error) {
+	if key != t.cep {
+		// Alias keys were unknown before the fetch; guard from now on.
+		t = s.generations.begin(key)
+		defer s.generations.end(t)
+	}
+	return s.saveIfCurrent(ctx, t, fresh, fetchedAt, mode)
 }
 
-// Ping confirms the database connection is alive.
+// fetchShared fetches cep from the provider, coalescing with other lookups for
+// the same CEP when a coalescing window is configured.
+func (s *Service) fetchShared(ctx context.Context, cep string) (*Response, bool, error) {
+	if s.coalescer == nil {
+		resp, err := s.fetchObserved(ctx, cep)
+		return resp, false, err
+	}
+	return s.coalescer.fetch(ctx, cep, func(ctx context.Context) (*Response, error) {
+		return s.fetchObserved(ctx, cep)
+	})
+}
+
+// fetchObserved performs a provider fetch, recording counters and failure logs.
+func (s *Service) fetchObserved(ctx context.Context, cep string) (*Response, error) {
+	s.counters.providerCalls.Add(1)
+	fresh, err := s.fetchFromProviders(ctx, cep)
+	switch {
+	case err == nil, errors.Is(err, ErrNotFound):
+		s.logProviderSuccess(ctx)
+		s.shadowLookup(ctx, cep, fresh)
+		if err == nil {
+			s.matchRequested(ctx, cep, fresh)
+		}
+	case ctx.Err() == nil:
+		s.logProviderFailure(ctx, cep, err)
+	}
+	return fresh, err
+}
+
+// cachedResult builds the Result for a cache-served entry.
+func (s *Service) cachedResult(entry *cacheEntry) *Result {
+	return &Result{
+		Response:  entry.resp,
+		Source:    s.cacheSource(),
+		Age:       s.now().Sub(entry.updatedAt),
+		UpdatedAt: entry.updatedAt.UTC(),
+		Stale:     entry.expired,
+	}
+}
+
+// staleFallback returns the expired entry as a stale Result when the provider
+// failed and the entry is still within the stale-on-error grace period.
+func (s *Service) staleFallback(entry *cacheEntry, providerErr error) *Result {
+	if entry == nil || s.staleGrace <= 0 || errors.Is(providerErr, ErrNotFound) {
+		return nil
+	}
+	if s.now().Sub(entry.updatedAt) > s.cacheTTL+s.staleGrace {
+		return nil
+	}
+	s.counters.staleOnError.Add(1)
+	return s.cachedResult(entry)
+}
+
+// matchRequested rewrites resp to the requested CEP under MismatchRequested.
+// It runs inside the provider fetch, before coalesced callers share the answer,
+// so every one of them gets the CEP the leader caches.
+func (s *Service) matchRequested(ctx context.Context, requested string, resp *Response) {
+	if s.mismatch != MismatchRequested {
+		return
+	}
+	returned, err := normalizeCEP(resp.Cep)
+	if err != nil || returned == requested {
+		return
+	}
+	s.logger.WarnContext(ctx, "provider returned a different cep", "returned", returned, "requested", requested, "policy", s.mismatch)
+	resp.Cep = formatCEP(requested)
+}
+
+// cacheKeys returns the CEPs a fresh provider answer is cached under, applying
+// the mismatch policy when the provider returned a different CEP than requested.
+// Answers under MismatchRequested were already rewritten by matchRequested.
+func (s *Service) cacheKeys(ctx context.Context, requested string, resp *Response) []string {
+	returned, err := normalizeCEP(resp.Cep)
+	if err != nil || returned == requested {
+		return []string{requested}
+	}
+
+	s.logger.WarnContext(ctx, "provider returned a different cep", "returned", returned, "requested", requested, "policy", s.mismatch)
+	switch s.mismatch {
+	case MismatchRequested:
+		return []string{requested}
+	case MismatchCanonical:
+		return []string{returned}
+	default:
+		return []string{requested, returned}
+	}
+}
+
+// Ping confirms the database connection is alive. Memory-only services have
+// no external dependency and always succeed.
 func (s *Service) Ping(ctx context.Context) error {
+	if s.db == nil {
+		return nil
+	}
 	return s.db.PingContext(ctx)
 }
 
-func (s *Service) loadFromCache(ctx context.Context, cep string) (*Response, error) {
-	query := fmt.Sprintf("SELECT payload, updated_at FROM %s WHERE cep = $1", s.tableName)
+// isExpired reports whether an entry written at updatedAt must be refreshed.
+// Entries force-expired by ExpireAll are stale even when the TTL is disabled.
+func (s *Service) isExpired(updatedAt time.Time) bool {
+	if !updatedAt.After(expiredAt) {
+		return true
+	}
+	return s.cacheTTL > 0 && s.now().Sub(updatedAt) > s.cacheTTL
+}
+
+// probeCEP is looked up when checking that the provider is reachable.
+const probeCEP = "01001000"
+
+// CheckDataSources verifies that at least one data source can serve lookups:
+// a reachable PostgreSQL cache, or a reachable provider. The in-memory cache
+// starts empty, so in memory-only mode the provider must be reachable.
+func (s *Service) CheckDataSources(ctx context.Context) error {
+	cacheErr := errors.New("memory cache starts empty")
+	if s.db != nil {
+		cacheErr = s.db.PingContext(ctx)
+	}
+	if cacheErr == nil {
+		return nil
+	}
+
+	providerErr := errors.New("no provider configured")
+	if len(s.providers) > 0 {
+		_, providerErr = s.fetchFromProviders(ctx, probeCEP)
+	}
+	if providerErr == nil || errors.Is(providerErr, ErrNotFound) {
+		return nil
+	}
+
+	return fmt.Errorf("%w: cache: %v; provider: %v", ErrNoDataSource, cacheErr, providerErr)
+}
+
+// cacheSource names the cache tier backing this service.
+func (s *Service) cacheSource() string {
+	if s.db == nil {
+		return SourceMemory
+	}
+	return SourcePostgres
+}
+
+// Inspect reports the cache state of a CEP. It never triggers a ViaCEP fetch.
+func (s *Service) Inspect(ctx context.Context, rawCEP string) (*CacheInfo, error) {
+	cepDigits, err := s.normalize(rawCEP)
+	if err != nil {
+		return nil, err
+	}
+
+	var updatedAt time.Time
+	if s.db == nil {
+		entry, ok := s.memory.get(cepDigits)
+		if !ok {
+			return &CacheInfo{}, nil
+		}
+		updatedAt = entry.updatedAt
+	} else {
+		query := fmt.Sprintf("SELECT updated_at FROM %s WHERE cep = $1", s.tableName)
+		row := s.db.QueryRowContext(ctx, query, cepDigits)
+
+		switch err := row.Scan(&updatedAt); {
+		case errors.Is(err, sql.ErrNoRows):
+			return &CacheInfo{}, nil
+		case err != nil:
+			return nil, fmt.Errorf("query cache: %w", err)
+		}
+	}
+
+	age := s.now().Sub(updatedAt)
+	return &CacheInfo{
+		Cached:    true,
+		Expired:   s.isExpired(updatedAt),
+		UpdatedAt: updatedAt,
+		Age:       age,
+		Source:    s.cacheSource(),
+	}, nil
+}
+
+// cacheEntry is a cached response. Expired entries are still returned so they
+// can be served as a stale fallback when the provider fails.
+type cacheEntry struct {
+	resp      *Response
+	updatedAt time.Time
+	expired   bool
+}
+
+func (s *Service) loadFromCache(ctx context.Context, cep string) (*cacheEntry, error) {
+	if s.db == nil {
+		entry, ok := s.memory.get(cep)
+		if !ok {
+			return nil, nil
+		}
+		resp := entry.resp
+		return &cacheEntry{resp: &resp, updatedAt: entry.updatedAt, expired: s.isExpired(entry.updatedAt)}, nil
+	}
+
+	query := fmt.Sprintf("SELECT payload, updated_at, schema_version FROM %s WHERE cep = $1", s.tableName)
+	start := s.now()
 	row := s.db.QueryRowContext(ctx, query, cep)
 
 	var payload []byte
 	var updatedAt time.Time
+	var schemaVersion int
 
-	switch err := row.Scan(&payload, &updatedAt); {
-	case errors.Is(err, sql.ErrNoRows):
+	err := row.Scan(&payload, &updatedAt, &schemaVersion)
+	if errors.Is(err, sql.ErrNoRows) {
+		s.reportQuery(QueryCacheSelect, start, nil)
 		return nil, nil
-	case err != nil:
+	}
+	s.reportQuery(QueryCacheSelect, start, err)
+	if err != nil {
 		return nil, err
 	}
 
-	if s.cacheTTL > 0 && s.now().Sub(updatedAt) > s.cacheTTL {
+	// Rows written before the current Response shape are treated as misses so
+	// the refreshed value replaces them.
+	if schemaVersion < CacheSchemaVersion {
 		return nil, nil
 	}
 
@@ -119,64 +635,155 @@ func (s *Service) loadFromCache(ctx context.Context, cep string) (*Response, err
 	if err := json.Unmarshal(payload, &resp); err != nil {
 		return nil, err
 	}
-	return &resp, nil
+	return &cacheEntry{resp: &resp, updatedAt: updatedAt, expired: s.isExpired(updatedAt)}, nil
 }
 
-func (s *Service) saveToCache(ctx context.Context, cep string, data *Response) error {
+// saveToCache stores data under cep as fetched from the provider at fetchedAt.
+// An entry fetched later than that is kept, and so is one still within the
+// cache TTL when mode is UpsertKeepFresh.
+func (s *Service) saveToCache(ctx context.Context, cep string, data *Response, fetchedAt time.Time, mode UpsertMode) error {
+	if s.db == nil {
+		s.memory.set(cep, *data, fetchedAt.UTC())
+		return nil
+	}
+
 	payload, err := json.Marshal(data)
 	if err != nil {
 		return err
 	}
 
+	args := []any{cep, payload, fetchedAt.UTC(), CacheSchemaVersion}
+
+	// Never let a slower writer replace a row that a concurrent lookup already
+	// refreshed with newer data. Rows with an outdated schema are always replaced.
+	guard := fmt.Sprintf("(%[1]s.updated_at <= EXCLUDED.updated_at OR %[1]s.schema_version < EXCLUDED.schema_version)", s.tableName)
+	if mode == UpsertKeepFresh && s.cacheTTL > 0 {
+		guard += fmt.Sprintf(" AND (%[1]s.updated_at < $5 OR %[1]s.schema_version < EXCLUDED.schema_version)", s.tableName)
+		args = append(args, s.now().UTC().Add(-s.cacheTTL))
+	}
+
 	query := fmt.Sprintf(`
-		INSERT INTO %s (cep, payload, updated_at)
-		VALUES ($1, $2, $3)
+		INSERT INTO %s (cep, payload, updated_at, schema_version)
+		VALUES ($1, $2, $3, $4)
 		ON CONFLICT (cep)
-		DO UPDATE SET payload = EXCLUDED.payload, updated_at = EXCLUDED.updated_at
-	`, s.tableName)
-
-	_, err = s.db.ExecContext(ctx, query, cep, payload, s.now().UTC())
-	return err
+		DO UPDATE SET payload = EXCLUDED.payload, updated_at = EXCLUDED.updated_at, schema_version = EXCLUDED.schema_version
+		WHERE %s
+	`, s.tableName, guard)
+
+	// The upsert touches a single row, so concurrent writers of the same CEP only
+	// serialise on that row lock. Postgres can still abort one of them with a
+	// deadlock or serialization failure; the statement is idempotent, so retry.
+	for attempt := 1; ; attempt++ {
+		start := s.now()
+		_, err = s.db.ExecContext(ctx, query, args...)
+		s.reportQuery(QueryCacheUpsert, start, err)
+		if err == nil || attempt == maxUpsertAttempts || !isRetryableWriteError(err) || ctx.Err() != nil {
+			return err
+		}
+	}
 }
 
-func (s *Service) fetchFromViaCEP(ctx context.Context, cep string) (*Response, error) {
-	url := fmt.Sprintf(viaCepURL, cep)
-	req, err := http.NewRequestWithContext(ctx, http.MethodGet, url, nil)
-	if err != nil {
-		return nil, err
+// reportQuery passes a cache query that began at start to the QueryObserver.
+func (s *Service) reportQuery(query string, start time.Time, err error) {
+	if s.observeQuery != nil {
+		s.observeQuery(query, s.now().Sub(start), err)
 	}
+}
 
-	resp, err := s.client.Do(req)
-	if err != nil {
-		return nil, err
+// maxUpsertAttempts bounds retries of a cache write aborted by Postgres.
+const maxUpsertAttempts = 3
+
+// isRetryableWriteError reports whether err is a transient Postgres conflict
+// (serialization_failure or deadlock_detected). Drivers expose the code through
+// SQLState, which keeps this package independent of pgx.
+func isRetryableWriteError(err error) bool {
+	var pgErr interface{ SQLState() string }
+	if !errors.As(err, &pgErr) {
+		return false
 	}
-	defer resp.Body.Close()
+	switch pgErr.SQLState() {
+	case "40001", "40P01":
+		return true
+	}
+	return false
+}
 
-	if resp.StatusCode == http.StatusNotFound {
-		return nil, ErrNotFound
+// authorize applies provider credentials to req.
+func authorize(req *http.Request, auth ProviderAuth) {
+	if auth.APIKey == "" {
+		return
 	}
-	if resp.StatusCode >= 400 {
-		return nil, fmt.Errorf("viacep returned status %d", resp.StatusCode)
+	if auth.QueryParam != "" {
+		query := req.URL.Query()
+		query.Set(auth.QueryParam, auth.APIKey)
+		req.URL.RawQuery = query.Encode()
+		return
 	}
+	req.Header.Set(auth.Header, auth.APIKey)
+}
 
-	var body Response
-	if err := json.NewDecoder(resp.Body).Decode(&body); err != nil {
-		return nil, err
+// trimResponse trims every string field so whitespace-only values become empty.
+func trimResponse(r *Response) {
+	fields := []*string{
+		&r.Cep, &r.Logradouro, &r.Complemento, &r.Bairro, &r.Localidade,
+		&r.Uf, &r.Ibge, &r.Gia, &r.DDD, &r.Siafi, &r.Unidade, &r.Latitude, &r.Longitude,
+	}
+	for _, field := range fields {
+		*field = strings.TrimSpace(*field)
 	}
+}
 
-	if body.Erro {
-		return nil, ErrNotFound
+// normalize validates a CEP using the configured strictness.
+func (s *Service) normalize(value string) (string, error) {
+	digits, err := normalizeCEP(value)
+	if err == nil || !s.lenientCEP || errors.Is(err, ErrNonASCIIDigit) {
+		return digits, err
 	}
+	return correctOCRDigits(value)
+}
 
-	if body.Cep == "" {
-		body.Cep = formatCEP(cep)
+// ocrDigits maps characters commonly produced by OCR in place of digits.
+var ocrDigits = map[rune]rune{
+	'O': '0', 'o': '0', 'Q': '0', 'D': '0',
+	'I': '1', 'l': '1', 'i': '1', '|': '1',
+	'Z': '2', 'z': '2',
+	'S': '5', 's': '5',
+	'G': '6', 'b': '6',
+	'T': '7',
+	'B': '8',
+	'g': '9', 'q': '9',
+}
+
+// correctOCRDigits maps OCR look-alikes to digits. Letters without an obvious
+// digit counterpart make the input ambiguous, so it is rejected rather than guessed.
+func correctOCRDigits(value string) (string, error) {
+	var b strings.Builder
+	for _, r := range value {
+		switch {
+		case r >= '0' && r <= '9':
+			b.WriteRune(r)
+		case ocrDigits[r] != 0:
+			b.WriteRune(ocrDigits[r])
+		case unicode.IsLetter(r) || unicode.IsDigit(r):
+			return "", ErrInvalidCEP
+		}
 	}
 
-	return &body, nil
+	if b.Len() != 8 {
+		return "", ErrInvalidCEP
+	}
+	return b.String(), nil
 }
 
-// normalizeCEP strips non-digits and validates CEP length.
+// normalizeCEP strips separators and validates CEP length. Digits outside
+// ASCII are rejected with ErrNonASCIIDigit instead of being stripped.
 func normalizeCEP(value string) (string, error) {
+	for _, r := range value {
+		if r > unicode.MaxASCII && unicode.IsDigit(r) {
+			return "", ErrNonASCIIDigit
+		}
+	}
+
 	onlyDigits := strings.Map(func(r rune) rune {
 		if r >= '0' && r <= '9' {
 			return r
@@ -4,25 +4,32 @@ import (
 	"context"
 	"database/sql"
 	"encoding/json"
+	"errors"
+	"fmt"
 	"io"
-	"log"
+	"log/slog"
 	"net/http"
 	"strings"
+	"sync"
+	"sync/atomic"
 	"testing"
 	"time"
 
 	"github.com/DATA-DOG/go-sqlmock"
 	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/requestid"
 )
 
 type stubHTTPClient struct {
 	response *http.Response
 	err      error
 	calls    int
+	lastReq  *http.Request
 }
 
 func (s *stubHTTPClient) Do(req *http.Request) (*http.Response, error) {
 	s.calls++
+	s.lastReq = req
 	return s.response, s.err
 }
 
@@ -37,6 +44,56 @@ func TestNormalizeCEP(t *testing.T) {
 	assert.ErrorIs(t, err, ErrInvalidCEP)
 }
 
+func TestNormalizeCEPRejectsNonASCIIDigits(t *testing.T) {
+	t.Parallel()
+
+	for _, input := range []string{
+		"١٢٣٤٥٦٧٨",   // Arabic-Indic digits only
+		"01001-000٣", // trailing Arabic-Indic digit on an otherwise valid CEP
+		"۰01001000",  // leading Extended Arabic-Indic digit
+		"0100１-000",  // fullwidth digit
+		"01001-00०0", // Devanagari digit
+	} {
+		_, err := normalizeCEP(input)
+		assert.ErrorIs(t, err, ErrNonASCIIDigit, input)
+		assert.ErrorIs(t, err, ErrInvalidCEP, input)
+	}
+
+	// Non-digit separators are still stripped.
+	digits, err := normalizeCEP("01001–000")
+	assert.NoError(t, err)
+	assert.Equal(t, "01001000", digits)
+
+	lenient := NewService(nil, nil, time.Hour, noopLogger(), WithLenientCEP(true))
+	_, err = lenient.Get(context.Background(), "01001-000٣")
+	assert.ErrorIs(t, err, ErrNonASCIIDigit)
+}
+
+func TestNormalizeLenientCEP(t *testing.T) {
+	t.Parallel()
+
+	strict := NewService(nil, nil, time.Hour, noopLogger())
+	_, err := strict.normalize("O1OO1-OOO")
+	assert.ErrorIs(t, err, ErrInvalidCEP)
+
+	lenient := NewService(nil, nil, time.Hour, noopLogger(), WithLenientCEP(true))
+	for input, expected := range map[string]string{
+		"O1OO1-OOO": "01001000",
+		"l234S-678": "12345678",
+		"7654B 2I0": "76548210",
+		"01001-000": "01001000",
+	} {
+		digits, err := lenient.normalize(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, expected, digits, input)
+	}
+
+	for _, input := range []string{"0100X-000", "O1OO1", "CEP O1OO1-OOO", "١٢٣٤٥٦٧٨"} {
+		_, err := lenient.normalize(input)
+		assert.ErrorIs(t, err, ErrInvalidCEP, input)
+	}
+}
+
 func TestServiceGetCacheHit(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
@@ -55,11 +112,11 @@ func TestServiceGetCacheHit(t *testing.T) {
 	payload, err := json.Marshal(expected)
 	assert.NoError(t, err)
 
-	mock.ExpectQuery(`SELECT payload, updated_at FROM ceps WHERE cep = \$1`).
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
 		WithArgs("12345678").
 		WillReturnRows(
-			sqlmock.NewRows([]string{"payload", "updated_at"}).
-				AddRow(payload, time.Now()),
+			sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).
+				AddRow(payload, time.Now(), CacheSchemaVersion),
 		)
 
 	client := &stubHTTPClient{}
@@ -77,7 +134,7 @@ func TestServiceGetCacheMiss(t *testing.T) {
 	assert.NoError(t, err)
 	t.Cleanup(func() { _ = db.Close() })
 
-	mock.ExpectQuery(`SELECT payload, updated_at FROM ceps WHERE cep = \$1`).
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
 		WithArgs("76543210").
 		WillReturnError(sql.ErrNoRows)
 
@@ -91,7 +148,7 @@ func TestServiceGetCacheMiss(t *testing.T) {
 	}
 
 	mock.ExpectExec(`INSERT INTO ceps`).
-		WithArgs("76543210", sqlmock.AnyArg(), sqlmock.AnyArg()).
+		WithArgs("76543210", sqlmock.AnyArg(), sqlmock.AnyArg(), CacheSchemaVersion).
 		WillReturnResult(sqlmock.NewResult(1, 1))
 
 	service := NewService(db, client, time.Hour, noopLogger())
@@ -103,12 +160,87 @@ func TestServiceGetCacheMiss(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
+func TestServiceLookupCacheHitProvenance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("12345678").
+		WillReturnRows(
+			sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).
+				AddRow(`{"cep":"12345-678"}`, now.Add(-2*time.Minute), CacheSchemaVersion),
+		)
+
+	client := &stubHTTPClient{}
+	service := NewService(db, client, time.Hour, noopLogger())
+	service.now = func() time.Time { return now }
+
+	res, err := service.Lookup(context.Background(), "12345-678")
+	assert.NoError(t, err)
+	assert.Equal(t, "12345-678", res.Response.Cep)
+	assert.Equal(t, SourcePostgres, res.Source)
+	assert.Equal(t, 2*time.Minute, res.Age)
+	assert.False(t, res.ProviderCalled)
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceLookupCacheMissProvenance(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT payload`).WithArgs("76543210").WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(1, 1))
+
+	client := &stubHTTPClient{
+		response: &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"76543-210"}`))},
+	}
+	service := NewService(db, client, time.Hour, noopLogger())
+
+	res, err := service.Lookup(context.Background(), "76543-210")
+	assert.NoError(t, err)
+	assert.Equal(t, "76543-210", res.Response.Cep)
+	assert.Equal(t, SourceProvider, res.Source)
+	assert.Zero(t, res.Age)
+	assert.True(t, res.ProviderCalled)
+	assert.Equal(t, 1, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceQueryObserver(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT payload`).WillReturnError(sql.ErrNoRows)
+	mock.ExpectExec(`INSERT INTO ceps`).WillReturnError(errors.New("disk full"))
+
+	client := &stubHTTPClient{response: &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"cep":"76543-210"}`)),
+	}}
+
+	var observed []string
+	service := NewService(db, client, time.Hour, noopLogger(),
+		WithQueryObserver(func(query string, _ time.Duration, err error) {
+			observed = append(observed, fmt.Sprintf("%s:%v", query, err))
+		}))
+
+	_, err = service.Get(context.Background(), "76543210")
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"cache_select:<nil>", "cache_upsert:disk full"}, observed)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
 func TestServiceGetRemoteNotFound(t *testing.T) {
 	db, mock, err := sqlmock.New()
 	assert.NoError(t, err)
 	t.Cleanup(func() { _ = db.Close() })
 
-	mock.ExpectQuery(`SELECT payload, updated_at FROM ceps WHERE cep = \$1`).
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
 		WithArgs("00000000").
 		WillReturnError(sql.ErrNoRows)
 
@@ -127,6 +259,536 @@ func TestServiceGetRemoteNotFound(t *testing.T) {
 	assert.NoError(t, mock.ExpectationsWereMet())
 }
 
-func noopLogger() *log.Logger {
-	return log.New(io.Discard, "", 0)
+func TestServiceGetTrimsWhitespaceFields(t *testing.T) {
+	body := `{"cep":" ","logradouro":"  Rua Nova ","complemento":" ","bairro":"\t","localidade":"Cidade","uf":"ST","gia":"   "}`
+
+	for _, tc := range []struct {
+		name     string
+		opts     []Option
+		expected Response
+	}{
+		{
+			name:     "enabled by default",
+			expected: Response{Cep: "76543-210", Logradouro: "Rua Nova", Localidade: "Cidade", Uf: "ST"},
+		},
+		{
+			name:     "disabled",
+			opts:     []Option{WithTrimWhitespace(false)},
+			expected: Response{Cep: " ", Logradouro: "  Rua Nova ", Complemento: " ", Bairro: "\t", Localidade: "Cidade", Uf: "ST", Gia: "   "},
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			t.Cleanup(func() { _ = db.Close() })
+
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("76543210").
+				WillReturnError(sql.ErrNoRows)
+			mock.ExpectExec(`INSERT INTO ceps`).
+				WithArgs("76543210", sqlmock.AnyArg(), sqlmock.AnyArg(), CacheSchemaVersion).
+				WillReturnResult(sqlmock.NewResult(1, 1))
+
+			client := &stubHTTPClient{
+				response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(body)),
+				},
+			}
+
+			service := NewService(db, client, time.Hour, noopLogger(), tc.opts...)
+
+			res, err := service.Get(context.Background(), "76543210")
+			assert.NoError(t, err)
+			assert.Equal(t, &tc.expected, res)
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestServiceGetTrailingData(t *testing.T) {
+	body := `{"cep":"76543-210","localidade":"Cidade","uf":"ST"} <html>mirror banner</html>`
+
+	for _, tc := range []struct {
+		policy  TrailingDataPolicy
+		wantErr bool
+		wantLog bool
+	}{
+		{policy: TrailingDataIgnore},
+		{policy: TrailingDataWarn, wantLog: true},
+		{policy: TrailingDataStrict, wantErr: true},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			t.Cleanup(func() { _ = db.Close() })
+
+			mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+				WithArgs("76543210").
+				WillReturnError(sql.ErrNoRows)
+			if !tc.wantErr {
+				mock.ExpectExec(`INSERT INTO ceps`).
+					WithArgs("76543210", sqlmock.AnyArg(), sqlmock.AnyArg(), CacheSchemaVersion).
+					WillReturnResult(sqlmock.NewResult(1, 1))
+			}
+
+			client := &stubHTTPClient{
+				response: &http.Response{
+					StatusCode: http.StatusOK,
+					Body:       io.NopCloser(strings.NewReader(body)),
+				},
+			}
+
+			var logs strings.Builder
+			service := NewService(db, client, time.Hour, textLogger(&logs), WithTrailingDataPolicy(tc.policy))
+
+			res, err := service.Get(context.Background(), "76543210")
+			if tc.wantErr {
+				assert.ErrorIs(t, err, ErrUpstreamBadResponse)
+			} else {
+				assert.NoError(t, err)
+				assert.Equal(t, "76543-210", res.Cep)
+			}
+			assert.Equal(t, tc.wantLog, strings.Contains(logs.String(), "response has trailing data after JSON object"))
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+func TestServiceSaveToCacheDoesNotOverwriteNewerRow(t *testing.T) {
+	writeAt := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	data := &Response{Cep: "76543-210"}
+
+	t.Run("update mode", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		// Zero rows affected: a concurrent writer already stored a newer row.
+		mock.ExpectExec(`ON CONFLICT \(cep\)\s+DO UPDATE SET .*\s+WHERE \(ceps\.updated_at <= EXCLUDED\.updated_at OR ceps\.schema_version < EXCLUDED\.schema_version\)\s*$`).
+			WithArgs("76543210", sqlmock.AnyArg(), writeAt, CacheSchemaVersion).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		service := NewService(db, nil, time.Hour, noopLogger())
+		service.now = func() time.Time { return writeAt }
+
+		assert.NoError(t, service.saveToCache(context.Background(), "76543210", data, writeAt, UpsertUpdate))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("keep-fresh mode", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		mock.ExpectExec(`WHERE \(ceps\.updated_at <= EXCLUDED\.updated_at .*\) AND \(ceps\.updated_at < \$5 OR ceps\.schema_version < EXCLUDED\.schema_version\)`).
+			WithArgs("76543210", sqlmock.AnyArg(), writeAt, CacheSchemaVersion, writeAt.Add(-time.Hour)).
+			WillReturnResult(sqlmock.NewResult(0, 0))
+
+		service := NewService(db, nil, time.Hour, noopLogger(), WithUpsertMode(UpsertKeepFresh))
+		service.now = func() time.Time { return writeAt }
+
+		assert.NoError(t, service.saveToCache(context.Background(), "76543210", data, writeAt, UpsertKeepFresh))
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+}
+
+func TestServiceCachesFetchStartTime(t *testing.T) {
+	fetchStart := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	now := fetchStart
+
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+	mock.ExpectQuery(`SELECT payload`).WithArgs("01001000").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+	// The row is stamped with when the fetch began, not when it was written.
+	mock.ExpectExec(`INSERT INTO ceps`).
+		WithArgs("01001000", sqlmock.AnyArg(), fetchStart, CacheSchemaVersion).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		now = now.Add(5 * time.Second) // a slow provider
+		return okResponse(), nil
+	})
+	service := NewService(db, client, time.Hour, noopLogger())
+	service.now = func() time.Time { return now }
+
+	result, err := service.Lookup(context.Background(), "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, fetchStart, result.UpdatedAt)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceSaveToCacheKeepsLaterFetch(t *testing.T) {
+	older := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	newer := older.Add(time.Second)
+	service := NewService(nil, nil, time.Hour, noopLogger())
+	service.now = func() time.Time { return newer.Add(time.Second) }
+
+	// A fetch that started later finishes first; the slower, older one must
+	// not replace it.
+	assert.NoError(t, service.saveToCache(context.Background(), "76543210", &Response{Cep: "76543-210", Logradouro: "Rua Nova"}, newer, UpsertUpdate))
+	assert.NoError(t, service.saveToCache(context.Background(), "76543210", &Response{Cep: "76543-210", Logradouro: "Rua Antiga"}, older, UpsertUpdate))
+
+	entry, ok := service.memory.get("76543210")
+	assert.True(t, ok)
+	assert.Equal(t, "Rua Nova", entry.resp.Logradouro)
+	assert.Equal(t, newer, entry.updatedAt)
+}
+
+// sqlStateError mimics driver errors that expose a Postgres SQLSTATE code.
+type sqlStateError string
+
+func (e sqlStateError) Error() string    { return "sqlstate " + string(e) }
+func (e sqlStateError) SQLState() string { return string(e) }
+
+func TestServiceSaveToCacheRetriesTransientConflicts(t *testing.T) {
+	for _, tc := range []struct {
+		name     string
+		failures []error
+		wantErr  bool
+	}{
+		{name: "deadlock then success", failures: []error{sqlStateError("40P01")}},
+		{name: "serialization failures then success", failures: []error{sqlStateError("40001"), sqlStateError("40001")}},
+		{name: "gives up after max attempts", failures: []error{sqlStateError("40P01"), sqlStateError("40P01"), sqlStateError("40P01")}, wantErr: true},
+		{name: "does not retry other errors", failures: []error{sqlStateError("23505")}, wantErr: true},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			db, mock, err := sqlmock.New()
+			assert.NoError(t, err)
+			t.Cleanup(func() { _ = db.Close() })
+
+			for _, failure := range tc.failures {
+				mock.ExpectExec(`INSERT INTO ceps`).WillReturnError(failure)
+			}
+			if !tc.wantErr {
+				mock.ExpectExec(`INSERT INTO ceps`).WillReturnResult(sqlmock.NewResult(0, 1))
+			}
+
+			err = NewService(db, nil, time.Hour, noopLogger()).saveToCache(context.Background(), "01001000", &Response{Cep: "01001-000"}, time.Now(), UpsertUpdate)
+			if tc.wantErr {
+				assert.Error(t, err)
+			} else {
+				assert.NoError(t, err)
+			}
+			assert.NoError(t, mock.ExpectationsWereMet())
+		})
+	}
+}
+
+// concurrentClient serves a fresh ViaCEP body per call and is safe for concurrent use.
+type concurrentClient struct {
+	calls atomic.Int32
+}
+
+func (c *concurrentClient) Do(req *http.Request) (*http.Response, error) {
+	c.calls.Add(1)
+	return &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"cep":"01001-000","uf":"SP"}`)),
+	}, nil
+}
+
+func TestServiceConcurrentWritesSameCEP(t *testing.T) {
+	const workers = 16
+
+	t.Run("postgres", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		mock.MatchExpectationsInOrder(false)
+
+		// The first writer loses the row lock race, is aborted by Postgres and retries.
+		mock.ExpectExec(`INSERT INTO ceps`).WithArgs("01001000", sqlmock.AnyArg(), sqlmock.AnyArg(), CacheSchemaVersion).
+			WillReturnError(sqlStateError("40P01"))
+
+		for i := 0; i < workers; i++ {
+			mock.ExpectQuery(`SELECT payload`).WithArgs("01001000").
+				WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}))
+			mock.ExpectExec(`INSERT INTO ceps`).WithArgs("01001000", sqlmock.AnyArg(), sqlmock.AnyArg(), CacheSchemaVersion).
+				WillReturnResult(sqlmock.NewResult(0, 1))
+		}
+
+		service := NewService(db, &concurrentClient{}, time.Hour, noopLogger())
+		runConcurrentLookups(t, service, workers)
+		assert.NoError(t, mock.ExpectationsWereMet())
+	})
+
+	t.Run("memory", func(t *testing.T) {
+		client := &concurrentClient{}
+		service := NewService(nil, client, time.Hour, noopLogger())
+		runConcurrentLookups(t, service, workers)
+
+		info, err := service.Inspect(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.True(t, info.Cached)
+	})
+}
+
+func runConcurrentLookups(t *testing.T, service *Service, workers int) {
+	t.Helper()
+
+	var wg sync.WaitGroup
+	errs := make(chan error, workers)
+	for i := 0; i < workers; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			resp, err := service.Get(context.Background(), "01001000")
+			if err == nil && resp.Cep != "01001-000" {
+				err = fmt.Errorf("unexpected cep %q", resp.Cep)
+			}
+			errs <- err
+		}()
+	}
+	wg.Wait()
+	close(errs)
+
+	for err := range errs {
+		assert.NoError(t, err)
+	}
+}
+
+func TestServiceGetProviderCEPMismatch(t *testing.T) {
+	for _, tc := range []struct {
+		policy     MismatchPolicy
+		wantCEP    string
+		wantCached []string
+		wantMissed []string
+	}{
+		{policy: MismatchRequested, wantCEP: "01310-999", wantCached: []string{"01310999"}, wantMissed: []string{"01310000"}},
+		{policy: MismatchCanonical, wantCEP: "01310-000", wantCached: []string{"01310000"}, wantMissed: []string{"01310999"}},
+		{policy: MismatchAlias, wantCEP: "01310-000", wantCached: []string{"01310999", "01310000"}},
+	} {
+		t.Run(string(tc.policy), func(t *testing.T) {
+			client := &stubHTTPClient{response: &http.Response{
+				StatusCode: http.StatusOK,
+				Body:       io.NopCloser(strings.NewReader(`{"cep":"01310-000","logradouro":"Avenida Paulista"}`)),
+			}}
+			var logs strings.Builder
+			service := NewService(nil, client, time.Hour, textLogger(&logs), WithMismatchPolicy(tc.policy))
+
+			resp, err := service.Get(context.Background(), "01310999")
+			assert.NoError(t, err)
+			assert.Equal(t, tc.wantCEP, resp.Cep)
+			assert.Contains(t, logs.String(), `msg="provider returned a different cep" returned=01310000 requested=01310999`)
+
+			for _, key := range tc.wantCached {
+				info, err := service.Inspect(context.Background(), key)
+				assert.NoError(t, err)
+				assert.True(t, info.Cached, "expected %s cached", key)
+			}
+			for _, key := range tc.wantMissed {
+				info, err := service.Inspect(context.Background(), key)
+				assert.NoError(t, err)
+				assert.False(t, info.Cached, "expected %s not cached", key)
+			}
+		})
+	}
+}
+
+func TestServiceGetRefreshesOutdatedSchemaRow(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("76543210").
+		WillReturnRows(sqlmock.NewRows([]string{"payload", "updated_at", "schema_version"}).
+			AddRow([]byte(`{"cep":"76543-210","logradouro":"Rua Antiga"}`), time.Now(), CacheSchemaVersion-1))
+	mock.ExpectExec(`INSERT INTO ceps`).
+		WithArgs("76543210", sqlmock.AnyArg(), sqlmock.AnyArg(), CacheSchemaVersion).
+		WillReturnResult(sqlmock.NewResult(1, 1))
+
+	client := &stubHTTPClient{
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"76543-210","logradouro":"Rua Nova"}`)),
+		},
+	}
+	service := NewService(db, client, time.Hour, noopLogger())
+
+	res, err := service.Lookup(context.Background(), "76543210")
+	assert.NoError(t, err)
+	assert.Equal(t, "Rua Nova", res.Response.Logradouro)
+	assert.True(t, res.ProviderCalled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceGetEmptyUpstreamBody(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	mock.ExpectQuery(`SELECT payload, updated_at, schema_version FROM ceps WHERE cep = \$1`).
+		WithArgs("76543210").
+		WillReturnError(sql.ErrNoRows)
+
+	client := &stubHTTPClient{
+		response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader("")),
+		},
+	}
+	service := NewService(db, client, time.Hour, noopLogger())
+
+	_, err = service.Get(context.Background(), "76543210")
+	assert.ErrorIs(t, err, ErrUpstreamBadResponse)
+	assert.Contains(t, err.Error(), "empty body")
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceGetCancelledContext(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+
+	client := &stubHTTPClient{}
+	_, err = NewService(db, client, time.Hour, noopLogger()).Get(ctx, "01001000")
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.Equal(t, 0, client.calls)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestServiceProviderAuth(t *testing.T) {
+	newClient := func() *stubHTTPClient {
+		return &stubHTTPClient{response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`)),
+		}}
+	}
+
+	t.Run("header", func(t *testing.T) {
+		client := newClient()
+		svc := NewService(nil, client, time.Hour, noopLogger(),
+			WithProviderAuth(ProviderAuth{APIKey: "s3cret", Header: "X-API-Key"}))
+
+		_, err := svc.Get(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cret", client.lastReq.Header.Get("X-API-Key"))
+		assert.Empty(t, client.lastReq.URL.RawQuery)
+	})
+
+	t.Run("query param", func(t *testing.T) {
+		client := newClient()
+		svc := NewService(nil, client, time.Hour, noopLogger(),
+			WithProviderAuth(ProviderAuth{APIKey: "s3cret", Header: "X-API-Key", QueryParam: "token"}))
+
+		_, err := svc.Get(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.Equal(t, "s3cret", client.lastReq.URL.Query().Get("token"))
+		assert.Empty(t, client.lastReq.Header.Get("X-API-Key"))
+	})
+
+	t.Run("no credentials", func(t *testing.T) {
+		client := newClient()
+		_, err := NewService(nil, client, time.Hour, noopLogger()).Get(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.Empty(t, client.lastReq.Header)
+		assert.Equal(t, "https://viacep.com.br/ws/01001000/json/", client.lastReq.URL.String())
+	})
+}
+
+func TestServiceForwardsRequestID(t *testing.T) {
+	client := &stubHTTPClient{response: &http.Response{
+		StatusCode: http.StatusOK,
+		Body:       io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`)),
+	}}
+
+	ctx := requestid.NewContext(context.Background(), "req-1")
+	_, err := NewService(nil, client, time.Hour, noopLogger()).Get(ctx, "01001000")
+	assert.NoError(t, err)
+	assert.Equal(t, "req-1", client.lastReq.Header.Get("X-Request-ID"))
+}
+
+func TestServiceCheckDataSources(t *testing.T) {
+	okProvider := func() *stubHTTPClient {
+		return &stubHTTPClient{response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`)),
+		}}
+	}
+	downProvider := &stubHTTPClient{err: errors.New("dial tcp: connection refused")}
+
+	t.Run("reachable database", func(t *testing.T) {
+		db, mock, err := sqlmock.New(sqlmock.MonitorPingsOption(true))
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+		mock.ExpectPing()
+
+		client := &stubHTTPClient{}
+		assert.NoError(t, NewService(db, client, time.Hour, noopLogger()).CheckDataSources(context.Background()))
+		assert.Equal(t, 0, client.calls)
+	})
+
+	t.Run("memory cache with reachable provider", func(t *testing.T) {
+		assert.NoError(t, NewService(nil, okProvider(), time.Hour, noopLogger()).CheckDataSources(context.Background()))
+	})
+
+	t.Run("memory cache with unreachable provider", func(t *testing.T) {
+		err := NewService(nil, downProvider, time.Hour, noopLogger()).CheckDataSources(context.Background())
+		assert.ErrorIs(t, err, ErrNoDataSource)
+		assert.ErrorContains(t, err, "connection refused")
+	})
+
+	t.Run("no cache and no provider", func(t *testing.T) {
+		err := NewService(nil, nil, time.Hour, noopLogger()).CheckDataSources(context.Background())
+		assert.ErrorIs(t, err, ErrNoDataSource)
+		assert.ErrorContains(t, err, "no provider configured")
+	})
+}
+
+func TestServiceGetRuntimeDataSourceGuard(t *testing.T) {
+	t.Run("cache down falls back to provider", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		mock.ExpectQuery(`SELECT payload`).WillReturnError(errors.New("connection reset"))
+		mock.ExpectExec(`INSERT INTO ceps`).WillReturnError(errors.New("connection reset"))
+
+		client := &stubHTTPClient{response: &http.Response{
+			StatusCode: http.StatusOK,
+			Body:       io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`)),
+		}}
+		res, err := NewService(db, client, time.Hour, noopLogger()).Lookup(context.Background(), "01001000")
+		assert.NoError(t, err)
+		assert.Equal(t, SourceProvider, res.Source)
+	})
+
+	t.Run("cache and provider down", func(t *testing.T) {
+		db, mock, err := sqlmock.New()
+		assert.NoError(t, err)
+		t.Cleanup(func() { _ = db.Close() })
+
+		mock.ExpectQuery(`SELECT payload`).WillReturnError(errors.New("connection reset"))
+
+		client := &stubHTTPClient{err: errors.New("i/o timeout")}
+		_, err = NewService(db, client, time.Hour, noopLogger()).Get(context.Background(), "01001000")
+		assert.ErrorIs(t, err, ErrNoDataSource)
+		assert.ErrorContains(t, err, "connection reset")
+		assert.ErrorContains(t, err, "i/o timeout")
+	})
+}
+
+func noopLogger() *slog.Logger {
+	return slog.New(slog.NewTextHandler(io.Discard, nil))
+}
+
+// textLogger writes logfmt lines without the timestamp, so tests can match
+// whole lines.
+func textLogger(w io.Writer) *slog.Logger {
+	return slog.New(slog.NewTextHandler(w, &slog.HandlerOptions{
+		ReplaceAttr: func(groups []string, a slog.Attr) slog.Attr {
+			if len(groups) == 0 && a.Key == slog.TimeKey {
+				return slog.Attr{}
+			}
+			return a
+		},
+	}))
 }
@@ -0,0 +1,118 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"math/rand/v2"
+	"sync"
+	"time"
+)
+
+// shadowTimeout bounds a shadow lookup whose provider has no timeout of its own.
+const shadowTimeout = 5 * time.Second
+
+// shadowMaxInFlight caps concurrent shadow lookups; samples beyond it are skipped.
+const shadowMaxInFlight = 10
+
+// WithShadowProvider queries the named built-in provider in the background for
+// percent% of provider fetches and compares its answer with the one served,
+// logging and counting discrepancies. It never affects the response, which
+// makes it safe for vetting a provider before promoting it in CEP_PROVIDERS.
+// A percent <= 0 disables it.
+func WithShadowProvider(name ProviderName, percent int) Option {
+	return func(s *Service) {
+		s.shadowName = name
+		s.shadowPercent = min(percent, 100)
+	}
+}
+
+// shadowing holds the resolved shadow provider.
+type shadowing struct {
+	provider Provider
+	name     string
+	percent  int
+	slots    chan struct{}
+	wg       sync.WaitGroup
+}
+
+// initShadow resolves the shadow provider once every option is applied.
+func (s *Service) initShadow() {
+	if s.shadowPercent <= 0 || s.client == nil {
+		return
+	}
+	provider := s.builtinProvider(s.shadowName)
+	if provider == nil {
+		return
+	}
+	s.shadow = &shadowing{
+		provider: provider,
+		name:     string(s.shadowName),
+		percent:  s.shadowPercent,
+		slots:    make(chan struct{}, shadowMaxInFlight),
+	}
+}
+
+// shadowLookup samples a provider fetch for comparison with the shadow
+// provider. served is the answer the caller got, nil when it was ErrNotFound.
+func (s *Service) shadowLookup(ctx context.Context, cep string, served *Response) {
+	sh := s.shadow
+	if sh == nil || rand.IntN(100) >= sh.percent {
+		return
+	}
+	select {
+	case sh.slots <- struct{}{}:
+	default:
+		return
+	}
+
+	// The served response may still be adjusted by the caller; compare a copy.
+	var expected *Response
+	if served != nil {
+		copied := *served
+		expected = &copied
+	}
+
+	sh.wg.Add(1)
+	go func() {
+		defer sh.wg.Done()
+		defer func() { <-sh.slots }()
+
+		ctx, cancel := context.WithTimeout(context.WithoutCancel(ctx), shadowTimeout)
+		defer cancel()
+		got, err := sh.provider.Lookup(ctx, cep)
+		if err != nil && !errors.Is(err, ErrNotFound) {
+			s.logger.WarnContext(ctx, "shadow provider failed", "provider", sh.name, "cep", cep, "err", err)
+			return
+		}
+		s.counters.shadowCompared.Add(1)
+
+		switch {
+		case expected == nil && got == nil:
+			return
+		case expected == nil:
+			s.logger.WarnContext(ctx, "shadow provider found a cep the primary did not", "provider", sh.name, "cep", cep)
+		case got == nil:
+			s.logger.WarnContext(ctx, "shadow provider does not know the cep", "provider", sh.name, "cep", cep)
+		default:
+			s.finishResponse(cep, got)
+			changes := shadowDiff(expected, got)
+			if len(changes) == 0 {
+				return
+			}
+			s.logger.WarnContext(ctx, "shadow provider disagrees", "provider", sh.name, "cep", cep, "changes", describeChanges(changes))
+		}
+		s.counters.shadowMismatches.Add(1)
+	}()
+}
+
+// shadowDiff compares only the fields the shadow provider filled in, since
+// providers differ in which fields they return at all.
+func shadowDiff(served, shadow *Response) []FieldChange {
+	var changes []FieldChange
+	for _, change := range Diff(served, shadow) {
+		if change.New != "" {
+			changes = append(changes, change)
+		}
+	}
+	return changes
+}
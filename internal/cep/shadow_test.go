@@ -0,0 +1,78 @@
+package cep
+
+import (
+	"bytes"
+	"context"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceShadowProvider(t *testing.T) {
+	viaCEPBody := `{"cep":"01001-000","logradouro":"Praça da Sé","bairro":"Sé","localidade":"São Paulo","uf":"SP","ibge":"3550308"}`
+
+	for _, tc := range []struct {
+		name         string
+		shadowStatus int
+		shadowBody   string
+		wantMismatch bool
+		wantLog      string
+	}{
+		{
+			name:         "agrees on shared fields",
+			shadowStatus: http.StatusOK,
+			shadowBody:   `{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Sé","street":"Praça da Sé"}`,
+		},
+		{
+			name:         "disagrees",
+			shadowStatus: http.StatusOK,
+			shadowBody:   `{"cep":"01001000","state":"SP","city":"São Paulo","neighborhood":"Centro","street":"Praça da Sé"}`,
+			wantMismatch: true,
+			wantLog:      `msg="shadow provider disagrees" provider=brasilapi cep=01001000 changes=bairro`,
+		},
+		{
+			name:         "does not know the cep",
+			shadowStatus: http.StatusNotFound,
+			wantMismatch: true,
+			wantLog:      `msg="shadow provider does not know the cep" provider=brasilapi cep=01001000`,
+		},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			client := clientFunc(func(req *http.Request) (*http.Response, error) {
+				if req.URL.Host == "viacep.com.br" {
+					return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(viaCEPBody))}, nil
+				}
+				return &http.Response{StatusCode: tc.shadowStatus, Body: io.NopCloser(strings.NewReader(tc.shadowBody))}, nil
+			})
+			var logs bytes.Buffer
+			service := NewService(nil, client, time.Hour, textLogger(&logs), WithShadowProvider(ProviderBrasilAPI, 100))
+
+			resp, err := service.Get(context.Background(), "01001000")
+			assert.NoError(t, err)
+			assert.Equal(t, "Sé", resp.Bairro, "the shadow answer is never served")
+			service.shadow.wg.Wait()
+
+			metrics := service.Metrics()
+			assert.Equal(t, uint64(1), metrics.ShadowCompared)
+			if !tc.wantMismatch {
+				assert.Equal(t, uint64(0), metrics.ShadowMismatches)
+				assert.Empty(t, logs.String())
+				return
+			}
+			assert.Equal(t, uint64(1), metrics.ShadowMismatches)
+			assert.Contains(t, logs.String(), tc.wantLog)
+		})
+	}
+}
+
+func TestServiceShadowProviderDisabled(t *testing.T) {
+	service := NewService(nil, &stubHTTPClient{response: okResponse()}, time.Hour, noopLogger(), WithShadowProvider(ProviderBrasilAPI, 0))
+	assert.Nil(t, service.shadow)
+
+	service = NewService(nil, &stubHTTPClient{response: okResponse()}, time.Hour, noopLogger(), WithShadowProvider("postmon", 100))
+	assert.Nil(t, service.shadow)
+}
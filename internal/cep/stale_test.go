@@ -0,0 +1,57 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"net/http"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestServiceStaleOnError(t *testing.T) {
+	now := time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+	downClient := clientFunc(func(*http.Request) (*http.Response, error) { return nil, errors.New("connection refused") })
+
+	for _, tc := range []struct {
+		name      string
+		grace     time.Duration
+		writtenAt time.Time
+		wantStale bool
+	}{
+		{name: "within grace", grace: time.Hour, writtenAt: now.Add(-90 * time.Minute), wantStale: true},
+		{name: "beyond grace", grace: time.Hour, writtenAt: now.Add(-3 * time.Hour)},
+		{name: "disabled", grace: 0, writtenAt: now.Add(-90 * time.Minute)},
+	} {
+		t.Run(tc.name, func(t *testing.T) {
+			service := NewService(nil, downClient, time.Hour, noopLogger(), WithStaleOnError(tc.grace))
+			service.now = func() time.Time { return now }
+			service.memory.set("01001000", Response{Cep: "01001-000"}, tc.writtenAt)
+
+			result, err := service.Lookup(context.Background(), "01001000")
+			if !tc.wantStale {
+				assert.Error(t, err)
+				assert.Equal(t, uint64(0), service.Metrics().StaleServedOnError)
+				return
+			}
+			assert.NoError(t, err)
+			assert.True(t, result.Stale)
+			assert.Equal(t, "01001-000", result.Response.Cep)
+			assert.Equal(t, 90*time.Minute, result.Age)
+			assert.Equal(t, uint64(1), service.Metrics().StaleServedOnError)
+		})
+	}
+}
+
+func TestServiceStaleOnErrorSkipsNotFound(t *testing.T) {
+	client := clientFunc(func(*http.Request) (*http.Response, error) {
+		return &http.Response{StatusCode: http.StatusNotFound, Body: http.NoBody}, nil
+	})
+	service := NewService(nil, client, time.Hour, noopLogger(), WithStaleOnError(time.Hour))
+	service.memory.set("01001000", Response{Cep: "01001-000"}, time.Now().Add(-90*time.Minute))
+
+	_, err := service.Lookup(context.Background(), "01001000")
+	assert.ErrorIs(t, err, ErrNotFound)
+	assert.Equal(t, uint64(0), service.Metrics().StaleServedOnError)
+}
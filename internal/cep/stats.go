@@ -0,0 +1,76 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"sync/atomic"
+)
+
+// MetricsSnapshot is a point-in-time copy of the in-process lookup counters.
+type MetricsSnapshot struct {
+	CacheHits   uint64 `json:"cache_hits"`
+	CacheMisses uint64 `json:"cache_misses"`
+	// CacheExpired counts the misses that found an entry older than the TTL.
+	CacheExpired  uint64 `json:"cache_expired"`
+	ProviderCalls uint64 `json:"provider_calls"`
+	// StaleServedOnError counts expired entries served because the provider failed.
+	StaleServedOnError uint64 `json:"stale_served_on_error"`
+	// DataChanged counts forced refreshes whose provider data differed from the cache.
+	DataChanged uint64 `json:"data_changed"`
+	// Hedges counts extra provider requests sent by WithHedging.
+	Hedges uint64 `json:"hedges"`
+	// Retries counts provider requests repeated by WithRetries.
+	Retries uint64 `json:"retries"`
+	// ShadowCompared counts answers compared with the shadow provider, and
+	// ShadowMismatches those that disagreed.
+	ShadowCompared   uint64 `json:"shadow_compared"`
+	ShadowMismatches uint64 `json:"shadow_mismatches"`
+	// Errors counts failed lookups, excluding invalid or unknown CEPs and
+	// requests abandoned by the caller.
+	Errors uint64 `json:"errors"`
+}
+
+// lookupCounters holds the counters behind MetricsSnapshot.
+type lookupCounters struct {
+	cacheHits        atomic.Uint64
+	cacheMisses      atomic.Uint64
+	cacheExpired     atomic.Uint64
+	providerCalls    atomic.Uint64
+	hedges           atomic.Uint64
+	retries          atomic.Uint64
+	shadowCompared   atomic.Uint64
+	shadowMismatches atomic.Uint64
+	staleOnError     atomic.Uint64
+	dataChanged      atomic.Uint64
+	errors           atomic.Uint64
+}
+
+// Metrics returns the lookup counters accumulated since the Service was built.
+// It needs no metrics backend, which keeps it handy for tests and admin views.
+func (s *Service) Metrics() MetricsSnapshot {
+	return MetricsSnapshot{
+		CacheHits:          s.counters.cacheHits.Load(),
+		CacheMisses:        s.counters.cacheMisses.Load(),
+		CacheExpired:       s.counters.cacheExpired.Load(),
+		ProviderCalls:      s.counters.providerCalls.Load(),
+		DataChanged:        s.counters.dataChanged.Load(),
+		Hedges:             s.counters.hedges.Load(),
+		Retries:            s.counters.retries.Load(),
+		ShadowCompared:     s.counters.shadowCompared.Load(),
+		ShadowMismatches:   s.counters.shadowMismatches.Load(),
+		StaleServedOnError: s.counters.staleOnError.Load(),
+		Errors:             s.counters.errors.Load(),
+	}
+}
+
+// countLookupError records err in the error counter unless it is an expected outcome.
+func (s *Service) countLookupError(err error) {
+	switch {
+	case err == nil,
+		errors.Is(err, ErrInvalidCEP),
+		errors.Is(err, ErrNotFound),
+		errors.Is(err, context.Canceled):
+		return
+	}
+	s.counters.errors.Add(1)
+}
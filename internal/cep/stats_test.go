@@ -0,0 +1,59 @@
+package cep
+
+import (
+	"context"
+	"errors"
+	"io"
+	"net/http"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+// clientFunc adapts a function to HTTPClient.
+type clientFunc func(*http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+func TestServiceMetrics(t *testing.T) {
+	client := clientFunc(func(req *http.Request) (*http.Response, error) {
+		switch {
+		case strings.Contains(req.URL.Path, "/01001000/"):
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"cep":"01001-000"}`))}, nil
+		case strings.Contains(req.URL.Path, "/99999999/"):
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(`{"erro":true}`))}, nil
+		default:
+			return nil, errors.New("connection refused")
+		}
+	})
+	service := NewService(nil, client, time.Hour, noopLogger())
+	ctx := context.Background()
+
+	assert.Equal(t, MetricsSnapshot{}, service.Metrics())
+
+	_, err := service.Get(ctx, "01001000") // miss, provider call
+	assert.NoError(t, err)
+	_, err = service.Get(ctx, "01001-000") // hit
+	assert.NoError(t, err)
+	_, err = service.Get(ctx, "99999999") // miss, provider call, not found
+	assert.ErrorIs(t, err, ErrNotFound)
+	_, err = service.Get(ctx, "12345678") // miss, provider call, error
+	assert.Error(t, err)
+	_, err = service.Get(ctx, "abc") // invalid input is not counted
+	assert.ErrorIs(t, err, ErrInvalidCEP)
+
+	later := time.Now().Add(2 * time.Hour)
+	service.now = func() time.Time { return later }
+	_, err = service.Get(ctx, "01001000") // expired, miss, provider call
+	assert.NoError(t, err)
+
+	assert.Equal(t, MetricsSnapshot{
+		CacheHits:     1,
+		CacheMisses:   4,
+		CacheExpired:  1,
+		ProviderCalls: 4,
+		Errors:        1,
+	}, service.Metrics())
+}
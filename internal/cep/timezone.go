@@ -0,0 +1,41 @@
+package cep
+
+import "strings"
+
+// ufTimezones maps each UF to the IANA zone covering its capital. Brazil spans
+// four offsets, and states such as AC, AM, MT and RO differ from Brasília.
+var ufTimezones = map[string]string{
+	"AC": "America/Rio_Branco",
+	"AL": "America/Maceio",
+	"AM": "America/Manaus",
+	"AP": "America/Belem",
+	"BA": "America/Bahia",
+	"CE": "America/Fortaleza",
+	"DF": "America/Sao_Paulo",
+	"ES": "America/Sao_Paulo",
+	"GO": "America/Sao_Paulo",
+	"MA": "America/Fortaleza",
+	"MG": "America/Sao_Paulo",
+	"MS": "America/Campo_Grande",
+	"MT": "America/Cuiaba",
+	"PA": "America/Belem",
+	"PB": "America/Fortaleza",
+	"PE": "America/Recife",
+	"PI": "America/Fortaleza",
+	"PR": "America/Sao_Paulo",
+	"RJ": "America/Sao_Paulo",
+	"RN": "America/Fortaleza",
+	"RO": "America/Porto_Velho",
+	"RR": "America/Boa_Vista",
+	"RS": "America/Sao_Paulo",
+	"SC": "America/Sao_Paulo",
+	"SE": "America/Maceio",
+	"SP": "America/Sao_Paulo",
+	"TO": "America/Araguaina",
+}
+
+// TimezoneForUF returns the IANA timezone for a UF, if known.
+func TimezoneForUF(uf string) (string, bool) {
+	tz, ok := ufTimezones[strings.ToUpper(strings.TrimSpace(uf))]
+	return tz, ok
+}
@@ -0,0 +1,37 @@
+package cep
+
+import (
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestTimezoneForUF(t *testing.T) {
+	t.Parallel()
+
+	for uf, expected := range map[string]string{
+		"AC": "America/Rio_Branco",
+		"AM": "America/Manaus",
+		"MT": "America/Cuiaba",
+		"SP": "America/Sao_Paulo",
+		"pe": "America/Recife",
+	} {
+		tz, ok := TimezoneForUF(uf)
+		assert.True(t, ok, uf)
+		assert.Equal(t, expected, tz, uf)
+	}
+
+	_, ok := TimezoneForUF("XX")
+	assert.False(t, ok)
+}
+
+func TestUFTimezonesAreValidIANANames(t *testing.T) {
+	t.Parallel()
+
+	assert.Len(t, ufTimezones, 27)
+	for uf, tz := range ufTimezones {
+		_, err := time.LoadLocation(tz)
+		assert.NoError(t, err, uf)
+	}
+}
@@ -0,0 +1,62 @@
+package cep
+
+import (
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+)
+
+const viaCEPURL = "https://viacep.com.br/ws/%s/json/"
+
+// viaCEP is the built-in provider. It shares the Service's HTTP client,
+// credentials, hedging and trailing data policy.
+type viaCEP struct {
+	service   *Service
+	urlFormat string
+}
+
+func (p *viaCEP) String() string { return string(ProviderViaCEP) }
+
+func (p *viaCEP) Lookup(ctx context.Context, cep string) (*Response, error) {
+	s := p.service
+	resp, err := s.doProviderRequest(ctx, fmt.Sprintf(p.urlFormat, cep), s.authFor(ProviderViaCEP))
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode == http.StatusNotFound {
+		return nil, ErrNotFound
+	}
+	if resp.StatusCode >= 400 {
+		return nil, fmt.Errorf("viacep returned status %d", resp.StatusCode)
+	}
+
+	var body Response
+	decoder := json.NewDecoder(resp.Body)
+	if err := decoder.Decode(&body); err != nil {
+		// ViaCEP occasionally answers 200 with no body at all.
+		if errors.Is(err, io.EOF) {
+			return nil, fmt.Errorf("%w: empty body with status %d", ErrUpstreamBadResponse, resp.StatusCode)
+		}
+		return nil, err
+	}
+
+	if s.trailingData != TrailingDataIgnore {
+		if _, err := decoder.Token(); !errors.Is(err, io.EOF) {
+			if s.trailingData == TrailingDataStrict {
+				return nil, fmt.Errorf("%w: trailing data after JSON object", ErrUpstreamBadResponse)
+			}
+			s.logger.WarnContext(ctx, "viacep response has trailing data after JSON object", "cep", cep)
+		}
+	}
+
+	if body.Erro {
+		return nil, ErrNotFound
+	}
+
+	return &body, nil
+}
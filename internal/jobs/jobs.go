@@ -0,0 +1,255 @@
+// Package jobs persists asynchronous batch lookups in PostgreSQL so they
+// outlive the pod that accepted them.
+package jobs
+
+import (
+	"context"
+	"crypto/rand"
+	"database/sql"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"log/slog"
+	"time"
+)
+
+// Schema creates the job table. It is applied with the rest of the DDL at startup.
+const Schema = `
+CREATE TABLE IF NOT EXISTS batch_jobs (
+	id TEXT PRIMARY KEY,
+	status TEXT NOT NULL,
+	ceps JSONB NOT NULL,
+	results JSONB NOT NULL DEFAULT '{}',
+	created_at TIMESTAMPTZ NOT NULL DEFAULT NOW(),
+	updated_at TIMESTAMPTZ NOT NULL DEFAULT NOW()
+);
+CREATE INDEX IF NOT EXISTS batch_jobs_status_created_at_idx ON batch_jobs (status, created_at);`
+
+// Job states.
+const (
+	StatusPending = "pending"
+	StatusRunning = "running"
+	StatusDone    = "done"
+)
+
+// ErrNotFound is returned for unknown job IDs.
+var ErrNotFound = errors.New("job not found")
+
+// Job is the persisted state of a batch job. Results grow as chunks finish.
+type Job struct {
+	ID        string                     `json:"id"`
+	Status    string                     `json:"status"`
+	Total     int                        `json:"total"`
+	Processed int                        `json:"processed"`
+	Results   map[string]json.RawMessage `json:"results"`
+	CreatedAt time.Time                  `json:"created_at"`
+	UpdatedAt time.Time                  `json:"updated_at"`
+}
+
+// Store reads and writes jobs in PostgreSQL.
+type Store struct {
+	db  *sql.DB
+	now func() time.Time
+}
+
+// NewStore builds a Store on db.
+func NewStore(db *sql.DB) *Store {
+	return &Store{db: db, now: time.Now}
+}
+
+// Create persists a pending job for ceps and returns its ID.
+func (s *Store) Create(ctx context.Context, ceps []string) (string, error) {
+	id, err := newID()
+	if err != nil {
+		return "", err
+	}
+	payload, err := json.Marshal(ceps)
+	if err != nil {
+		return "", err
+	}
+	now := s.now().UTC()
+	_, err = s.db.ExecContext(ctx,
+		`INSERT INTO batch_jobs (id, status, ceps, created_at, updated_at) VALUES ($1, $2, $3, $4, $4)`,
+		id, StatusPending, payload, now)
+	if err != nil {
+		return "", fmt.Errorf("create job: %w", err)
+	}
+	return id, nil
+}
+
+// Get loads a job with the results gathered so far.
+func (s *Store) Get(ctx context.Context, id string) (*Job, error) {
+	var (
+		job           = Job{ID: id}
+		ceps, results []byte
+	)
+	err := s.db.QueryRowContext(ctx,
+		`SELECT status, ceps, results, created_at, updated_at FROM batch_jobs WHERE id = $1`, id).
+		Scan(&job.Status, &ceps, &results, &job.CreatedAt, &job.UpdatedAt)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, ErrNotFound
+	}
+	if err != nil {
+		return nil, fmt.Errorf("load job %s: %w", id, err)
+	}
+
+	var keys []string
+	if err := json.Unmarshal(ceps, &keys); err != nil {
+		return nil, fmt.Errorf("decode job %s ceps: %w", id, err)
+	}
+	if err := json.Unmarshal(results, &job.Results); err != nil {
+		return nil, fmt.Errorf("decode job %s results: %w", id, err)
+	}
+	job.Total, job.Processed = len(keys), len(job.Results)
+	return &job, nil
+}
+
+// claimed is a job taken by a Runner.
+type claimed struct {
+	id      string
+	ceps    []string
+	results map[string]json.RawMessage
+}
+
+// claim takes the oldest pending job, or a running one whose runner has not
+// saved progress within lease (e.g. its pod died). It returns nil when there
+// is nothing to do. SKIP LOCKED lets several pods claim concurrently.
+func (s *Store) claim(ctx context.Context, lease time.Duration) (*claimed, error) {
+	now := s.now().UTC()
+	var ceps, results []byte
+	job := &claimed{}
+	err := s.db.QueryRowContext(ctx, `
+UPDATE batch_jobs SET status = $1, updated_at = $2
+WHERE id = (
+	SELECT id FROM batch_jobs
+	WHERE status = $3 OR (status = $1 AND updated_at < $4)
+	ORDER BY created_at
+	LIMIT 1
+	FOR UPDATE SKIP LOCKED
+)
+RETURNING id, ceps, results`,
+		StatusRunning, now, StatusPending, now.Add(-lease)).
+		Scan(&job.id, &ceps, &results)
+	if errors.Is(err, sql.ErrNoRows) {
+		return nil, nil
+	}
+	if err != nil {
+		return nil, fmt.Errorf("claim job: %w", err)
+	}
+	if err := json.Unmarshal(ceps, &job.ceps); err != nil {
+		return nil, fmt.Errorf("decode job %s ceps: %w", job.id, err)
+	}
+	if err := json.Unmarshal(results, &job.results); err != nil {
+		return nil, fmt.Errorf("decode job %s results: %w", job.id, err)
+	}
+	return job, nil
+}
+
+// saveProgress stores the results so far, which also renews the lease.
+func (s *Store) saveProgress(ctx context.Context, id string, results map[string]json.RawMessage, status string) error {
+	payload, err := json.Marshal(results)
+	if err != nil {
+		return err
+	}
+	_, err = s.db.ExecContext(ctx,
+		`UPDATE batch_jobs SET results = $2, status = $3, updated_at = $4 WHERE id = $1`,
+		id, payload, status, s.now().UTC())
+	if err != nil {
+		return fmt.Errorf("save job %s: %w", id, err)
+	}
+	return nil
+}
+
+func newID() (string, error) {
+	b := make([]byte, 16)
+	if _, err := rand.Read(b); err != nil {
+		return "", fmt.Errorf("generate job id: %w", err)
+	}
+	return hex.EncodeToString(b), nil
+}
+
+// Processor resolves a chunk of CEPs into one JSON result per CEP.
+type Processor func(ctx context.Context, ceps []string) map[string]json.RawMessage
+
+// Runner works through pending jobs one chunk at a time, saving after each
+// chunk so a restarted pod resumes where the previous one stopped.
+type Runner struct {
+	store        *Store
+	process      Processor
+	chunkSize    int
+	pollInterval time.Duration
+	lease        time.Duration
+	logger       *slog.Logger
+}
+
+// NewRunner builds a Runner. lease must comfortably exceed the time to process
+// one chunk, or live jobs would be reclaimed by other pods.
+func NewRunner(store *Store, process Processor, chunkSize int, pollInterval, lease time.Duration, logger *slog.Logger) *Runner {
+	return &Runner{
+		store:        store,
+		process:      process,
+		chunkSize:    max(chunkSize, 1),
+		pollInterval: pollInterval,
+		lease:        lease,
+		logger:       logger,
+	}
+}
+
+// Run processes jobs until ctx is cancelled.
+func (r *Runner) Run(ctx context.Context) {
+	for {
+		worked, err := r.runOnce(ctx)
+		if err != nil && ctx.Err() == nil {
+			r.logger.Warn("batch job runner failed", "err", err)
+		}
+		if worked {
+			continue
+		}
+		select {
+		case <-ctx.Done():
+			return
+		case <-time.After(r.pollInterval):
+		}
+	}
+}
+
+// runOnce claims and processes at most one job, reporting whether it found one.
+func (r *Runner) runOnce(ctx context.Context) (bool, error) {
+	job, err := r.store.claim(ctx, r.lease)
+	if err != nil || job == nil {
+		return false, err
+	}
+	if job.results == nil {
+		job.results = map[string]json.RawMessage{}
+	}
+
+	var pending []string
+	for _, cep := range job.ceps {
+		if _, ok := job.results[cep]; !ok {
+			pending = append(pending, cep)
+		}
+	}
+
+	for start := 0; start < len(pending); start += r.chunkSize {
+		chunk := pending[start:min(start+r.chunkSize, len(pending))]
+		results := r.process(ctx, chunk)
+		if ctx.Err() != nil {
+			// Results of an interrupted chunk may be cancellation errors; the
+			// job is reclaimed once its lease expires.
+			return true, ctx.Err()
+		}
+		for cep, result := range results {
+			job.results[cep] = result
+		}
+		if err := r.store.saveProgress(ctx, job.id, job.results, StatusRunning); err != nil {
+			return true, err
+		}
+	}
+
+	if err := r.store.saveProgress(ctx, job.id, job.results, StatusDone); err != nil {
+		return true, err
+	}
+	r.logger.Info("batch job done", "job_id", job.id, "ceps", len(job.ceps))
+	return true, nil
+}
@@ -0,0 +1,132 @@
+package jobs
+
+import (
+	"context"
+	"encoding/json"
+	"io"
+	"log/slog"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+func newTestStore(t *testing.T) (*Store, sqlmock.Sqlmock) {
+	t.Helper()
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	store := NewStore(db)
+	store.now = func() time.Time { return testNow }
+	return store, mock
+}
+
+func claimRows(id, ceps, results string) *sqlmock.Rows {
+	return sqlmock.NewRows([]string{"id", "ceps", "results"}).AddRow(id, []byte(ceps), []byte(results))
+}
+
+func TestStoreGetNotFound(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("FROM batch_jobs").WithArgs("nope").
+		WillReturnRows(sqlmock.NewRows([]string{"status", "ceps", "results", "created_at", "updated_at"}))
+
+	_, err := store.Get(context.Background(), "nope")
+
+	assert.ErrorIs(t, err, ErrNotFound)
+}
+
+func TestStoreClaimTakesPendingOrExpiredJobs(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("UPDATE batch_jobs SET status").
+		WithArgs(StatusRunning, testNow, StatusPending, testNow.Add(-time.Minute)).
+		WillReturnRows(sqlmock.NewRows([]string{"id", "ceps", "results"}))
+
+	job, err := store.claim(context.Background(), time.Minute)
+
+	assert.NoError(t, err)
+	assert.Nil(t, job)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunnerProcessesInChunks(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("UPDATE batch_jobs SET status").
+		WillReturnRows(claimRows("job1", `["a","b","c"]`, `{}`))
+	mock.ExpectExec("UPDATE batch_jobs SET results").
+		WithArgs("job1", []byte(`{"a":"A","b":"B"}`), StatusRunning, testNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE batch_jobs SET results").
+		WithArgs("job1", []byte(`{"a":"A","b":"B","c":"C"}`), StatusRunning, testNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE batch_jobs SET results").
+		WithArgs("job1", []byte(`{"a":"A","b":"B","c":"C"}`), StatusDone, testNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var chunks [][]string
+	runner := NewRunner(store, upperProcessor(&chunks), 2, time.Second, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	worked, err := runner.runOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.True(t, worked)
+	assert.Equal(t, [][]string{{"a", "b"}, {"c"}}, chunks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunnerResumesWithoutRepeatingDoneCEPs(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("UPDATE batch_jobs SET status").
+		WillReturnRows(claimRows("job1", `["a","b","c"]`, `{"a":"A"}`))
+	mock.ExpectExec("UPDATE batch_jobs SET results").
+		WithArgs("job1", []byte(`{"a":"A","b":"B","c":"C"}`), StatusRunning, testNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+	mock.ExpectExec("UPDATE batch_jobs SET results").
+		WithArgs("job1", []byte(`{"a":"A","b":"B","c":"C"}`), StatusDone, testNow).
+		WillReturnResult(sqlmock.NewResult(0, 1))
+
+	var chunks [][]string
+	runner := NewRunner(store, upperProcessor(&chunks), 10, time.Second, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	_, err := runner.runOnce(context.Background())
+
+	assert.NoError(t, err)
+	assert.Equal(t, [][]string{{"b", "c"}}, chunks)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+func TestRunnerLeavesInterruptedChunkUnsaved(t *testing.T) {
+	store, mock := newTestStore(t)
+	mock.ExpectQuery("UPDATE batch_jobs SET status").
+		WillReturnRows(claimRows("job1", `["a"]`, `{}`))
+
+	ctx, cancel := context.WithCancel(context.Background())
+	process := func(context.Context, []string) map[string]json.RawMessage {
+		cancel()
+		return map[string]json.RawMessage{"a": json.RawMessage(`"canceled"`)}
+	}
+	runner := NewRunner(store, process, 10, time.Second, time.Minute, slog.New(slog.NewTextHandler(io.Discard, nil)))
+
+	_, err := runner.runOnce(ctx)
+
+	assert.ErrorIs(t, err, context.Canceled)
+	assert.NoError(t, mock.ExpectationsWereMet())
+}
+
+// upperProcessor records each chunk and answers every CEP with its upper-case
+// form as a JSON string.
+func upperProcessor(chunks *[][]string) Processor {
+	return func(_ context.Context, ceps []string) map[string]json.RawMessage {
+		*chunks = append(*chunks, ceps)
+		out := map[string]json.RawMessage{}
+		for _, cep := range ceps {
+			raw, _ := json.Marshal(strings.ToUpper(cep))
+			out[cep] = raw
+		}
+		return out
+	}
+}
@@ -0,0 +1,375 @@
+// Package jwtauth validates JWT bearer tokens issued by an OpenID Connect
+// provider, with the signing keys fetched from its JWKS URL. Only asymmetric
+// algorithms are accepted, so the service never holds a signing secret.
+package jwtauth
+
+import (
+	"bytes"
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/rsa"
+	_ "crypto/sha256" // registers SHA-256 for crypto.Hash
+	_ "crypto/sha512" // registers SHA-384 and SHA-512 for crypto.Hash
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"net/http"
+	"slices"
+	"strings"
+	"sync"
+	"time"
+)
+
+var (
+	// ErrInvalidToken is returned for tokens that are malformed, badly signed,
+	// expired or meant for another issuer or audience.
+	ErrInvalidToken = errors.New("invalid token")
+	// ErrKeysUnavailable is returned when the JWKS cannot be fetched and no
+	// usable key is cached, so the token could not be checked at all.
+	ErrKeysUnavailable = errors.New("jwks unavailable")
+)
+
+// HTTPClient is the subset of *http.Client used to fetch the JWKS.
+type HTTPClient interface {
+	Do(req *http.Request) (*http.Response, error)
+}
+
+// Config describes the identity provider tokens must come from.
+type Config struct {
+	JWKSURL  string
+	Issuer   string
+	Audience string
+	// Leeway absorbs clock skew when checking exp and nbf.
+	Leeway time.Duration
+}
+
+// Claims are the registered claims of a validated token.
+type Claims struct {
+	Subject   string
+	Issuer    string
+	Audience  []string
+	ExpiresAt time.Time
+}
+
+const (
+	// keysTTL is how long fetched keys are trusted before being refetched.
+	keysTTL = time.Hour
+	// minRefresh limits refetches, whether triggered by expired keys or unknown
+	// key IDs, so neither an outage nor a flood of forged tokens makes every
+	// request hammer the identity provider.
+	minRefresh = time.Minute
+	// maxJWKSSize bounds the JWKS response body.
+	maxJWKSSize = 1 << 20
+)
+
+// Verifier validates tokens against one issuer. It is safe for concurrent use.
+type Verifier struct {
+	cfg    Config
+	client HTTPClient
+	now    func() time.Time
+
+	mu          sync.Mutex
+	keys        map[string]crypto.PublicKey
+	fetchedAt   time.Time
+	attemptedAt time.Time
+	// refresh is the JWKS fetch in flight, if any. It runs without mu held;
+	// callers that need its outcome wait on it instead of fetching again.
+	refresh *keysRefresh
+}
+
+// keysRefresh is one JWKS fetch shared by the callers waiting on it.
+type keysRefresh struct {
+	done chan struct{}
+	err  error
+}
+
+// NewVerifier builds a Verifier. Keys are fetched on first use.
+func NewVerifier(cfg Config, client HTTPClient) *Verifier {
+	return &Verifier{cfg: cfg, client: client, now: time.Now}
+}
+
+// header is the JOSE header of a JWS in compact serialization.
+type header struct {
+	Alg string `json:"alg"`
+	Kid string `json:"kid"`
+}
+
+// payload holds the registered claims. aud may be a string or an array.
+type payload struct {
+	Iss string          `json:"iss"`
+	Sub string          `json:"sub"`
+	Aud json.RawMessage `json:"aud"`
+	Exp *json.Number    `json:"exp"`
+	Nbf *json.Number    `json:"nbf"`
+}
+
+// Verify checks the signature and the registered claims of token.
+func (v *Verifier) Verify(ctx context.Context, token string) (*Claims, error) {
+	parts := strings.Split(token, ".")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("%w: expected three segments", ErrInvalidToken)
+	}
+
+	var h header
+	if err := decodeSegment(parts[0], &h); err != nil {
+		return nil, fmt.Errorf("%w: header: %v", ErrInvalidToken, err)
+	}
+	signature, err := base64.RawURLEncoding.DecodeString(parts[2])
+	if err != nil {
+		return nil, fmt.Errorf("%w: signature: %v", ErrInvalidToken, err)
+	}
+	key, err := v.key(ctx, h.Kid)
+	if err != nil {
+		return nil, err
+	}
+	if err := verifySignature(h.Alg, key, []byte(parts[0]+"."+parts[1]), signature); err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+
+	var p payload
+	if err := decodeSegment(parts[1], &p); err != nil {
+		return nil, fmt.Errorf("%w: claims: %v", ErrInvalidToken, err)
+	}
+	claims, err := v.checkClaims(p)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidToken, err)
+	}
+	return claims, nil
+}
+
+// checkClaims enforces exp, nbf, iss and aud. exp is required: a token that
+// never expires cannot be revoked.
+func (v *Verifier) checkClaims(p payload) (*Claims, error) {
+	now := v.now()
+	if p.Exp == nil {
+		return nil, errors.New("missing exp")
+	}
+	exp, err := numericDate(*p.Exp)
+	if err != nil {
+		return nil, fmt.Errorf("exp: %w", err)
+	}
+	if !now.Before(exp.Add(v.cfg.Leeway)) {
+		return nil, errors.New("token expired")
+	}
+	if p.Nbf != nil {
+		nbf, err := numericDate(*p.Nbf)
+		if err != nil {
+			return nil, fmt.Errorf("nbf: %w", err)
+		}
+		if now.Add(v.cfg.Leeway).Before(nbf) {
+			return nil, errors.New("token not valid yet")
+		}
+	}
+	if p.Iss != v.cfg.Issuer {
+		return nil, fmt.Errorf("unexpected issuer %q", p.Iss)
+	}
+
+	var audience []string
+	if len(p.Aud) > 0 && p.Aud[0] == '"' {
+		var single string
+		if err := json.Unmarshal(p.Aud, &single); err != nil {
+			return nil, fmt.Errorf("aud: %w", err)
+		}
+		audience = []string{single}
+	} else if len(p.Aud) > 0 {
+		if err := json.Unmarshal(p.Aud, &audience); err != nil {
+			return nil, fmt.Errorf("aud: %w", err)
+		}
+	}
+	if !slices.Contains(audience, v.cfg.Audience) {
+		return nil, fmt.Errorf("audience %q not accepted", v.cfg.Audience)
+	}
+
+	return &Claims{Subject: p.Sub, Issuer: p.Iss, Audience: audience, ExpiresAt: exp}, nil
+}
+
+// key returns the public key for kid, fetching the JWKS when the cache is
+// empty, old, or lacks kid (the provider may have rotated keys). A token
+// without kid is accepted only while the JWKS holds a single key. Expired keys
+// keep being served while a refresh is in flight or has failed.
+func (v *Verifier) key(ctx context.Context, kid string) (crypto.PublicKey, error) {
+	v.mu.Lock()
+	now := v.now()
+	key, ok := v.lookup(kid)
+	if ok && now.Sub(v.fetchedAt) < keysTTL {
+		v.mu.Unlock()
+		return key, nil
+	}
+	call, leader := v.refresh, false
+	if call == nil && now.Sub(v.attemptedAt) >= minRefresh {
+		v.attemptedAt = now
+		call, leader = &keysRefresh{done: make(chan struct{})}, true
+		v.refresh = call
+	}
+	v.mu.Unlock()
+
+	switch {
+	case leader:
+		// Other callers may be waiting on this fetch, so it outlives ctx.
+		keys, err := v.fetch(context.WithoutCancel(ctx))
+		v.mu.Lock()
+		if err == nil {
+			v.keys, v.fetchedAt = keys, v.now()
+		}
+		v.refresh = nil
+		v.mu.Unlock()
+		call.err = err
+		close(call.done)
+	case call != nil && !ok:
+		select {
+		case <-call.done:
+		case <-ctx.Done():
+			return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, ctx.Err())
+		}
+	case ok:
+		return key, nil
+	}
+
+	v.mu.Lock()
+	defer v.mu.Unlock()
+	if key, ok := v.lookup(kid); ok {
+		return key, nil
+	}
+	switch {
+	case call != nil && call.err != nil:
+		return nil, fmt.Errorf("%w: %v", ErrKeysUnavailable, call.err)
+	case v.keys == nil:
+		return nil, fmt.Errorf("%w: no keys fetched yet", ErrKeysUnavailable)
+	}
+	return nil, fmt.Errorf("%w: unknown key id %q", ErrInvalidToken, kid)
+}
+
+func (v *Verifier) lookup(kid string) (crypto.PublicKey, bool) {
+	if kid == "" && len(v.keys) == 1 {
+		for _, key := range v.keys {
+			return key, true
+		}
+	}
+	key, ok := v.keys[kid]
+	return key, ok
+}
+
+// jwk is a JSON Web Key; only the members of RSA and EC public keys are read.
+type jwk struct {
+	Kty string `json:"kty"`
+	Kid string `json:"kid"`
+	Use string `json:"use"`
+	N   string `json:"n"`
+	E   string `json:"e"`
+	Crv string `json:"crv"`
+	X   string `json:"x"`
+	Y   string `json:"y"`
+}
+
+// fetch downloads and parses the JWKS. Keys of other types or uses are
+// skipped rather than failing the whole set.
+func (v *Verifier) fetch(ctx context.Context) (map[string]crypto.PublicKey, error) {
+	ctx, cancel := context.WithTimeout(ctx, 5*time.Second)
+	defer cancel()
+
+	req, err := http.NewRequestWithContext(ctx, http.MethodGet, v.cfg.JWKSURL, nil)
+	if err != nil {
+		return nil, err
+	}
+	req.Header.Set("Accept", "application/json")
+	resp, err := v.client.Do(req)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+	if resp.StatusCode != http.StatusOK {
+		return nil, fmt.Errorf("unexpected status %d", resp.StatusCode)
+	}
+
+	var set struct {
+		Keys []jwk `json:"keys"`
+	}
+	if err := json.NewDecoder(http.MaxBytesReader(nil, resp.Body, maxJWKSSize)).Decode(&set); err != nil {
+		return nil, fmt.Errorf("decode jwks: %w", err)
+	}
+
+	keys := map[string]crypto.PublicKey{}
+	for _, k := range set.Keys {
+		if k.Use != "" && k.Use != "sig" {
+			continue
+		}
+		key, err := k.publicKey()
+		if err != nil {
+			continue
+		}
+		keys[k.Kid] = key
+	}
+	if len(keys) == 0 {
+		return nil, errors.New("jwks has no usable signing key")
+	}
+	return keys, nil
+}
+
+func (k jwk) publicKey() (crypto.PublicKey, error) {
+	switch k.Kty {
+	case "RSA":
+		n, err := decodeInt(k.N)
+		if err != nil {
+			return nil, err
+		}
+		e, err := decodeInt(k.E)
+		if err != nil {
+			return nil, err
+		}
+		if !e.IsInt64() || e.Int64() < 3 || e.Int64() > 1<<31-1 || n.BitLen() < 2048 {
+			return nil, errors.New("unsupported rsa key")
+		}
+		return &rsa.PublicKey{N: n, E: int(e.Int64())}, nil
+	case "EC":
+		curve, ok := curves[k.Crv]
+		if !ok {
+			return nil, fmt.Errorf("unsupported curve %q", k.Crv)
+		}
+		x, err := decodeInt(k.X)
+		if err != nil {
+			return nil, err
+		}
+		y, err := decodeInt(k.Y)
+		if err != nil {
+			return nil, err
+		}
+		key := &ecdsa.PublicKey{Curve: curve.params, X: x, Y: y}
+		// ECDH rejects points that are not on the curve.
+		if _, err := key.ECDH(); err != nil {
+			return nil, err
+		}
+		return key, nil
+	default:
+		return nil, fmt.Errorf("unsupported key type %q", k.Kty)
+	}
+}
+
+func decodeSegment(segment string, v any) error {
+	raw, err := base64.RawURLEncoding.DecodeString(segment)
+	if err != nil {
+		return err
+	}
+	dec := json.NewDecoder(bytes.NewReader(raw))
+	dec.UseNumber()
+	return dec.Decode(v)
+}
+
+func decodeInt(value string) (*big.Int, error) {
+	raw, err := base64.RawURLEncoding.DecodeString(value)
+	if err != nil || len(raw) == 0 {
+		return nil, errors.New("invalid key parameter")
+	}
+	return new(big.Int).SetBytes(raw), nil
+}
+
+// numericDate reads a NumericDate, seconds since the epoch, possibly fractional.
+func numericDate(n json.Number) (time.Time, error) {
+	f, err := n.Float64()
+	if err != nil {
+		return time.Time{}, err
+	}
+	return time.Unix(0, int64(f*float64(time.Second))), nil
+}
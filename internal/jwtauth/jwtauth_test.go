@@ -0,0 +1,261 @@
+package jwtauth
+
+import (
+	"context"
+	"crypto"
+	"crypto/ecdsa"
+	"crypto/elliptic"
+	"crypto/rand"
+	"crypto/rsa"
+	"encoding/base64"
+	"encoding/json"
+	"errors"
+	"io"
+	"math/big"
+	"net/http"
+	"strings"
+	"sync/atomic"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+)
+
+var testNow = time.Date(2024, 5, 1, 12, 0, 0, 0, time.UTC)
+
+type clientFunc func(*http.Request) (*http.Response, error)
+
+func (f clientFunc) Do(req *http.Request) (*http.Response, error) { return f(req) }
+
+var (
+	rsaKey, _ = rsa.GenerateKey(rand.Reader, 2048)
+	ecKey, _  = ecdsa.GenerateKey(elliptic.P256(), rand.Reader)
+)
+
+func b64(b []byte) string { return base64.RawURLEncoding.EncodeToString(b) }
+
+func jwks(t *testing.T) string {
+	t.Helper()
+	raw, err := json.Marshal(map[string]any{"keys": []map[string]string{
+		{"kty": "RSA", "kid": "rsa-1", "use": "sig", "n": b64(rsaKey.N.Bytes()), "e": b64(big.NewInt(int64(rsaKey.E)).Bytes())},
+		{"kty": "EC", "kid": "ec-1", "crv": "P-256", "x": b64(ecKey.X.FillBytes(make([]byte, 32))), "y": b64(ecKey.Y.FillBytes(make([]byte, 32)))},
+		{"kty": "oct", "kid": "hmac", "k": "c2VjcmV0"},
+	}})
+	assert.NoError(t, err)
+	return string(raw)
+}
+
+// sign builds a compact JWS with the given header and claims.
+func sign(t *testing.T, alg, kid string, claims map[string]any) string {
+	t.Helper()
+	header, _ := json.Marshal(map[string]string{"alg": alg, "kid": kid, "typ": "JWT"})
+	payload, _ := json.Marshal(claims)
+	signed := b64(header) + "." + b64(payload)
+
+	if alg == "none" {
+		return signed + "."
+	}
+	hash := map[string]crypto.Hash{"256": crypto.SHA256, "384": crypto.SHA384, "512": crypto.SHA512}[alg[2:]]
+	h := hash.New()
+	h.Write([]byte(signed))
+	digest := h.Sum(nil)
+
+	var sig []byte
+	var err error
+	switch alg[:2] {
+	case "RS":
+		sig, err = rsa.SignPKCS1v15(rand.Reader, rsaKey, hash, digest)
+	case "PS":
+		sig, err = rsa.SignPSS(rand.Reader, rsaKey, hash, digest, &rsa.PSSOptions{SaltLength: rsa.PSSSaltLengthEqualsHash})
+	case "ES":
+		var r, s *big.Int
+		r, s, err = ecdsa.Sign(rand.Reader, ecKey, digest)
+		sig = append(r.FillBytes(make([]byte, 32)), s.FillBytes(make([]byte, 32))...)
+	default:
+		sig = []byte("forged")
+	}
+	assert.NoError(t, err)
+	return signed + "." + b64(sig)
+}
+
+func validClaims() map[string]any {
+	return map[string]any{
+		"iss": "https://idp.example.com",
+		"sub": "svc-frete",
+		"aud": "gocep",
+		"exp": testNow.Add(time.Hour).Unix(),
+	}
+}
+
+func newTestVerifier(t *testing.T, fetches *int) *Verifier {
+	t.Helper()
+	body := jwks(t)
+	v := NewVerifier(Config{
+		JWKSURL:  "https://idp.example.com/jwks",
+		Issuer:   "https://idp.example.com",
+		Audience: "gocep",
+		Leeway:   30 * time.Second,
+	}, clientFunc(func(*http.Request) (*http.Response, error) {
+		*fetches++
+		return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+	}))
+	v.now = func() time.Time { return testNow }
+	return v
+}
+
+func TestVerifyValidTokens(t *testing.T) {
+	var fetches int
+	v := newTestVerifier(t, &fetches)
+
+	for _, alg := range []string{"RS256", "PS256", "ES256"} {
+		kid := "rsa-1"
+		if alg == "ES256" {
+			kid = "ec-1"
+		}
+		claims, err := v.Verify(context.Background(), sign(t, alg, kid, validClaims()))
+		assert.NoError(t, err, alg)
+		if assert.NotNil(t, claims, alg) {
+			assert.Equal(t, "svc-frete", claims.Subject)
+			assert.Equal(t, []string{"gocep"}, claims.Audience)
+		}
+	}
+
+	withArray := validClaims()
+	withArray["aud"] = []string{"outro", "gocep"}
+	_, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-1", withArray))
+	assert.NoError(t, err)
+
+	// Within leeway.
+	skewed := validClaims()
+	skewed["exp"] = testNow.Add(-10 * time.Second).Unix()
+	_, err = v.Verify(context.Background(), sign(t, "RS256", "rsa-1", skewed))
+	assert.NoError(t, err)
+
+	assert.Equal(t, 1, fetches)
+}
+
+func TestVerifyRejectsInvalidTokens(t *testing.T) {
+	var fetches int
+	v := newTestVerifier(t, &fetches)
+
+	with := func(key string, value any) map[string]any {
+		claims := validClaims()
+		if value == nil {
+			delete(claims, key)
+		} else {
+			claims[key] = value
+		}
+		return claims
+	}
+	tampered := strings.Split(sign(t, "RS256", "rsa-1", validClaims()), ".")
+	forgedPayload, _ := json.Marshal(with("sub", "admin"))
+	tampered[1] = b64(forgedPayload)
+
+	for name, token := range map[string]string{
+		"expired":        sign(t, "RS256", "rsa-1", with("exp", testNow.Add(-time.Minute).Unix())),
+		"not yet valid":  sign(t, "RS256", "rsa-1", with("nbf", testNow.Add(time.Minute).Unix())),
+		"missing exp":    sign(t, "RS256", "rsa-1", with("exp", nil)),
+		"wrong issuer":   sign(t, "RS256", "rsa-1", with("iss", "https://evil.example.com")),
+		"wrong audience": sign(t, "RS256", "rsa-1", with("aud", "outro")),
+		"no audience":    sign(t, "RS256", "rsa-1", with("aud", nil)),
+		"alg none":       sign(t, "none", "rsa-1", validClaims()),
+		"hmac":           sign(t, "HS256", "hmac", validClaims()),
+		"alg mismatch":   sign(t, "ES256", "rsa-1", validClaims()),
+		"tampered":       strings.Join(tampered, "."),
+		"unknown kid":    sign(t, "RS256", "rsa-2", validClaims()),
+		"ambiguous kid":  sign(t, "RS256", "", validClaims()),
+		"garbage":        "not-a-jwt",
+	} {
+		_, err := v.Verify(context.Background(), token)
+		assert.ErrorIs(t, err, ErrInvalidToken, name)
+	}
+
+	// Unknown key IDs trigger at most one refetch per minRefresh.
+	assert.Equal(t, 1, fetches)
+	v.now = func() time.Time { return testNow.Add(2 * time.Minute) }
+	_, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-2", validClaims()))
+	assert.ErrorIs(t, err, ErrInvalidToken)
+	assert.Equal(t, 2, fetches)
+}
+
+func TestVerifyKeysUnavailable(t *testing.T) {
+	up := true
+	body := jwks(t)
+	v := NewVerifier(Config{JWKSURL: "https://idp.example.com/jwks", Issuer: "https://idp.example.com", Audience: "gocep"},
+		clientFunc(func(*http.Request) (*http.Response, error) {
+			if !up {
+				return nil, errors.New("connection refused")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}))
+	now := testNow
+	v.now = func() time.Time { return now }
+
+	up = false
+	_, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-1", validClaims()))
+	assert.ErrorIs(t, err, ErrKeysUnavailable)
+
+	up = true
+	now = now.Add(minRefresh)
+	_, err = v.Verify(context.Background(), sign(t, "RS256", "rsa-1", validClaims()))
+	assert.NoError(t, err)
+
+	// Cached keys keep working past their TTL while the provider is down.
+	up = false
+	now = now.Add(keysTTL)
+	claims := validClaims()
+	claims["exp"] = now.Add(time.Hour).Unix()
+	_, err = v.Verify(context.Background(), sign(t, "RS256", "rsa-1", claims))
+	assert.NoError(t, err)
+}
+
+func TestVerifyOutageAfterTTL(t *testing.T) {
+	var fetches atomic.Int32
+	up := true
+	started, release := make(chan struct{}, 1), make(chan struct{})
+	body := jwks(t)
+	v := NewVerifier(Config{JWKSURL: "https://idp.example.com/jwks", Issuer: "https://idp.example.com", Audience: "gocep"},
+		clientFunc(func(*http.Request) (*http.Response, error) {
+			fetches.Add(1)
+			if !up {
+				started <- struct{}{}
+				<-release
+				return nil, errors.New("i/o timeout")
+			}
+			return &http.Response{StatusCode: http.StatusOK, Body: io.NopCloser(strings.NewReader(body))}, nil
+		}))
+	now := testNow
+	v.now = func() time.Time { return now }
+	verify := func() error {
+		claims := validClaims()
+		claims["exp"] = now.Add(time.Hour).Unix()
+		_, err := v.Verify(context.Background(), sign(t, "RS256", "rsa-1", claims))
+		return err
+	}
+
+	assert.NoError(t, verify())
+	up = false
+	now = now.Add(keysTTL)
+
+	// The refetch of expired keys hangs; requests meanwhile are served with
+	// the cached key instead of queueing behind it or fetching themselves.
+	leader := make(chan error)
+	go func() { leader <- verify() }()
+	<-started
+	for i := 0; i < 10; i++ {
+		assert.NoError(t, verify())
+	}
+	close(release)
+	assert.NoError(t, <-leader)
+	assert.Equal(t, int32(2), fetches.Load())
+
+	// Failed refetches are throttled like unknown key IDs.
+	now = now.Add(minRefresh / 2)
+	assert.NoError(t, verify())
+	assert.Equal(t, int32(2), fetches.Load())
+
+	now = now.Add(minRefresh / 2)
+	assert.NoError(t, verify())
+	<-started
+	assert.Equal(t, int32(3), fetches.Load())
+}
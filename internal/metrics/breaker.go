@@ -0,0 +1,48 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// CircuitStateSource is implemented by cep.Service.
+type CircuitStateSource interface {
+	CircuitStates() []cep.CircuitState
+}
+
+// circuitStates are exported as one series each, set to 1 for the current state.
+var circuitStates = []string{cep.CircuitClosed, cep.CircuitHalfOpen, cep.CircuitOpen}
+
+// CircuitCollector exports the circuit breaker state of each provider.
+type CircuitCollector struct {
+	source CircuitStateSource
+	state  *prometheus.Desc
+}
+
+// NewCircuitCollector builds a collector reading breaker states from source on scrape.
+func NewCircuitCollector(source CircuitStateSource) *CircuitCollector {
+	return &CircuitCollector{
+		source: source,
+		state: prometheus.NewDesc(
+			"gocep_provider_circuit_state", "Circuit breaker state per provider; 1 marks the current state.",
+			[]string{"provider", "state"}, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *CircuitCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.state
+}
+
+// Collect implements prometheus.Collector.
+func (c *CircuitCollector) Collect(ch chan<- prometheus.Metric) {
+	for _, circuit := range c.source.CircuitStates() {
+		for _, state := range circuitStates {
+			value := 0.0
+			if state == circuit.State {
+				value = 1
+			}
+			ch <- prometheus.MustNewConstMetric(c.state, prometheus.GaugeValue, value, circuit.Provider, state)
+		}
+	}
+}
@@ -0,0 +1,28 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+type circuitSource []cep.CircuitState
+
+func (s circuitSource) CircuitStates() []cep.CircuitState { return s }
+
+func TestCircuitCollector(t *testing.T) {
+	collector := NewCircuitCollector(circuitSource{{Provider: "viacep", State: cep.CircuitOpen}})
+
+	expected := `
+# HELP gocep_provider_circuit_state Circuit breaker state per provider; 1 marks the current state.
+# TYPE gocep_provider_circuit_state gauge
+gocep_provider_circuit_state{provider="viacep",state="closed"} 0
+gocep_provider_circuit_state{provider="viacep",state="half-open"} 0
+gocep_provider_circuit_state{provider="viacep",state="open"} 1
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected)))
+	assert.Equal(t, 0, testutil.CollectAndCount(NewCircuitCollector(circuitSource{})))
+}
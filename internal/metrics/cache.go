@@ -0,0 +1,105 @@
+// Package metrics exposes goCep telemetry as Prometheus collectors.
+package metrics
+
+import (
+	"context"
+	"strconv"
+	"sync"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// CacheStatsSource is implemented by cep.Service.
+type CacheStatsSource interface {
+	CacheStats(ctx context.Context) (cep.CacheStats, error)
+}
+
+// CacheCollector reports cache size and age distribution on scrape. The
+// aggregate query behind it scans the whole table, so results are reused for
+// minInterval between scrapes.
+type CacheCollector struct {
+	source      CacheStatsSource
+	minInterval time.Duration
+	timeout     time.Duration
+	now         func() time.Time
+
+	mu        sync.Mutex
+	last      cep.CacheStats
+	lastErr   error
+	collected time.Time
+
+	entries   *prometheus.Desc
+	expired   *prometheus.Desc
+	byAge     *prometheus.Desc
+	oldestAge *prometheus.Desc
+	up        *prometheus.Desc
+}
+
+// NewCacheCollector builds a collector that queries source at most once per minInterval.
+func NewCacheCollector(source CacheStatsSource, minInterval time.Duration) *CacheCollector {
+	return &CacheCollector{
+		source:      source,
+		minInterval: minInterval,
+		timeout:     5 * time.Second,
+		now:         time.Now,
+		entries: prometheus.NewDesc(
+			"gocep_cache_entries", "Number of CEPs stored in the cache.", nil, nil),
+		expired: prometheus.NewDesc(
+			"gocep_cache_entries_expired", "Number of cached CEPs past their TTL.", nil, nil),
+		byAge: prometheus.NewDesc(
+			"gocep_cache_entries_by_age", "Cached CEPs updated within max_age_seconds (cumulative).", []string{"max_age_seconds"}, nil),
+		oldestAge: prometheus.NewDesc(
+			"gocep_cache_oldest_entry_age_seconds", "Age of the oldest cached CEP.", nil, nil),
+		up: prometheus.NewDesc(
+			"gocep_cache_stats_up", "Whether the last cache stats query succeeded.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *CacheCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.entries
+	ch <- c.expired
+	ch <- c.byAge
+	ch <- c.oldestAge
+	ch <- c.up
+}
+
+// Collect implements prometheus.Collector.
+func (c *CacheCollector) Collect(ch chan<- prometheus.Metric) {
+	stats, err := c.stats()
+	if err != nil {
+		ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 0)
+		return
+	}
+
+	ch <- prometheus.MustNewConstMetric(c.up, prometheus.GaugeValue, 1)
+	ch <- prometheus.MustNewConstMetric(c.entries, prometheus.GaugeValue, float64(stats.Entries))
+	ch <- prometheus.MustNewConstMetric(c.expired, prometheus.GaugeValue, float64(stats.Expired))
+	ch <- prometheus.MustNewConstMetric(c.oldestAge, prometheus.GaugeValue, stats.OldestAge.Seconds())
+	for i, bound := range cep.CacheAgeBuckets {
+		if i >= len(stats.AgeBuckets) {
+			break
+		}
+		ch <- prometheus.MustNewConstMetric(c.byAge, prometheus.GaugeValue,
+			float64(stats.AgeBuckets[i]), strconv.Itoa(int(bound.Seconds())))
+	}
+}
+
+// stats returns the cached aggregate, refreshing it once minInterval has passed.
+func (c *CacheCollector) stats() (cep.CacheStats, error) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+
+	if !c.collected.IsZero() && c.now().Sub(c.collected) < c.minInterval {
+		return c.last, c.lastErr
+	}
+
+	ctx, cancel := context.WithTimeout(context.Background(), c.timeout)
+	defer cancel()
+
+	c.last, c.lastErr = c.source.CacheStats(ctx)
+	c.collected = c.now()
+	return c.last, c.lastErr
+}
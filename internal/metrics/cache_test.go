@@ -0,0 +1,91 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/DATA-DOG/go-sqlmock"
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestCacheCollectorScrape(t *testing.T) {
+	db, mock, err := sqlmock.New()
+	assert.NoError(t, err)
+	t.Cleanup(func() { _ = db.Close() })
+
+	oldest := time.Now().Add(-48 * time.Hour)
+	mock.ExpectQuery(`SELECT COUNT\(\*\), COUNT\(\*\) FILTER \(WHERE updated_at <= \$1\).* FROM ceps`).
+		WillReturnRows(sqlmock.NewRows([]string{"count", "expired", "b1", "b2", "b3", "b4", "min"}).
+			AddRow(10, 4, 2, 6, 10, 10, oldest))
+
+	service := cep.NewService(db, nil, 24*time.Hour, nil)
+	collector := NewCacheCollector(service, time.Minute)
+
+	registry := prometheus.NewPedanticRegistry()
+	registry.MustRegister(collector)
+
+	expected := `
+# HELP gocep_cache_entries Number of CEPs stored in the cache.
+# TYPE gocep_cache_entries gauge
+gocep_cache_entries 10
+# HELP gocep_cache_entries_by_age Cached CEPs updated within max_age_seconds (cumulative).
+# TYPE gocep_cache_entries_by_age gauge
+gocep_cache_entries_by_age{max_age_seconds="3600"} 2
+gocep_cache_entries_by_age{max_age_seconds="86400"} 6
+gocep_cache_entries_by_age{max_age_seconds="604800"} 10
+gocep_cache_entries_by_age{max_age_seconds="2592000"} 10
+# HELP gocep_cache_entries_expired Number of cached CEPs past their TTL.
+# TYPE gocep_cache_entries_expired gauge
+gocep_cache_entries_expired 4
+# HELP gocep_cache_stats_up Whether the last cache stats query succeeded.
+# TYPE gocep_cache_stats_up gauge
+gocep_cache_stats_up 1
+`
+	names := []string{"gocep_cache_entries", "gocep_cache_entries_by_age", "gocep_cache_entries_expired", "gocep_cache_stats_up"}
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), names...))
+
+	// A second scrape within the throttle interval must not hit the database again.
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), names...))
+	assert.NoError(t, mock.ExpectationsWereMet())
+
+	count, err := testutil.GatherAndCount(registry, "gocep_cache_oldest_entry_age_seconds")
+	assert.NoError(t, err)
+	assert.Equal(t, 1, count)
+}
+
+type failingSource struct{ calls int }
+
+func (f *failingSource) CacheStats(context.Context) (cep.CacheStats, error) {
+	f.calls++
+	return cep.CacheStats{}, errors.New("db down")
+}
+
+func TestCacheCollectorThrottlesAndReportsErrors(t *testing.T) {
+	source := &failingSource{}
+	collector := NewCacheCollector(source, time.Minute)
+
+	now := time.Now()
+	collector.now = func() time.Time { return now }
+
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(collector)
+
+	expected := `
+# HELP gocep_cache_stats_up Whether the last cache stats query succeeded.
+# TYPE gocep_cache_stats_up gauge
+gocep_cache_stats_up 0
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "gocep_cache_stats_up"))
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "gocep_cache_stats_up"))
+	assert.Equal(t, 1, source.calls)
+
+	now = now.Add(2 * time.Minute)
+	_, _ = registry.Gather()
+	assert.Equal(t, 2, source.calls)
+}
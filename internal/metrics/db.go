@@ -0,0 +1,43 @@
+package metrics
+
+import (
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// DBQueries is a histogram of PostgreSQL cache query durations by query and
+// outcome, to tell a slow database from a saturated connection pool.
+type DBQueries struct {
+	histogram *prometheus.HistogramVec
+}
+
+// NewDBQueries builds the gocep_db_query_duration_seconds histogram.
+func NewDBQueries() *DBQueries {
+	return &DBQueries{
+		histogram: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gocep_db_query_duration_seconds",
+			Help:    "Duration of PostgreSQL cache queries, including the wait for a pooled connection.",
+			Buckets: []float64{.001, .0025, .005, .01, .025, .05, .1, .25, .5, 1, 2.5},
+		}, []string{"query", "outcome"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (d *DBQueries) Describe(ch chan<- *prometheus.Desc) {
+	d.histogram.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (d *DBQueries) Collect(ch chan<- prometheus.Metric) {
+	d.histogram.Collect(ch)
+}
+
+// Observe records a cache query; it matches cep.QueryObserver.
+func (d *DBQueries) Observe(query string, elapsed time.Duration, err error) {
+	result := "ok"
+	if err != nil {
+		result = "error"
+	}
+	d.histogram.WithLabelValues(query, result).Observe(elapsed.Seconds())
+}
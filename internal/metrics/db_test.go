@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"errors"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+func TestDBQueries(t *testing.T) {
+	queries := NewDBQueries()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(queries)
+
+	queries.Observe(cep.QueryCacheSelect, 2*time.Millisecond, nil)
+	queries.Observe(cep.QueryCacheSelect, 3*time.Millisecond, nil)
+	queries.Observe(cep.QueryCacheUpsert, time.Second, errors.New("deadlock detected"))
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+	assert.Len(t, families, 1)
+
+	counts := map[string]uint64{}
+	for _, metric := range families[0].GetMetric() {
+		labels := metric.GetLabel()
+		// Labels are sorted by name: outcome, query.
+		counts[labels[1].GetValue()+"/"+labels[0].GetValue()] = metric.GetHistogram().GetSampleCount()
+	}
+	assert.Equal(t, map[string]uint64{"cache_select/ok": 2, "cache_upsert/error": 1}, counts)
+}
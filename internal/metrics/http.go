@@ -0,0 +1,75 @@
+package metrics
+
+import (
+	"strconv"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+)
+
+// HTTPRequests tracks the requests served by the HTTP API. Series are labelled
+// with the route template, e.g. /cep/{cep}, never the raw path, so the number
+// of series does not grow with the CEPs looked up.
+type HTTPRequests struct {
+	requests *prometheus.CounterVec
+	duration *prometheus.HistogramVec
+	inFlight prometheus.Gauge
+	shed     prometheus.Counter
+}
+
+// NewHTTPRequests builds the gocep_http_* request metrics.
+func NewHTTPRequests() *HTTPRequests {
+	return &HTTPRequests{
+		requests: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gocep_http_requests_total",
+			Help: "HTTP requests served, by method, route and status code.",
+		}, []string{"method", "route", "status"}),
+		duration: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gocep_http_request_duration_seconds",
+			Help:    "Duration of HTTP requests, by method, route and status code.",
+			Buckets: []float64{.005, .01, .025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"method", "route", "status"}),
+		inFlight: prometheus.NewGauge(prometheus.GaugeOpts{
+			Name: "gocep_http_requests_in_flight",
+			Help: "HTTP requests currently being served.",
+		}),
+		shed: prometheus.NewCounter(prometheus.CounterOpts{
+			Name: "gocep_http_requests_shed_total",
+			Help: "HTTP requests rejected with 503 because MAX_IN_FLIGHT was reached.",
+		}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (h *HTTPRequests) Describe(ch chan<- *prometheus.Desc) {
+	h.requests.Describe(ch)
+	h.duration.Describe(ch)
+	h.inFlight.Describe(ch)
+	h.shed.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (h *HTTPRequests) Collect(ch chan<- prometheus.Metric) {
+	h.requests.Collect(ch)
+	h.duration.Collect(ch)
+	h.inFlight.Collect(ch)
+	h.shed.Collect(ch)
+}
+
+// Started counts a request as in flight until the matching Finished call.
+func (h *HTTPRequests) Started() {
+	h.inFlight.Inc()
+}
+
+// Finished records a completed request.
+func (h *HTTPRequests) Finished(method, route string, status int, elapsed time.Duration) {
+	h.inFlight.Dec()
+	code := strconv.Itoa(status)
+	h.requests.WithLabelValues(method, route, code).Inc()
+	h.duration.WithLabelValues(method, route, code).Observe(elapsed.Seconds())
+}
+
+// Shed counts a request turned away before reaching a handler.
+func (h *HTTPRequests) Shed() {
+	h.shed.Inc()
+}
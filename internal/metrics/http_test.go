@@ -0,0 +1,34 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestHTTPRequests(t *testing.T) {
+	requests := NewHTTPRequests()
+
+	requests.Started()
+	requests.Started()
+	requests.Finished("GET", "/cep/{cep}", 200, 20*time.Millisecond)
+
+	assert.NoError(t, testutil.CollectAndCompare(requests, strings.NewReader(`
+# HELP gocep_http_requests_in_flight HTTP requests currently being served.
+# TYPE gocep_http_requests_in_flight gauge
+gocep_http_requests_in_flight 1
+# HELP gocep_http_requests_total HTTP requests served, by method, route and status code.
+# TYPE gocep_http_requests_total counter
+gocep_http_requests_total{method="GET",route="/cep/{cep}",status="200"} 1
+`), "gocep_http_requests_in_flight", "gocep_http_requests_total"))
+
+	requests.Finished("GET", "/cep/{cep}", 404, time.Second)
+	assert.Equal(t, 2, testutil.CollectAndCount(requests, "gocep_http_request_duration_seconds"))
+	assert.Equal(t, float64(0), testutil.ToFloat64(requests.inFlight))
+
+	requests.Shed()
+	assert.Equal(t, float64(1), testutil.ToFloat64(requests.shed))
+}
@@ -0,0 +1,92 @@
+package metrics
+
+import (
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+// LookupStatsSource is implemented by cep.Service.
+type LookupStatsSource interface {
+	Metrics() cep.MetricsSnapshot
+}
+
+// LookupCollector exports the service's in-process lookup counters.
+type LookupCollector struct {
+	source LookupStatsSource
+
+	cacheHits        *prometheus.Desc
+	cacheMisses      *prometheus.Desc
+	cacheExpired     *prometheus.Desc
+	providerCalls    *prometheus.Desc
+	staleOnError     *prometheus.Desc
+	dataChanged      *prometheus.Desc
+	hedges           *prometheus.Desc
+	retries          *prometheus.Desc
+	shadowCompared   *prometheus.Desc
+	shadowMismatches *prometheus.Desc
+	errors           *prometheus.Desc
+}
+
+// NewLookupCollector builds a collector reading counters from source on scrape.
+func NewLookupCollector(source LookupStatsSource) *LookupCollector {
+	return &LookupCollector{
+		source: source,
+		cacheHits: prometheus.NewDesc(
+			"gocep_lookup_cache_hits_total", "Lookups served from a fresh cache entry.", nil, nil),
+		cacheMisses: prometheus.NewDesc(
+			"gocep_lookup_cache_misses_total", "Lookups without a fresh cache entry.", nil, nil),
+		cacheExpired: prometheus.NewDesc(
+			"gocep_lookup_cache_expired_total", "Cache misses that found an entry older than CACHE_TTL.", nil, nil),
+		providerCalls: prometheus.NewDesc(
+			"gocep_lookup_provider_calls_total", "Lookups that called the provider.", nil, nil),
+		staleOnError: prometheus.NewDesc(
+			"gocep_stale_served_on_error_total", "Expired entries served because the provider failed.", nil, nil),
+		dataChanged: prometheus.NewDesc(
+			"gocep_cep_data_changed_total", "Forced refreshes whose provider data differed from the cached value.", nil, nil),
+		hedges: prometheus.NewDesc(
+			"gocep_lookup_hedges_total", "Hedged provider requests sent.", nil, nil),
+		retries: prometheus.NewDesc(
+			"gocep_lookup_retries_total", "Provider requests retried after a 5xx or network error.", nil, nil),
+		shadowCompared: prometheus.NewDesc(
+			"gocep_shadow_compared_total", "Provider answers compared with the shadow provider.", nil, nil),
+		shadowMismatches: prometheus.NewDesc(
+			"gocep_shadow_mismatches_total", "Provider answers the shadow provider disagreed with.", nil, nil),
+		errors: prometheus.NewDesc(
+			"gocep_lookup_errors_total", "Lookups that failed for reasons other than an invalid or unknown CEP.", nil, nil),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (c *LookupCollector) Describe(ch chan<- *prometheus.Desc) {
+	ch <- c.cacheHits
+	ch <- c.cacheMisses
+	ch <- c.cacheExpired
+	ch <- c.providerCalls
+	ch <- c.staleOnError
+	ch <- c.dataChanged
+	ch <- c.hedges
+	ch <- c.retries
+	ch <- c.shadowCompared
+	ch <- c.shadowMismatches
+	ch <- c.errors
+}
+
+// Collect implements prometheus.Collector.
+func (c *LookupCollector) Collect(ch chan<- prometheus.Metric) {
+	snapshot := c.source.Metrics()
+	for desc, value := range map[*prometheus.Desc]uint64{
+		c.cacheHits:        snapshot.CacheHits,
+		c.cacheMisses:      snapshot.CacheMisses,
+		c.cacheExpired:     snapshot.CacheExpired,
+		c.providerCalls:    snapshot.ProviderCalls,
+		c.staleOnError:     snapshot.StaleServedOnError,
+		c.dataChanged:      snapshot.DataChanged,
+		c.hedges:           snapshot.Hedges,
+		c.retries:          snapshot.Retries,
+		c.shadowCompared:   snapshot.ShadowCompared,
+		c.shadowMismatches: snapshot.ShadowMismatches,
+		c.errors:           snapshot.Errors,
+	} {
+		ch <- prometheus.MustNewConstMetric(desc, prometheus.CounterValue, float64(value))
+	}
+}
@@ -0,0 +1,33 @@
+package metrics
+
+import (
+	"strings"
+	"testing"
+
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+)
+
+type snapshotSource cep.MetricsSnapshot
+
+func (s snapshotSource) Metrics() cep.MetricsSnapshot { return cep.MetricsSnapshot(s) }
+
+func TestLookupCollector(t *testing.T) {
+	collector := NewLookupCollector(snapshotSource{CacheHits: 7, CacheExpired: 3, StaleServedOnError: 2})
+
+	expected := `
+# HELP gocep_lookup_cache_hits_total Lookups served from a fresh cache entry.
+# TYPE gocep_lookup_cache_hits_total counter
+gocep_lookup_cache_hits_total 7
+# HELP gocep_lookup_cache_expired_total Cache misses that found an entry older than CACHE_TTL.
+# TYPE gocep_lookup_cache_expired_total counter
+gocep_lookup_cache_expired_total 3
+# HELP gocep_stale_served_on_error_total Expired entries served because the provider failed.
+# TYPE gocep_stale_served_on_error_total counter
+gocep_stale_served_on_error_total 2
+`
+	assert.NoError(t, testutil.CollectAndCompare(collector, strings.NewReader(expected),
+		"gocep_lookup_cache_hits_total", "gocep_lookup_cache_expired_total", "gocep_stale_served_on_error_total"))
+	assert.Equal(t, 11, testutil.CollectAndCount(collector))
+}
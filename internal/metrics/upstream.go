@@ -0,0 +1,95 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"net"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	"github.com/victor-dias21/goCep-k8s/internal/tracing"
+)
+
+// UpstreamRequests tracks calls to each CEP provider: a histogram of their
+// durations by outcome and a counter of failures by reason. When the request
+// carries a trace context, observations attach the trace ID as an OpenMetrics
+// exemplar so a slow bucket links straight to a trace.
+type UpstreamRequests struct {
+	histogram *prometheus.HistogramVec
+	errors    *prometheus.CounterVec
+}
+
+// NewUpstreamRequests builds the gocep_upstream_request_duration_seconds
+// histogram and the gocep_upstream_errors_total counter.
+func NewUpstreamRequests() *UpstreamRequests {
+	return &UpstreamRequests{
+		histogram: prometheus.NewHistogramVec(prometheus.HistogramOpts{
+			Name:    "gocep_upstream_request_duration_seconds",
+			Help:    "Duration of CEP provider calls.",
+			Buckets: []float64{.025, .05, .1, .25, .5, 1, 2.5, 5, 10},
+		}, []string{"provider", "outcome"}),
+		errors: prometheus.NewCounterVec(prometheus.CounterOpts{
+			Name: "gocep_upstream_errors_total",
+			Help: "CEP provider calls that failed, by reason. Unknown CEPs are not failures.",
+		}, []string{"provider", "reason"}),
+	}
+}
+
+// Describe implements prometheus.Collector.
+func (u *UpstreamRequests) Describe(ch chan<- *prometheus.Desc) {
+	u.histogram.Describe(ch)
+	u.errors.Describe(ch)
+}
+
+// Collect implements prometheus.Collector.
+func (u *UpstreamRequests) Collect(ch chan<- prometheus.Metric) {
+	u.histogram.Collect(ch)
+	u.errors.Collect(ch)
+}
+
+// Observe records a provider call; it matches cep.ProviderObserver.
+func (u *UpstreamRequests) Observe(ctx context.Context, provider string, elapsed time.Duration, err error) {
+	result := outcome(err)
+	if result == "error" {
+		u.errors.WithLabelValues(provider, errorReason(err)).Inc()
+	}
+
+	observer := u.histogram.WithLabelValues(provider, result)
+	sc, ok := tracing.FromContext(ctx)
+	if !ok {
+		observer.Observe(elapsed.Seconds())
+		return
+	}
+	observer.(prometheus.ExemplarObserver).ObserveWithExemplar(elapsed.Seconds(), prometheus.Labels{
+		"trace_id": sc.TraceID,
+		"span_id":  sc.SpanID,
+	})
+}
+
+func outcome(err error) string {
+	switch {
+	case err == nil:
+		return "ok"
+	case errors.Is(err, cep.ErrNotFound):
+		return "not_found"
+	default:
+		return "error"
+	}
+}
+
+// errorReason classifies a failed call coarsely enough to keep the label
+// set small.
+func errorReason(err error) string {
+	var netErr net.Error
+	switch {
+	case errors.Is(err, context.DeadlineExceeded), errors.As(err, &netErr) && netErr.Timeout():
+		return "timeout"
+	case errors.Is(err, cep.ErrCircuitOpen):
+		return "circuit_open"
+	case errors.Is(err, cep.ErrUpstreamBadResponse):
+		return "bad_response"
+	default:
+		return "other"
+	}
+}
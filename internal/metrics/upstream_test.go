@@ -0,0 +1,71 @@
+package metrics
+
+import (
+	"context"
+	"errors"
+	"fmt"
+	"strings"
+	"testing"
+	"time"
+
+	"github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/stretchr/testify/assert"
+	"github.com/victor-dias21/goCep-k8s/internal/cep"
+	"github.com/victor-dias21/goCep-k8s/internal/tracing"
+)
+
+func TestUpstreamRequestsExemplar(t *testing.T) {
+	upstream := NewUpstreamRequests()
+	registry := prometheus.NewRegistry()
+	registry.MustRegister(upstream)
+
+	sc := tracing.SpanContext{TraceID: "4bf92f3577b34da6a3ce929d0e0e4736", SpanID: "00f067aa0ba902b7"}
+	upstream.Observe(tracing.NewContext(context.Background(), sc), "viacep", 80*time.Millisecond, nil)
+	upstream.Observe(context.Background(), "viacep", 30*time.Millisecond, cep.ErrNotFound)
+	upstream.Observe(context.Background(), "viacep", 3*time.Second, errors.New("timeout"))
+
+	families, err := registry.Gather()
+	assert.NoError(t, err)
+
+	exemplars := map[string][]string{}
+	for _, family := range families {
+		if family.GetName() != "gocep_upstream_request_duration_seconds" {
+			continue
+		}
+		for _, metric := range family.GetMetric() {
+			label := metric.GetLabel()[0].GetValue()
+			assert.Equal(t, uint64(1), metric.GetHistogram().GetSampleCount(), label)
+			for _, bucket := range metric.GetHistogram().GetBucket() {
+				for _, pair := range bucket.GetExemplar().GetLabel() {
+					exemplars[label] = append(exemplars[label], pair.GetName()+"="+pair.GetValue())
+				}
+			}
+		}
+	}
+
+	assert.ElementsMatch(t, []string{"trace_id=4bf92f3577b34da6a3ce929d0e0e4736", "span_id=00f067aa0ba902b7"}, exemplars["ok"])
+	assert.Empty(t, exemplars["not_found"])
+	assert.Empty(t, exemplars["error"])
+	assert.Equal(t, 3, testutil.CollectAndCount(upstream, "gocep_upstream_request_duration_seconds"))
+}
+
+func TestUpstreamRequestsPerProvider(t *testing.T) {
+	upstream := NewUpstreamRequests()
+	ctx := context.Background()
+
+	upstream.Observe(ctx, "viacep", time.Second, context.DeadlineExceeded)
+	upstream.Observe(ctx, "viacep", time.Millisecond, cep.ErrCircuitOpen)
+	upstream.Observe(ctx, "brasilapi", 50*time.Millisecond, nil)
+	upstream.Observe(ctx, "brasilapi", 50*time.Millisecond, fmt.Errorf("%w: status 502", cep.ErrUpstreamBadResponse))
+	upstream.Observe(ctx, "brasilapi", 50*time.Millisecond, cep.ErrNotFound)
+
+	assert.NoError(t, testutil.CollectAndCompare(upstream, strings.NewReader(`
+# HELP gocep_upstream_errors_total CEP provider calls that failed, by reason. Unknown CEPs are not failures.
+# TYPE gocep_upstream_errors_total counter
+gocep_upstream_errors_total{provider="brasilapi",reason="bad_response"} 1
+gocep_upstream_errors_total{provider="viacep",reason="circuit_open"} 1
+gocep_upstream_errors_total{provider="viacep",reason="timeout"} 1
+`), "gocep_upstream_errors_total"))
+	assert.Equal(t, 4, testutil.CollectAndCount(upstream, "gocep_upstream_request_duration_seconds"))
+}
@@ -0,0 +1,49 @@
+// Package requestid carries the X-Request-ID of an inbound request through its
+// context so logs and outbound provider calls can be correlated with it.
+package requestid
+
+import (
+	"context"
+	"crypto/rand"
+	"encoding/hex"
+)
+
+// Header is the HTTP header the ID is read from and propagated in.
+const Header = "X-Request-ID"
+
+// maxLength bounds IDs accepted from callers, which end up in every log line.
+const maxLength = 128
+
+type contextKey struct{}
+
+// New returns a random 128-bit ID in hex.
+func New() string {
+	var b [16]byte
+	_, _ = rand.Read(b[:])
+	return hex.EncodeToString(b[:])
+}
+
+// Valid reports whether id is safe to reuse: non-empty, at most 128 bytes and
+// made of printable ASCII only, so it cannot forge log fields or headers.
+func Valid(id string) bool {
+	if id == "" || len(id) > maxLength {
+		return false
+	}
+	for i := 0; i < len(id); i++ {
+		if id[i] < 0x21 || id[i] > 0x7e {
+			return false
+		}
+	}
+	return true
+}
+
+// NewContext returns a copy of ctx carrying id.
+func NewContext(ctx context.Context, id string) context.Context {
+	return context.WithValue(ctx, contextKey{}, id)
+}
+
+// FromContext returns the request ID stored in ctx, if any.
+func FromContext(ctx context.Context) (string, bool) {
+	id, ok := ctx.Value(contextKey{}).(string)
+	return id, ok
+}
@@ -0,0 +1,46 @@
+package requestid
+
+import (
+	"context"
+	"strings"
+	"testing"
+
+	"github.com/stretchr/testify/assert"
+)
+
+func TestNew(t *testing.T) {
+	t.Parallel()
+
+	id := New()
+	assert.Len(t, id, 32)
+	assert.True(t, Valid(id))
+	assert.NotEqual(t, id, New())
+}
+
+func TestValid(t *testing.T) {
+	t.Parallel()
+
+	assert.True(t, Valid("req-1"))
+	assert.True(t, Valid("3f2a9c1e-8d4b-4f6a-9e2d-1b7c5a0f9e3d"))
+
+	for _, invalid := range []string{
+		"",
+		"with space",
+		"line\nbreak",
+		"acentuação",
+		strings.Repeat("a", 129),
+	} {
+		assert.False(t, Valid(invalid), invalid)
+	}
+}
+
+func TestContext(t *testing.T) {
+	t.Parallel()
+
+	_, ok := FromContext(context.Background())
+	assert.False(t, ok)
+
+	id, ok := FromContext(NewContext(context.Background(), "req-1"))
+	assert.True(t, ok)
+	assert.Equal(t, "req-1", id)
+}
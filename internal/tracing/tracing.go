@@ -0,0 +1,62 @@
+// Package tracing carries W3C trace context (the traceparent header) through
+// request contexts so telemetry can be correlated with upstream traces.
+package tracing
+
+import (
+	"context"
+	"strings"
+)
+
+// SpanContext identifies the span a request belongs to.
+type SpanContext struct {
+	TraceID string
+	SpanID  string
+}
+
+type contextKey struct{}
+
+// Parse decodes a traceparent header ("00-<trace-id>-<span-id>-<flags>").
+// All-zero IDs are invalid per the specification and rejected.
+func Parse(traceparent string) (SpanContext, bool) {
+	parts := strings.Split(strings.TrimSpace(traceparent), "-")
+	if len(parts) < 4 || len(parts[0]) != 2 || parts[0] == "ff" {
+		return SpanContext{}, false
+	}
+	if !isLowerHex(parts[0]) || !isLowerHex(parts[3]) || len(parts[3]) != 2 {
+		return SpanContext{}, false
+	}
+	// Version 00 has exactly four fields; later versions may append more.
+	if parts[0] == "00" && len(parts) != 4 {
+		return SpanContext{}, false
+	}
+
+	sc := SpanContext{TraceID: parts[1], SpanID: parts[2]}
+	if !validID(sc.TraceID, 32) || !validID(sc.SpanID, 16) {
+		return SpanContext{}, false
+	}
+	return sc, true
+}
+
+// NewContext returns a copy of ctx carrying sc.
+func NewContext(ctx context.Context, sc SpanContext) context.Context {
+	return context.WithValue(ctx, contextKey{}, sc)
+}
+
+// FromContext returns the span context stored in ctx, if any.
+func FromContext(ctx context.Context) (SpanContext, bool) {
+	sc, ok := ctx.Value(contextKey{}).(SpanContext)
+	return sc, ok
+}
+
+func validID(id string, length int) bool {
+	return len(id) == length && isLowerHex(id) && strings.Trim(id, "0") != ""
+}
+
+func isLowerHex(value string) bool {
+	for _, r := range value {
+		if (r < '0' || r > '9') && (r < 'a' || r > 'f') {
+			return false
+		}
+	}
+	return true
+}
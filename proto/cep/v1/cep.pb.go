@@ -0,0 +1,693 @@
+// Code generated by protoc-gen-go. DO NOT EDIT.
+// versions:
+// 	protoc-gen-go v1.36.0
+// 	protoc        (unknown)
+// source: cep/v1/cep.proto
+
+package cepv1
+
+import (
+	_ "google.golang.org/genproto/googleapis/api/annotations"
+	protoreflect "google.golang.org/protobuf/reflect/protoreflect"
+	protoimpl "google.golang.org/protobuf/runtime/protoimpl"
+	timestamppb "google.golang.org/protobuf/types/known/timestamppb"
+	reflect "reflect"
+	sync "sync"
+)
+
+const (
+	// Verify that this generated code is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(20 - protoimpl.MinVersion)
+	// Verify that runtime/protoimpl is sufficiently up-to-date.
+	_ = protoimpl.EnforceVersion(protoimpl.MaxVersion - 20)
+)
+
+// Address mirrors the JSON body of GET /cep/{cep}.
+type Address struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Cep           string                 `protobuf:"bytes,1,opt,name=cep,proto3" json:"cep,omitempty"`
+	Logradouro    string                 `protobuf:"bytes,2,opt,name=logradouro,proto3" json:"logradouro,omitempty"`
+	Complemento   string                 `protobuf:"bytes,3,opt,name=complemento,proto3" json:"complemento,omitempty"`
+	Bairro        string                 `protobuf:"bytes,4,opt,name=bairro,proto3" json:"bairro,omitempty"`
+	Localidade    string                 `protobuf:"bytes,5,opt,name=localidade,proto3" json:"localidade,omitempty"`
+	Uf            string                 `protobuf:"bytes,6,opt,name=uf,proto3" json:"uf,omitempty"`
+	Ibge          string                 `protobuf:"bytes,7,opt,name=ibge,proto3" json:"ibge,omitempty"`
+	Gia           string                 `protobuf:"bytes,8,opt,name=gia,proto3" json:"gia,omitempty"`
+	Ddd           string                 `protobuf:"bytes,9,opt,name=ddd,proto3" json:"ddd,omitempty"`
+	Siafi         string                 `protobuf:"bytes,10,opt,name=siafi,proto3" json:"siafi,omitempty"`
+	Unidade       string                 `protobuf:"bytes,11,opt,name=unidade,proto3" json:"unidade,omitempty"`
+	Latitude      string                 `protobuf:"bytes,12,opt,name=latitude,proto3" json:"latitude,omitempty"`
+	Longitude     string                 `protobuf:"bytes,13,opt,name=longitude,proto3" json:"longitude,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *Address) Reset() {
+	*x = Address{}
+	mi := &file_cep_v1_cep_proto_msgTypes[0]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *Address) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*Address) ProtoMessage() {}
+
+func (x *Address) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[0]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use Address.ProtoReflect.Descriptor instead.
+func (*Address) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{0}
+}
+
+func (x *Address) GetCep() string {
+	if x != nil {
+		return x.Cep
+	}
+	return ""
+}
+
+func (x *Address) GetLogradouro() string {
+	if x != nil {
+		return x.Logradouro
+	}
+	return ""
+}
+
+func (x *Address) GetComplemento() string {
+	if x != nil {
+		return x.Complemento
+	}
+	return ""
+}
+
+func (x *Address) GetBairro() string {
+	if x != nil {
+		return x.Bairro
+	}
+	return ""
+}
+
+func (x *Address) GetLocalidade() string {
+	if x != nil {
+		return x.Localidade
+	}
+	return ""
+}
+
+func (x *Address) GetUf() string {
+	if x != nil {
+		return x.Uf
+	}
+	return ""
+}
+
+func (x *Address) GetIbge() string {
+	if x != nil {
+		return x.Ibge
+	}
+	return ""
+}
+
+func (x *Address) GetGia() string {
+	if x != nil {
+		return x.Gia
+	}
+	return ""
+}
+
+func (x *Address) GetDdd() string {
+	if x != nil {
+		return x.Ddd
+	}
+	return ""
+}
+
+func (x *Address) GetSiafi() string {
+	if x != nil {
+		return x.Siafi
+	}
+	return ""
+}
+
+func (x *Address) GetUnidade() string {
+	if x != nil {
+		return x.Unidade
+	}
+	return ""
+}
+
+func (x *Address) GetLatitude() string {
+	if x != nil {
+		return x.Latitude
+	}
+	return ""
+}
+
+func (x *Address) GetLongitude() string {
+	if x != nil {
+		return x.Longitude
+	}
+	return ""
+}
+
+type GetCepRequest struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// CEP with or without the hyphen.
+	Cep           string `protobuf:"bytes,1,opt,name=cep,proto3" json:"cep,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCepRequest) Reset() {
+	*x = GetCepRequest{}
+	mi := &file_cep_v1_cep_proto_msgTypes[1]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCepRequest) ProtoMessage() {}
+
+func (x *GetCepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[1]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCepRequest.ProtoReflect.Descriptor instead.
+func (*GetCepRequest) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{1}
+}
+
+func (x *GetCepRequest) GetCep() string {
+	if x != nil {
+		return x.Cep
+	}
+	return ""
+}
+
+type GetCepResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Address *Address               `protobuf:"bytes,1,opt,name=address,proto3" json:"address,omitempty"`
+	// Tier that served the address: memory, postgres or provider.
+	Source string `protobuf:"bytes,2,opt,name=source,proto3" json:"source,omitempty"`
+	// When the address was fetched from the provider.
+	UpdatedAt *timestamppb.Timestamp `protobuf:"bytes,3,opt,name=updated_at,json=updatedAt,proto3" json:"updated_at,omitempty"`
+	// Set when an expired entry was served because the provider failed.
+	Stale         bool `protobuf:"varint,4,opt,name=stale,proto3" json:"stale,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *GetCepResponse) Reset() {
+	*x = GetCepResponse{}
+	mi := &file_cep_v1_cep_proto_msgTypes[2]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *GetCepResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*GetCepResponse) ProtoMessage() {}
+
+func (x *GetCepResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[2]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use GetCepResponse.ProtoReflect.Descriptor instead.
+func (*GetCepResponse) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{2}
+}
+
+func (x *GetCepResponse) GetAddress() *Address {
+	if x != nil {
+		return x.Address
+	}
+	return nil
+}
+
+func (x *GetCepResponse) GetSource() string {
+	if x != nil {
+		return x.Source
+	}
+	return ""
+}
+
+func (x *GetCepResponse) GetUpdatedAt() *timestamppb.Timestamp {
+	if x != nil {
+		return x.UpdatedAt
+	}
+	return nil
+}
+
+func (x *GetCepResponse) GetStale() bool {
+	if x != nil {
+		return x.Stale
+	}
+	return false
+}
+
+type BatchGetCepRequest struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Ceps          []string               `protobuf:"bytes,1,rep,name=ceps,proto3" json:"ceps,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetCepRequest) Reset() {
+	*x = BatchGetCepRequest{}
+	mi := &file_cep_v1_cep_proto_msgTypes[3]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetCepRequest) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetCepRequest) ProtoMessage() {}
+
+func (x *BatchGetCepRequest) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[3]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetCepRequest.ProtoReflect.Descriptor instead.
+func (*BatchGetCepRequest) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{3}
+}
+
+func (x *BatchGetCepRequest) GetCeps() []string {
+	if x != nil {
+		return x.Ceps
+	}
+	return nil
+}
+
+type BatchItem struct {
+	state protoimpl.MessageState `protogen:"open.v1"`
+	// Types that are valid to be assigned to Outcome:
+	//
+	//	*BatchItem_Address
+	//	*BatchItem_Error
+	Outcome       isBatchItem_Outcome `protobuf_oneof:"outcome"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchItem) Reset() {
+	*x = BatchItem{}
+	mi := &file_cep_v1_cep_proto_msgTypes[4]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchItem) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchItem) ProtoMessage() {}
+
+func (x *BatchItem) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[4]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchItem.ProtoReflect.Descriptor instead.
+func (*BatchItem) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{4}
+}
+
+func (x *BatchItem) GetOutcome() isBatchItem_Outcome {
+	if x != nil {
+		return x.Outcome
+	}
+	return nil
+}
+
+func (x *BatchItem) GetAddress() *Address {
+	if x != nil {
+		if x, ok := x.Outcome.(*BatchItem_Address); ok {
+			return x.Address
+		}
+	}
+	return nil
+}
+
+func (x *BatchItem) GetError() string {
+	if x != nil {
+		if x, ok := x.Outcome.(*BatchItem_Error); ok {
+			return x.Error
+		}
+	}
+	return ""
+}
+
+type isBatchItem_Outcome interface {
+	isBatchItem_Outcome()
+}
+
+type BatchItem_Address struct {
+	Address *Address `protobuf:"bytes,1,opt,name=address,proto3,oneof"`
+}
+
+type BatchItem_Error struct {
+	Error string `protobuf:"bytes,2,opt,name=error,proto3,oneof"`
+}
+
+func (*BatchItem_Address) isBatchItem_Outcome() {}
+
+func (*BatchItem_Error) isBatchItem_Outcome() {}
+
+type BatchSummary struct {
+	state         protoimpl.MessageState `protogen:"open.v1"`
+	Total         int32                  `protobuf:"varint,1,opt,name=total,proto3" json:"total,omitempty"`
+	Succeeded     int32                  `protobuf:"varint,2,opt,name=succeeded,proto3" json:"succeeded,omitempty"`
+	Failed        int32                  `protobuf:"varint,3,opt,name=failed,proto3" json:"failed,omitempty"`
+	NotFound      int32                  `protobuf:"varint,4,opt,name=not_found,json=notFound,proto3" json:"not_found,omitempty"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchSummary) Reset() {
+	*x = BatchSummary{}
+	mi := &file_cep_v1_cep_proto_msgTypes[5]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchSummary) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchSummary) ProtoMessage() {}
+
+func (x *BatchSummary) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[5]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchSummary.ProtoReflect.Descriptor instead.
+func (*BatchSummary) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{5}
+}
+
+func (x *BatchSummary) GetTotal() int32 {
+	if x != nil {
+		return x.Total
+	}
+	return 0
+}
+
+func (x *BatchSummary) GetSucceeded() int32 {
+	if x != nil {
+		return x.Succeeded
+	}
+	return 0
+}
+
+func (x *BatchSummary) GetFailed() int32 {
+	if x != nil {
+		return x.Failed
+	}
+	return 0
+}
+
+func (x *BatchSummary) GetNotFound() int32 {
+	if x != nil {
+		return x.NotFound
+	}
+	return 0
+}
+
+type BatchGetCepResponse struct {
+	state   protoimpl.MessageState `protogen:"open.v1"`
+	Summary *BatchSummary          `protobuf:"bytes,1,opt,name=summary,proto3" json:"summary,omitempty"`
+	// Keyed by CEP as submitted, after trimming; repeats are looked up once.
+	Results       map[string]*BatchItem `protobuf:"bytes,2,rep,name=results,proto3" json:"results,omitempty" protobuf_key:"bytes,1,opt,name=key" protobuf_val:"bytes,2,opt,name=value"`
+	unknownFields protoimpl.UnknownFields
+	sizeCache     protoimpl.SizeCache
+}
+
+func (x *BatchGetCepResponse) Reset() {
+	*x = BatchGetCepResponse{}
+	mi := &file_cep_v1_cep_proto_msgTypes[6]
+	ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+	ms.StoreMessageInfo(mi)
+}
+
+func (x *BatchGetCepResponse) String() string {
+	return protoimpl.X.MessageStringOf(x)
+}
+
+func (*BatchGetCepResponse) ProtoMessage() {}
+
+func (x *BatchGetCepResponse) ProtoReflect() protoreflect.Message {
+	mi := &file_cep_v1_cep_proto_msgTypes[6]
+	if x != nil {
+		ms := protoimpl.X.MessageStateOf(protoimpl.Pointer(x))
+		if ms.LoadMessageInfo() == nil {
+			ms.StoreMessageInfo(mi)
+		}
+		return ms
+	}
+	return mi.MessageOf(x)
+}
+
+// Deprecated: Use BatchGetCepResponse.ProtoReflect.Descriptor instead.
+func (*BatchGetCepResponse) Descriptor() ([]byte, []int) {
+	return file_cep_v1_cep_proto_rawDescGZIP(), []int{6}
+}
+
+func (x *BatchGetCepResponse) GetSummary() *BatchSummary {
+	if x != nil {
+		return x.Summary
+	}
+	return nil
+}
+
+func (x *BatchGetCepResponse) GetResults() map[string]*BatchItem {
+	if x != nil {
+		return x.Results
+	}
+	return nil
+}
+
+var File_cep_v1_cep_proto protoreflect.FileDescriptor
+
+var file_cep_v1_cep_proto_rawDesc = []byte{
+	0x0a, 0x10, 0x63, 0x65, 0x70, 0x2f, 0x76, 0x31, 0x2f, 0x63, 0x65, 0x70, 0x2e, 0x70, 0x72, 0x6f,
+	0x74, 0x6f, 0x12, 0x0c, 0x67, 0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31,
+	0x1a, 0x1c, 0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x61, 0x70, 0x69, 0x2f, 0x61, 0x6e, 0x6e,
+	0x6f, 0x74, 0x61, 0x74, 0x69, 0x6f, 0x6e, 0x73, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x1a, 0x1f,
+	0x67, 0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2f,
+	0x74, 0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x22,
+	0xc7, 0x02, 0x0a, 0x07, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x12, 0x10, 0x0a, 0x03, 0x63,
+	0x65, 0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x65, 0x70, 0x12, 0x1e, 0x0a,
+	0x0a, 0x6c, 0x6f, 0x67, 0x72, 0x61, 0x64, 0x6f, 0x75, 0x72, 0x6f, 0x18, 0x02, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x0a, 0x6c, 0x6f, 0x67, 0x72, 0x61, 0x64, 0x6f, 0x75, 0x72, 0x6f, 0x12, 0x20, 0x0a,
+	0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x6f, 0x18, 0x03, 0x20, 0x01,
+	0x28, 0x09, 0x52, 0x0b, 0x63, 0x6f, 0x6d, 0x70, 0x6c, 0x65, 0x6d, 0x65, 0x6e, 0x74, 0x6f, 0x12,
+	0x16, 0x0a, 0x06, 0x62, 0x61, 0x69, 0x72, 0x72, 0x6f, 0x18, 0x04, 0x20, 0x01, 0x28, 0x09, 0x52,
+	0x06, 0x62, 0x61, 0x69, 0x72, 0x72, 0x6f, 0x12, 0x1e, 0x0a, 0x0a, 0x6c, 0x6f, 0x63, 0x61, 0x6c,
+	0x69, 0x64, 0x61, 0x64, 0x65, 0x18, 0x05, 0x20, 0x01, 0x28, 0x09, 0x52, 0x0a, 0x6c, 0x6f, 0x63,
+	0x61, 0x6c, 0x69, 0x64, 0x61, 0x64, 0x65, 0x12, 0x0e, 0x0a, 0x02, 0x75, 0x66, 0x18, 0x06, 0x20,
+	0x01, 0x28, 0x09, 0x52, 0x02, 0x75, 0x66, 0x12, 0x12, 0x0a, 0x04, 0x69, 0x62, 0x67, 0x65, 0x18,
+	0x07, 0x20, 0x01, 0x28, 0x09, 0x52, 0x04, 0x69, 0x62, 0x67, 0x65, 0x12, 0x10, 0x0a, 0x03, 0x67,
+	0x69, 0x61, 0x18, 0x08, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x67, 0x69, 0x61, 0x12, 0x10, 0x0a,
+	0x03, 0x64, 0x64, 0x64, 0x18, 0x09, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x64, 0x64, 0x64, 0x12,
+	0x14, 0x0a, 0x05, 0x73, 0x69, 0x61, 0x66, 0x69, 0x18, 0x0a, 0x20, 0x01, 0x28, 0x09, 0x52, 0x05,
+	0x73, 0x69, 0x61, 0x66, 0x69, 0x12, 0x18, 0x0a, 0x07, 0x75, 0x6e, 0x69, 0x64, 0x61, 0x64, 0x65,
+	0x18, 0x0b, 0x20, 0x01, 0x28, 0x09, 0x52, 0x07, 0x75, 0x6e, 0x69, 0x64, 0x61, 0x64, 0x65, 0x12,
+	0x1a, 0x0a, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x0c, 0x20, 0x01, 0x28,
+	0x09, 0x52, 0x08, 0x6c, 0x61, 0x74, 0x69, 0x74, 0x75, 0x64, 0x65, 0x12, 0x1c, 0x0a, 0x09, 0x6c,
+	0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x18, 0x0d, 0x20, 0x01, 0x28, 0x09, 0x52, 0x09,
+	0x6c, 0x6f, 0x6e, 0x67, 0x69, 0x74, 0x75, 0x64, 0x65, 0x22, 0x21, 0x0a, 0x0d, 0x47, 0x65, 0x74,
+	0x43, 0x65, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12, 0x10, 0x0a, 0x03, 0x63, 0x65,
+	0x70, 0x18, 0x01, 0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x63, 0x65, 0x70, 0x22, 0xaa, 0x01, 0x0a,
+	0x0e, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12,
+	0x2f, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b,
+	0x32, 0x15, 0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31, 0x2e,
+	0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73,
+	0x12, 0x16, 0x0a, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x09,
+	0x52, 0x06, 0x73, 0x6f, 0x75, 0x72, 0x63, 0x65, 0x12, 0x39, 0x0a, 0x0a, 0x75, 0x70, 0x64, 0x61,
+	0x74, 0x65, 0x64, 0x5f, 0x61, 0x74, 0x18, 0x03, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67,
+	0x6f, 0x6f, 0x67, 0x6c, 0x65, 0x2e, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x62, 0x75, 0x66, 0x2e, 0x54,
+	0x69, 0x6d, 0x65, 0x73, 0x74, 0x61, 0x6d, 0x70, 0x52, 0x09, 0x75, 0x70, 0x64, 0x61, 0x74, 0x65,
+	0x64, 0x41, 0x74, 0x12, 0x14, 0x0a, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x18, 0x04, 0x20, 0x01,
+	0x28, 0x08, 0x52, 0x05, 0x73, 0x74, 0x61, 0x6c, 0x65, 0x22, 0x28, 0x0a, 0x12, 0x42, 0x61, 0x74,
+	0x63, 0x68, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x12,
+	0x12, 0x0a, 0x04, 0x63, 0x65, 0x70, 0x73, 0x18, 0x01, 0x20, 0x03, 0x28, 0x09, 0x52, 0x04, 0x63,
+	0x65, 0x70, 0x73, 0x22, 0x61, 0x0a, 0x09, 0x42, 0x61, 0x74, 0x63, 0x68, 0x49, 0x74, 0x65, 0x6d,
+	0x12, 0x31, 0x0a, 0x07, 0x61, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x18, 0x01, 0x20, 0x01, 0x28,
+	0x0b, 0x32, 0x15, 0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31,
+	0x2e, 0x41, 0x64, 0x64, 0x72, 0x65, 0x73, 0x73, 0x48, 0x00, 0x52, 0x07, 0x61, 0x64, 0x64, 0x72,
+	0x65, 0x73, 0x73, 0x12, 0x16, 0x0a, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x18, 0x02, 0x20, 0x01,
+	0x28, 0x09, 0x48, 0x00, 0x52, 0x05, 0x65, 0x72, 0x72, 0x6f, 0x72, 0x42, 0x09, 0x0a, 0x07, 0x6f,
+	0x75, 0x74, 0x63, 0x6f, 0x6d, 0x65, 0x22, 0x77, 0x0a, 0x0c, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53,
+	0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x14, 0x0a, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x18,
+	0x01, 0x20, 0x01, 0x28, 0x05, 0x52, 0x05, 0x74, 0x6f, 0x74, 0x61, 0x6c, 0x12, 0x1c, 0x0a, 0x09,
+	0x73, 0x75, 0x63, 0x63, 0x65, 0x65, 0x64, 0x65, 0x64, 0x18, 0x02, 0x20, 0x01, 0x28, 0x05, 0x52,
+	0x09, 0x73, 0x75, 0x63, 0x63, 0x65, 0x65, 0x64, 0x65, 0x64, 0x12, 0x16, 0x0a, 0x06, 0x66, 0x61,
+	0x69, 0x6c, 0x65, 0x64, 0x18, 0x03, 0x20, 0x01, 0x28, 0x05, 0x52, 0x06, 0x66, 0x61, 0x69, 0x6c,
+	0x65, 0x64, 0x12, 0x1b, 0x0a, 0x09, 0x6e, 0x6f, 0x74, 0x5f, 0x66, 0x6f, 0x75, 0x6e, 0x64, 0x18,
+	0x04, 0x20, 0x01, 0x28, 0x05, 0x52, 0x08, 0x6e, 0x6f, 0x74, 0x46, 0x6f, 0x75, 0x6e, 0x64, 0x22,
+	0xea, 0x01, 0x0a, 0x13, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52,
+	0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x12, 0x34, 0x0a, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61,
+	0x72, 0x79, 0x18, 0x01, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x1a, 0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70,
+	0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x53, 0x75, 0x6d,
+	0x6d, 0x61, 0x72, 0x79, 0x52, 0x07, 0x73, 0x75, 0x6d, 0x6d, 0x61, 0x72, 0x79, 0x12, 0x48, 0x0a,
+	0x07, 0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x18, 0x02, 0x20, 0x03, 0x28, 0x0b, 0x32, 0x2e,
+	0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61,
+	0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73,
+	0x65, 0x2e, 0x52, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x52, 0x07,
+	0x72, 0x65, 0x73, 0x75, 0x6c, 0x74, 0x73, 0x1a, 0x53, 0x0a, 0x0c, 0x52, 0x65, 0x73, 0x75, 0x6c,
+	0x74, 0x73, 0x45, 0x6e, 0x74, 0x72, 0x79, 0x12, 0x10, 0x0a, 0x03, 0x6b, 0x65, 0x79, 0x18, 0x01,
+	0x20, 0x01, 0x28, 0x09, 0x52, 0x03, 0x6b, 0x65, 0x79, 0x12, 0x2d, 0x0a, 0x05, 0x76, 0x61, 0x6c,
+	0x75, 0x65, 0x18, 0x02, 0x20, 0x01, 0x28, 0x0b, 0x32, 0x17, 0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70,
+	0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x49, 0x74, 0x65,
+	0x6d, 0x52, 0x05, 0x76, 0x61, 0x6c, 0x75, 0x65, 0x3a, 0x02, 0x38, 0x01, 0x32, 0xe1, 0x01, 0x0a,
+	0x0a, 0x43, 0x65, 0x70, 0x53, 0x65, 0x72, 0x76, 0x69, 0x63, 0x65, 0x12, 0x5e, 0x0a, 0x06, 0x47,
+	0x65, 0x74, 0x43, 0x65, 0x70, 0x12, 0x1b, 0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65,
+	0x70, 0x2e, 0x76, 0x31, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65,
+	0x73, 0x74, 0x1a, 0x1c, 0x2e, 0x67, 0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76,
+	0x31, 0x2e, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65,
+	0x22, 0x19, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x13, 0x12, 0x11, 0x2f, 0x76, 0x31, 0x2f, 0x72, 0x70,
+	0x63, 0x2f, 0x63, 0x65, 0x70, 0x2f, 0x7b, 0x63, 0x65, 0x70, 0x7d, 0x12, 0x73, 0x0a, 0x0b, 0x42,
+	0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x12, 0x20, 0x2e, 0x67, 0x6f, 0x63,
+	0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63, 0x68, 0x47,
+	0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x71, 0x75, 0x65, 0x73, 0x74, 0x1a, 0x21, 0x2e, 0x67,
+	0x6f, 0x63, 0x65, 0x70, 0x2e, 0x63, 0x65, 0x70, 0x2e, 0x76, 0x31, 0x2e, 0x42, 0x61, 0x74, 0x63,
+	0x68, 0x47, 0x65, 0x74, 0x43, 0x65, 0x70, 0x52, 0x65, 0x73, 0x70, 0x6f, 0x6e, 0x73, 0x65, 0x22,
+	0x1f, 0x82, 0xd3, 0xe4, 0x93, 0x02, 0x19, 0x3a, 0x01, 0x2a, 0x22, 0x14, 0x2f, 0x76, 0x31, 0x2f,
+	0x72, 0x70, 0x63, 0x2f, 0x63, 0x65, 0x70, 0x3a, 0x62, 0x61, 0x74, 0x63, 0x68, 0x47, 0x65, 0x74,
+	0x42, 0x37, 0x5a, 0x35, 0x67, 0x69, 0x74, 0x68, 0x75, 0x62, 0x2e, 0x63, 0x6f, 0x6d, 0x2f, 0x76,
+	0x69, 0x63, 0x74, 0x6f, 0x72, 0x2d, 0x64, 0x69, 0x61, 0x73, 0x32, 0x31, 0x2f, 0x67, 0x6f, 0x43,
+	0x65, 0x70, 0x2d, 0x6b, 0x38, 0x73, 0x2f, 0x70, 0x72, 0x6f, 0x74, 0x6f, 0x2f, 0x63, 0x65, 0x70,
+	0x2f, 0x76, 0x31, 0x3b, 0x63, 0x65, 0x70, 0x76, 0x31, 0x62, 0x06, 0x70, 0x72, 0x6f, 0x74, 0x6f,
+	0x33,
+}
+
+var (
+	file_cep_v1_cep_proto_rawDescOnce sync.Once
+	file_cep_v1_cep_proto_rawDescData = file_cep_v1_cep_proto_rawDesc
+)
+
+func file_cep_v1_cep_proto_rawDescGZIP() []byte {
+	file_cep_v1_cep_proto_rawDescOnce.Do(func() {
+		file_cep_v1_cep_proto_rawDescData = protoimpl.X.CompressGZIP(file_cep_v1_cep_proto_rawDescData)
+	})
+	return file_cep_v1_cep_proto_rawDescData
+}
+
+var file_cep_v1_cep_proto_msgTypes = make([]protoimpl.MessageInfo, 8)
+var file_cep_v1_cep_proto_goTypes = []any{
+	(*Address)(nil),               // 0: gocep.cep.v1.Address
+	(*GetCepRequest)(nil),         // 1: gocep.cep.v1.GetCepRequest
+	(*GetCepResponse)(nil),        // 2: gocep.cep.v1.GetCepResponse
+	(*BatchGetCepRequest)(nil),    // 3: gocep.cep.v1.BatchGetCepRequest
+	(*BatchItem)(nil),             // 4: gocep.cep.v1.BatchItem
+	(*BatchSummary)(nil),          // 5: gocep.cep.v1.BatchSummary
+	(*BatchGetCepResponse)(nil),   // 6: gocep.cep.v1.BatchGetCepResponse
+	nil,                           // 7: gocep.cep.v1.BatchGetCepResponse.ResultsEntry
+	(*timestamppb.Timestamp)(nil), // 8: google.protobuf.Timestamp
+}
+var file_cep_v1_cep_proto_depIdxs = []int32{
+	0, // 0: gocep.cep.v1.GetCepResponse.address:type_name -> gocep.cep.v1.Address
+	8, // 1: gocep.cep.v1.GetCepResponse.updated_at:type_name -> google.protobuf.Timestamp
+	0, // 2: gocep.cep.v1.BatchItem.address:type_name -> gocep.cep.v1.Address
+	5, // 3: gocep.cep.v1.BatchGetCepResponse.summary:type_name -> gocep.cep.v1.BatchSummary
+	7, // 4: gocep.cep.v1.BatchGetCepResponse.results:type_name -> gocep.cep.v1.BatchGetCepResponse.ResultsEntry
+	4, // 5: gocep.cep.v1.BatchGetCepResponse.ResultsEntry.value:type_name -> gocep.cep.v1.BatchItem
+	1, // 6: gocep.cep.v1.CepService.GetCep:input_type -> gocep.cep.v1.GetCepRequest
+	3, // 7: gocep.cep.v1.CepService.BatchGetCep:input_type -> gocep.cep.v1.BatchGetCepRequest
+	2, // 8: gocep.cep.v1.CepService.GetCep:output_type -> gocep.cep.v1.GetCepResponse
+	6, // 9: gocep.cep.v1.CepService.BatchGetCep:output_type -> gocep.cep.v1.BatchGetCepResponse
+	8, // [8:10] is the sub-list for method output_type
+	6, // [6:8] is the sub-list for method input_type
+	6, // [6:6] is the sub-list for extension type_name
+	6, // [6:6] is the sub-list for extension extendee
+	0, // [0:6] is the sub-list for field type_name
+}
+
+func init() { file_cep_v1_cep_proto_init() }
+func file_cep_v1_cep_proto_init() {
+	if File_cep_v1_cep_proto != nil {
+		return
+	}
+	file_cep_v1_cep_proto_msgTypes[4].OneofWrappers = []any{
+		(*BatchItem_Address)(nil),
+		(*BatchItem_Error)(nil),
+	}
+	type x struct{}
+	out := protoimpl.TypeBuilder{
+		File: protoimpl.DescBuilder{
+			GoPackagePath: reflect.TypeOf(x{}).PkgPath(),
+			RawDescriptor: file_cep_v1_cep_proto_rawDesc,
+			NumEnums:      0,
+			NumMessages:   8,
+			NumExtensions: 0,
+			NumServices:   1,
+		},
+		GoTypes:           file_cep_v1_cep_proto_goTypes,
+		DependencyIndexes: file_cep_v1_cep_proto_depIdxs,
+		MessageInfos:      file_cep_v1_cep_proto_msgTypes,
+	}.Build()
+	File_cep_v1_cep_proto = out.File
+	file_cep_v1_cep_proto_rawDesc = nil
+	file_cep_v1_cep_proto_goTypes = nil
+	file_cep_v1_cep_proto_depIdxs = nil
+}
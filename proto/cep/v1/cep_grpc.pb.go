@@ -0,0 +1,175 @@
+// Code generated by protoc-gen-go-grpc. DO NOT EDIT.
+// versions:
+// - protoc-gen-go-grpc v1.5.1
+// - protoc             (unknown)
+// source: cep/v1/cep.proto
+
+package cepv1
+
+import (
+	context "context"
+	grpc "google.golang.org/grpc"
+	codes "google.golang.org/grpc/codes"
+	status "google.golang.org/grpc/status"
+)
+
+// This is a compile-time assertion to ensure that this generated file
+// is compatible with the grpc package it is being compiled against.
+// Requires gRPC-Go v1.64.0 or later.
+const _ = grpc.SupportPackageIsVersion9
+
+const (
+	CepService_GetCep_FullMethodName      = "/gocep.cep.v1.CepService/GetCep"
+	CepService_BatchGetCep_FullMethodName = "/gocep.cep.v1.CepService/BatchGetCep"
+)
+
+// CepServiceClient is the client API for CepService service.
+//
+// For semantics around ctx use and closing/ending streaming RPCs, please refer to https://pkg.go.dev/google.golang.org/grpc/?tab=doc#ClientConn.NewStream.
+//
+// CepService resolves Brazilian postal codes through the same cache and
+// providers as the HTTP API. The google.api.http bindings generate the REST
+// layer served under /v1/rpc by grpc-gateway, beside the native /v1 routes.
+type CepServiceClient interface {
+	// GetCep looks up a single CEP. Unknown CEPs fail with NOT_FOUND and
+	// malformed ones with INVALID_ARGUMENT.
+	GetCep(ctx context.Context, in *GetCepRequest, opts ...grpc.CallOption) (*GetCepResponse, error)
+	// BatchGetCep looks up several CEPs concurrently. Per-CEP failures are
+	// reported in the results instead of failing the call.
+	BatchGetCep(ctx context.Context, in *BatchGetCepRequest, opts ...grpc.CallOption) (*BatchGetCepResponse, error)
+}
+
+type cepServiceClient struct {
+	cc grpc.ClientConnInterface
+}
+
+func NewCepServiceClient(cc grpc.ClientConnInterface) CepServiceClient {
+	return &cepServiceClient{cc}
+}
+
+func (c *cepServiceClient) GetCep(ctx context.Context, in *GetCepRequest, opts ...grpc.CallOption) (*GetCepResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(GetCepResponse)
+	err := c.cc.Invoke(ctx, CepService_GetCep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+func (c *cepServiceClient) BatchGetCep(ctx context.Context, in *BatchGetCepRequest, opts ...grpc.CallOption) (*BatchGetCepResponse, error) {
+	cOpts := append([]grpc.CallOption{grpc.StaticMethod()}, opts...)
+	out := new(BatchGetCepResponse)
+	err := c.cc.Invoke(ctx, CepService_BatchGetCep_FullMethodName, in, out, cOpts...)
+	if err != nil {
+		return nil, err
+	}
+	return out, nil
+}
+
+// CepServiceServer is the server API for CepService service.
+// All implementations must embed UnimplementedCepServiceServer
+// for forward compatibility.
+//
+// CepService resolves Brazilian postal codes through the same cache and
+// providers as the HTTP API. The google.api.http bindings generate the REST
+// layer served under /v1/rpc by grpc-gateway, beside the native /v1 routes.
+type CepServiceServer interface {
+	// GetCep looks up a single CEP. Unknown CEPs fail with NOT_FOUND and
+	// malformed ones with INVALID_ARGUMENT.
+	GetCep(context.Context, *GetCepRequest) (*GetCepResponse, error)
+	// BatchGetCep looks up several CEPs concurrently. Per-CEP failures are
+	// reported in the results instead of failing the call.
+	BatchGetCep(context.Context, *BatchGetCepRequest) (*BatchGetCepResponse, error)
+	mustEmbedUnimplementedCepServiceServer()
+}
+
+// UnimplementedCepServiceServer must be embedded to have
+// forward compatible implementations.
+//
+// NOTE: this should be embedded by value instead of pointer to avoid a nil
+// pointer dereference when methods are called.
+type UnimplementedCepServiceServer struct{}
+
+func (UnimplementedCepServiceServer) GetCep(context.Context, *GetCepRequest) (*GetCepResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method GetCep not implemented")
+}
+func (UnimplementedCepServiceServer) BatchGetCep(context.Context, *BatchGetCepRequest) (*BatchGetCepResponse, error) {
+	return nil, status.Errorf(codes.Unimplemented, "method BatchGetCep not implemented")
+}
+func (UnimplementedCepServiceServer) mustEmbedUnimplementedCepServiceServer() {}
+func (UnimplementedCepServiceServer) testEmbeddedByValue()                    {}
+
+// UnsafeCepServiceServer may be embedded to opt out of forward compatibility for this service.
+// Use of this interface is not recommended, as added methods to CepServiceServer will
+// result in compilation errors.
+type UnsafeCepServiceServer interface {
+	mustEmbedUnimplementedCepServiceServer()
+}
+
+func RegisterCepServiceServer(s grpc.ServiceRegistrar, srv CepServiceServer) {
+	// If the following call pancis, it indicates UnimplementedCepServiceServer was
+	// embedded by pointer and is nil.  This will cause panics if an
+	// unimplemented method is ever invoked, so we test this at initialization
+	// time to prevent it from happening at runtime later due to I/O.
+	if t, ok := srv.(interface{ testEmbeddedByValue() }); ok {
+		t.testEmbeddedByValue()
+	}
+	s.RegisterService(&CepService_ServiceDesc, srv)
+}
+
+func _CepService_GetCep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(GetCepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CepServiceServer).GetCep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CepService_GetCep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CepServiceServer).GetCep(ctx, req.(*GetCepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+func _CepService_BatchGetCep_Handler(srv interface{}, ctx context.Context, dec func(interface{}) error, interceptor grpc.UnaryServerInterceptor) (interface{}, error) {
+	in := new(BatchGetCepRequest)
+	if err := dec(in); err != nil {
+		return nil, err
+	}
+	if interceptor == nil {
+		return srv.(CepServiceServer).BatchGetCep(ctx, in)
+	}
+	info := &grpc.UnaryServerInfo{
+		Server:     srv,
+		FullMethod: CepService_BatchGetCep_FullMethodName,
+	}
+	handler := func(ctx context.Context, req interface{}) (interface{}, error) {
+		return srv.(CepServiceServer).BatchGetCep(ctx, req.(*BatchGetCepRequest))
+	}
+	return interceptor(ctx, in, info, handler)
+}
+
+// CepService_ServiceDesc is the grpc.ServiceDesc for CepService service.
+// It's only intended for direct use with grpc.RegisterService,
+// and not to be introspected or modified (even as a copy)
+var CepService_ServiceDesc = grpc.ServiceDesc{
+	ServiceName: "gocep.cep.v1.CepService",
+	HandlerType: (*CepServiceServer)(nil),
+	Methods: []grpc.MethodDesc{
+		{
+			MethodName: "GetCep",
+			Handler:    _CepService_GetCep_Handler,
+		},
+		{
+			MethodName: "BatchGetCep",
+			Handler:    _CepService_BatchGetCep_Handler,
+		},
+	},
+	Streams:  []grpc.StreamDesc{},
+	Metadata: "cep/v1/cep.proto",
+}
@@ -0,0 +1,5 @@
+// Package cepv1 holds the gRPC API and its grpc-gateway REST layer, generated
+// from cep.proto.
+package cepv1
+
+//go:generate protoc -I ../.. --go_out=../.. --go_opt=paths=source_relative --go-grpc_out=../.. --go-grpc_opt=paths=source_relative --grpc-gateway_out=../.. --grpc-gateway_opt=paths=source_relative cep/v1/cep.proto